@@ -0,0 +1,197 @@
+/*
+ * Copyright (C) 2025 Anthrove
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/anthrove/identity/pkg/logic"
+	"github.com/anthrove/identity/pkg/object"
+	"io"
+	"log"
+	"os"
+	"strings"
+)
+
+type auth0User struct {
+	Username     string `json:"username"`
+	Email        string `json:"email"`
+	Name         string `json:"name"`
+	PasswordHash string `json:"passwordHash"`
+}
+
+type keycloakRealm struct {
+	Users []keycloakUser `json:"users"`
+}
+
+type keycloakUser struct {
+	ID          string            `json:"id"`
+	Username    string            `json:"username"`
+	Email       string            `json:"email"`
+	FirstName   string            `json:"firstName"`
+	LastName    string            `json:"lastName"`
+	Credentials []json.RawMessage `json:"credentials"`
+}
+
+// importUsers runs the import-users command, which imports users of another system into a tenant:
+//
+//	identity import-users -tenant <tenant_id> -format identity|auth0|keycloak -file <path>
+func importUsers(service logic.IdentityService, args []string) error {
+	flags := flag.NewFlagSet("import-users", flag.ExitOnError)
+	tenantID := flags.String("tenant", "", "tenant to import the users into")
+	format := flags.String("format", "identity", "format of the file: identity (JSON array of users), auth0 (newline delimited JSON export) or keycloak (realm export)")
+	file := flags.String("file", "", "file to import, - reads from stdin")
+
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	if *tenantID == "" || *file == "" {
+		flags.Usage()
+		return errors.New("tenant and file are required")
+	}
+
+	reader := io.Reader(os.Stdin)
+	if *file != "-" {
+		f, err := os.Open(*file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		reader = f
+	}
+
+	users, err := readImportUsers(reader, *format)
+	if err != nil {
+		return errors.Join(fmt.Errorf("problem while reading %s users", *format), err)
+	}
+
+	results, err := service.ImportUsers(context.Background(), *tenantID, users)
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, result := range results {
+		if result.Error != "" {
+			failed++
+			log.Printf("failed to import user %s: %s", result.Username, result.Error)
+		}
+	}
+
+	log.Printf("imported %d of %d users", len(results)-failed, len(results))
+	return nil
+}
+
+func readImportUsers(reader io.Reader, format string) ([]object.ImportUser, error) {
+	switch format {
+	case "identity":
+		var users []object.ImportUser
+		err := json.NewDecoder(reader).Decode(&users)
+		return users, err
+	case "auth0":
+		return readAuth0Users(reader)
+	case "keycloak":
+		return readKeycloakUsers(reader)
+	}
+
+	return nil, fmt.Errorf("unknown format: %s", format)
+}
+
+func readAuth0Users(reader io.Reader) ([]object.ImportUser, error) {
+	users := make([]object.ImportUser, 0)
+
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var user auth0User
+		err := json.Unmarshal([]byte(line), &user)
+		if err != nil {
+			return nil, err
+		}
+
+		username := user.Username
+		if username == "" {
+			username = user.Email
+		}
+
+		displayName := user.Name
+		if displayName == "" {
+			displayName = username
+		}
+
+		users = append(users, object.ImportUser{
+			Username:     username,
+			DisplayName:  displayName,
+			Email:        user.Email,
+			PasswordHash: user.PasswordHash,
+		})
+	}
+
+	return users, scanner.Err()
+}
+
+func readKeycloakUsers(reader io.Reader) ([]object.ImportUser, error) {
+	var realm keycloakRealm
+	err := json.NewDecoder(reader).Decode(&realm)
+	if err != nil {
+		return nil, err
+	}
+
+	users := make([]object.ImportUser, 0, len(realm.Users))
+	for _, user := range realm.Users {
+		displayName := strings.TrimSpace(user.FirstName + " " + user.LastName)
+		if displayName == "" {
+			displayName = user.Username
+		}
+
+		importUser := object.ImportUser{
+			Username:    user.Username,
+			DisplayName: displayName,
+			Email:       user.Email,
+		}
+
+		for _, credential := range user.Credentials {
+			var credentialType struct {
+				Type string `json:"type"`
+			}
+
+			err = json.Unmarshal(credential, &credentialType)
+			if err != nil {
+				return nil, err
+			}
+
+			if credentialType.Type == "password" {
+				importUser.PasswordCredential = credential
+			}
+		}
+
+		users = append(users, importUser)
+	}
+
+	return users, nil
+}
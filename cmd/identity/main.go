@@ -18,11 +18,15 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"github.com/anthrove/identity/internal/api"
+	"github.com/anthrove/identity/pkg/crypto"
 	"github.com/anthrove/identity/pkg/logic"
+	"github.com/anthrove/identity/pkg/provider/auth"
 	"github.com/anthrove/identity/pkg/repository"
 	"github.com/gin-gonic/gin"
 	"log"
+	"os"
 )
 
 //	@contact.name	API Support
@@ -43,6 +47,18 @@ func main() {
 		log.Panic("Problem while migrating database: ", err)
 	}
 
+	err = crypto.LoadPepper()
+
+	if err != nil {
+		log.Panic("Problem while loading password pepper: ", err)
+	}
+
+	err = auth.LoadBreachedPasswords()
+
+	if err != nil {
+		log.Panic("Problem while loading breached password corpus: ", err)
+	}
+
 	service := logic.NewIdentityService(engine)
 
 	_, err = service.SetupAdminTenant(context.Background())
@@ -51,6 +67,22 @@ func main() {
 		log.Panic("Problem while creating admin tenant: ", err)
 	}
 
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "import-users":
+			err = importUsers(service, os.Args[2:])
+		case "migrate-resources":
+			err = migrateResources(service, os.Args[2:])
+		default:
+			err = fmt.Errorf("unknown command: %s", os.Args[1])
+		}
+
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	router := gin.Default()
 	api.SetupRoutes(router, service)
 	err = router.Run(":8080")
@@ -0,0 +1,85 @@
+/*
+ * Copyright (C) 2025 Anthrove
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"github.com/anthrove/identity/pkg/logic"
+	"github.com/anthrove/identity/pkg/object"
+	"log"
+	"os"
+	"os/signal"
+	"time"
+)
+
+// migrateResources runs the migrate-resources command, which moves the resources of a tenant to another storage provider:
+//
+//	identity migrate-resources -tenant <tenant_id> -source <provider_id> -target <provider_id> [-delete-source]
+func migrateResources(service logic.IdentityService, args []string) error {
+	flags := flag.NewFlagSet("migrate-resources", flag.ExitOnError)
+	tenantID := flags.String("tenant", "", "tenant whose resources are migrated")
+	sourceProviderID := flags.String("source", "", "storage provider the resources are moved from")
+	targetProviderID := flags.String("target", "", "storage provider the resources are moved to")
+	deleteSource := flags.Bool("delete-source", false, "delete the content from the source provider once it was copied")
+
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	if *tenantID == "" || *sourceProviderID == "" || *targetProviderID == "" {
+		flags.Usage()
+		return errors.New("tenant, source and target are required")
+	}
+
+	// an interrupted migration stops after the current content, running the command again resumes it
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	lastStep := 0
+	progress, err := service.MigrateResources(ctx, *tenantID, object.MigrateResources{
+		SourceProviderID: *sourceProviderID,
+		TargetProviderID: *targetProviderID,
+		DeleteSource:     *deleteSource,
+	}, func(progress object.ResourceMigrationProgress) {
+		// log every 5 percent, the progress is reported after each content
+		if progress.Total == 0 {
+			return
+		}
+
+		step := (progress.Migrated + progress.Failed) * 20 / progress.Total
+		if step == lastStep {
+			return
+		}
+
+		lastStep = step
+		log.Printf("migrated %d of %d resources, %d failed, %d bytes copied", progress.Migrated, progress.Total, progress.Failed, progress.Bytes)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	for _, migrationError := range progress.Errors {
+		log.Printf("failed to migrate %s", migrationError)
+	}
+
+	log.Printf("migrated %d of %d resources in %s", progress.Migrated, progress.Total, progress.FinishedAt.Sub(progress.StartedAt).Round(time.Millisecond))
+	return nil
+}
@@ -0,0 +1,526 @@
+/*
+ * Copyright (C) 2025 Anthrove
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"errors"
+	"github.com/anthrove/identity/pkg/i18n/templates"
+	"github.com/anthrove/identity/pkg/logic"
+	"github.com/anthrove/identity/pkg/object"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// uiAccount is the signed in user of a self-service page.
+type uiAccount struct {
+	tenant      object.Tenant
+	application object.Application
+	sessionID   string
+	user        object.User
+}
+
+// accountDoneMessages are the messages shown after an action of the self-service, the action redirects with the key in the done parameter.
+var accountDoneMessages = map[string]string{
+	"profile":         "Your profile was saved.",
+	"email":           "We sent a code to your new email, enter it to finish the change.",
+	"email_sent":      "We sent you a code.",
+	"email_verified":  "Your email was verified.",
+	"password":        "Your password was changed.",
+	"mfa_removed":     "The sign in method was removed.",
+	"session_revoked": "The session was signed out.",
+	"device_removed":  "The device is not trusted anymore.",
+	"consent_revoked": "The application has no access anymore.",
+	"avatar":          "Your avatar was saved.",
+	"avatar_removed":  "Your avatar was removed.",
+}
+
+//	@Summary	Self-service page of the signed in user, without a session the user is sent to the hosted login
+//	@Tags		Account
+//	@Produce	html
+//	@Param		tenant_id		path	string	true	"Tenant ID"
+//	@Param		application_id	query	string	false	"Application ID, used for the branding and the login"
+//	@Success	200
+//	@Success	303
+//	@Failure	400
+//	@Router		/account/{tenant_id} [get]
+func (ir IdentityRoutes) uiAccountPage(c *gin.Context) {
+	account, ok := ir.uiAccountUser(c)
+
+	if !ok {
+		return
+	}
+
+	page, ok := ir.uiAccountPageData(c, account)
+
+	if !ok {
+		return
+	}
+
+	page.Info = accountDoneMessages[c.Query("done")]
+
+	ir.renderUIPage(c, http.StatusOK, "account", page)
+}
+
+//	@Summary	Saves the profile fields of the signed in user
+//	@Tags		Account
+//	@Accept		x-www-form-urlencoded
+//	@Produce	html
+//	@Param		tenant_id		path		string	true	"Tenant ID"
+//	@Param		application_id	formData	string	false	"Application ID"
+//	@Success	303
+//	@Failure	400
+//	@Router		/account/{tenant_id}/profile [post]
+func (ir IdentityRoutes) uiAccountProfile(c *gin.Context) {
+	ir.uiAccountAction(c, "profile", func(account uiAccount) error {
+		profile, err := ir.service.FindOwnProfile(c, account.tenant.ID, account.user.ID)
+
+		if err != nil {
+			return err
+		}
+
+		var updateProfilePage object.UpdateProfilePage
+
+		for _, value := range profile {
+			formValues, ok := c.GetPostFormArray("field_" + value.Field.Identifier)
+
+			if !ok || !value.Modifiable {
+				continue
+			}
+
+			field := object.ProfilePageField{Identifier: value.Field.Identifier}
+
+			if value.Field.Type == object.ProfileFieldTypeMultiSelect {
+				// the form sends an empty value first, so an empty selection clears the field
+				selected := make([]string, 0, len(formValues))
+				for _, formValue := range formValues {
+					if len(formValue) > 0 {
+						selected = append(selected, formValue)
+					}
+				}
+				field.Value = selected
+			} else if formValue := strings.TrimSpace(formValues[len(formValues)-1]); len(formValue) > 0 {
+				// checkboxes of bool fields follow a hidden false value, so the last value wins
+				field.Value = formValue
+			}
+
+			updateProfilePage.Fields = append(updateProfilePage.Fields, field)
+		}
+
+		return ir.service.UpdateOwnProfile(c, account.tenant.ID, account.user.ID, updateProfilePage)
+	})
+}
+
+//	@Summary	Uploads the avatar of the signed in user
+//	@Tags		Account
+//	@Accept		multipart/form-data
+//	@Produce	html
+//	@Param		tenant_id		path		string	true	"Tenant ID"
+//	@Param		application_id	formData	string	false	"Application ID"
+//	@Param		file			formData	file	true	"Image"
+//	@Success	303
+//	@Failure	400
+//	@Router		/account/{tenant_id}/avatar [post]
+func (ir IdentityRoutes) uiAccountAvatar(c *gin.Context) {
+	ir.uiAccountAction(c, "avatar", func(account uiAccount) error {
+		limitUpload(c, logic.MaxAvatarSize)
+
+		file, err := c.FormFile("file")
+		if err != nil {
+			return err
+		}
+
+		fileContent, err := file.Open()
+		if err != nil {
+			return err
+		}
+		defer fileContent.Close()
+
+		_, err = ir.service.UploadAvatar(c, account.tenant.ID, account.user.ID, file.Filename, fileContent)
+		return err
+	})
+}
+
+//	@Summary	Removes the avatar of the signed in user
+//	@Tags		Account
+//	@Accept		x-www-form-urlencoded
+//	@Produce	html
+//	@Param		tenant_id		path		string	true	"Tenant ID"
+//	@Param		application_id	formData	string	false	"Application ID"
+//	@Success	303
+//	@Failure	400
+//	@Router		/account/{tenant_id}/avatar/delete [post]
+func (ir IdentityRoutes) uiAccountAvatarDelete(c *gin.Context) {
+	ir.uiAccountAction(c, "avatar_removed", func(account uiAccount) error {
+		return ir.service.KillAvatar(c, account.tenant.ID, account.user.ID)
+	})
+}
+
+//	@Summary	Starts the email change of the signed in user
+//	@Tags		Account
+//	@Accept		x-www-form-urlencoded
+//	@Produce	html
+//	@Param		tenant_id		path		string	true	"Tenant ID"
+//	@Param		application_id	formData	string	false	"Application ID"
+//	@Param		email			formData	string	true	"New Email"
+//	@Param		current_password	formData	string	false	"Current Password, required unless the user signed in recently"
+//	@Success	303
+//	@Failure	400
+//	@Router		/account/{tenant_id}/email [post]
+func (ir IdentityRoutes) uiAccountEmail(c *gin.Context) {
+	ir.uiAccountAction(c, "email", func(account uiAccount) error {
+		return ir.service.ChangeEmail(c, account.tenant.ID, account.user.ID, sessionAuthenticatedAt(c), object.ChangeEmail{
+			Email:           strings.TrimSpace(c.PostForm("email")),
+			CurrentPassword: c.PostForm("current_password"),
+		})
+	})
+}
+
+//	@Summary	Sends a verification code to the email of the signed in user
+//	@Tags		Account
+//	@Accept		x-www-form-urlencoded
+//	@Produce	html
+//	@Param		tenant_id		path		string	true	"Tenant ID"
+//	@Param		application_id	formData	string	false	"Application ID"
+//	@Success	303
+//	@Failure	400
+//	@Router		/account/{tenant_id}/email/send [post]
+func (ir IdentityRoutes) uiAccountEmailSend(c *gin.Context) {
+	ir.uiAccountAction(c, "email_sent", func(account uiAccount) error {
+		return ir.service.SendEmailVerification(c, account.tenant.ID, account.user.ID)
+	})
+}
+
+//	@Summary	Verifies the email or the new email of the signed in user
+//	@Tags		Account
+//	@Accept		x-www-form-urlencoded
+//	@Produce	html
+//	@Param		tenant_id		path		string	true	"Tenant ID"
+//	@Param		application_id	formData	string	false	"Application ID"
+//	@Param		token			formData	string	true	"Verification Code"
+//	@Success	303
+//	@Failure	400
+//	@Router		/account/{tenant_id}/email/verify [post]
+func (ir IdentityRoutes) uiAccountEmailVerify(c *gin.Context) {
+	ir.uiAccountAction(c, "email_verified", func(account uiAccount) error {
+		return ir.service.VerifyEmail(c, account.tenant.ID, account.user.ID, object.VerifyEmail{
+			Token: strings.TrimSpace(c.PostForm("token")),
+		})
+	})
+}
+
+//	@Summary	Changes the password of the signed in user
+//	@Tags		Account
+//	@Accept		x-www-form-urlencoded
+//	@Produce	html
+//	@Param		tenant_id			path		string	true	"Tenant ID"
+//	@Param		application_id		formData	string	false	"Application ID"
+//	@Param		current_password	formData	string	true	"Current Password"
+//	@Param		new_password		formData	string	true	"New Password"
+//	@Success	303
+//	@Failure	400
+//	@Failure	429
+//	@Router		/account/{tenant_id}/password [post]
+func (ir IdentityRoutes) uiAccountPassword(c *gin.Context) {
+	ir.uiAccountAction(c, "password", func(account uiAccount) error {
+		return ir.service.ChangePassword(c, account.tenant.ID, account.user.ID, object.ChangePassword{
+			CurrentPassword: c.PostForm("current_password"),
+			NewPassword:     c.PostForm("new_password"),
+		})
+	})
+}
+
+//	@Summary	Removes a MFA of the signed in user
+//	@Tags		Account
+//	@Accept		x-www-form-urlencoded
+//	@Produce	html
+//	@Param		tenant_id		path		string	true	"Tenant ID"
+//	@Param		application_id	formData	string	false	"Application ID"
+//	@Param		mfa_id			formData	string	true	"MFA ID"
+//	@Success	303
+//	@Failure	400
+//	@Router		/account/{tenant_id}/mfa/delete [post]
+func (ir IdentityRoutes) uiAccountMFADelete(c *gin.Context) {
+	ir.uiAccountAction(c, "mfa_removed", func(account uiAccount) error {
+		return ir.service.KillMFA(c, account.tenant.ID, account.user.ID, c.PostForm("mfa_id"))
+	})
+}
+
+//	@Summary	Regenerates the recovery codes of a MFA of the signed in user
+//	@Description	The new codes are only shown on the returned page.
+//	@Tags		Account
+//	@Accept		x-www-form-urlencoded
+//	@Produce	html
+//	@Param		tenant_id		path		string	true	"Tenant ID"
+//	@Param		application_id	formData	string	false	"Application ID"
+//	@Param		mfa_id			formData	string	true	"MFA ID"
+//	@Success	200
+//	@Failure	400
+//	@Router		/account/{tenant_id}/mfa/recovery [post]
+func (ir IdentityRoutes) uiAccountRecoveryCodes(c *gin.Context) {
+	account, ok := ir.uiAccountUser(c)
+
+	if !ok {
+		return
+	}
+
+	recoveryCodes, err := ir.service.RegenerateRecoveryCodes(c, account.tenant.ID, account.user.ID, c.PostForm("mfa_id"))
+
+	page, ok := ir.uiAccountPageData(c, account)
+
+	if !ok {
+		return
+	}
+
+	if err != nil {
+		page.Error = err.Error()
+		ir.renderUIPage(c, http.StatusBadRequest, "account", page)
+		return
+	}
+
+	page.Account.RecoveryCodes = recoveryCodes.RecoveryCodes
+
+	ir.renderUIPage(c, http.StatusOK, "account", page)
+}
+
+//	@Summary	Signs out a session of the signed in user
+//	@Tags		Account
+//	@Accept		x-www-form-urlencoded
+//	@Produce	html
+//	@Param		tenant_id		path		string	true	"Tenant ID"
+//	@Param		application_id	formData	string	false	"Application ID"
+//	@Param		session_id		formData	string	true	"Session ID"
+//	@Success	303
+//	@Failure	400
+//	@Router		/account/{tenant_id}/session/revoke [post]
+func (ir IdentityRoutes) uiAccountSessionRevoke(c *gin.Context) {
+	ir.uiAccountAction(c, "session_revoked", func(account uiAccount) error {
+		return ir.service.KillUserSession(c, account.tenant.ID, account.user.ID, c.PostForm("session_id"))
+	})
+}
+
+//	@Summary	Removes a trusted device of the signed in user
+//	@Tags		Account
+//	@Accept		x-www-form-urlencoded
+//	@Produce	html
+//	@Param		tenant_id		path		string	true	"Tenant ID"
+//	@Param		application_id	formData	string	false	"Application ID"
+//	@Param		device_id		formData	string	true	"Trusted Device ID"
+//	@Success	303
+//	@Failure	400
+//	@Router		/account/{tenant_id}/device/revoke [post]
+func (ir IdentityRoutes) uiAccountDeviceRevoke(c *gin.Context) {
+	ir.uiAccountAction(c, "device_removed", func(account uiAccount) error {
+		return ir.service.KillTrustedDevice(c, account.tenant.ID, account.user.ID, c.PostForm("device_id"))
+	})
+}
+
+//	@Summary	Revokes the consent the signed in user gave to an application
+//	@Tags		Account
+//	@Accept		x-www-form-urlencoded
+//	@Produce	html
+//	@Param		tenant_id				path		string	true	"Tenant ID"
+//	@Param		application_id			formData	string	false	"Application ID"
+//	@Param		consent_application_id	formData	string	true	"Application ID of the consent"
+//	@Success	303
+//	@Failure	400
+//	@Router		/account/{tenant_id}/consent/revoke [post]
+func (ir IdentityRoutes) uiAccountConsentRevoke(c *gin.Context) {
+	ir.uiAccountAction(c, "consent_revoked", func(account uiAccount) error {
+		return ir.service.RevokeConsent(c, account.tenant.ID, account.user.ID, c.PostForm("consent_application_id"))
+	})
+}
+
+//	@Summary	Signs out the session of the self-service
+//	@Tags		Account
+//	@Accept		x-www-form-urlencoded
+//	@Produce	html
+//	@Param		tenant_id		path		string	true	"Tenant ID"
+//	@Param		application_id	formData	string	false	"Application ID"
+//	@Success	303
+//	@Router		/account/{tenant_id}/logout [post]
+func (ir IdentityRoutes) uiAccountLogout(c *gin.Context) {
+	account, ok := ir.uiAccountUser(c)
+
+	if !ok {
+		return
+	}
+
+	ir.service.KillSession(c, account.sessionID)
+
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie("identity_session_id", "", -1, "/", "", c.Request.TLS != nil, true)
+
+	c.Redirect(http.StatusSeeOther, uiURL(account.tenant.ID, "/login", url.Values{
+		"application_id": {account.application.ID},
+	}))
+}
+
+//	@Summary	Downloads everything stored about the signed in user
+//	@Tags		Account
+//	@Produce	json
+//	@Param		tenant_id		path	string	true	"Tenant ID"
+//	@Param		application_id	query	string	false	"Application ID"
+//	@Success	200	{object}	object.PersonalData
+//	@Failure	400
+//	@Router		/account/{tenant_id}/data [get]
+func (ir IdentityRoutes) uiAccountData(c *gin.Context) {
+	account, ok := ir.uiAccountUser(c)
+
+	if !ok {
+		return
+	}
+
+	personalData, err := ir.service.ExportPersonalData(c, account.tenant.ID, account.user.ID, account.sessionID)
+
+	if err != nil {
+		ir.renderUIError(c, http.StatusBadRequest, account.tenant, err)
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="personal-data.json"`)
+	c.Header("Cache-Control", "no-store")
+	c.IndentedJSON(http.StatusOK, personalData)
+}
+
+// uiAccountAction runs an action of the self-service and redirects back to the page with the done message.
+// The forms don't need a CSRF token, the session cookie is SameSite=Lax and not sent with cross-site posts.
+func (ir IdentityRoutes) uiAccountAction(c *gin.Context, done string, action func(account uiAccount) error) {
+	account, ok := ir.uiAccountUser(c)
+
+	if !ok {
+		return
+	}
+
+	err := action(account)
+
+	if err != nil {
+		page, ok := ir.uiAccountPageData(c, account)
+
+		if !ok {
+			return
+		}
+
+		page.Error = err.Error()
+		ir.renderUIPage(c, attemptErrorStatus(err), "account", page)
+		return
+	}
+
+	c.Redirect(http.StatusSeeOther, accountURL(account.tenant.ID, "", url.Values{
+		"application_id": {account.application.ID},
+		"done":           {done},
+	}))
+}
+
+// uiAccountUser loads the signed in user of a self-service page. The application is taken from the application_id
+// parameter or from the session, it is used for the branding and to sign in again.
+// Without a signed in user, the browser is redirected to the hosted login and false is returned.
+func (ir IdentityRoutes) uiAccountUser(c *gin.Context) (uiAccount, bool) {
+	tenant, err := ir.service.FindTenant(c, c.Param("tenant_id"))
+
+	if err != nil {
+		ir.renderUIError(c, http.StatusBadRequest, object.Tenant{}, errors.New("tenant not found"))
+		return uiAccount{}, false
+	}
+
+	sessionID, session := ir.uiSession(c, tenant.ID)
+
+	applicationID := uiFormValue(c, "application_id")
+
+	if len(applicationID) == 0 {
+		applicationID, _ = session["application_id"].(string)
+	}
+
+	application, err := ir.service.FindApplication(c, tenant.ID, applicationID)
+
+	if err != nil {
+		ir.renderUIError(c, http.StatusBadRequest, tenant, errors.New("application not found"))
+		return uiAccount{}, false
+	}
+
+	setUIRequestInfo(c, application.ID)
+
+	loggedIn, _ := session["logged_in"].(bool)
+	sessionUser, hasUser := session["user"].(object.User)
+
+	if !loggedIn || !hasUser {
+		c.Redirect(http.StatusSeeOther, uiURL(tenant.ID, "/login", url.Values{
+			"application_id": {application.ID},
+		}))
+		return uiAccount{}, false
+	}
+
+	// the user of the session is a snapshot of the sign in, the page shows the current data
+	user, err := ir.service.FindUser(c, tenant.ID, sessionUser.ID)
+
+	if err != nil {
+		ir.renderUIError(c, http.StatusBadRequest, tenant, err)
+		return uiAccount{}, false
+	}
+
+	// the audit events of the actions are recorded with the user as actor
+	c.Set("session", session)
+
+	return uiAccount{
+		tenant:      tenant,
+		application: application,
+		sessionID:   sessionID,
+		user:        user,
+	}, true
+}
+
+// uiAccountPageData collects everything shown on the self-service page.
+// If it can't be loaded, the error page is rendered and false is returned.
+func (ir IdentityRoutes) uiAccountPageData(c *gin.Context, account uiAccount) (templates.Page, bool) {
+	tenantID, userID := account.tenant.ID, account.user.ID
+
+	page := newUIPage("Your Account", account.tenant, account.application, "")
+	page.PasswordEnabled = passwordEnabled(account.application)
+	page.Account.User = account.user
+	page.Account.Sessions = ir.service.FindUserSessions(c, tenantID, userID, account.sessionID)
+
+	var err error
+	page.Account.Profile, err = ir.service.FindOwnProfile(c, tenantID, userID)
+
+	if err == nil {
+		page.Account.AvatarURL, err = ir.service.FindAvatarURL(c, tenantID, account.user)
+	}
+
+	if err == nil {
+		page.Account.MFAs, err = ir.service.FindMFAs(c, tenantID, userID, object.Pagination{Limit: 20, Page: 1})
+	}
+
+	if err == nil {
+		page.Account.TrustedDevices, err = ir.service.FindTrustedDevices(c, tenantID, userID, object.Pagination{Limit: 20, Page: 1})
+	}
+
+	if err == nil {
+		page.Account.Consents, err = ir.service.FindOwnConsents(c, tenantID, userID)
+	}
+
+	if err != nil {
+		ir.renderUIError(c, http.StatusBadRequest, account.tenant, err)
+		return templates.Page{}, false
+	}
+
+	return page, true
+}
+
+func accountURL(tenantID string, path string, query url.Values) string {
+	return "/account/" + tenantID + path + "?" + query.Encode()
+}
@@ -0,0 +1,65 @@
+/*
+ * Copyright (C) 2025 Anthrove
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"errors"
+	"github.com/anthrove/identity/pkg/object"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+//	@Summary	Get the audit events of a tenant, newest first
+//	@Tags		Audit API
+//	@Accept		json
+//	@Produce	json
+//	@Param		page		query		string										false	"Page"
+//	@Param		page_limit	query		string										false	"Page Limit"
+//	@Param		tenant_id	path		string										true	"Tenant ID"
+//	@Success	200			{object}	HttpResponse{data=[]object.AuditEvent{}}	"Audit Events"
+//	@Failure	400			{object}	HttpResponse{data=nil}						"Bad Request"
+//	@Router		/api/v1/tenant/{tenant_id}/audit [get]
+func (ir IdentityRoutes) findAuditEvents(c *gin.Context) {
+	tenantID := c.Param("tenant_id")
+
+	pagination, ok := c.Get("pagination")
+	if !ok {
+		c.JSON(http.StatusInternalServerError, HttpResponse{
+			Error: "pagination parameter is missing",
+		})
+		return
+	}
+
+	paginationObj, ok := pagination.(object.Pagination)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, errors.New("pagination parameter cant be converted to object.Pagination"))
+		return
+	}
+
+	auditEvents, err := ir.service.FindAuditEvents(c, tenantID, paginationObj)
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, HttpResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, HttpResponse{
+		Data: auditEvents,
+	})
+}
@@ -20,6 +20,7 @@ import (
 	"github.com/anthrove/identity/pkg/object"
 	"github.com/gin-gonic/gin"
 	"net/http"
+	"time"
 )
 
 //	@Summary	Login
@@ -64,8 +65,9 @@ func (ir IdentityRoutes) signInBegin(c *gin.Context) {
 //	@Param		tenant_id		path	string					true	"Tenant ID"
 //	@Param		application_id	path	string					true	"Application ID"
 //	@Param		"Sign In"		body	object.SignInRequest	true	"SignIn Data"
-//	@Success	200
-//	@Failure	400	{object}	HttpResponse{data=nil}	"Bad Request"
+//	@Success	200	{object}	HttpResponse{data=object.SignInResponse{}}	"Sign In Step"
+//	@Failure	400	{object}	HttpResponse{data=nil}					"Bad Request"
+//	@Failure	429	{object}	HttpResponse{data=nil}					"Too Many Attempts"
 //	@Router		/api/v1/tenant/{tenant_id}/application/{application_id}/login [post]
 func (ir IdentityRoutes) signInSubmit(c *gin.Context) {
 	tenantID := c.Param("tenant_id")
@@ -81,10 +83,13 @@ func (ir IdentityRoutes) signInSubmit(c *gin.Context) {
 		return
 	}
 
-	session, user, err := ir.service.SignInSubmit(c, tenantID, applicationID, body)
+	// the cookie is optional, without it the second factor is required as usual
+	body.TrustedDeviceToken, _ = c.Cookie(trustedDeviceCookie(tenantID))
+
+	session, response, err := ir.service.SignInSubmit(c, tenantID, applicationID, body)
 
 	if err != nil {
-		c.JSON(http.StatusBadRequest, HttpResponse{
+		c.JSON(attemptErrorStatus(err), HttpResponse{
 			Error: err.Error(),
 		})
 		return
@@ -92,6 +97,158 @@ func (ir IdentityRoutes) signInSubmit(c *gin.Context) {
 
 	c.SetCookie("identity_session_id", session, 60*60*24*30, "", "", false, true)
 	c.JSON(http.StatusOK, HttpResponse{
-		Data: user,
+		Data: response,
+	})
+}
+
+//	@Summary	Login with MFA
+//	@Tags		Authentication API
+//	@Accept		json
+//	@Produce	json
+//	@Param		tenant_id		path		string									true	"Tenant ID"
+//	@Param		application_id	path		string									true	"Application ID"
+//	@Param		"Sign In MFA"	body		object.SignInMFARequest					true	"SignIn MFA Data"
+//	@Success	200				{object}	HttpResponse{data=object.SignInResponse{}}	"Sign In Step"
+//	@Failure	400				{object}	HttpResponse{data=nil}					"Bad Request"
+//	@Failure	429				{object}	HttpResponse{data=nil}					"Too Many Attempts"
+//	@Router		/api/v1/tenant/{tenant_id}/application/{application_id}/login/mfa [post]
+func (ir IdentityRoutes) signInMFA(c *gin.Context) {
+	tenantID := c.Param("tenant_id")
+	applicationID := c.Param("application_id")
+
+	sessionID, err := c.Cookie("identity_session_id")
+
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, HttpResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	var body object.SignInMFARequest
+	err = c.ShouldBind(&body)
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, HttpResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	response, err := ir.service.SignInMFA(c, tenantID, applicationID, sessionID, body)
+
+	if err != nil {
+		c.JSON(attemptErrorStatus(err), HttpResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	if len(response.TrustedDeviceToken) > 0 {
+		c.SetCookie(trustedDeviceCookie(tenantID), response.TrustedDeviceToken, int(time.Until(response.TrustedDeviceUntil).Seconds()), "", "", false, true)
+	}
+
+	c.JSON(http.StatusOK, HttpResponse{
+		Data: response,
+	})
+}
+
+//	@Summary	Sets a new password during login, if the login returned the password_change_required step
+//	@Tags		Authentication API
+//	@Accept		json
+//	@Produce	json
+//	@Param		tenant_id					path		string									true	"Tenant ID"
+//	@Param		application_id				path		string									true	"Application ID"
+//	@Param		"Sign In Change Password"	body		object.SignInChangePasswordRequest		true	"SignIn Change Password Data"
+//	@Success	200							{object}	HttpResponse{data=object.SignInResponse}	"Sign In Response"
+//	@Failure	400							{object}	HttpResponse{data=nil}					"Bad Request"
+//	@Failure	401							{object}	HttpResponse{data=nil}					"Unauthorized"
+//	@Router		/api/v1/tenant/{tenant_id}/application/{application_id}/login/password [post]
+func (ir IdentityRoutes) signInChangePassword(c *gin.Context) {
+	tenantID := c.Param("tenant_id")
+	applicationID := c.Param("application_id")
+
+	sessionID, err := c.Cookie("identity_session_id")
+
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, HttpResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	var body object.SignInChangePasswordRequest
+	err = c.ShouldBind(&body)
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, HttpResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	response, err := ir.service.SignInChangePassword(c, tenantID, applicationID, sessionID, body)
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, HttpResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, HttpResponse{
+		Data: response,
 	})
 }
+
+//	@Summary	Starts the validation of a MFA during login, e.g. by sending a one-time code
+//	@Tags		Authentication API
+//	@Accept		json
+//	@Produce	json
+//	@Param		tenant_id			path		string								true	"Tenant ID"
+//	@Param		application_id		path		string								true	"Application ID"
+//	@Param		"Sign In MFA Init"	body		object.SignInMFAInitRequest			true	"SignIn MFA Init Data"
+//	@Success	200					{object}	HttpResponse{data=map[string]any}	"MFA Provider Data"
+//	@Failure	400					{object}	HttpResponse{data=nil}				"Bad Request"
+//	@Router		/api/v1/tenant/{tenant_id}/application/{application_id}/login/mfa/init [post]
+func (ir IdentityRoutes) signInMFAInit(c *gin.Context) {
+	tenantID := c.Param("tenant_id")
+	applicationID := c.Param("application_id")
+
+	sessionID, err := c.Cookie("identity_session_id")
+
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, HttpResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	var body object.SignInMFAInitRequest
+	err = c.ShouldBind(&body)
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, HttpResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	response, err := ir.service.SignInMFAInit(c, tenantID, applicationID, sessionID, body)
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, HttpResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, HttpResponse{
+		Data: response,
+	})
+}
+
+// trustedDeviceCookie returns the name of the cookie which holds the trusted device token, every tenant has its own.
+func trustedDeviceCookie(tenantID string) string {
+	return "identity_trusted_device_" + tenantID
+}
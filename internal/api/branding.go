@@ -0,0 +1,101 @@
+/*
+ * Copyright (C) 2025 Anthrove
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+//	@Summary	Get the branding of a tenant or application
+//	@Tags		Branding API
+//	@Accept		json
+//	@Produce	json
+//	@Param		tenant_id		path		string										true	"Tenant ID"
+//	@Param		application_id	query		string										false	"Application ID"
+//	@Success	200				{object}	HttpResponse{data=object.AppliedBranding{}}	"Branding"
+//	@Failure	400				{object}	HttpResponse{data=nil}						"Bad Request"
+//	@Router		/api/v1/tenant/{tenant_id}/branding [get]
+func (ir IdentityRoutes) findBranding(c *gin.Context) {
+	tenantID := c.Param("tenant_id")
+	applicationID := c.Query("application_id")
+
+	branding, err := ir.service.FindBranding(c, tenantID, applicationID)
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, HttpResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, HttpResponse{
+		Data: branding,
+	})
+}
+
+//	@Summary	Preview a hosted page with sample data
+//	@Tags		Branding API
+//	@Produce	html
+//	@Param		tenant_id		path		string					true	"Tenant ID"
+//	@Param		page_name		path		string					true	"Page Name"	Enums(login, mfa, password, consent, reset, reset_complete, error)
+//	@Param		application_id	query		string					false	"Application ID"
+//	@Success	200
+//	@Failure	400				{object}	HttpResponse{data=nil}	"Bad Request"
+//	@Router		/api/v1/tenant/{tenant_id}/branding/preview/page/{page_name} [get]
+func (ir IdentityRoutes) previewPage(c *gin.Context) {
+	tenantID := c.Param("tenant_id")
+	pageName := c.Param("page_name")
+	applicationID := c.Query("application_id")
+
+	page, err := ir.service.PreviewPage(c, tenantID, applicationID, pageName)
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, HttpResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	writeUIPage(c, http.StatusOK, []byte(page), "", nil)
+}
+
+//	@Summary	Preview an email with sample data
+//	@Tags		Branding API
+//	@Produce	html
+//	@Param		tenant_id		path		string					true	"Tenant ID"
+//	@Param		template_type	path		string					true	"Template Type"
+//	@Param		application_id	query		string					false	"Application ID"
+//	@Success	200
+//	@Failure	400				{object}	HttpResponse{data=nil}	"Bad Request"
+//	@Router		/api/v1/tenant/{tenant_id}/branding/preview/email/{template_type} [get]
+func (ir IdentityRoutes) previewEmail(c *gin.Context) {
+	tenantID := c.Param("tenant_id")
+	templateType := c.Param("template_type")
+	applicationID := c.Query("application_id")
+
+	message, err := ir.service.PreviewMessageTemplate(c, tenantID, applicationID, templateType)
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, HttpResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	writeUIPage(c, http.StatusOK, []byte(message), "", nil)
+}
@@ -1,25 +1,106 @@
 package api
 
 import (
+	"errors"
+	"github.com/anthrove/identity/pkg/logic"
+	"github.com/anthrove/identity/pkg/object"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"mime"
 	"net/http"
+	"path"
+	"slices"
 )
 
+// cdnCacheControl is sent with public files, uploads get a random prefix so a path never changes its content.
+const cdnCacheControl = "public, max-age=31536000, immutable"
+
+// cdnPrivateCacheControl is sent with files which are not public, browsers keep them but have to revalidate the access.
+const cdnPrivateCacheControl = "private, no-cache"
+
+// cdnInlineMimeTypes are shown by the browser, all other files are downloaded so uploaded html can't run on this origin.
+var cdnInlineMimeTypes = []string{"image/jpeg", "image/png", "image/gif", "image/webp", "image/avif"}
+
 // @Summary	Get an file
+// @Description	Streams a resource from the storage provider it was uploaded to. Supports Range, If-None-Match and If-Modified-Since requests.
+// @Description	Only raster images are shown inline, all other files are sent as attachment.
+// @Description	Resources which are not public need a session of the tenant which passes their visibility or a signed URL.
 // @Tags		CDN API
-// @Accept		json
-// @Produce	json
+// @Produce	octet-stream
 // @Param		tenant_id	path	string	true	"Tenant ID"
+// @Param		file_path	path	string	true	"Resource path"
+// @Param		Range	header	string	false	"Byte range"
+// @Param		If-None-Match	header	string	false	"ETag of a cached copy"
+// @Param		expires	query	string	false	"Expiry of a signed URL"
+// @Param		signature	query	string	false	"Signature of a signed URL"
+// @Success	200
+// @Success	206
+// @Success	304
+// @Failure	403	{object}	HttpResponse{data=nil,error=string}
+// @Failure	404	{object}	HttpResponse{data=nil,error=string}
 // @Router		/api/v1/cdn/{tenant_id}/{file_path} [get]
 func (ir IdentityRoutes) cdnGetFile(c *gin.Context) {
 	tenantID := c.Param("tenant_id")
 	filePath := c.Param("file_path")
 
-	localFilePath, err := ir.service.ServeResource(c, tenantID, filePath)
+	access := object.ResourceAccess{
+		Expires:   c.Query("expires"),
+		Signature: c.Query("signature"),
+	}
+
+	if sessionID, err := c.Cookie("identity_session_id"); err == nil {
+		session := ir.service.FindSession(c, sessionID)
+		loggedIn, _ := session["logged_in"].(bool)
+		user, _ := session["user"].(object.User)
+
+		if loggedIn && session["tenant_id"] == tenantID {
+			access.UserID = user.ID
+		}
+	}
+
+	resource, content, err := ir.service.ServeResource(c, tenantID, filePath, access)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, err)
+		if errors.Is(err, logic.ErrResourceForbidden) {
+			c.JSON(http.StatusForbidden, HttpResponse{
+				Error: err.Error(),
+			})
+			return
+		}
+
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, HttpResponse{
+				Error: "resource not found",
+			})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, HttpResponse{
+			Error: err.Error(),
+		})
 		return
 	}
+	defer content.Close()
+
+	header := c.Writer.Header()
+	header.Set("Cache-Control", cdnCacheControl)
+	if resource.Visibility != "" && resource.Visibility != object.ResourceVisibilityPublic {
+		header.Set("Cache-Control", cdnPrivateCacheControl)
+	}
+	header.Set("X-Content-Type-Options", "nosniff")
+
+	if len(resource.Hash) != 0 {
+		header.Set("ETag", `"`+resource.Hash+`"`)
+	}
+
+	if len(resource.MimeType) != 0 {
+		header.Set("Content-Type", resource.MimeType)
+	}
+
+	if !slices.Contains(cdnInlineMimeTypes, resource.MimeType) {
+		header.Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": path.Base(resource.FilePath)}))
+		header.Set("Content-Security-Policy", "sandbox; default-src 'none'")
+	}
 
-	c.File(localFilePath)
+	// ServeContent answers conditional and range requests based on the headers above
+	http.ServeContent(c.Writer, c.Request, path.Base(resource.FilePath), resource.UpdatedAt, content)
 }
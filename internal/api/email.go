@@ -0,0 +1,152 @@
+/*
+ * Copyright (C) 2025 Anthrove
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"github.com/anthrove/identity/pkg/object"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+//	@Summary	Send a new email verification mail to a user
+//	@Tags		User API
+//	@Accept		json
+//	@Produce	json
+//	@Param		tenant_id	path	string	true	"Tenant ID"
+//	@Param		user_id		path	string	true	"User ID"
+//	@Success	204
+//	@Failure	400	{object}	HttpResponse{data=nil}	"Bad Request"
+//	@Router		/api/v1/tenant/{tenant_id}/user/{user_id}/email/verification [post]
+func (ir IdentityRoutes) sendEmailVerification(c *gin.Context) {
+	tenantID := c.Param("tenant_id")
+	userID := c.Param("user_id")
+
+	err := ir.service.SendEmailVerification(c, tenantID, userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, HttpResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+//	@Summary	Send a new email verification mail to the profile
+//	@Tags		Profile API
+//	@Accept		json
+//	@Produce	json
+//	@Success	204
+//	@Failure	400	{object}	HttpResponse{data=nil}	"Bad Request"
+//	@Router		/api/v1/profile/email/verification [post]
+func (ir IdentityRoutes) profileSendEmailVerification(c *gin.Context) {
+	user, err := sessionConvert(c)
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return
+	}
+
+	err = ir.service.SendEmailVerification(c, user.TenantID, user.ID)
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, HttpResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+//	@Summary	Confirm the email of the profile with the verification token
+//	@Tags		Profile API
+//	@Accept		json
+//	@Produce	json
+//	@Param		"Verify Email"	body	object.VerifyEmail	true	"Verify Email Data"
+//	@Success	204
+//	@Failure	400	{object}	HttpResponse{data=nil}	"Bad Request"
+//	@Router		/api/v1/profile/email/verify [post]
+func (ir IdentityRoutes) profileVerifyEmail(c *gin.Context) {
+	user, err := sessionConvert(c)
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return
+	}
+
+	var body object.VerifyEmail
+	err = c.ShouldBind(&body)
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, HttpResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	err = ir.service.VerifyEmail(c, user.TenantID, user.ID, body)
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, HttpResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+//	@Summary		Change the email of the profile, the new email has to be confirmed with the verification token
+//	@Description	The current password is required, unless the user signed in within the last 10 minutes.
+//	@Tags			Profile API
+//	@Accept			json
+//	@Produce		json
+//	@Param			"Change Email"	body	object.ChangeEmail	true	"Change Email Data"
+//	@Success		204
+//	@Failure		400	{object}	HttpResponse{data=nil}	"Bad Request"
+//	@Failure		429	{object}	HttpResponse{data=nil}	"Too Many Attempts"
+//	@Router		/api/v1/profile/email [put]
+func (ir IdentityRoutes) profileChangeEmail(c *gin.Context) {
+	user, err := sessionConvert(c)
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return
+	}
+
+	var body object.ChangeEmail
+	err = c.ShouldBind(&body)
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, HttpResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	err = ir.service.ChangeEmail(c, user.TenantID, user.ID, sessionAuthenticatedAt(c), body)
+
+	if err != nil {
+		c.JSON(attemptErrorStatus(err), HttpResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,151 @@
+/*
+ * Copyright (C) 2025 Anthrove
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"errors"
+	"github.com/anthrove/identity/pkg/object"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// @Summary	Creates a new Invitation
+// @Tags		Invitation API
+// @Accept		json
+// @Produce	json
+// @Param		tenant_id		path		string									true	"Tenant ID"
+// @Param		"Invitation"	body		object.CreateInvitation					true	"Create Invitation Data"
+// @Success	201				{object}	HttpResponse{data=object.Invitation{}}	"Invitation"
+// @Failure	400				{object}	HttpResponse{data=nil}					"Bad Request"
+// @Router		/api/v1/tenant/{tenant_id}/invitation [post]
+func (ir IdentityRoutes) createInvitation(c *gin.Context) {
+	tenantID := c.Param("tenant_id")
+
+	var body object.CreateInvitation
+	err := c.ShouldBind(&body)
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, HttpResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	invitation, err := ir.service.CreateInvitation(c, tenantID, body)
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, HttpResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, HttpResponse{
+		Data: invitation,
+	})
+}
+
+// @Summary	Kill an existing Invitation
+// @Tags		Invitation API
+// @Accept		json
+// @Produce	json
+// @Param		tenant_id		path	string	true	"Tenant ID"
+// @Param		invitation_id	path	string	true	"Invitation ID"
+// @Success	204
+// @Failure	400	{object}	HttpResponse{data=nil}	"Bad Request"
+// @Router		/api/v1/tenant/{tenant_id}/invitation/{invitation_id} [delete]
+func (ir IdentityRoutes) killInvitation(c *gin.Context) {
+	tenantID := c.Param("tenant_id")
+	invitationID := c.Param("invitation_id")
+
+	err := ir.service.KillInvitation(c, tenantID, invitationID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, HttpResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary	Get an existing Invitation
+// @Tags		Invitation API
+// @Accept		json
+// @Produce	json
+// @Param		tenant_id		path		string									true	"Tenant ID"
+// @Param		invitation_id	path		string									true	"Invitation ID"
+// @Success	200				{object}	HttpResponse{data=object.Invitation{}}	"Invitation"
+// @Failure	400				{object}	HttpResponse{data=nil}					"Bad Request"
+// @Router		/api/v1/tenant/{tenant_id}/invitation/{invitation_id} [get]
+func (ir IdentityRoutes) findInvitation(c *gin.Context) {
+	tenantID := c.Param("tenant_id")
+	invitationID := c.Param("invitation_id")
+
+	invitation, err := ir.service.FindInvitation(c, tenantID, invitationID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, HttpResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, HttpResponse{
+		Data: invitation,
+	})
+}
+
+// @Summary	Get existing Invitations
+// @Tags		Invitation API
+// @Accept		json
+// @Produce	json
+// @Param		page		query		string									false	"Page"
+// @Param		page_limit	query		string									false	"Page Limit"
+// @Param		tenant_id	path		string									true	"Tenant ID"
+// @Success	200			{object}	HttpResponse{data=[]object.Invitation{}}	"Invitations"
+// @Failure	400			{object}	HttpResponse{data=nil}					"Bad Request"
+// @Router		/api/v1/tenant/{tenant_id}/invitation [get]
+func (ir IdentityRoutes) findInvitations(c *gin.Context) {
+	tenantID := c.Param("tenant_id")
+
+	pagination, ok := c.Get("pagination")
+	if !ok {
+		c.JSON(http.StatusInternalServerError, HttpResponse{
+			Error: "pagination parameter is missing",
+		})
+		return
+	}
+
+	paginationObj, ok := pagination.(object.Pagination)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, errors.New("pagination parameter cant be converted to object.Pagination"))
+		return
+	}
+
+	invitations, err := ir.service.FindInvitations(c, tenantID, paginationObj)
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, HttpResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, HttpResponse{
+		Data: invitations,
+	})
+}
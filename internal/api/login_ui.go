@@ -0,0 +1,924 @@
+/*
+ * Copyright (C) 2025 Anthrove
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/anthrove/identity/pkg/i18n/templates"
+	"github.com/anthrove/identity/pkg/logic"
+	"github.com/anthrove/identity/pkg/object"
+	"github.com/anthrove/identity/pkg/util"
+	"github.com/gin-gonic/gin"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+func newUIPage(title string, tenant object.Tenant, application object.Application, requestID string) templates.Page {
+	return templates.Page{
+		Title:           title,
+		TenantID:        tenant.ID,
+		TenantName:      tenant.DisplayName,
+		ApplicationID:   application.ID,
+		ApplicationName: application.DisplayName,
+		RequestID:       requestID,
+		SignUpURL:       application.SignUpURL,
+		SignInURL:       application.SignInURL,
+		TermsURL:        application.TermsURL,
+	}
+}
+
+//	@Summary	Hosted login page of an OIDC auth request or the self-service, a still valid session of the tenant skips the login
+//	@Tags		Hosted Login
+//	@Produce	html
+//	@Param		tenant_id		path	string	true	"Tenant ID"
+//	@Param		request_id		query	string	false	"Auth Request ID"
+//	@Param		application_id	query	string	false	"Application ID, if no auth request is given"
+//	@Success	200
+//	@Success	303
+//	@Failure	400
+//	@Router		/auth/{tenant_id}/login [get]
+func (ir IdentityRoutes) uiLoginPage(c *gin.Context) {
+	tenant, authRequest, application, ok := ir.uiAuthRequest(c)
+
+	if !ok {
+		return
+	}
+
+	_, session := ir.uiSession(c, tenant.ID)
+
+	if loggedIn, _ := session["logged_in"].(bool); loggedIn && !slices.Contains(authRequest.Prompt, "login") {
+		user, _ := session["user"].(object.User)
+		authenticatedAt, _ := session["authenticated_at"].(time.Time)
+
+		if authRequest.MaxAuthAge == nil || time.Since(authenticatedAt) <= *authRequest.MaxAuthAge {
+			ir.uiSignInDone(c, tenant, authRequest, user, authenticatedAt)
+			return
+		}
+	}
+
+	page := newUIPage("Sign In", tenant, application, authRequest.ID)
+	page.Username = authRequest.LoginHint
+	page.PasswordEnabled = passwordEnabled(application)
+
+	ir.renderUIPage(c, http.StatusOK, "login", page)
+}
+
+//	@Summary	Signs in with the hosted login page
+//	@Tags		Hosted Login
+//	@Accept		x-www-form-urlencoded
+//	@Produce	html
+//	@Param		tenant_id		path		string	true	"Tenant ID"
+//	@Param		request_id		formData	string	false	"Auth Request ID"
+//	@Param		application_id	formData	string	false	"Application ID, if no auth request is given"
+//	@Param		username		formData	string	true	"Username"
+//	@Param		password		formData	string	true	"Password"
+//	@Success	303
+//	@Failure	400
+//	@Failure	429
+//	@Router		/auth/{tenant_id}/login [post]
+func (ir IdentityRoutes) uiLoginSubmit(c *gin.Context) {
+	tenant, authRequest, application, ok := ir.uiAuthRequest(c)
+
+	if !ok {
+		return
+	}
+
+	username := c.PostForm("username")
+
+	// the auth request is completed by the hosted login itself, because the consent can still be missing
+	signInData := object.SignInRequest{
+		Username: username,
+		Type:     "password",
+		Metadata: map[string]any{
+			"password": c.PostForm("password"),
+		},
+	}
+	signInData.TrustedDeviceToken, _ = c.Cookie(trustedDeviceCookie(tenant.ID))
+
+	sessionID, response, err := ir.service.SignInSubmit(c, tenant.ID, application.ID, signInData)
+
+	if err != nil {
+		page := newUIPage("Sign In", tenant, application, authRequest.ID)
+		page.Username = username
+		page.PasswordEnabled = passwordEnabled(application)
+		page.Error = uiSignInError(err)
+
+		ir.renderUIPage(c, attemptErrorStatus(err), "login", page)
+		return
+	}
+
+	setSessionCookie(c, sessionID)
+	ir.uiSignInStep(c, tenant, authRequest, response)
+}
+
+//	@Summary	Hosted page for the second factor of a sign in
+//	@Tags		Hosted Login
+//	@Produce	html
+//	@Param		tenant_id		path	string	true	"Tenant ID"
+//	@Param		request_id		query	string	false	"Auth Request ID"
+//	@Param		application_id	query	string	false	"Application ID, if no auth request is given"
+//	@Param		mfa_id			query	string	false	"MFA ID"
+//	@Success	200
+//	@Failure	400
+//	@Router		/auth/{tenant_id}/login/mfa [get]
+func (ir IdentityRoutes) uiMFAPage(c *gin.Context) {
+	tenant, authRequest, application, ok := ir.uiAuthRequest(c)
+
+	if !ok {
+		return
+	}
+
+	page, ok := ir.uiMFAPageData(c, tenant, authRequest, application, c.Query("mfa_id"))
+
+	if !ok {
+		return
+	}
+
+	if c.Query("sent") == "true" {
+		page.Pending = page.MFA.Type == "push"
+		page.Info = "We sent you a code."
+
+		if page.Pending {
+			page.Info = "We sent you a push notification."
+		}
+	}
+
+	ir.renderUIPage(c, http.StatusOK, "mfa", page)
+}
+
+//	@Summary	Starts the selected MFA of the hosted page, e.g. by sending a one-time code
+//	@Tags		Hosted Login
+//	@Accept		x-www-form-urlencoded
+//	@Produce	html
+//	@Param		tenant_id		path		string	true	"Tenant ID"
+//	@Param		request_id		formData	string	false	"Auth Request ID"
+//	@Param		application_id	formData	string	false	"Application ID, if no auth request is given"
+//	@Param		mfa_id			formData	string	true	"MFA ID"
+//	@Success	303
+//	@Failure	400
+//	@Router		/auth/{tenant_id}/login/mfa/send [post]
+func (ir IdentityRoutes) uiMFASend(c *gin.Context) {
+	tenant, authRequest, application, ok := ir.uiAuthRequest(c)
+
+	if !ok {
+		return
+	}
+
+	mfaID := c.PostForm("mfa_id")
+	sessionID, _ := ir.uiSession(c, tenant.ID)
+
+	_, err := ir.service.SignInMFAInit(c, tenant.ID, application.ID, sessionID, object.SignInMFAInitRequest{
+		MFAID: mfaID,
+	})
+
+	if err != nil {
+		page, ok := ir.uiMFAPageData(c, tenant, authRequest, application, mfaID)
+
+		if !ok {
+			return
+		}
+
+		page.Error = err.Error()
+		ir.renderUIPage(c, http.StatusBadRequest, "mfa", page)
+		return
+	}
+
+	query := uiRequestQuery(authRequest)
+	query.Set("mfa_id", mfaID)
+	query.Set("sent", "true")
+
+	c.Redirect(http.StatusSeeOther, uiURL(tenant.ID, "/login/mfa", query))
+}
+
+//	@Summary	Verifies the second factor or a recovery code on the hosted page
+//	@Tags		Hosted Login
+//	@Accept		x-www-form-urlencoded
+//	@Produce	html
+//	@Param		tenant_id		path		string	true	"Tenant ID"
+//	@Param		request_id		formData	string	true	"Auth Request ID"
+//	@Param		mfa_id			formData	string	false	"MFA ID"
+//	@Param		code			formData	string	false	"One-Time Code"
+//	@Param		credential		formData	string	false	"JSON encoded PublicKeyCredential of a webauthn MFA"
+//	@Param		recovery_code	formData	string	false	"Recovery Code"
+//	@Param		trust_device	formData	bool	false	"Trust Device"
+//	@Success	303
+//	@Failure	400
+//	@Failure	429
+//	@Router		/auth/{tenant_id}/login/mfa [post]
+func (ir IdentityRoutes) uiMFASubmit(c *gin.Context) {
+	tenant, authRequest, application, ok := ir.uiAuthRequest(c)
+
+	if !ok {
+		return
+	}
+
+	signInData := object.SignInMFARequest{
+		MFAID:        c.PostForm("mfa_id"),
+		RecoveryCode: strings.TrimSpace(c.PostForm("recovery_code")),
+		TrustDevice:  c.PostForm("trust_device") == "true",
+	}
+
+	if code := strings.TrimSpace(c.PostForm("code")); len(code) > 0 {
+		signInData.Metadata = map[string]any{
+			"otp": code,
+		}
+	}
+
+	// the webauthn script of the page posts the PublicKeyCredential of the browser as JSON
+	if credential := c.PostForm("credential"); len(credential) > 0 {
+		_ = json.Unmarshal([]byte(credential), &signInData.Metadata)
+	}
+
+	sessionID, _ := ir.uiSession(c, tenant.ID)
+	response, err := ir.service.SignInMFA(c, tenant.ID, application.ID, sessionID, signInData)
+
+	if err != nil || response.Step == object.SignInStepMFAPending {
+		page, ok := ir.uiMFAPageData(c, tenant, authRequest, application, signInData.MFAID)
+
+		if !ok {
+			return
+		}
+
+		status := http.StatusOK
+
+		if err != nil {
+			status = attemptErrorStatus(err)
+			page.Error = uiSignInError(err)
+		} else {
+			page.Pending = true
+			page.Info = "The sign in was not approved yet."
+		}
+
+		ir.renderUIPage(c, status, "mfa", page)
+		return
+	}
+
+	if len(response.TrustedDeviceToken) > 0 {
+		c.SetSameSite(http.SameSiteLaxMode)
+		c.SetCookie(trustedDeviceCookie(tenant.ID), response.TrustedDeviceToken, int(time.Until(response.TrustedDeviceUntil).Seconds()), "/", "", c.Request.TLS != nil, true)
+	}
+
+	ir.uiSignInStep(c, tenant, authRequest, response)
+}
+
+//	@Summary	Hosted page to set a new password during a sign in
+//	@Tags		Hosted Login
+//	@Produce	html
+//	@Param		tenant_id		path	string	true	"Tenant ID"
+//	@Param		request_id		query	string	false	"Auth Request ID"
+//	@Param		application_id	query	string	false	"Application ID, if no auth request is given"
+//	@Success	200
+//	@Failure	400
+//	@Router		/auth/{tenant_id}/login/password [get]
+func (ir IdentityRoutes) uiPasswordPage(c *gin.Context) {
+	tenant, authRequest, application, ok := ir.uiAuthRequest(c)
+
+	if !ok {
+		return
+	}
+
+	ir.renderUIPage(c, http.StatusOK, "password", newUIPage("Change Password", tenant, application, authRequest.ID))
+}
+
+//	@Summary	Sets a new password during a sign in on the hosted page
+//	@Tags		Hosted Login
+//	@Accept		x-www-form-urlencoded
+//	@Produce	html
+//	@Param		tenant_id		path		string	true	"Tenant ID"
+//	@Param		request_id		formData	string	false	"Auth Request ID"
+//	@Param		application_id	formData	string	false	"Application ID, if no auth request is given"
+//	@Param		password		formData	string	true	"New Password"
+//	@Success	303
+//	@Failure	400
+//	@Router		/auth/{tenant_id}/login/password [post]
+func (ir IdentityRoutes) uiPasswordSubmit(c *gin.Context) {
+	tenant, authRequest, application, ok := ir.uiAuthRequest(c)
+
+	if !ok {
+		return
+	}
+
+	sessionID, _ := ir.uiSession(c, tenant.ID)
+
+	response, err := ir.service.SignInChangePassword(c, tenant.ID, application.ID, sessionID, object.SignInChangePasswordRequest{
+		Password: c.PostForm("password"),
+	})
+
+	if err != nil {
+		page := newUIPage("Change Password", tenant, application, authRequest.ID)
+		page.Error = err.Error()
+
+		ir.renderUIPage(c, http.StatusBadRequest, "password", page)
+		return
+	}
+
+	ir.uiSignInStep(c, tenant, authRequest, response)
+}
+
+//	@Summary	Hosted page which asks the signed in user to grant the requested scopes to the application
+//	@Tags		Hosted Login
+//	@Produce	html
+//	@Param		tenant_id	path	string	true	"Tenant ID"
+//	@Param		request_id	query	string	true	"Auth Request ID"
+//	@Success	200
+//	@Failure	400
+//	@Failure	401
+//	@Router		/auth/{tenant_id}/consent [get]
+func (ir IdentityRoutes) uiConsentPage(c *gin.Context) {
+	tenant, authRequest, application, ok := ir.uiConsentRequest(c)
+
+	if !ok {
+		return
+	}
+
+	user, _, ok := ir.uiSignedInUser(c, tenant)
+
+	if !ok {
+		return
+	}
+
+	page := newUIPage("Allow Access", tenant, application, authRequest.ID)
+	page.Username = user.Username
+	page.Scopes = authRequest.Scopes
+
+	ir.renderUIPage(c, http.StatusOK, "consent", page)
+}
+
+//	@Summary	Allows or denies the requested scopes on the hosted consent page
+//	@Tags		Hosted Login
+//	@Accept		x-www-form-urlencoded
+//	@Produce	html
+//	@Param		tenant_id	path		string	true	"Tenant ID"
+//	@Param		request_id	formData	string	true	"Auth Request ID"
+//	@Param		action		formData	string	true	"allow or deny"
+//	@Success	303
+//	@Failure	400
+//	@Failure	401
+//	@Router		/auth/{tenant_id}/consent [post]
+func (ir IdentityRoutes) uiConsentSubmit(c *gin.Context) {
+	tenant, authRequest, _, ok := ir.uiConsentRequest(c)
+
+	if !ok {
+		return
+	}
+
+	user, authenticatedAt, ok := ir.uiSignedInUser(c, tenant)
+
+	if !ok {
+		return
+	}
+
+	if c.PostForm("action") != "allow" {
+		callbackURL, err := url.Parse(authRequest.CallbackURI)
+
+		if err != nil {
+			ir.renderUIError(c, http.StatusBadRequest, tenant, err)
+			return
+		}
+
+		query := callbackURL.Query()
+		query.Set("error", "access_denied")
+		query.Set("error_description", "the user denied the access")
+
+		if len(authRequest.TransferState) > 0 {
+			query.Set("state", authRequest.TransferState)
+		}
+
+		callbackURL.RawQuery = query.Encode()
+
+		// the auth request can't be used anymore after it was denied
+		_ = ir.service.KillAuthRequest(c, tenant.ID, authRequest.ID)
+
+		c.Redirect(http.StatusSeeOther, callbackURL.String())
+		return
+	}
+
+	err := ir.service.GrantConsent(c, tenant.ID, user.ID, authRequest)
+
+	if err != nil {
+		ir.renderUIError(c, http.StatusBadRequest, tenant, err)
+		return
+	}
+
+	ir.uiCompleteAuthRequest(c, tenant, authRequest, user, authenticatedAt)
+}
+
+//	@Summary	Hosted page to request a password reset mail
+//	@Tags		Hosted Login
+//	@Produce	html
+//	@Param		tenant_id		path	string	true	"Tenant ID"
+//	@Param		request_id		query	string	false	"Auth Request ID"
+//	@Param		application_id	query	string	false	"Application ID, if no auth request is given"
+//	@Success	200
+//	@Failure	400
+//	@Router		/auth/{tenant_id}/password/reset [get]
+func (ir IdentityRoutes) uiPasswordResetPage(c *gin.Context) {
+	tenant, application, requestID, ok := ir.uiApplication(c)
+
+	if !ok {
+		return
+	}
+
+	ir.renderUIPage(c, http.StatusOK, "reset", newUIPage("Reset Password", tenant, application, requestID))
+}
+
+//	@Summary	Requests a password reset mail on the hosted page
+//	@Description	The page always reports success, so it can't be used to find out which users exist.
+//	@Tags		Hosted Login
+//	@Accept		x-www-form-urlencoded
+//	@Produce	html
+//	@Param		tenant_id		path		string	true	"Tenant ID"
+//	@Param		request_id		formData	string	false	"Auth Request ID"
+//	@Param		application_id	formData	string	false	"Application ID, if no auth request is given"
+//	@Param		username		formData	string	true	"Username or Email"
+//	@Success	200
+//	@Failure	400
+//	@Router		/auth/{tenant_id}/password/reset [post]
+func (ir IdentityRoutes) uiPasswordResetSubmit(c *gin.Context) {
+	tenant, application, requestID, ok := ir.uiApplication(c)
+
+	if !ok {
+		return
+	}
+
+	var requestPasswordReset object.RequestPasswordReset
+	username := strings.TrimSpace(c.PostForm("username"))
+
+	if strings.Contains(username, "@") {
+		requestPasswordReset.Email = username
+	} else {
+		requestPasswordReset.Username = username
+	}
+
+	page := newUIPage("Reset Password", tenant, application, requestID)
+	err := ir.service.RequestPasswordReset(c, tenant.ID, application.ID, requestPasswordReset)
+
+	if err != nil {
+		page.Username = username
+		page.Error = err.Error()
+
+		ir.renderUIPage(c, http.StatusBadRequest, "reset", page)
+		return
+	}
+
+	page.Done = true
+	page.Info = "If an account exists, we sent you a mail with a link to reset your password."
+
+	ir.renderUIPage(c, http.StatusOK, "reset", page)
+}
+
+//	@Summary	Hosted page which sets the new password with the link of the password reset mail
+//	@Description	To use it, set the forget url of the application to /auth/{tenant_id}/password/reset/complete?application_id={application_id}
+//	@Tags		Hosted Login
+//	@Produce	html
+//	@Param		tenant_id		path	string	true	"Tenant ID"
+//	@Param		application_id	query	string	true	"Application ID"
+//	@Param		token			query	string	true	"Password Reset Token"
+//	@Success	200
+//	@Failure	400
+//	@Router		/auth/{tenant_id}/password/reset/complete [get]
+func (ir IdentityRoutes) uiPasswordResetCompletePage(c *gin.Context) {
+	tenant, application, _, ok := ir.uiApplication(c)
+
+	if !ok {
+		return
+	}
+
+	page := newUIPage("Set New Password", tenant, application, "")
+	page.Token = c.Query("token")
+
+	ir.renderUIPage(c, http.StatusOK, "reset_complete", page)
+}
+
+//	@Summary	Sets the new password with the token of the password reset mail on the hosted page
+//	@Tags		Hosted Login
+//	@Accept		x-www-form-urlencoded
+//	@Produce	html
+//	@Param		tenant_id		path		string	true	"Tenant ID"
+//	@Param		application_id	formData	string	true	"Application ID"
+//	@Param		token			formData	string	true	"Password Reset Token"
+//	@Param		password		formData	string	true	"New Password"
+//	@Success	200
+//	@Failure	400
+//	@Router		/auth/{tenant_id}/password/reset/complete [post]
+func (ir IdentityRoutes) uiPasswordResetCompleteSubmit(c *gin.Context) {
+	tenant, application, _, ok := ir.uiApplication(c)
+
+	if !ok {
+		return
+	}
+
+	page := newUIPage("Set New Password", tenant, application, "")
+	page.Token = c.PostForm("token")
+
+	err := ir.service.CompletePasswordReset(c, tenant.ID, application.ID, object.CompletePasswordReset{
+		Token:    page.Token,
+		Password: c.PostForm("password"),
+	})
+
+	if err != nil {
+		page.Error = err.Error()
+
+		ir.renderUIPage(c, http.StatusBadRequest, "reset_complete", page)
+		return
+	}
+
+	page.Done = true
+	page.Token = ""
+	page.Info = "Your password was changed, you can sign in with it now."
+
+	ir.renderUIPage(c, http.StatusOK, "reset_complete", page)
+}
+
+// uiAuthRequest loads the auth request of the page together with its tenant and application.
+// Without an auth request the user signs in to the self-service, then only the application is loaded from the
+// application_id parameter and the returned auth request has no ID.
+// If they can't be loaded, the error page is rendered and false is returned.
+func (ir IdentityRoutes) uiAuthRequest(c *gin.Context) (object.Tenant, object.AuthRequest, object.Application, bool) {
+	tenant, err := ir.service.FindTenant(c, c.Param("tenant_id"))
+
+	if err != nil {
+		ir.renderUIError(c, http.StatusBadRequest, object.Tenant{}, errors.New("tenant not found"))
+		return object.Tenant{}, object.AuthRequest{}, object.Application{}, false
+	}
+
+	authRequest := object.AuthRequest{
+		ApplicationID: uiFormValue(c, "application_id"),
+	}
+
+	if requestID := uiFormValue(c, "request_id"); len(requestID) > 0 || len(authRequest.ApplicationID) == 0 {
+		authRequest, err = ir.service.FindAuthRequest(c, tenant.ID, requestID)
+
+		if err != nil {
+			ir.renderUIError(c, http.StatusBadRequest, tenant, errors.New("the sign in request is unknown or expired"))
+			return object.Tenant{}, object.AuthRequest{}, object.Application{}, false
+		}
+
+		if authRequest.Authenticated {
+			ir.renderUIError(c, http.StatusBadRequest, tenant, errors.New("the sign in request was already completed"))
+			return object.Tenant{}, object.AuthRequest{}, object.Application{}, false
+		}
+	}
+
+	application, err := ir.service.FindApplication(c, tenant.ID, authRequest.ApplicationID)
+
+	if err != nil {
+		ir.renderUIError(c, http.StatusBadRequest, tenant, errors.New("application not found"))
+		return object.Tenant{}, object.AuthRequest{}, object.Application{}, false
+	}
+
+	setUIRequestInfo(c, application.ID)
+
+	return tenant, authRequest, application, true
+}
+
+// uiApplication loads the application of a page which can be used without an auth request, like the password reset.
+// The application is taken from the auth request if one is given, otherwise from the application_id parameter.
+func (ir IdentityRoutes) uiApplication(c *gin.Context) (object.Tenant, object.Application, string, bool) {
+	tenant, authRequest, application, ok := ir.uiAuthRequest(c)
+	return tenant, application, authRequest.ID, ok
+}
+
+// uiConsentRequest loads the auth request of the consent page, the consent is always given for an auth request.
+func (ir IdentityRoutes) uiConsentRequest(c *gin.Context) (object.Tenant, object.AuthRequest, object.Application, bool) {
+	tenant, authRequest, application, ok := ir.uiAuthRequest(c)
+
+	if ok && len(authRequest.ID) == 0 {
+		ir.renderUIError(c, http.StatusBadRequest, tenant, errors.New("the sign in request is unknown or expired"))
+		return object.Tenant{}, object.AuthRequest{}, object.Application{}, false
+	}
+
+	return tenant, authRequest, application, ok
+}
+
+// uiSession returns the session of the browser, if it belongs to the tenant.
+func (ir IdentityRoutes) uiSession(c *gin.Context, tenantID string) (string, map[string]any) {
+	sessionID, err := c.Cookie("identity_session_id")
+
+	if err != nil || len(sessionID) == 0 {
+		return "", nil
+	}
+
+	session := ir.service.FindSession(c, sessionID)
+
+	if session == nil || session["tenant_id"] != tenantID {
+		return "", nil
+	}
+
+	return sessionID, session
+}
+
+// uiSignedInUser returns the user of the session, if the sign in was completed.
+// Otherwise, the error page is rendered and false is returned.
+func (ir IdentityRoutes) uiSignedInUser(c *gin.Context, tenant object.Tenant) (object.User, time.Time, bool) {
+	_, session := ir.uiSession(c, tenant.ID)
+
+	loggedIn, _ := session["logged_in"].(bool)
+	user, hasUser := session["user"].(object.User)
+
+	if !loggedIn || !hasUser {
+		ir.renderUIError(c, http.StatusUnauthorized, tenant, errors.New("you are not signed in"))
+		return object.User{}, time.Time{}, false
+	}
+
+	authenticatedAt, _ := session["authenticated_at"].(time.Time)
+
+	return user, authenticatedAt, true
+}
+
+// uiMFAPageData collects the MFAs of the user who is in the middle of a sign in. The selected MFA is used on the page,
+// without a selection the first one is used. A webauthn MFA gets a new challenge, the one of a failed attempt is used up.
+func (ir IdentityRoutes) uiMFAPageData(c *gin.Context, tenant object.Tenant, authRequest object.AuthRequest, application object.Application, mfaID string) (templates.Page, bool) {
+	_, session := ir.uiSession(c, tenant.ID)
+
+	mfaRequired, _ := session["mfa_required"].(bool)
+	user, hasUser := session["user"].(object.User)
+
+	if !mfaRequired || !hasUser {
+		ir.renderUIError(c, http.StatusUnauthorized, tenant, errors.New("no sign in was started"))
+		return templates.Page{}, false
+	}
+
+	mfas, err := ir.service.FindVerifiedMFAs(c, tenant.ID, user.ID)
+
+	if err != nil || len(mfas) == 0 {
+		ir.renderUIError(c, http.StatusBadRequest, tenant, errors.New("no verified mfa found"))
+		return templates.Page{}, false
+	}
+
+	page := newUIPage("Verify Sign In", tenant, application, authRequest.ID)
+	page.MFAs = mfas
+	page.MFA = mfas[0]
+	page.TrustDeviceAllowed = tenant.TrustedDeviceDays > 0 && !application.RequireFreshMFA
+
+	for _, userMFA := range mfas {
+		if userMFA.ID == mfaID {
+			page.MFA = userMFA
+			break
+		}
+	}
+
+	if page.MFA.Type == "webauthn" {
+		sessionID, _ := ir.uiSession(c, tenant.ID)
+
+		options, err := ir.service.SignInMFAInit(c, tenant.ID, application.ID, sessionID, object.SignInMFAInitRequest{
+			MFAID: page.MFA.ID,
+		})
+
+		if err == nil {
+			var optionsJson []byte
+			optionsJson, err = json.Marshal(options)
+			page.WebAuthnOptions = string(optionsJson)
+		}
+
+		if err != nil {
+			page.Error = err.Error()
+		}
+	}
+
+	return page, true
+}
+
+// uiSignInStep redirects to the page of the next sign in step.
+func (ir IdentityRoutes) uiSignInStep(c *gin.Context, tenant object.Tenant, authRequest object.AuthRequest, response object.SignInResponse) {
+	query := uiRequestQuery(authRequest)
+
+	switch response.Step {
+	case object.SignInStepMFARequired:
+		c.Redirect(http.StatusSeeOther, uiURL(tenant.ID, "/login/mfa", query))
+	case object.SignInStepPasswordChangeRequired:
+		c.Redirect(http.StatusSeeOther, uiURL(tenant.ID, "/login/password", query))
+	default:
+		_, session := ir.uiSession(c, tenant.ID)
+		authenticatedAt, _ := session["authenticated_at"].(time.Time)
+
+		ir.uiSignInDone(c, tenant, authRequest, response.User, authenticatedAt)
+	}
+}
+
+// uiSignInDone asks for the consent of the user if it is required, otherwise the auth request is completed.
+// A sign in without auth request continues in the self-service.
+func (ir IdentityRoutes) uiSignInDone(c *gin.Context, tenant object.Tenant, authRequest object.AuthRequest, user object.User, authenticatedAt time.Time) {
+	if len(authRequest.ID) == 0 {
+		c.Redirect(http.StatusSeeOther, accountURL(tenant.ID, "", url.Values{
+			"application_id": {authRequest.ApplicationID},
+		}))
+		return
+	}
+
+	consentRequired, err := ir.service.ConsentRequired(c, tenant.ID, user.ID, authRequest)
+
+	if err != nil {
+		ir.renderUIError(c, http.StatusBadRequest, tenant, err)
+		return
+	}
+
+	if consentRequired {
+		c.Redirect(http.StatusSeeOther, uiURL(tenant.ID, "/consent", url.Values{
+			"request_id": {authRequest.ID},
+		}))
+		return
+	}
+
+	ir.uiCompleteAuthRequest(c, tenant, authRequest, user, authenticatedAt)
+}
+
+// uiCompleteAuthRequest completes the auth request and hands the user back to the OIDC provider, which redirects to the application.
+func (ir IdentityRoutes) uiCompleteAuthRequest(c *gin.Context, tenant object.Tenant, authRequest object.AuthRequest, user object.User, authenticatedAt time.Time) {
+	err := ir.service.CompleteAuthRequest(c, tenant.ID, authRequest.ID, user.ID, authenticatedAt)
+
+	if err != nil {
+		ir.renderUIError(c, http.StatusBadRequest, tenant, err)
+		return
+	}
+
+	c.Redirect(http.StatusSeeOther, "/"+tenant.ID+"/authorize/callback?id="+url.QueryEscape(authRequest.ID))
+}
+
+// setUIRequestInfo adds the application to the request info, the hosted pages don't have it in the path.
+func setUIRequestInfo(c *gin.Context, applicationID string) {
+	c.Set("request_info", object.RequestInfo{
+		IPAddress:     c.ClientIP(),
+		UserAgent:     c.Request.UserAgent(),
+		ApplicationID: applicationID,
+	})
+}
+
+// setSessionCookie sets the session cookie for all paths, so the hosted pages and the api share the session.
+func setSessionCookie(c *gin.Context, sessionID string) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie("identity_session_id", sessionID, 60*60*24*30, "/", "", c.Request.TLS != nil, true)
+}
+
+// renderUIPage renders a hosted page with the branding of its application. If a custom template of the branding fails,
+// the built-in page is rendered instead, so a broken template doesn't lock out the users.
+func (ir IdentityRoutes) renderUIPage(c *gin.Context, status int, name string, page templates.Page) {
+	page.Branding = object.DefaultBranding
+
+	if len(page.TenantID) > 0 {
+		branding, err := ir.service.FindBranding(c, page.TenantID, page.ApplicationID)
+
+		if err == nil {
+			page.Branding = branding
+		}
+	}
+
+	scriptNonce, err := util.RandomString(32)
+
+	if err != nil {
+		_ = c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	page.ScriptNonce = scriptNonce
+
+	var buffer bytes.Buffer
+	err = templates.RenderPage(&buffer, name, page)
+
+	if err != nil && len(page.Branding.PageTemplates[name]) > 0 {
+		log.Printf("problem while rendering custom template of page %s: %v", name, err)
+
+		page.Branding.PageTemplates = nil
+		err = templates.RenderPage(&buffer, name, page)
+	}
+
+	if err != nil {
+		_ = c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	// custom templates are rendered without the nonce, their scripts stay blocked
+	if len(page.Branding.PageTemplates[name]) > 0 {
+		scriptNonce = ""
+	}
+
+	// the sign in ends with a redirect to the application, browsers check the redirects of a sent form against form-action
+	var formOrigins []string
+
+	if len(page.ApplicationID) > 0 {
+		application, err := ir.service.FindApplication(c, page.TenantID, page.ApplicationID)
+
+		if err == nil {
+			formOrigins = redirectOrigins(application.RedirectURLs)
+		}
+	}
+
+	writeUIPage(c, status, buffer.Bytes(), scriptNonce, formOrigins)
+}
+
+func (ir IdentityRoutes) renderUIError(c *gin.Context, status int, tenant object.Tenant, err error) {
+	page := newUIPage("Something went wrong", tenant, object.Application{}, "")
+	page.Error = err.Error()
+
+	ir.renderUIPage(c, status, "error", page)
+}
+
+// writeUIPage writes a rendered page. The content security policy only allows the scripts with the given nonce, which
+// are the first-party scripts of the built-in pages, and the pages must not be embedded by other sites, e.g. to trick
+// users into signing in. Forms can only be sent to the identity server, the form origins are the applications the
+// sign in redirects to afterward.
+func writeUIPage(c *gin.Context, status int, page []byte, scriptNonce string, formOrigins []string) {
+	scriptSource := ""
+
+	if len(scriptNonce) > 0 {
+		scriptSource = "; script-src 'nonce-" + scriptNonce + "'"
+	}
+
+	formAction := strings.Join(append([]string{"'self'"}, formOrigins...), " ")
+
+	c.Header("Content-Security-Policy", "default-src 'none'; img-src * data:; style-src 'unsafe-inline'; font-src https: data:; base-uri 'none'; form-action "+formAction+"; frame-ancestors 'none'"+scriptSource)
+	c.Header("X-Frame-Options", "DENY")
+	c.Header("Cache-Control", "no-store")
+
+	c.Data(status, "text/html; charset=utf-8", page)
+}
+
+// redirectOrigins returns the origins of the redirect urls of an application as sources of a content security policy.
+// Apps with custom schemes are allowed by their scheme, urls which can't be expressed as source are left out.
+func redirectOrigins(redirectURLs []string) []string {
+	origins := make([]string, 0, len(redirectURLs))
+
+	for _, redirectURL := range redirectURLs {
+		parsed, err := url.Parse(redirectURL)
+
+		if err != nil || len(parsed.Scheme) == 0 {
+			continue
+		}
+
+		origin := parsed.Scheme + ":"
+
+		if parsed.Scheme == "http" || parsed.Scheme == "https" {
+			origin = parsed.Scheme + "://" + parsed.Host
+		}
+
+		if strings.ContainsAny(origin, " ;,'\"") || slices.Contains(origins, origin) {
+			continue
+		}
+
+		origins = append(origins, origin)
+	}
+
+	return origins
+}
+
+// uiSignInError hides why credentials were rejected, only the throttling is shown to the user.
+func uiSignInError(err error) string {
+	if errors.Is(err, logic.ErrTooManyAttempts) {
+		return err.Error()
+	}
+
+	return "The sign in failed, please check your input and try again."
+}
+
+// uiFormValue returns a parameter of a hosted page, it is either in the query or in the submitted form.
+func uiFormValue(c *gin.Context, key string) string {
+	if value := c.Query(key); len(value) > 0 {
+		return value
+	}
+
+	return c.PostForm(key)
+}
+
+// uiRequestQuery returns the query which identifies the sign in on the next page.
+func uiRequestQuery(authRequest object.AuthRequest) url.Values {
+	if len(authRequest.ID) == 0 {
+		return url.Values{"application_id": {authRequest.ApplicationID}}
+	}
+
+	return url.Values{"request_id": {authRequest.ID}}
+}
+
+func uiURL(tenantID string, path string, query url.Values) string {
+	return "/auth/" + tenantID + path + "?" + query.Encode()
+}
+
+// passwordEnabled reports if the password provider is enabled for the application.
+func passwordEnabled(application object.Application) bool {
+	for _, provider := range application.AuthProvider {
+		if provider.ProviderType == "password" {
+			return true
+		}
+	}
+
+	return false
+}
@@ -207,6 +207,7 @@ func (ir IdentityRoutes) findMFAs(c *gin.Context) {
 //	@Param		MFA			body	map[string]any	true	"Verify MFA Body"
 //	@Success	204
 //	@Failure	400	{object}	HttpResponse{data=nil}	"Bad Request"
+//	@Failure	429	{object}	HttpResponse{data=nil}	"Too Many Attempts"
 //	@Router		/tenant/{tenant_id}/user/{user_id}/mfa/{mfa_id}/verify [post]
 func (ir IdentityRoutes) verifyMFA(c *gin.Context) {
 	tenantID := c.Param("tenant_id")
@@ -226,7 +227,7 @@ func (ir IdentityRoutes) verifyMFA(c *gin.Context) {
 	err = ir.service.VerifyMFA(c, tenantID, userID, mfaID, body)
 
 	if err != nil {
-		c.JSON(http.StatusBadRequest, HttpResponse{
+		c.JSON(attemptErrorStatus(err), HttpResponse{
 			Error: err.Error(),
 		})
 		return
@@ -236,3 +237,126 @@ func (ir IdentityRoutes) verifyMFA(c *gin.Context) {
 		Data: gin.H{},
 	})
 }
+
+//	@Summary	Starts the validation of a MFA, e.g. by sending a one-time code
+//	@Tags		MFA API
+//	@Accept		json
+//	@Produce	json
+//	@Param		tenant_id	path		string								true	"Tenant ID"
+//	@Param		user_id		path		string								true	"User ID"
+//	@Param		mfa_id		path		string								true	"MFA ID"
+//	@Success	200			{object}	HttpResponse{data=map[string]any}	"MFA Provider Data"
+//	@Failure	400			{object}	HttpResponse{data=nil}				"Bad Request"
+//	@Router		/tenant/{tenant_id}/user/{user_id}/mfa/{mfa_id}/init [post]
+func (ir IdentityRoutes) initMFA(c *gin.Context) {
+	tenantID := c.Param("tenant_id")
+	userID := c.Param("user_id")
+	mfaID := c.Param("mfa_id")
+
+	response, err := ir.service.MfaInitDataflow(c, tenantID, userID, mfaID)
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, HttpResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, HttpResponse{
+		Data: response,
+	})
+}
+
+//	@Summary	Get the amount of remaining recovery codes of a MFA
+//	@Tags		MFA API
+//	@Accept		json
+//	@Produce	json
+//	@Param		tenant_id	path		string											true	"Tenant ID"
+//	@Param		user_id		path		string											true	"User ID"
+//	@Param		mfa_id		path		string											true	"MFA ID"
+//	@Success	200			{object}	HttpResponse{data=object.MFARecoveryCodeCount{}}	"Remaining Recovery Codes"
+//	@Failure	400			{object}	HttpResponse{data=nil}							"Bad Request"
+//	@Router		/tenant/{tenant_id}/user/{user_id}/mfa/{mfa_id}/recovery [get]
+func (ir IdentityRoutes) findMFARecoveryCodeCount(c *gin.Context) {
+	tenantID := c.Param("tenant_id")
+	userID := c.Param("user_id")
+	mfaID := c.Param("mfa_id")
+
+	count, err := ir.service.FindRecoveryCodeCount(c, tenantID, userID, mfaID)
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, HttpResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, HttpResponse{
+		Data: count,
+	})
+}
+
+//	@Summary	Regenerate the recovery codes of a MFA
+//	@Tags		MFA API
+//	@Accept		json
+//	@Produce	json
+//	@Param		tenant_id	path		string										true	"Tenant ID"
+//	@Param		user_id		path		string										true	"User ID"
+//	@Param		mfa_id		path		string										true	"MFA ID"
+//	@Success	200			{object}	HttpResponse{data=object.MFARecoveryCodes{}}	"New Recovery Codes"
+//	@Failure	400			{object}	HttpResponse{data=nil}						"Bad Request"
+//	@Router		/tenant/{tenant_id}/user/{user_id}/mfa/{mfa_id}/recovery [post]
+func (ir IdentityRoutes) regenerateMFARecoveryCodes(c *gin.Context) {
+	tenantID := c.Param("tenant_id")
+	userID := c.Param("user_id")
+	mfaID := c.Param("mfa_id")
+
+	recoveryCodes, err := ir.service.RegenerateRecoveryCodes(c, tenantID, userID, mfaID)
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, HttpResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, HttpResponse{
+		Data: recoveryCodes,
+	})
+}
+
+//	@Summary	Approves or denies a pending push approval
+//	@Tags		MFA API
+//	@Accept		json
+//	@Produce	json
+//	@Param		tenant_id		path	string						true	"Tenant ID"
+//	@Param		approval_id		path	string						true	"Approval ID"
+//	@Param		Decision		body	object.PushApprovalDecision	true	"Push Approval Decision"
+//	@Success	204
+//	@Failure	400	{object}	HttpResponse{data=nil}	"Bad Request"
+//	@Router		/api/v1/tenant/{tenant_id}/mfa/push/{approval_id} [post]
+func (ir IdentityRoutes) decidePushApproval(c *gin.Context) {
+	tenantID := c.Param("tenant_id")
+	approvalID := c.Param("approval_id")
+
+	var body object.PushApprovalDecision
+	err := c.ShouldBind(&body)
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, HttpResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	err = ir.service.DecidePushApproval(c, tenantID, approvalID, body)
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, HttpResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
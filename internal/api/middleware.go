@@ -65,6 +65,22 @@ func Pagination() gin.HandlerFunc {
 	}
 }
 
+// RequestInfo is a middleware function which collects information about the client of the request.
+// The sign in uses it to make sure every step is done by the same client.
+//
+// Returns:
+//   - A gin.HandlerFunc that can be used as middleware in a Gin router.
+func RequestInfo() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set("request_info", object.RequestInfo{
+			IPAddress:     c.ClientIP(),
+			UserAgent:     c.Request.UserAgent(),
+			ApplicationID: c.Param("application_id"),
+		})
+		c.Next()
+	}
+}
+
 func (ir IdentityRoutes) Authorization() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		sessionID, err := c.Cookie("identity_session_id")
@@ -90,6 +106,12 @@ func (ir IdentityRoutes) Authorization() gin.HandlerFunc {
 			return
 		}
 
+		// Sessions which still wait for their second factor are not logged in yet
+		if loggedIn, _ := session["logged_in"].(bool); !loggedIn {
+			c.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+
 		c.Set("session", session)
 
 		tenantID := c.Param("tenant_id")
@@ -0,0 +1,158 @@
+/*
+ * Copyright (C) 2025 Anthrove
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"github.com/anthrove/identity/pkg/object"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+//	@Summary	Require a user to change the password on the next sign in
+//	@Tags		User API
+//	@Accept		json
+//	@Produce	json
+//	@Param		tenant_id	path	string	true	"Tenant ID"
+//	@Param		user_id		path	string	true	"User ID"
+//	@Success	204
+//	@Failure	400	{object}	HttpResponse{data=nil}	"Bad Request"
+//	@Router		/api/v1/tenant/{tenant_id}/user/{user_id}/password/require_change [post]
+func (ir IdentityRoutes) requirePasswordChange(c *gin.Context) {
+	tenantID := c.Param("tenant_id")
+	userID := c.Param("user_id")
+
+	err := ir.service.RequirePasswordChange(c, tenantID, userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, HttpResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+//	@Summary	Change the password of a profile
+//	@Tags		Profile API
+//	@Accept		json
+//	@Produce	json
+//	@Param		"Change Password"	body	object.ChangePassword	true	"Change Password Data"
+//	@Success	204
+//	@Failure	400	{object}	HttpResponse{data=nil}	"Bad Request"
+//	@Failure	429	{object}	HttpResponse{data=nil}	"Too Many Attempts"
+//	@Router		/api/v1/profile/password [put]
+func (ir IdentityRoutes) profileChangePassword(c *gin.Context) {
+	user, err := sessionConvert(c)
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return
+	}
+
+	var body object.ChangePassword
+	err = c.ShouldBind(&body)
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, HttpResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	err = ir.service.ChangePassword(c, user.TenantID, user.ID, body)
+
+	if err != nil {
+		c.JSON(attemptErrorStatus(err), HttpResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+//	@Summary	Request a password reset mail
+//	@Tags		Authentication API
+//	@Accept		json
+//	@Produce	json
+//	@Param		tenant_id				path	string						true	"Tenant ID"
+//	@Param		application_id			path	string						true	"Application ID"
+//	@Param		"Request Password Reset"	body	object.RequestPasswordReset	true	"Username or Email"
+//	@Success	204
+//	@Failure	400	{object}	HttpResponse{data=nil}	"Bad Request"
+//	@Router		/api/v1/tenant/{tenant_id}/application/{application_id}/password/reset [post]
+func (ir IdentityRoutes) requestPasswordReset(c *gin.Context) {
+	tenantID := c.Param("tenant_id")
+	applicationID := c.Param("application_id")
+
+	var body object.RequestPasswordReset
+	err := c.ShouldBind(&body)
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, HttpResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	err = ir.service.RequestPasswordReset(c, tenantID, applicationID, body)
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, HttpResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+//	@Summary	Set a new password with a password reset token
+//	@Tags		Authentication API
+//	@Accept		json
+//	@Produce	json
+//	@Param		tenant_id					path	string							true	"Tenant ID"
+//	@Param		application_id				path	string							true	"Application ID"
+//	@Param		"Complete Password Reset"	body	object.CompletePasswordReset	true	"Token and new Password"
+//	@Success	204
+//	@Failure	400	{object}	HttpResponse{data=nil}	"Bad Request"
+//	@Router		/api/v1/tenant/{tenant_id}/application/{application_id}/password/reset/complete [post]
+func (ir IdentityRoutes) completePasswordReset(c *gin.Context) {
+	tenantID := c.Param("tenant_id")
+	applicationID := c.Param("application_id")
+
+	var body object.CompletePasswordReset
+	err := c.ShouldBind(&body)
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, HttpResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	err = ir.service.CompletePasswordReset(c, tenantID, applicationID, body)
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, HttpResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
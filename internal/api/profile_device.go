@@ -0,0 +1,100 @@
+/*
+ * Copyright (C) 2025 Anthrove
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"errors"
+	"github.com/anthrove/identity/pkg/object"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+//	@Summary	List all trusted devices from a profile
+//	@Tags		Profile API
+//	@Accept		json
+//	@Produce	json
+//
+//	@Param		page		query		string										false	"Page"
+//	@Param		page_limit	query		string										false	"Page Limit"
+//
+//	@Success	200			{object}	HttpResponse{data=[]object.TrustedDevice{}}	"Trusted Devices"
+//	@Failure	400			{object}	HttpResponse{data=nil}						"Bad Request"
+//	@Router		/api/v1/profile/device [get]
+func (ir IdentityRoutes) profileGetTrustedDevices(c *gin.Context) {
+	user, err := sessionConvert(c)
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return
+	}
+
+	pagination, ok := c.Get("pagination")
+	if !ok {
+		c.JSON(http.StatusInternalServerError, HttpResponse{
+			Error: "pagination parameter is missing",
+		})
+		return
+	}
+
+	paginationObj, ok := pagination.(object.Pagination)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, errors.New("pagination parameter cant be converted to object.Pagination"))
+		return
+	}
+
+	trustedDevices, err := ir.service.FindTrustedDevices(c, user.TenantID, user.ID, paginationObj)
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, HttpResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, HttpResponse{
+		Data: trustedDevices,
+	})
+}
+
+//	@Summary	Revokes a trusted device from a profile
+//	@Tags		Profile API
+//	@Accept		json
+//	@Produce	json
+//	@Param		device_id	path	string	true	"Trusted Device ID"
+//	@Success	204
+//	@Failure	400	{object}	HttpResponse{data=nil}	"Bad Request"
+//	@Router		/api/v1/profile/device/{device_id} [delete]
+func (ir IdentityRoutes) profileKillTrustedDevice(c *gin.Context) {
+	deviceID := c.Param("device_id")
+	user, err := sessionConvert(c)
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return
+	}
+
+	err = ir.service.KillTrustedDevice(c, user.TenantID, user.ID, deviceID)
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, HttpResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
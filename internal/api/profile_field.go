@@ -0,0 +1,129 @@
+/*
+ * Copyright (C) 2025 Anthrove
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"errors"
+	"github.com/anthrove/identity/pkg/object"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// @Summary	Look up Users by a Profile field
+// @Description	Only unique and indexed fields can be used, multi select fields match users who selected the value.
+// @Tags		Profile API
+// @Accept		json
+// @Produce	json
+// @Param		page		query		string								false	"Page"
+// @Param		page_limit	query		string								false	"Page Limit"
+// @Param		tenant_id	path		string								true	"Tenant ID"
+// @Param		identifier	path		string								true	"Profile field identifier"
+// @Param		value		query		string								true	"Value of the field"
+// @Success	200			{object}	HttpResponse{data=[]object.User{}}	"User"
+// @Failure	400			{object}	HttpResponse{data=nil}				"Bad Request"
+// @Router		/api/v1/tenant/{tenant_id}/profile/field/{identifier}/user [get]
+func (ir IdentityRoutes) findUsersByProfileField(c *gin.Context) {
+	tenantID := c.Param("tenant_id")
+	identifier := c.Param("identifier")
+
+	pagination, ok := c.Get("pagination")
+	if !ok {
+		c.JSON(http.StatusInternalServerError, HttpResponse{
+			Error: "pagination parameter is missing",
+		})
+		return
+	}
+
+	paginationObj, ok := pagination.(object.Pagination)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, errors.New("pagination parameter cant be converted to object.Pagination"))
+		return
+	}
+
+	users, err := ir.service.FindUsersByProfileAttribute(c, tenantID, identifier, c.Query("value"), paginationObj)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, HttpResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, HttpResponse{
+		Data: users,
+	})
+}
+
+// @Summary	Rename a Profile field
+// @Description	The stored values of all users are moved to the new identifier.
+// @Tags		Profile API
+// @Accept		json
+// @Produce	json
+// @Param		tenant_id	path	string						true	"Tenant ID"
+// @Param		identifier	path	string						true	"Profile field identifier"
+// @Param		"Rename"	body	object.RenameProfileField	true	"New identifier"
+// @Success	204
+// @Failure	400	{object}	HttpResponse{data=nil}	"Bad Request"
+// @Router		/api/v1/tenant/{tenant_id}/profile/field/{identifier}/rename [post]
+func (ir IdentityRoutes) renameProfileField(c *gin.Context) {
+	tenantID := c.Param("tenant_id")
+	identifier := c.Param("identifier")
+
+	var body object.RenameProfileField
+	err := c.ShouldBind(&body)
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, HttpResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	err = ir.service.RenameProfileField(c, tenantID, identifier, body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, HttpResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary	Delete a Profile field
+// @Description	The stored values of all users are deleted as well.
+// @Tags		Profile API
+// @Accept		json
+// @Produce	json
+// @Param		tenant_id	path	string	true	"Tenant ID"
+// @Param		identifier	path	string	true	"Profile field identifier"
+// @Success	204
+// @Failure	400	{object}	HttpResponse{data=nil}	"Bad Request"
+// @Router		/api/v1/tenant/{tenant_id}/profile/field/{identifier} [delete]
+func (ir IdentityRoutes) dropProfileField(c *gin.Context) {
+	tenantID := c.Param("tenant_id")
+	identifier := c.Param("identifier")
+
+	err := ir.service.DropProfileField(c, tenantID, identifier)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, HttpResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,199 @@
+/*
+ * Copyright (C) 2025 Anthrove
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"github.com/anthrove/identity/pkg/logic"
+	"github.com/anthrove/identity/pkg/object"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+//	@Summary	Upload the avatar of the signed in user
+//	@Description	Jpeg, png and gif images up to 5 MiB are accepted. The image is cropped to a square, metadata is removed and variants with 256, 128 and 64 pixels are created.
+//	@Tags		Profile API
+//	@Accept		multipart/form-data
+//	@Produce	json
+//	@Param		file	formData	file									true	"Image"
+//	@Success	201		{object}	HttpResponse{data=object.Resource{}}	"Avatar"
+//	@Failure	400		{object}	HttpResponse{data=nil}					"Bad Request"
+//	@Failure	413		{object}	HttpResponse{data=nil}					"File too large"
+//	@Failure	507		{object}	HttpResponse{data=nil}					"Storage quota exceeded"
+//	@Router		/api/v1/profile/avatar [put]
+func (ir IdentityRoutes) profileUploadAvatar(c *gin.Context) {
+	user, err := sessionConvert(c)
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return
+	}
+
+	ir.uploadAvatar(c, user.TenantID, user.ID)
+}
+
+//	@Summary	Delete the avatar of the signed in user
+//	@Tags		Profile API
+//	@Accept		json
+//	@Produce	json
+//	@Success	204
+//	@Failure	400	{object}	HttpResponse{data=nil}	"Bad Request"
+//	@Router		/api/v1/profile/avatar [delete]
+func (ir IdentityRoutes) profileKillAvatar(c *gin.Context) {
+	user, err := sessionConvert(c)
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return
+	}
+
+	ir.killAvatar(c, user.TenantID, user.ID)
+}
+
+//	@Summary	Upload a file for a Profile field of the signed in user
+//	@Description	The field needs to be of type resource, the mime types and the size of the field are checked. Metadata of jpeg and png images is removed.
+//	@Tags		Profile API
+//	@Accept		multipart/form-data
+//	@Produce	json
+//	@Param		identifier	path		string									true	"Profile field identifier"
+//	@Param		file		formData	file									true	"File"
+//	@Success	201			{object}	HttpResponse{data=object.Resource{}}	"File"
+//	@Failure	400			{object}	HttpResponse{data=nil}					"Bad Request"
+//	@Failure	413			{object}	HttpResponse{data=nil}					"File too large"
+//	@Failure	507			{object}	HttpResponse{data=nil}					"Storage quota exceeded"
+//	@Router		/api/v1/profile/field/{identifier}/file [put]
+func (ir IdentityRoutes) profileUploadFile(c *gin.Context) {
+	user, err := sessionConvert(c)
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return
+	}
+
+	maxSize, err := ir.service.FindProfileFileMaxSize(c, user.TenantID, c.Param("identifier"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, HttpResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	limitUpload(c, maxSize)
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(uploadErrorStatus(err), HttpResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	fileContent, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, HttpResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+	defer fileContent.Close()
+
+	resource, err := ir.service.UploadProfileFile(c, user.TenantID, user.ID, object.ProfileViewer{UserID: user.ID}, c.Param("identifier"), file.Filename, fileContent)
+	if err != nil {
+		c.JSON(uploadErrorStatus(err), HttpResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, HttpResponse{
+		Data: resource,
+	})
+}
+
+//	@Summary	Upload the avatar of a user as admin
+//	@Description	Jpeg, png and gif images up to 5 MiB are accepted. The image is cropped to a square, metadata is removed and variants with 256, 128 and 64 pixels are created.
+//	@Tags		User API
+//	@Accept		multipart/form-data
+//	@Produce	json
+//	@Param		tenant_id	path		string									true	"Tenant ID"
+//	@Param		user_id		path		string									true	"User ID"
+//	@Param		file		formData	file									true	"Image"
+//	@Success	201			{object}	HttpResponse{data=object.Resource{}}	"Avatar"
+//	@Failure	400			{object}	HttpResponse{data=nil}					"Bad Request"
+//	@Failure	413			{object}	HttpResponse{data=nil}					"File too large"
+//	@Failure	507			{object}	HttpResponse{data=nil}					"Storage quota exceeded"
+//	@Router		/api/v1/tenant/{tenant_id}/user/{user_id}/avatar [put]
+func (ir IdentityRoutes) uploadUserAvatar(c *gin.Context) {
+	ir.uploadAvatar(c, c.Param("tenant_id"), c.Param("user_id"))
+}
+
+//	@Summary	Delete the avatar of a user as admin
+//	@Tags		User API
+//	@Accept		json
+//	@Produce	json
+//	@Param		tenant_id	path	string	true	"Tenant ID"
+//	@Param		user_id		path	string	true	"User ID"
+//	@Success	204
+//	@Failure	400	{object}	HttpResponse{data=nil}	"Bad Request"
+//	@Router		/api/v1/tenant/{tenant_id}/user/{user_id}/avatar [delete]
+func (ir IdentityRoutes) killUserAvatar(c *gin.Context) {
+	ir.killAvatar(c, c.Param("tenant_id"), c.Param("user_id"))
+}
+
+func (ir IdentityRoutes) uploadAvatar(c *gin.Context, tenantID string, userID string) {
+	limitUpload(c, logic.MaxAvatarSize)
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(uploadErrorStatus(err), HttpResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	fileContent, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, HttpResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+	defer fileContent.Close()
+
+	resource, err := ir.service.UploadAvatar(c, tenantID, userID, file.Filename, fileContent)
+	if err != nil {
+		c.JSON(uploadErrorStatus(err), HttpResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, HttpResponse{
+		Data: resource,
+	})
+}
+
+func (ir IdentityRoutes) killAvatar(c *gin.Context, tenantID string, userID string) {
+	err := ir.service.KillAvatar(c, tenantID, userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, HttpResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
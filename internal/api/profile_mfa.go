@@ -61,12 +61,44 @@ func (ir IdentityRoutes) profileCreateMFA(c *gin.Context) {
 	})
 }
 
+//	@Summary	Starts the validation of a MFA from a profile, e.g. by sending a one-time code
+//	@Tags		Profile API
+//	@Accept		json
+//	@Produce	json
+//	@Param		mfa_id	path		string								true	"MFA ID"
+//	@Success	200		{object}	HttpResponse{data=map[string]any}	"MFA Provider Data"
+//	@Failure	400		{object}	HttpResponse{data=nil}				"Bad Request"
+//	@Router		/api/v1/profile/mfa/{mfa_id}/init [post]
+func (ir IdentityRoutes) profileInitMFA(c *gin.Context) {
+	mfaID := c.Param("mfa_id")
+	user, err := sessionConvert(c)
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return
+	}
+
+	response, err := ir.service.MfaInitDataflow(c, user.TenantID, user.ID, mfaID)
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, HttpResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, HttpResponse{
+		Data: response,
+	})
+}
+
 //	@Summary	Verify MFA from a profile
 //	@Tags		Profile API
 //	@Accept		json
 //	@Produce	json
 //	@Failure	200	{object}	HttpResponse{data=nil}	"Profile"
 //	@Failure	400	{object}	HttpResponse{data=nil}	"Bad Request"
+//	@Failure	429	{object}	HttpResponse{data=nil}	"Too Many Attempts"
 //	@Router		/api/v1/profile/mfa/{mfa_id}/verify [post]
 func (ir IdentityRoutes) profileVerifyMFA(c *gin.Context) {
 	mfaID := c.Param("mfa_id")
@@ -89,7 +121,7 @@ func (ir IdentityRoutes) profileVerifyMFA(c *gin.Context) {
 
 	err = ir.service.VerifyMFA(c, user.TenantID, user.ID, mfaID, body)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, HttpResponse{
+		c.JSON(attemptErrorStatus(err), HttpResponse{
 			Error: err.Error(),
 		})
 		return
@@ -140,6 +172,66 @@ func (ir IdentityRoutes) profileUpdateMFA(c *gin.Context) {
 	})
 }
 
+//	@Summary	Get the amount of remaining recovery codes of a MFA from a profile
+//	@Tags		Profile API
+//	@Accept		json
+//	@Produce	json
+//	@Param		mfa_id	path		string											true	"MFA ID"
+//	@Success	200		{object}	HttpResponse{data=object.MFARecoveryCodeCount{}}	"Remaining Recovery Codes"
+//	@Failure	400		{object}	HttpResponse{data=nil}							"Bad Request"
+//	@Router		/api/v1/profile/mfa/{mfa_id}/recovery [get]
+func (ir IdentityRoutes) profileFindMFARecoveryCodeCount(c *gin.Context) {
+	mfaID := c.Param("mfa_id")
+	user, err := sessionConvert(c)
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return
+	}
+
+	count, err := ir.service.FindRecoveryCodeCount(c, user.TenantID, user.ID, mfaID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, HttpResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, HttpResponse{
+		Data: count,
+	})
+}
+
+//	@Summary	Regenerate the recovery codes of a MFA from a profile
+//	@Tags		Profile API
+//	@Accept		json
+//	@Produce	json
+//	@Param		mfa_id	path		string										true	"MFA ID"
+//	@Success	200		{object}	HttpResponse{data=object.MFARecoveryCodes{}}	"New Recovery Codes"
+//	@Failure	400		{object}	HttpResponse{data=nil}						"Bad Request"
+//	@Router		/api/v1/profile/mfa/{mfa_id}/recovery [post]
+func (ir IdentityRoutes) profileRegenerateMFARecoveryCodes(c *gin.Context) {
+	mfaID := c.Param("mfa_id")
+	user, err := sessionConvert(c)
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return
+	}
+
+	recoveryCodes, err := ir.service.RegenerateRecoveryCodes(c, user.TenantID, user.ID, mfaID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, HttpResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, HttpResponse{
+		Data: recoveryCodes,
+	})
+}
+
 //	@Summary	List all MFA from a profile
 //	@Tags		Profile API
 //	@Accept		json
@@ -187,17 +279,14 @@ func (ir IdentityRoutes) profileGetMFAs(c *gin.Context) {
 	})
 }
 
-//	@Summary	List all MFA from a profile
+//	@Summary	Removes a MFA from a profile
 //	@Tags		Profile API
 //	@Accept		json
 //	@Produce	json
-//
-//	@Param		page		query	string	false	"Page"
-//	@Param		page_limit	query	string	false	"Page Limit"
-//
-//	@Failure	204
+//	@Param		mfa_id	path	string	true	"MFA ID"
+//	@Success	204
 //	@Failure	400	{object}	HttpResponse{data=nil}	"Bad Request"
-//	@Router		/api/v1/profile/mfa [get]
+//	@Router		/api/v1/profile/mfa/{mfa_id} [delete]
 func (ir IdentityRoutes) profileKillMFAs(c *gin.Context) {
 	mfaID := c.Param("mfa_id")
 	user, err := sessionConvert(c)
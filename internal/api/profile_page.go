@@ -22,17 +22,13 @@ import (
 	"net/http"
 )
 
-type ProfileFieldValue struct {
-	Field object.ProfileField `json:"field"`
-	Value any                 `json:"value"`
-}
-
 //	@Summary	Get Profile fields
+//	@Description	Only the fields the user is allowed to see are returned.
 //	@Tags		Profile API
 //	@Accept		json
 //	@Produce	json
-//	@Failure	200	{object}	HttpResponse{data=[]ProfileFieldValue{}}	"Profile"
-//	@Failure	400	{object}	HttpResponse{data=nil}						"Bad Request"
+//	@Success	200	{object}	HttpResponse{data=[]object.ProfileFieldValue{}}	"Profile"
+//	@Failure	400	{object}	HttpResponse{data=nil}							"Bad Request"
 //	@Router		/api/v1/profile [get]
 func (ir IdentityRoutes) getProfileFields(c *gin.Context) {
 	user, err := sessionConvert(c)
@@ -42,7 +38,7 @@ func (ir IdentityRoutes) getProfileFields(c *gin.Context) {
 		return
 	}
 
-	profilePage, err := ir.service.FindProfilePage(c, user.TenantID, user.ID)
+	values, err := ir.service.FindOwnProfile(c, user.TenantID, user.ID)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, HttpResponse{
 			Error: err.Error(),
@@ -50,7 +46,31 @@ func (ir IdentityRoutes) getProfileFields(c *gin.Context) {
 		return
 	}
 
-	tenant, err := ir.service.FindTenant(c, user.TenantID)
+	c.JSON(http.StatusOK, HttpResponse{
+		Data: values,
+	})
+}
+
+//	@Summary	Upsert Profile fields
+//	@Description	Only the given fields are changed, immutable fields can only be set once.
+//	@Tags		Profile API
+//	@Accept		json
+//	@Produce	json
+//	@Param		"Profile Pages"	body	object.UpdateProfilePage	true	"Create Provider Data"
+//	@Success	204
+//	@Failure	400	{object}	HttpResponse{data=nil}	"Bad Request"
+//	@Router		/api/v1/profile [put]
+func (ir IdentityRoutes) upsertProfileFields(c *gin.Context) {
+	user, err := sessionConvert(c)
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return
+	}
+
+	var body object.UpdateProfilePage
+	err = c.ShouldBind(&body)
+
 	if err != nil {
 		c.JSON(http.StatusBadRequest, HttpResponse{
 			Error: err.Error(),
@@ -58,89 +78,118 @@ func (ir IdentityRoutes) getProfileFields(c *gin.Context) {
 		return
 	}
 
-	values := make([]ProfileFieldValue, 0, len(tenant.ProfileFields))
-profilePage:
-	for _, tenantField := range tenant.ProfileFields {
-		for _, profile := range profilePage.Fields {
-			if tenantField.Identifier == profile.Identifier {
-				values = append(values, ProfileFieldValue{
-					Field: tenantField,
-					Value: profile.Value,
-				})
-				continue profilePage
-			}
-		}
+	err = ir.service.UpdateOwnProfile(c, user.TenantID, user.ID, body)
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, HttpResponse{
+			Error: err.Error(),
+		})
+		return
+	}
 
-		values = append(values, ProfileFieldValue{
-			Field: tenantField,
-			Value: nil,
+	c.Status(http.StatusNoContent)
+}
+
+//	@Summary	Get the Profile fields of a user as admin
+//	@Description	All fields are returned, including the ones with view_by admin.
+//	@Tags		User API
+//	@Accept		json
+//	@Produce	json
+//	@Param		tenant_id	path		string											true	"Tenant ID"
+//	@Param		user_id		path		string											true	"User ID"
+//	@Success	200			{object}	HttpResponse{data=[]object.ProfileFieldValue{}}	"Profile"
+//	@Failure	400			{object}	HttpResponse{data=nil}							"Bad Request"
+//	@Router		/api/v1/tenant/{tenant_id}/user/{user_id}/profile [get]
+func (ir IdentityRoutes) findUserProfile(c *gin.Context) {
+	tenantID := c.Param("tenant_id")
+	userID := c.Param("user_id")
+
+	values, err := ir.service.FindProfile(c, tenantID, userID, object.ProfileViewer{Admin: true})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, HttpResponse{
+			Error: err.Error(),
 		})
+		return
 	}
 
-	c.JSON(http.StatusCreated, HttpResponse{
+	c.JSON(http.StatusOK, HttpResponse{
 		Data: values,
 	})
 }
 
-//	@Summary	Upsert Profile fields
-//	@Tags		Profile API
+//	@Summary	Update the Profile fields of a user as admin
+//	@Description	Only the given fields are changed, admins can also change immutable fields.
+//	@Tags		User API
 //	@Accept		json
 //	@Produce	json
-//	@Param		"Profile Pages"	body	object.UpdateProfilePage	true	"Create Provider Data"
+//	@Param		tenant_id		path	string						true	"Tenant ID"
+//	@Param		user_id			path	string						true	"User ID"
+//	@Param		"Profile Pages"	body	object.UpdateProfilePage	true	"Changed Profile Fields"
 //	@Success	204
 //	@Failure	400	{object}	HttpResponse{data=nil}	"Bad Request"
-//	@Router		/api/v1/profile [put]
-func (ir IdentityRoutes) upsertProfileFields(c *gin.Context) {
-	user, err := sessionConvert(c)
+//	@Router		/api/v1/tenant/{tenant_id}/user/{user_id}/profile [put]
+func (ir IdentityRoutes) updateUserProfile(c *gin.Context) {
+	tenantID := c.Param("tenant_id")
+	userID := c.Param("user_id")
+
+	var body object.UpdateProfilePage
+	err := c.ShouldBind(&body)
 
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, err)
+		c.JSON(http.StatusBadRequest, HttpResponse{
+			Error: err.Error(),
+		})
 		return
 	}
 
-	_, err = ir.service.FindProfilePage(c, user.TenantID, user.ID)
-
-	if err == nil {
-		// Update
-		var body object.UpdateProfilePage
-		err := c.ShouldBind(&body)
+	err = ir.service.UpdateProfilePage(c, tenantID, userID, object.ProfileViewer{Admin: true}, body)
 
-		if err != nil {
-			c.JSON(http.StatusBadRequest, HttpResponse{
-				Error: err.Error(),
-			})
-			return
-		}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, HttpResponse{
+			Error: err.Error(),
+		})
+		return
+	}
 
-		err = ir.service.UpdateProfilePage(c, user.TenantID, user.ID, body)
+	c.Status(http.StatusNoContent)
+}
 
-		if err != nil {
-			c.JSON(http.StatusBadRequest, HttpResponse{
-				Error: err.Error(),
-			})
-			return
-		}
-	} else {
-		// Create
-		var body object.CreateProfilePage
-		err := c.ShouldBind(&body)
-
-		if err != nil {
-			c.JSON(http.StatusBadRequest, HttpResponse{
-				Error: err.Error(),
-			})
-			return
+//	@Summary	Get the public Profile of a user
+//	@Description	Anonymous callers only get the public fields. With a session of the tenant, the fields shared with the groups of the caller are returned too.
+//	@Tags		Profile API
+//	@Accept		json
+//	@Produce	json
+//	@Param		tenant_id	path		string											true	"Tenant ID"
+//	@Param		user_id		path		string											true	"User ID"
+//	@Success	200			{object}	HttpResponse{data=[]object.ProfileFieldValue{}}	"Profile"
+//	@Failure	404			{object}	HttpResponse{data=nil}							"Not Found"
+//	@Router		/api/v1/tenant/{tenant_id}/user/{user_id}/profile/public [get]
+func (ir IdentityRoutes) findPublicProfile(c *gin.Context) {
+	tenantID := c.Param("tenant_id")
+	userID := c.Param("user_id")
+
+	// the endpoint works without a session, a session only adds the fields the signed-in user may see
+	var viewerUserID string
+
+	if sessionID, err := c.Cookie("identity_session_id"); err == nil {
+		session := ir.service.FindSession(c, sessionID)
+		loggedIn, _ := session["logged_in"].(bool)
+		user, _ := session["user"].(object.User)
+
+		if loggedIn && session["tenant_id"] == tenantID {
+			viewerUserID = user.ID
 		}
+	}
 
-		_, err = ir.service.CreateProfilePage(c, user.TenantID, user.ID, body)
-
-		if err != nil {
-			c.JSON(http.StatusBadRequest, HttpResponse{
-				Error: err.Error(),
-			})
-			return
-		}
+	values, err := ir.service.FindVisibleProfile(c, tenantID, userID, viewerUserID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, HttpResponse{
+			Error: "profile not found",
+		})
+		return
 	}
 
-	c.Status(http.StatusNoContent)
+	c.JSON(http.StatusOK, HttpResponse{
+		Data: values,
+	})
 }
@@ -0,0 +1,160 @@
+/*
+ * Copyright (C) 2025 Anthrove
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+//	@Summary	List the signed in sessions of a profile
+//	@Tags		Profile API
+//	@Accept		json
+//	@Produce	json
+//	@Success	200	{object}	HttpResponse{data=[]object.UserSession{}}	"Sessions"
+//	@Failure	400	{object}	HttpResponse{data=nil}						"Bad Request"
+//	@Router		/api/v1/profile/session [get]
+func (ir IdentityRoutes) profileGetSessions(c *gin.Context) {
+	user, err := sessionConvert(c)
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return
+	}
+
+	sessionID, _ := c.Cookie("identity_session_id")
+
+	c.JSON(http.StatusOK, HttpResponse{
+		Data: ir.service.FindUserSessions(c, user.TenantID, user.ID, sessionID),
+	})
+}
+
+//	@Summary	Signs out a session of a profile
+//	@Tags		Profile API
+//	@Accept		json
+//	@Produce	json
+//	@Param		session_id	path	string	true	"Session ID"
+//	@Success	204
+//	@Failure	400	{object}	HttpResponse{data=nil}	"Bad Request"
+//	@Router		/api/v1/profile/session/{session_id} [delete]
+func (ir IdentityRoutes) profileKillSession(c *gin.Context) {
+	user, err := sessionConvert(c)
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return
+	}
+
+	err = ir.service.KillUserSession(c, user.TenantID, user.ID, c.Param("session_id"))
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, HttpResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+//	@Summary	List the applications a profile gave consent to
+//	@Tags		Profile API
+//	@Accept		json
+//	@Produce	json
+//	@Success	200	{object}	HttpResponse{data=[]object.Consent{}}	"Consents"
+//	@Failure	400	{object}	HttpResponse{data=nil}					"Bad Request"
+//	@Router		/api/v1/profile/consent [get]
+func (ir IdentityRoutes) profileGetConsents(c *gin.Context) {
+	user, err := sessionConvert(c)
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return
+	}
+
+	consents, err := ir.service.FindOwnConsents(c, user.TenantID, user.ID)
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, HttpResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, HttpResponse{
+		Data: consents,
+	})
+}
+
+//	@Summary	Revokes the consent of a profile to an application, the tokens of the application are revoked too
+//	@Tags		Profile API
+//	@Accept		json
+//	@Produce	json
+//	@Param		application_id	path	string	true	"Application ID"
+//	@Success	204
+//	@Failure	400	{object}	HttpResponse{data=nil}	"Bad Request"
+//	@Router		/api/v1/profile/consent/{application_id} [delete]
+func (ir IdentityRoutes) profileKillConsent(c *gin.Context) {
+	user, err := sessionConvert(c)
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return
+	}
+
+	err = ir.service.RevokeConsent(c, user.TenantID, user.ID, c.Param("application_id"))
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, HttpResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+//	@Summary	Exports everything stored about a profile
+//	@Tags		Profile API
+//	@Accept		json
+//	@Produce	json
+//	@Success	200	{object}	HttpResponse{data=object.PersonalData{}}	"Personal Data"
+//	@Failure	400	{object}	HttpResponse{data=nil}						"Bad Request"
+//	@Router		/api/v1/profile/export [get]
+func (ir IdentityRoutes) profileExportPersonalData(c *gin.Context) {
+	user, err := sessionConvert(c)
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return
+	}
+
+	sessionID, _ := c.Cookie("identity_session_id")
+
+	personalData, err := ir.service.ExportPersonalData(c, user.TenantID, user.ID, sessionID)
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, HttpResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, HttpResponse{
+		Data: personalData,
+	})
+}
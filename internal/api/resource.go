@@ -18,8 +18,10 @@ package api
 
 import (
 	"errors"
+	"github.com/anthrove/identity/pkg/logic"
 	"github.com/anthrove/identity/pkg/object"
 	"github.com/gin-gonic/gin"
+	"io"
 	"net/http"
 )
 
@@ -29,8 +31,11 @@ import (
 // @Param		tenant_id	path		string									true	"Tenant ID"
 // @Param		provider_id	query		string									true	"Provider ID"
 // @Param		tag			query		string									true	"Tag"
+// @Param		visibility	query		string									false	"Visibility"	Enums(public, tenant, owner, group)
+// @Param		group_id	query		string									false	"Group ID of a resource with the visibility group"
 // @Success	200			{object}	HttpResponse{data=object.Resource{}}	"Resource"
 // @Failure	400			{object}	HttpResponse{data=nil}					"Bad Request"
+// @Failure	507			{object}	HttpResponse{data=nil}					"Storage quota exceeded"
 // @Produce	json
 // @Router		/api/v1/tenant/{tenant_id}/resource [post]
 func (ir IdentityRoutes) createResource(c *gin.Context) {
@@ -52,6 +57,8 @@ func (ir IdentityRoutes) createResource(c *gin.Context) {
 		FileSize:   file.Size,
 		FileName:   file.Filename,
 		MimeType:   file.Header.Get("Content-Type"),
+		Visibility: object.ResourceVisibility(c.Query("visibility")),
+		GroupID:    c.Query("group_id"),
 	}
 
 	fileContent, err := file.Open()
@@ -65,7 +72,12 @@ func (ir IdentityRoutes) createResource(c *gin.Context) {
 
 	resource, err := ir.service.CreateResource(c, tenantID, createResource, fileContent)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, HttpResponse{
+		status := http.StatusInternalServerError
+		if errors.Is(err, logic.ErrStorageQuotaExceeded) {
+			status = http.StatusInsufficientStorage
+		}
+
+		c.JSON(status, HttpResponse{
 			Error: err.Error(),
 		})
 		return
@@ -166,3 +178,168 @@ func (ir IdentityRoutes) findResources(c *gin.Context) {
 		Data: groups,
 	})
 }
+
+// @Summary	Change the visibility of a resource
+// @Description	Changes who can download a resource and its variants through the CDN.
+// @Tags		Resource API
+// @Accept		json
+// @Produce	json
+// @Param		tenant_id	path	string								true	"Tenant ID"
+// @Param		resource_id	path	string								true	"Resource ID"
+// @Param		"Visibility"	body	object.UpdateResourceVisibility	true	"Visibility Data"
+// @Success	204
+// @Failure	400	{object}	HttpResponse{data=nil}	"Bad Request"
+// @Router		/api/v1/tenant/{tenant_id}/resource/{resource_id}/visibility [put]
+func (ir IdentityRoutes) updateResourceVisibility(c *gin.Context) {
+	tenantID := c.Param("tenant_id")
+	resourceID := c.Param("resource_id")
+
+	var body object.UpdateResourceVisibility
+	err := c.ShouldBind(&body)
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, HttpResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	err = ir.service.UpdateResourceVisibility(c, tenantID, resourceID, body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, HttpResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary	Create a signed URL of a resource
+// @Description	Creates a download URL which works without a session until it expires. S3 resources get a presigned URL of the bucket.
+// @Tags		Resource API
+// @Accept		json
+// @Produce	json
+// @Param		tenant_id	path		string									true	"Tenant ID"
+// @Param		resource_id	path		string									true	"Resource ID"
+// @Param		"URL"		body		object.CreateResourceURL				false	"URL Data"
+// @Success	200			{object}	HttpResponse{data=object.ResourceURL{}}	"Resource URL"
+// @Failure	400			{object}	HttpResponse{data=nil}					"Bad Request"
+// @Router		/api/v1/tenant/{tenant_id}/resource/{resource_id}/url [post]
+func (ir IdentityRoutes) createResourceURL(c *gin.Context) {
+	tenantID := c.Param("tenant_id")
+	resourceID := c.Param("resource_id")
+
+	// the body is optional, without it the URL gets the default lifetime
+	var body object.CreateResourceURL
+	err := c.ShouldBind(&body)
+
+	if err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, HttpResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	resourceURL, err := ir.service.CreateResourceURL(c, tenantID, resourceID, body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, HttpResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, HttpResponse{
+		Data: resourceURL,
+	})
+}
+
+// @Summary	Get the storage usage
+// @Description	Returns the size and number of the resources of a tenant together with its storage quota. Content shared by several resources is counted once.
+// @Tags		Resource API
+// @Produce	json
+// @Param		tenant_id	path		string										true	"Tenant ID"
+// @Success	200			{object}	HttpResponse{data=object.StorageUsage{}}	"Storage Usage"
+// @Failure	400			{object}	HttpResponse{data=nil}						"Bad Request"
+// @Router		/api/v1/tenant/{tenant_id}/resource/usage [get]
+func (ir IdentityRoutes) findStorageUsage(c *gin.Context) {
+	tenantID := c.Param("tenant_id")
+
+	usage, err := ir.service.FindStorageUsage(c, tenantID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, HttpResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, HttpResponse{
+		Data: usage,
+	})
+}
+
+// @Summary	Start a storage migration
+// @Description	Moves all resources of a tenant from one storage provider to another in the background. The content is verified
+// @Description	against its hash before a resource is moved and the CDN paths stay the same. Starting the migration again resumes it.
+// @Tags		Resource API
+// @Accept		json
+// @Produce	json
+// @Param		tenant_id	path		string												true	"Tenant ID"
+// @Param		"Migration"	body		object.MigrateResources								true	"Migration Data"
+// @Success	202			{object}	HttpResponse{data=object.ResourceMigrationProgress{}}	"Migration Progress"
+// @Failure	400			{object}	HttpResponse{data=nil}								"Bad Request"
+// @Failure	409			{object}	HttpResponse{data=nil}								"Migration already running"
+// @Router		/api/v1/tenant/{tenant_id}/resource/migration [post]
+func (ir IdentityRoutes) startResourceMigration(c *gin.Context) {
+	tenantID := c.Param("tenant_id")
+
+	var body object.MigrateResources
+	err := c.ShouldBind(&body)
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, HttpResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	progress, err := ir.service.StartResourceMigration(c, tenantID, body)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, logic.ErrResourceMigrationRunning) {
+			status = http.StatusConflict
+		}
+
+		c.JSON(status, HttpResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, HttpResponse{
+		Data: progress,
+	})
+}
+
+// @Summary	Get the progress of the storage migration
+// @Tags		Resource API
+// @Produce	json
+// @Param		tenant_id	path		string												true	"Tenant ID"
+// @Success	200			{object}	HttpResponse{data=object.ResourceMigrationProgress{}}	"Migration Progress"
+// @Failure	404			{object}	HttpResponse{data=nil}								"Not Found"
+// @Router		/api/v1/tenant/{tenant_id}/resource/migration [get]
+func (ir IdentityRoutes) findResourceMigration(c *gin.Context) {
+	tenantID := c.Param("tenant_id")
+
+	progress, err := ir.service.FindResourceMigration(c, tenantID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, HttpResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, HttpResponse{
+		Data: progress,
+	})
+}
@@ -42,7 +42,7 @@ func SetupRoutes(r *gin.Engine, service logic.IdentityService) {
 
 	identityRoutes := &IdentityRoutes{service}
 
-	v1 := r.Group("/api/v1")
+	v1 := r.Group("/api/v1", RequestInfo())
 	v1Auth := v1.Group("", identityRoutes.Authorization())
 	v1.POST("/tenant", identityRoutes.createTenant)
 	v1.GET("/tenant", Pagination(), identityRoutes.findTenants)
@@ -56,18 +56,37 @@ func SetupRoutes(r *gin.Engine, service logic.IdentityService) {
 	v1Auth.PUT("/tenant/:tenant_id/group/:group_id", identityRoutes.updateGroup)
 	v1Auth.DELETE("/tenant/:tenant_id/group/:group_id", identityRoutes.killGroup)
 
+	v1Auth.POST("/tenant/:tenant_id/invitation", identityRoutes.createInvitation)
+	v1Auth.GET("/tenant/:tenant_id/invitation", Pagination(), identityRoutes.findInvitations)
+	v1Auth.GET("/tenant/:tenant_id/invitation/:invitation_id", identityRoutes.findInvitation)
+	v1Auth.DELETE("/tenant/:tenant_id/invitation/:invitation_id", identityRoutes.killInvitation)
+
 	v1Auth.POST("/tenant/:tenant_id/user", identityRoutes.createUser)
 	v1Auth.GET("/tenant/:tenant_id/user", Pagination(), identityRoutes.findUsers)
+	v1Auth.POST("/tenant/:tenant_id/user/import", identityRoutes.importUsers)
 	v1Auth.GET("/tenant/:tenant_id/user/:user_id", identityRoutes.findUser)
 	v1Auth.PUT("/tenant/:tenant_id/user/:user_id", identityRoutes.updateUser)
 	v1Auth.DELETE("/tenant/:tenant_id/user/:user_id", identityRoutes.killUser)
+	v1Auth.DELETE("/tenant/:tenant_id/user/:user_id/lockout", identityRoutes.unlockUser)
+	v1Auth.POST("/tenant/:tenant_id/user/:user_id/password/require_change", identityRoutes.requirePasswordChange)
+	v1Auth.POST("/tenant/:tenant_id/user/:user_id/email/verification", identityRoutes.sendEmailVerification)
+	v1Auth.GET("/tenant/:tenant_id/user/:user_id/profile", identityRoutes.findUserProfile)
+	v1Auth.PUT("/tenant/:tenant_id/user/:user_id/profile", identityRoutes.updateUserProfile)
+	v1Auth.PUT("/tenant/:tenant_id/user/:user_id/avatar", identityRoutes.uploadUserAvatar)
+	v1Auth.DELETE("/tenant/:tenant_id/user/:user_id/avatar", identityRoutes.killUserAvatar)
+	v1Auth.GET("/tenant/:tenant_id/profile/field/:identifier/user", Pagination(), identityRoutes.findUsersByProfileField)
+	v1Auth.POST("/tenant/:tenant_id/profile/field/:identifier/rename", identityRoutes.renameProfileField)
+	v1Auth.DELETE("/tenant/:tenant_id/profile/field/:identifier", identityRoutes.dropProfileField)
 
 	// TODO: Add VerifieMFA endpoint
 	v1Auth.POST("/tenant/:tenant_id/user/:user_id/mfa", identityRoutes.createMFA)
 	v1Auth.GET("/tenant/:tenant_id/user/:user_id/mfa", Pagination(), identityRoutes.findMFAs)
 	v1Auth.GET("/tenant/:tenant_id/user/:user_id/mfa/:mfa_id", identityRoutes.findMFA)
 	v1Auth.PUT("/tenant/:tenant_id/user/:user_id/mfa/:mfa_id", identityRoutes.updateMFA)
+	v1Auth.POST("/tenant/:tenant_id/user/:user_id/mfa/:mfa_id/init", identityRoutes.initMFA)
 	v1Auth.POST("/tenant/:tenant_id/user/:user_id/mfa/:mfa_id/verify", identityRoutes.verifyMFA)
+	v1Auth.GET("/tenant/:tenant_id/user/:user_id/mfa/:mfa_id/recovery", identityRoutes.findMFARecoveryCodeCount)
+	v1Auth.POST("/tenant/:tenant_id/user/:user_id/mfa/:mfa_id/recovery", identityRoutes.regenerateMFARecoveryCodes)
 	v1Auth.DELETE("/tenant/:tenant_id/user/:user_id/mfa/:mfa_id", identityRoutes.killMFA)
 
 	v1Auth.POST("/tenant/:tenant_id/provider", identityRoutes.createProvider)
@@ -93,6 +112,10 @@ func SetupRoutes(r *gin.Engine, service logic.IdentityService) {
 	v1Auth.DELETE("/tenant/:tenant_id/template/:template_id", identityRoutes.killMessageTemplate)
 	v1Auth.POST("/tenant/:tenant_id/template/:template_id/fill", identityRoutes.fillMessageTemplate)
 
+	v1Auth.GET("/tenant/:tenant_id/branding", identityRoutes.findBranding)
+	v1Auth.GET("/tenant/:tenant_id/branding/preview/page/:page_name", identityRoutes.previewPage)
+	v1Auth.GET("/tenant/:tenant_id/branding/preview/email/:template_type", identityRoutes.previewEmail)
+
 	v1Auth.POST("/tenant/:tenant_id/application", identityRoutes.createApplication)
 	v1Auth.GET("/tenant/:tenant_id/application", Pagination(), identityRoutes.findApplications)
 	v1Auth.GET("/tenant/:tenant_id/application/:application_id", identityRoutes.findApplication)
@@ -101,8 +124,13 @@ func SetupRoutes(r *gin.Engine, service logic.IdentityService) {
 
 	v1Auth.POST("/tenant/:tenant_id/resource", identityRoutes.createResource)
 	v1Auth.GET("/tenant/:tenant_id/resource", Pagination(), identityRoutes.findResources)
+	v1Auth.GET("/tenant/:tenant_id/resource/usage", identityRoutes.findStorageUsage)
+	v1Auth.POST("/tenant/:tenant_id/resource/migration", identityRoutes.startResourceMigration)
+	v1Auth.GET("/tenant/:tenant_id/resource/migration", identityRoutes.findResourceMigration)
 	v1Auth.GET("/tenant/:tenant_id/resource/:resource_id", identityRoutes.findResource)
 	v1Auth.DELETE("/tenant/:tenant_id/resource/:resource_id", identityRoutes.killResource)
+	v1Auth.PUT("/tenant/:tenant_id/resource/:resource_id/visibility", identityRoutes.updateResourceVisibility)
+	v1Auth.POST("/tenant/:tenant_id/resource/:resource_id/url", identityRoutes.createResourceURL)
 
 	v1Auth.POST("/tenant/:tenant_id/model", identityRoutes.createModel)
 	v1Auth.GET("/tenant/:tenant_id/model", Pagination(), identityRoutes.findModels)
@@ -129,18 +157,86 @@ func SetupRoutes(r *gin.Engine, service logic.IdentityService) {
 	v1Auth.DELETE("/tenant/:tenant_id/enforcer/:enforcer_id", identityRoutes.killEnforcer)
 	v1Auth.POST("/tenant/:tenant_id/enforcer/:enforcer_id/enforce", identityRoutes.enforce)
 
+	v1Auth.GET("/tenant/:tenant_id/lockout", Pagination(), identityRoutes.findLockouts)
+	v1Auth.DELETE("/tenant/:tenant_id/lockout/:lockout_id", identityRoutes.killLockout)
+
+	v1Auth.GET("/tenant/:tenant_id/audit", Pagination(), identityRoutes.findAuditEvents)
+
 	v1.GET("/tenant/:tenant_id/application/:application_id/login/begin", identityRoutes.signInBegin)
 	v1.POST("/tenant/:tenant_id/application/:application_id/login", identityRoutes.signInSubmit)
+	v1.POST("/tenant/:tenant_id/application/:application_id/login/mfa", identityRoutes.signInMFA)
+	v1.POST("/tenant/:tenant_id/application/:application_id/login/mfa/init", identityRoutes.signInMFAInit)
+	v1.POST("/tenant/:tenant_id/application/:application_id/login/password", identityRoutes.signInChangePassword)
+	v1.POST("/tenant/:tenant_id/application/:application_id/password/reset", identityRoutes.requestPasswordReset)
+	v1.POST("/tenant/:tenant_id/application/:application_id/password/reset/complete", identityRoutes.completePasswordReset)
+	v1.GET("/tenant/:tenant_id/application/:application_id/signup", identityRoutes.findSignUpConfiguration)
+	v1.POST("/tenant/:tenant_id/application/:application_id/signup", identityRoutes.signUp)
+	v1.POST("/tenant/:tenant_id/mfa/push/:approval_id", identityRoutes.decidePushApproval)
+	v1.GET("/tenant/:tenant_id/user/:user_id/profile/public", identityRoutes.findPublicProfile)
 
 	v1Auth.GET("/profile", identityRoutes.getProfileFields)
 	v1Auth.POST("/profile", identityRoutes.upsertProfileFields)
+	v1Auth.PUT("/profile/avatar", identityRoutes.profileUploadAvatar)
+	v1Auth.DELETE("/profile/avatar", identityRoutes.profileKillAvatar)
+	v1Auth.PUT("/profile/field/:identifier/file", identityRoutes.profileUploadFile)
+	v1Auth.PUT("/profile/password", identityRoutes.profileChangePassword)
+	v1Auth.PUT("/profile/email", identityRoutes.profileChangeEmail)
+	v1Auth.POST("/profile/email/verification", identityRoutes.profileSendEmailVerification)
+	v1Auth.POST("/profile/email/verify", identityRoutes.profileVerifyEmail)
 	v1Auth.POST("/profile/mfa", identityRoutes.profileCreateMFA)
+	v1Auth.POST("/profile/mfa/:mfa_id/init", identityRoutes.profileInitMFA)
 	v1Auth.POST("/profile/mfa/:mfa_id/verify", identityRoutes.profileVerifyMFA)
+	v1Auth.GET("/profile/mfa/:mfa_id/recovery", identityRoutes.profileFindMFARecoveryCodeCount)
+	v1Auth.POST("/profile/mfa/:mfa_id/recovery", identityRoutes.profileRegenerateMFARecoveryCodes)
 	v1Auth.POST("/profile/mfa/:mfa_id", identityRoutes.profileUpdateMFA)
 	v1Auth.GET("/profile/mfa", Pagination(), identityRoutes.profileGetMFAs)
-	v1Auth.DELETE("/profile/mfa/:mfa_id", identityRoutes.profileGetMFAs)
+	v1Auth.DELETE("/profile/mfa/:mfa_id", identityRoutes.profileKillMFAs)
+
+	v1Auth.GET("/profile/device", Pagination(), identityRoutes.profileGetTrustedDevices)
+	v1Auth.DELETE("/profile/device/:device_id", identityRoutes.profileKillTrustedDevice)
+
+	v1Auth.GET("/profile/session", identityRoutes.profileGetSessions)
+	v1Auth.DELETE("/profile/session/:session_id", identityRoutes.profileKillSession)
+
+	v1Auth.GET("/profile/consent", identityRoutes.profileGetConsents)
+	v1Auth.DELETE("/profile/consent/:application_id", identityRoutes.profileKillConsent)
+
+	v1Auth.GET("/profile/export", identityRoutes.profileExportPersonalData)
 
 	v1.GET("/cdn/:tenant_id/*file_path", identityRoutes.cdnGetFile)
+	v1.HEAD("/cdn/:tenant_id/*file_path", identityRoutes.cdnGetFile)
+
+	ui := r.Group("/auth/:tenant_id", RequestInfo())
+	ui.GET("/login", identityRoutes.uiLoginPage)
+	ui.POST("/login", identityRoutes.uiLoginSubmit)
+	ui.GET("/login/mfa", identityRoutes.uiMFAPage)
+	ui.POST("/login/mfa", identityRoutes.uiMFASubmit)
+	ui.POST("/login/mfa/send", identityRoutes.uiMFASend)
+	ui.GET("/login/password", identityRoutes.uiPasswordPage)
+	ui.POST("/login/password", identityRoutes.uiPasswordSubmit)
+	ui.GET("/consent", identityRoutes.uiConsentPage)
+	ui.POST("/consent", identityRoutes.uiConsentSubmit)
+	ui.GET("/password/reset", identityRoutes.uiPasswordResetPage)
+	ui.POST("/password/reset", identityRoutes.uiPasswordResetSubmit)
+	ui.GET("/password/reset/complete", identityRoutes.uiPasswordResetCompletePage)
+	ui.POST("/password/reset/complete", identityRoutes.uiPasswordResetCompleteSubmit)
+
+	account := r.Group("/account/:tenant_id", RequestInfo())
+	account.GET("", identityRoutes.uiAccountPage)
+	account.GET("/data", identityRoutes.uiAccountData)
+	account.POST("/profile", identityRoutes.uiAccountProfile)
+	account.POST("/avatar", identityRoutes.uiAccountAvatar)
+	account.POST("/avatar/delete", identityRoutes.uiAccountAvatarDelete)
+	account.POST("/email", identityRoutes.uiAccountEmail)
+	account.POST("/email/send", identityRoutes.uiAccountEmailSend)
+	account.POST("/email/verify", identityRoutes.uiAccountEmailVerify)
+	account.POST("/password", identityRoutes.uiAccountPassword)
+	account.POST("/mfa/delete", identityRoutes.uiAccountMFADelete)
+	account.POST("/mfa/recovery", identityRoutes.uiAccountRecoveryCodes)
+	account.POST("/session/revoke", identityRoutes.uiAccountSessionRevoke)
+	account.POST("/device/revoke", identityRoutes.uiAccountDeviceRevoke)
+	account.POST("/consent/revoke", identityRoutes.uiAccountConsentRevoke)
+	account.POST("/logout", identityRoutes.uiAccountLogout)
 
 	r.Any("/favicon.ico", func(context *gin.Context) {})
 
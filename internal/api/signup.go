@@ -0,0 +1,88 @@
+/*
+ * Copyright (C) 2025 Anthrove
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"github.com/anthrove/identity/pkg/object"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+//	@Summary	Sign up configuration of an application
+//	@Tags		Authentication API
+//	@Accept		json
+//	@Produce	json
+//	@Param		tenant_id		path		string											true	"Tenant ID"
+//	@Param		application_id	path		string											true	"Application ID"
+//	@Success	200				{object}	HttpResponse{data=object.SignUpConfiguration{}}	"Sign Up Configuration"
+//	@Failure	400				{object}	HttpResponse{data=nil}							"Bad Request"
+//	@Router		/api/v1/tenant/{tenant_id}/application/{application_id}/signup [get]
+func (ir IdentityRoutes) findSignUpConfiguration(c *gin.Context) {
+	tenantID := c.Param("tenant_id")
+	applicationID := c.Param("application_id")
+
+	configuration, err := ir.service.FindSignUpConfiguration(c, tenantID, applicationID)
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, HttpResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, HttpResponse{
+		Data: configuration,
+	})
+}
+
+//	@Summary	Sign up a new user
+//	@Tags		Authentication API
+//	@Accept		json
+//	@Produce	json
+//	@Param		tenant_id		path		string							true	"Tenant ID"
+//	@Param		application_id	path		string							true	"Application ID"
+//	@Param		"Sign Up"		body		object.SignUp					true	"Sign Up Data"
+//	@Success	201				{object}	HttpResponse{data=object.User{}}	"User"
+//	@Failure	400				{object}	HttpResponse{data=nil}			"Bad Request"
+//	@Router		/api/v1/tenant/{tenant_id}/application/{application_id}/signup [post]
+func (ir IdentityRoutes) signUp(c *gin.Context) {
+	tenantID := c.Param("tenant_id")
+	applicationID := c.Param("application_id")
+
+	var body object.SignUp
+	err := c.ShouldBind(&body)
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, HttpResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	user, err := ir.service.SignUp(c, tenantID, applicationID, body)
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, HttpResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, HttpResponse{
+		Data: user,
+	})
+}
@@ -59,6 +59,44 @@ func (ir IdentityRoutes) createUser(c *gin.Context) {
 	})
 }
 
+// @Summary	Imports Users in bulk
+// @Description	Users can be imported with a plaintext password, a password hash exported from another system or a Keycloak password credential.
+// @Description	Imported hashes are rehashed into the password type of the tenant on the next sign in.
+// @Tags		User API
+// @Accept		json
+// @Produce	json
+// @Param		tenant_id	path		string											true	"Tenant ID"
+// @Param		"Users"		body		[]object.ImportUser								true	"Import User Data"
+// @Success	200			{object}	HttpResponse{data=[]object.ImportUserResult{}}	"Import Results"
+// @Failure	400			{object}	HttpResponse{data=nil}							"Bad Request"
+// @Router		/api/v1/tenant/{tenant_id}/user/import [post]
+func (ir IdentityRoutes) importUsers(c *gin.Context) {
+	tenantID := c.Param("tenant_id")
+
+	var body []object.ImportUser
+	err := c.ShouldBind(&body)
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, HttpResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	results, err := ir.service.ImportUsers(c, tenantID, body)
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, HttpResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, HttpResponse{
+		Data: results,
+	})
+}
+
 // @Summary	Update an existing User
 // @Tags		User API
 // @Accept		json
@@ -18,8 +18,11 @@ package api
 
 import (
 	"errors"
+	"github.com/anthrove/identity/pkg/logic"
 	"github.com/anthrove/identity/pkg/object"
 	"github.com/gin-gonic/gin"
+	"net/http"
+	"time"
 )
 
 func sessionConvert(c *gin.Context) (object.User, error) {
@@ -49,3 +52,45 @@ func sessionConvert(c *gin.Context) (object.User, error) {
 
 	return user, nil
 }
+
+// sessionAuthenticatedAt returns when the user of the session signed in, the zero time if it is unknown.
+func sessionAuthenticatedAt(c *gin.Context) time.Time {
+	session, _ := c.Get("session")
+	sessionObj, _ := session.(map[string]any)
+	authenticatedAt, _ := sessionObj["authenticated_at"].(time.Time)
+
+	return authenticatedAt
+}
+
+// uploadFormOverhead is the room for the multipart headers and the other form fields of an upload.
+const uploadFormOverhead = 64 << 10
+
+// limitUpload limits the body of a multipart upload to a file of maxSize, so larger uploads are rejected while the form
+// is parsed instead of being read to memory or disk first.
+func limitUpload(c *gin.Context, maxSize int64) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSize+uploadFormOverhead)
+}
+
+// attemptErrorStatus returns the status code for a failed sign in or MFA attempt, throttled attempts are reported as too many requests.
+func attemptErrorStatus(err error) int {
+	if errors.Is(err, logic.ErrTooManyAttempts) {
+		return http.StatusTooManyRequests
+	}
+
+	return http.StatusBadRequest
+}
+
+// uploadErrorStatus returns the status code for a failed upload, uploads which exceed the storage quota are reported as
+// insufficient storage and uploads which exceed the size limit of the request as too large.
+func uploadErrorStatus(err error) int {
+	if errors.Is(err, logic.ErrStorageQuotaExceeded) {
+		return http.StatusInsufficientStorage
+	}
+
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return http.StatusRequestEntityTooLarge
+	}
+
+	return http.StatusBadRequest
+}
@@ -0,0 +1,23 @@
+/*
+ * Copyright (C) 2025 Anthrove
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package config
+
+// BreachedPasswords represents the configuration of the breached password corpus, which tenants can screen new passwords against.
+// File is the path of the corpus on the server, without it the screening is not available.
+type BreachedPasswords struct {
+	File string `env:"BREACHED_PASSWORD_FILE"`
+}
@@ -0,0 +1,25 @@
+/*
+ * Copyright (C) 2025 Anthrove
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package config
+
+// Pepper represents the configuration of the server-side password pepper.
+// Peppers is a list of key ID and base64 encoded key pairs (id:key,id:key), the key with the ID in PepperID is used for new hashes.
+// Old keys have to stay in the list until every hash using them was upgraded on sign in.
+type Pepper struct {
+	PepperID string            `env:"PASSWORD_PEPPER_ID"`
+	Peppers  map[string]string `env:"PASSWORD_PEPPERS" envKeyValSeparator:":"`
+}
@@ -69,7 +69,7 @@ func (a argon2IDHasher) ComparePassword(password string, decodeHash string, salt
 		return false, err
 	}
 
-	computedHash := argon2.IDKey([]byte(password), decodedSalt, iterations, memory, parallelism, a.keyLength)
+	computedHash := argon2.IDKey([]byte(password), decodedSalt, iterations, memory, parallelism, uint32(len(hash)))
 
 	if subtle.ConstantTimeCompare(hash, computedHash) == 1 {
 		return true, nil
@@ -78,6 +78,15 @@ func (a argon2IDHasher) ComparePassword(password string, decodeHash string, salt
 
 }
 
+func (a argon2IDHasher) NeedsRehash(hashedPassword string) bool {
+	memory, iterations, parallelism, _, hash, err := a.decodeHash(hashedPassword)
+	if err != nil {
+		return true
+	}
+
+	return memory != a.memory || iterations != a.iterations || parallelism != a.parallelism || uint32(len(hash)) != a.keyLength
+}
+
 func (a argon2IDHasher) decodeHash(encodedHash string) (memory uint32, iterations uint32, parallelism uint8, salt []byte, hash []byte, err error) {
 	var version int
 
@@ -43,3 +43,9 @@ func (h bcryptHasher) ComparePassword(password string, hashedPassword string, sa
 
 	return err == nil, nil
 }
+
+func (h bcryptHasher) NeedsRehash(hashedPassword string) bool {
+	cost, err := bcrypt.Cost([]byte(hashedPassword))
+
+	return err != nil || cost != h.costs
+}
@@ -0,0 +1,242 @@
+/*
+ * Copyright (C) 2025 Anthrove
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package crypto
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/pbkdf2"
+	"hash"
+	"strconv"
+	"strings"
+)
+
+// Password types which are only verified. They come from imports of other systems and are rehashed on the next sign in.
+const (
+	PasswordTypeArgon2I      = "argon2i"
+	PasswordTypeSSHA         = "ssha"
+	PasswordTypeDjangoPBKDF2 = "django_pbkdf2"
+)
+
+// PasswordVerifier compares a password against a hash without being able to create new hashes.
+type PasswordVerifier interface {
+	ComparePassword(password string, hashedPassword string, salt string) (bool, error)
+}
+
+// ParseForeignHash detects the password type of a hash exported from another system and converts it into the format stored by this system.
+//
+// Supported are bcrypt ($2a$, $2b$, $2y$), argon2id and argon2i PHC strings, pbkdf2 and scrypt PHC strings,
+// LDAP {SSHA}, {SSHA256} and {SSHA512} and Django pbkdf2_sha256$ and pbkdf2_sha1$ hashes.
+func ParseForeignHash(hashedPassword string) (string, string, error) {
+	switch {
+	case strings.HasPrefix(hashedPassword, "$2a$"), strings.HasPrefix(hashedPassword, "$2b$"), strings.HasPrefix(hashedPassword, "$2y$"):
+		return "bcrypt", hashedPassword, nil
+	case strings.HasPrefix(hashedPassword, "$argon2id$"):
+		return "argon2id", hashedPassword, nil
+	case strings.HasPrefix(hashedPassword, "$argon2i$"):
+		return PasswordTypeArgon2I, hashedPassword, nil
+	case strings.HasPrefix(hashedPassword, "$pbkdf2"):
+		return "pbkdf2", hashedPassword, nil
+	case strings.HasPrefix(hashedPassword, "$scrypt$"):
+		return "scrypt", hashedPassword, nil
+	case strings.HasPrefix(hashedPassword, "{SSHA"):
+		return PasswordTypeSSHA, hashedPassword, nil
+	case strings.HasPrefix(hashedPassword, "pbkdf2_sha256$"), strings.HasPrefix(hashedPassword, "pbkdf2_sha1$"):
+		return PasswordTypeDjangoPBKDF2, hashedPassword, nil
+	}
+
+	return "", "", errors.New("unsupported password hash format")
+}
+
+type keycloakCredential struct {
+	Algorithm      string          `json:"algorithm"`
+	HashIterations int             `json:"hashIterations"`
+	SecretData     json.RawMessage `json:"secretData"`
+	CredentialData json.RawMessage `json:"credentialData"`
+}
+
+type keycloakSecretData struct {
+	Value string `json:"value"`
+	Salt  string `json:"salt"`
+}
+
+type keycloakCredentialData struct {
+	Algorithm      string `json:"algorithm"`
+	HashIterations int    `json:"hashIterations"`
+}
+
+var keycloakDigests = map[string]string{
+	"pbkdf2":        "sha1",
+	"pbkdf2-sha256": "sha256",
+	"pbkdf2-sha512": "sha512",
+}
+
+// ParseKeycloakCredential converts a password credential of a Keycloak realm export into a pbkdf2 PHC string.
+// Keycloak stores secretData and credentialData as JSON encoded strings, both the string and the object form are accepted.
+func ParseKeycloakCredential(credential []byte) (string, string, error) {
+	var keycloak keycloakCredential
+	err := json.Unmarshal(credential, &keycloak)
+	if err != nil {
+		return "", "", errors.Join(errors.New("problem while parsing keycloak credential"), err)
+	}
+
+	var secretData keycloakSecretData
+	err = unmarshalKeycloakData(keycloak.SecretData, &secretData)
+	if err != nil {
+		return "", "", errors.Join(errors.New("problem while parsing keycloak secret data"), err)
+	}
+
+	credentialData := keycloakCredentialData{
+		Algorithm:      keycloak.Algorithm,
+		HashIterations: keycloak.HashIterations,
+	}
+
+	if len(keycloak.CredentialData) > 0 {
+		err = unmarshalKeycloakData(keycloak.CredentialData, &credentialData)
+		if err != nil {
+			return "", "", errors.Join(errors.New("problem while parsing keycloak credential data"), err)
+		}
+	}
+
+	digest, exists := keycloakDigests[credentialData.Algorithm]
+	if !exists {
+		return "", "", fmt.Errorf("unsupported keycloak algorithm: %s", credentialData.Algorithm)
+	}
+
+	salt, err := base64.StdEncoding.DecodeString(secretData.Salt)
+	if err != nil {
+		return "", "", err
+	}
+
+	value, err := base64.StdEncoding.DecodeString(secretData.Value)
+	if err != nil {
+		return "", "", err
+	}
+
+	if credentialData.HashIterations <= 0 || len(salt) == 0 || len(value) == 0 {
+		return "", "", errors.New("keycloak credential is incomplete")
+	}
+
+	encodedHash := fmt.Sprintf("$pbkdf2-%s$i=%d,l=%d$%s$%s", digest, credentialData.HashIterations, len(value), base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(value))
+	return "pbkdf2", encodedHash, nil
+}
+
+func unmarshalKeycloakData(data json.RawMessage, v any) error {
+	var encoded string
+	if json.Unmarshal(data, &encoded) == nil {
+		data = []byte(encoded)
+	}
+
+	return json.Unmarshal(data, v)
+}
+
+type argon2IVerifier struct{}
+
+// ComparePassword compares the password against an argon2i PHC string.
+func (a argon2IVerifier) ComparePassword(password string, hashedPassword string, _ string) (bool, error) {
+	if !strings.HasPrefix(hashedPassword, "$argon2i$") {
+		return false, errors.New("invalid hash format")
+	}
+
+	memory, iterations, parallelism, salt, storedHash, err := argon2IDHasher{}.decodeHash(hashedPassword)
+	if err != nil {
+		return false, err
+	}
+
+	computedHash := argon2.Key([]byte(password), salt, iterations, memory, parallelism, uint32(len(storedHash)))
+
+	return subtle.ConstantTimeCompare(storedHash, computedHash) == 1, nil
+}
+
+var sshaDigests = map[string]func() hash.Hash{
+	"{SSHA}":    sha1.New,
+	"{SSHA256}": sha256.New,
+	"{SSHA512}": sha512.New,
+}
+
+type sshaVerifier struct{}
+
+// ComparePassword compares the password against a LDAP salted SHA hash, the base64 encoded digest of password and salt followed by the salt.
+func (s sshaVerifier) ComparePassword(password string, hashedPassword string, _ string) (bool, error) {
+	scheme, encoded, found := strings.Cut(hashedPassword, "}")
+	if !found {
+		return false, errors.New("invalid hash format")
+	}
+
+	newDigest, exists := sshaDigests[scheme+"}"]
+	if !exists {
+		return false, fmt.Errorf("unsupported ssha scheme: %s}", scheme)
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return false, err
+	}
+
+	digest := newDigest()
+	if len(decoded) <= digest.Size() {
+		return false, errors.New("invalid hash format")
+	}
+
+	storedHash, salt := decoded[:digest.Size()], decoded[digest.Size():]
+
+	digest.Write([]byte(password))
+	digest.Write(salt)
+
+	return subtle.ConstantTimeCompare(storedHash, digest.Sum(nil)) == 1, nil
+}
+
+var djangoDigests = map[string]func() hash.Hash{
+	"pbkdf2_sha256": sha256.New,
+	"pbkdf2_sha1":   sha1.New,
+}
+
+type djangoPBKDF2Verifier struct{}
+
+// ComparePassword compares the password against a Django hash: <algorithm>$<iterations>$<salt>$<base64 hash>
+func (d djangoPBKDF2Verifier) ComparePassword(password string, hashedPassword string, _ string) (bool, error) {
+	parts := strings.Split(hashedPassword, "$")
+	if len(parts) != 4 {
+		return false, errors.New("invalid hash format")
+	}
+
+	digest, exists := djangoDigests[parts[0]]
+	if !exists {
+		return false, fmt.Errorf("unsupported django algorithm: %s", parts[0])
+	}
+
+	iterations, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return false, err
+	}
+
+	storedHash, err := base64.StdEncoding.DecodeString(parts[3])
+	if err != nil {
+		return false, err
+	}
+
+	computedHash := pbkdf2.Key([]byte(password), []byte(parts[2]), iterations, len(storedHash), digest)
+
+	return subtle.ConstantTimeCompare(storedHash, computedHash) == 1, nil
+}
@@ -0,0 +1,100 @@
+/*
+ * Copyright (C) 2025 Anthrove
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package crypto
+
+import (
+	"encoding/base64"
+	"fmt"
+	"golang.org/x/crypto/argon2"
+	"testing"
+)
+
+func TestParseForeignHash(t *testing.T) {
+	argon2Salt := []byte("somesaltsomesalt")
+	argon2IHash := fmt.Sprintf("$argon2i$v=%d$m=8192,t=2,p=1$%s$%s", argon2.Version,
+		base64.RawStdEncoding.EncodeToString(argon2Salt),
+		base64.RawStdEncoding.EncodeToString(argon2.Key([]byte("correct horse"), argon2Salt, 2, 8192, 1, 32)))
+
+	tests := []struct {
+		name         string
+		hash         string
+		password     string
+		passwordType string
+	}{
+		{"bcrypt 2y", "$2y$10$.vGA1O9wmRjrwAVXD98HNOgsNpDczlqm3Jq7KnEd1rVAGv3Fykk1a", "rasmuslerdorf", "bcrypt"},
+		{"argon2i", argon2IHash, "correct horse", PasswordTypeArgon2I},
+		{"ssha", "{SSHA}5pcxo13YdJml98eQSIlT3FMEBMMBAgME", "correct horse", PasswordTypeSSHA},
+		{"ssha512", "{SSHA512}59hB/WwyfgQUZfHrhGKG7B924+SMQ9dsAndq2fAiQ192K1Up6+Uyb2bNkO/rs2mynGTKvujt8okIdKCusVizJwECAwQ=", "correct horse", PasswordTypeSSHA},
+		{"django pbkdf2", "pbkdf2_sha256$1000$seasalt$mQnueSakb748zqBAC1tmWVZsZbi2zPGZarEzTGdfmso=", "correct horse", PasswordTypeDjangoPBKDF2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			passwordType, hash, err := ParseForeignHash(tt.hash)
+			if err != nil {
+				t.Fatalf("ParseForeignHash() error = %v", err)
+			}
+
+			if passwordType != tt.passwordType {
+				t.Fatalf("ParseForeignHash() type = %s, want %s", passwordType, tt.passwordType)
+			}
+
+			verifier, err := GetPasswordVerifier(passwordType)
+			if err != nil {
+				t.Fatalf("GetPasswordVerifier() error = %v", err)
+			}
+
+			match, err := verifier.ComparePassword(tt.password, hash, "")
+			if err != nil || !match {
+				t.Fatalf("ComparePassword() = %v, %v, want match", match, err)
+			}
+
+			match, err = verifier.ComparePassword("wrong password", hash, "")
+			if err != nil || match {
+				t.Fatalf("ComparePassword() with wrong password = %v, %v, want no match", match, err)
+			}
+		})
+	}
+
+	_, _, err := ParseForeignHash("md5$abc")
+	if err == nil {
+		t.Fatalf("ParseForeignHash() expected error for unsupported hash")
+	}
+}
+
+func TestParseKeycloakCredential(t *testing.T) {
+	credential := `{
+		"type": "password",
+		"secretData": "{\"value\":\"H5R9LZ0QvKxHLm1Lg0IeQXdtUAa+9gf2NeOZns+WHBTpnlaWE/+JwnJ/2OXEjNPW5EusP1xxGLqEqjWDbIcGlg==\",\"salt\":\"MDEyMzQ1Njc4OWFiY2RlZg==\"}",
+		"credentialData": "{\"hashIterations\":27500,\"algorithm\":\"pbkdf2-sha256\"}"
+	}`
+
+	passwordType, hash, err := ParseKeycloakCredential([]byte(credential))
+	if err != nil {
+		t.Fatalf("ParseKeycloakCredential() error = %v", err)
+	}
+
+	verifier, err := GetPasswordVerifier(passwordType)
+	if err != nil {
+		t.Fatalf("GetPasswordVerifier() error = %v", err)
+	}
+
+	match, err := verifier.ComparePassword("correct horse", hash, "")
+	if err != nil || !match {
+		t.Fatalf("ComparePassword() = %v, %v, want match", match, err)
+	}
+}
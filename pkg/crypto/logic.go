@@ -18,27 +18,82 @@ package crypto
 
 import (
 	"fmt"
-	"golang.org/x/crypto/bcrypt"
+	"github.com/anthrove/identity/pkg/object"
 	"golang.org/x/crypto/sha3"
 )
 
+// Default costs of the password hashers, they follow the OWASP password storage recommendations.
+const (
+	DefaultBcryptCost            = 12
+	DefaultArgon2Memory          = 64 * 1024
+	DefaultArgon2Iterations      = 3
+	DefaultArgon2Parallelism     = 4
+	DefaultPBKDF2Iterations      = 600000
+	DefaultScryptCost            = 17
+	DefaultScryptBlockSize       = 8
+	DefaultScryptParallelization = 1
+)
+
 type PasswordHasher interface {
 	HashPassword(password string, salt string) (string, error)
 	ComparePassword(password string, hashedPassword string, salt string) (bool, error)
+	// NeedsRehash reports if the hash was created with other parameters or in an older format than the hasher would use now.
+	NeedsRehash(hashedPassword string) bool
 }
 
+// GetPasswordHasher returns the hasher of a password type with the default parameters.
+// Hashes always embed their parameters, so this hasher can compare all hashes of its type.
 func GetPasswordHasher(passwordType string) (PasswordHasher, error) {
+	return GetPasswordHasherWithParameters(passwordType, object.PasswordHashParameters{})
+}
+
+// GetPasswordHasherWithParameters returns the hasher of a password type with the parameters of a tenant, zero values use the defaults.
+// The hasher mixes in the configured pepper, see LoadPepper.
+func GetPasswordHasherWithParameters(passwordType string, parameters object.PasswordHashParameters) (PasswordHasher, error) {
+	hasher, err := getPasswordHasher(passwordType, parameters)
+	if err != nil {
+		return nil, err
+	}
+
+	return withPepper(hasher), nil
+}
+
+func getPasswordHasher(passwordType string, parameters object.PasswordHashParameters) (PasswordHasher, error) {
 	switch passwordType {
 	case "bcrypt":
-		return NewBcryptHasher(bcrypt.DefaultCost), nil
+		return NewBcryptHasher(orDefault(parameters.BcryptCost, DefaultBcryptCost)), nil
 	case "argon2id":
-		return NewArgon2IDHasher(16*1024, 3, 2, 16, 32), nil
+		return NewArgon2IDHasher(orDefault(parameters.Argon2Memory, DefaultArgon2Memory), orDefault(parameters.Argon2Iterations, DefaultArgon2Iterations), orDefault(parameters.Argon2Parallelism, DefaultArgon2Parallelism), 16, 32), nil
 	case "pbkdf2":
-		return NewPBKDF2Hasher(sha3.New256, 1000, 32), nil
+		// sha3-256 was the digest before the PHC format, it is kept so existing hashes don't change their digest
+		return NewPBKDF2Hasher(sha3.New256, orDefault(parameters.PBKDF2Iterations, DefaultPBKDF2Iterations), 32), nil
 	case "scrypt":
-		return NewScryptHasher(16384, 8, 1, 32), nil
+		return NewScryptHasher(1<<orDefault(parameters.ScryptCost, DefaultScryptCost), orDefault(parameters.ScryptBlockSize, DefaultScryptBlockSize), orDefault(parameters.ScryptParallelization, DefaultScryptParallelization), 32), nil
 
 	default:
 		return nil, fmt.Errorf("unsupported password type: %s", passwordType)
 	}
 }
+
+// GetPasswordVerifier returns a verifier for every password type which can be stored, including the imported types which can't be used to hash new passwords.
+func GetPasswordVerifier(passwordType string) (PasswordVerifier, error) {
+	switch passwordType {
+	case PasswordTypeArgon2I:
+		return argon2IVerifier{}, nil
+	case PasswordTypeSSHA:
+		return sshaVerifier{}, nil
+	case PasswordTypeDjangoPBKDF2:
+		return djangoPBKDF2Verifier{}, nil
+	}
+
+	return GetPasswordHasher(passwordType)
+}
+
+func orDefault[T comparable](value T, defaultValue T) T {
+	var zero T
+	if value == zero {
+		return defaultValue
+	}
+
+	return value
+}
@@ -0,0 +1,107 @@
+/*
+ * Copyright (C) 2025 Anthrove
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package crypto
+
+import (
+	"github.com/anthrove/identity/pkg/object"
+	"golang.org/x/crypto/sha3"
+	"strings"
+	"testing"
+)
+
+func TestGetPasswordHasherWithParameters_Rehash(t *testing.T) {
+	current := object.PasswordHashParameters{
+		BcryptCost:       10,
+		Argon2Memory:     8 * 1024,
+		Argon2Iterations: 2,
+		PBKDF2Iterations: 2000,
+		ScryptCost:       10,
+	}
+
+	raised := object.PasswordHashParameters{
+		BcryptCost:       11,
+		Argon2Memory:     16 * 1024,
+		Argon2Iterations: 2,
+		PBKDF2Iterations: 4000,
+		ScryptCost:       11,
+	}
+
+	tests := []struct {
+		passwordType string
+		wantPrefix   string
+	}{
+		{"bcrypt", "$2a$10$"},
+		{"argon2id", "$argon2id$v=19$m=8192,t=2,p=4$"},
+		{"pbkdf2", "$pbkdf2-sha3-256$i=2000,l=32$"},
+		{"scrypt", "$scrypt$ln=10,r=8,p=1$"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.passwordType, func(t *testing.T) {
+			hasher, err := GetPasswordHasherWithParameters(tt.passwordType, current)
+			if err != nil {
+				t.Fatalf("GetPasswordHasherWithParameters() error = %v", err)
+			}
+
+			hash, err := hasher.HashPassword("password123", "SomeSalt")
+			if err != nil {
+				t.Fatalf("HashPassword() error = %v", err)
+			}
+
+			if !strings.HasPrefix(hash, tt.wantPrefix) {
+				t.Errorf("HashPassword() got = %v, want prefix %v", hash, tt.wantPrefix)
+			}
+
+			// the parameters are read from the hash, so a hasher with the defaults can compare it
+			defaultHasher, err := GetPasswordHasher(tt.passwordType)
+			if err != nil {
+				t.Fatalf("GetPasswordHasher() error = %v", err)
+			}
+
+			success, err := defaultHasher.ComparePassword("password123", hash, "SomeSalt")
+			if err != nil || !success {
+				t.Errorf("ComparePassword() got = %v, %v, want true", success, err)
+			}
+
+			if hasher.NeedsRehash(hash) {
+				t.Errorf("NeedsRehash() with the same parameters got = true, want false")
+			}
+
+			raisedHasher, err := GetPasswordHasherWithParameters(tt.passwordType, raised)
+			if err != nil {
+				t.Fatalf("GetPasswordHasherWithParameters() error = %v", err)
+			}
+
+			if !raisedHasher.NeedsRehash(hash) {
+				t.Errorf("NeedsRehash() with raised parameters got = false, want true")
+			}
+		})
+	}
+}
+
+func TestNeedsRehash_LegacyFormat(t *testing.T) {
+	pbkdf2Legacy := "$pbkdf2$iter=10000$keylen=32$U29tZVNhbHQ$9pWMSDVXvi0VVYRbk/+ZCIzyQpAEI+i9yNQPNYZB9Gc"
+	scryptLegacy := "$scrypt$costFactor=16384$blockSize=8$parallelization=1$keyLength=32$U29tZVNhbHQ$udrWtp+zcLL4CGV9wqC2aMQm3BkMFs5F1OIPkUow6qE"
+
+	if !NewPBKDF2Hasher(sha3.New256, 10000, 32).NeedsRehash(pbkdf2Legacy) {
+		t.Errorf("NeedsRehash() of legacy pbkdf2 hash got = false, want true")
+	}
+
+	if !NewScryptHasher(16384, 8, 1, 32).NeedsRehash(scryptLegacy) {
+		t.Errorf("NeedsRehash() of legacy scrypt hash got = false, want true")
+	}
+}
@@ -18,15 +18,28 @@ package crypto
 
 import (
 	"bytes"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
 	"crypto/subtle"
 	"encoding/base64"
 	"errors"
 	"fmt"
 	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/crypto/sha3"
 	"hash"
 	"strings"
 )
 
+// pbkdf2Digests are the digests which can be named in the PHC identifier, e.g. $pbkdf2-sha256$.
+var pbkdf2Digests = map[string]func() hash.Hash{
+	"sha1":     sha1.New,
+	"sha256":   sha256.New,
+	"sha512":   sha512.New,
+	"sha3-256": sha3.New256,
+	"sha3-512": sha3.New512,
+}
+
 type pbkdf2Hasher struct {
 	Digest     func() hash.Hash
 	Iterations int
@@ -41,6 +54,7 @@ func NewPBKDF2Hasher(digest func() hash.Hash, iterations int, keyLen int) Passwo
 	}
 }
 
+// HashPassword hashes the password in the PHC string format: $pbkdf2-<digest>$i=<iterations>,l=<key length>$<salt>$<hash>
 func (p pbkdf2Hasher) HashPassword(password string, salt string) (string, error) {
 	if len(salt) == 0 {
 		return "", errors.New("salt should not be empty")
@@ -57,7 +71,7 @@ func (p pbkdf2Hasher) HashPassword(password string, salt string) (string, error)
 	b64Salt := base64.RawStdEncoding.EncodeToString(saltBuffer)
 	b64Hash := base64.RawStdEncoding.EncodeToString(df)
 
-	encodedHash := fmt.Sprintf("$pbkdf2$iter=%d$keylen=%d$%s$%s", p.Iterations, p.KeyLen, b64Salt, b64Hash)
+	encodedHash := fmt.Sprintf("$%s$i=%d,l=%d$%s$%s", p.identifier(), p.Iterations, p.KeyLen, b64Salt, b64Hash)
 	return encodedHash, nil
 }
 
@@ -67,7 +81,12 @@ func (p pbkdf2Hasher) ComparePassword(password string, hashedPassword string, sa
 		return false, err
 	}
 
-	computedHash := pbkdf2.Key([]byte(password), decodedSalt, iterations, keyLen, p.Digest)
+	digest, err := p.digest(hashedPassword)
+	if err != nil {
+		return false, err
+	}
+
+	computedHash := pbkdf2.Key([]byte(password), decodedSalt, iterations, keyLen, digest)
 
 	if subtle.ConstantTimeCompare(passwordHash, computedHash) == 1 {
 		return true, nil
@@ -75,29 +94,76 @@ func (p pbkdf2Hasher) ComparePassword(password string, hashedPassword string, sa
 	return false, nil
 }
 
-func (p pbkdf2Hasher) decodeHash(encodedHash string) (iterations int, keyLen int, salt []byte, hash []byte, err error) {
-	vals := strings.Split(encodedHash, "$")
-	if len(vals) != 6 {
-		err = errors.New("invalid hash format")
-		return
+func (p pbkdf2Hasher) NeedsRehash(hashedPassword string) bool {
+	iterations, keyLen, _, _, err := p.decodeHash(hashedPassword)
+	if err != nil {
+		return true
 	}
 
-	_, err = fmt.Sscanf(vals[2], "iter=%d", &iterations)
-	if err != nil {
-		return
+	return strings.Split(hashedPassword, "$")[1] != p.identifier() || iterations != p.Iterations || keyLen != p.KeyLen
+}
+
+// identifier returns the PHC identifier of the hasher, it names the digest if it is known.
+func (p pbkdf2Hasher) identifier() string {
+	sum := p.Digest().Sum(nil)
+
+	for name, digest := range pbkdf2Digests {
+		if bytes.Equal(digest().Sum(nil), sum) {
+			return "pbkdf2-" + name
+		}
 	}
 
-	_, err = fmt.Sscanf(vals[3], "keylen=%d", &keyLen)
-	if err != nil {
+	return "pbkdf2"
+}
+
+// digest returns the digest named in the PHC identifier of the hash. Hashes without a named digest use the digest of the hasher.
+func (p pbkdf2Hasher) digest(encodedHash string) (func() hash.Hash, error) {
+	identifier := strings.Split(encodedHash, "$")[1]
+
+	name, named := strings.CutPrefix(identifier, "pbkdf2-")
+	if !named {
+		return p.Digest, nil
+	}
+
+	digest, exists := pbkdf2Digests[name]
+	if !exists {
+		return nil, fmt.Errorf("unsupported pbkdf2 digest: %s", name)
+	}
+
+	return digest, nil
+}
+
+// decodeHash decodes the PHC string format and the older $pbkdf2$iter=<iterations>$keylen=<key length>$<salt>$<hash> format.
+func (p pbkdf2Hasher) decodeHash(encodedHash string) (iterations int, keyLen int, salt []byte, hash []byte, err error) {
+	vals := strings.Split(encodedHash, "$")
+
+	switch {
+	case len(vals) == 6 && vals[1] == "pbkdf2":
+		_, err = fmt.Sscanf(vals[2], "iter=%d", &iterations)
+		if err != nil {
+			return
+		}
+
+		_, err = fmt.Sscanf(vals[3], "keylen=%d", &keyLen)
+		if err != nil {
+			return
+		}
+	case len(vals) == 5 && strings.HasPrefix(vals[1], "pbkdf2"):
+		_, err = fmt.Sscanf(vals[2], "i=%d,l=%d", &iterations, &keyLen)
+		if err != nil {
+			return
+		}
+	default:
+		err = errors.New("invalid hash format")
 		return
 	}
 
-	salt, err = base64.RawStdEncoding.DecodeString(vals[4])
+	salt, err = base64.RawStdEncoding.DecodeString(vals[len(vals)-2])
 	if err != nil {
 		return
 	}
 
-	hash, err = base64.RawStdEncoding.DecodeString(vals[5])
+	hash, err = base64.RawStdEncoding.DecodeString(vals[len(vals)-1])
 	if err != nil {
 		return
 	}
@@ -0,0 +1,158 @@
+/*
+ * Copyright (C) 2025 Anthrove
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package crypto
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"github.com/anthrove/identity/internal/config"
+	"github.com/caarlos0/env/v11"
+	"strings"
+)
+
+// pepperPrefix marks a peppered hash, it is followed by the key ID and the hash of the wrapped hasher: $pepper$k=<id>$2a$12$...
+const pepperPrefix = "$pepper$k="
+
+// minPepperLength is the minimum length of a pepper key in bytes.
+const minPepperLength = 16
+
+type pepperKeyring struct {
+	currentID string
+	keys      map[string][]byte
+}
+
+// peppers holds the configured pepper keys, it is empty until LoadPepper or SetPepper is called.
+var peppers = pepperKeyring{}
+
+// LoadPepper reads the pepper configuration from the environment variables PASSWORD_PEPPER_ID and PASSWORD_PEPPERS.
+// Without a configured pepper ID passwords are hashed without a pepper.
+func LoadPepper() error {
+	pepperConfig, err := env.ParseAs[config.Pepper]()
+	if err != nil {
+		return err
+	}
+
+	keys := make(map[string][]byte, len(pepperConfig.Peppers))
+	for id, encodedKey := range pepperConfig.Peppers {
+		key, err := base64.StdEncoding.DecodeString(encodedKey)
+		if err != nil {
+			return errors.Join(fmt.Errorf("problem while decoding pepper %s", id), err)
+		}
+
+		keys[id] = key
+	}
+
+	return SetPepper(pepperConfig.PepperID, keys)
+}
+
+// SetPepper configures the pepper keys. currentID selects the key used for new hashes, the other keys are only used to verify existing hashes.
+func SetPepper(currentID string, keys map[string][]byte) error {
+	for id, key := range keys {
+		if id == "" || strings.Contains(id, "$") {
+			return fmt.Errorf("invalid pepper id: %q", id)
+		}
+
+		if len(key) < minPepperLength {
+			return fmt.Errorf("pepper %s is shorter than %d bytes", id, minPepperLength)
+		}
+	}
+
+	if _, exists := keys[currentID]; currentID != "" && !exists {
+		return fmt.Errorf("pepper %s is not configured", currentID)
+	}
+
+	peppers = pepperKeyring{
+		currentID: currentID,
+		keys:      keys,
+	}
+
+	return nil
+}
+
+// pepperedHasher mixes the pepper into the password with an HMAC before the wrapped hasher hashes it.
+// The base64 encoded HMAC keeps the input of the wrapped hasher printable and below the 72 byte limit of bcrypt.
+type pepperedHasher struct {
+	hasher PasswordHasher
+}
+
+func withPepper(hasher PasswordHasher) PasswordHasher {
+	return &pepperedHasher{
+		hasher: hasher,
+	}
+}
+
+func (p pepperedHasher) HashPassword(password string, salt string) (string, error) {
+	if peppers.currentID == "" {
+		return p.hasher.HashPassword(password, salt)
+	}
+
+	hash, err := p.hasher.HashPassword(applyPepper(peppers.keys[peppers.currentID], password), salt)
+	if err != nil {
+		return "", err
+	}
+
+	return pepperPrefix + peppers.currentID + hash, nil
+}
+
+func (p pepperedHasher) ComparePassword(password string, hashedPassword string, salt string) (bool, error) {
+	id, hash, peppered := splitPepper(hashedPassword)
+	if !peppered {
+		return p.hasher.ComparePassword(password, hashedPassword, salt)
+	}
+
+	key, exists := peppers.keys[id]
+	if !exists {
+		return false, fmt.Errorf("pepper %s is not configured", id)
+	}
+
+	return p.hasher.ComparePassword(applyPepper(key, password), hash, salt)
+}
+
+// NeedsRehash also reports hashes without pepper or with an old pepper, so they get upgraded to the current pepper.
+func (p pepperedHasher) NeedsRehash(hashedPassword string) bool {
+	id, hash, _ := splitPepper(hashedPassword)
+	if id != peppers.currentID {
+		return true
+	}
+
+	return p.hasher.NeedsRehash(hash)
+}
+
+func applyPepper(key []byte, password string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(password))
+
+	return base64.RawStdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// splitPepper splits a peppered hash into the pepper ID and the hash of the wrapped hasher.
+func splitPepper(hashedPassword string) (string, string, bool) {
+	if !strings.HasPrefix(hashedPassword, pepperPrefix) {
+		return "", hashedPassword, false
+	}
+
+	rest := strings.TrimPrefix(hashedPassword, pepperPrefix)
+	index := strings.Index(rest, "$")
+	if index < 0 {
+		return "", hashedPassword, false
+	}
+
+	return rest[:index], rest[index:], true
+}
@@ -0,0 +1,119 @@
+/*
+ * Copyright (C) 2025 Anthrove
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package crypto
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPepperedHasher_Rotation(t *testing.T) {
+	t.Cleanup(func() {
+		_ = SetPepper("", nil)
+	})
+
+	hasher, err := GetPasswordHasher("bcrypt")
+	if err != nil {
+		t.Fatalf("GetPasswordHasher() error = %v", err)
+	}
+
+	plainHash, err := hasher.HashPassword("secret", "salt")
+	if err != nil {
+		t.Fatalf("HashPassword() error = %v", err)
+	}
+
+	keys := map[string][]byte{
+		"2025": []byte("0123456789abcdef0123456789abcdef"),
+		"2026": []byte("fedcba9876543210fedcba9876543210"),
+	}
+
+	err = SetPepper("2025", keys)
+	if err != nil {
+		t.Fatalf("SetPepper() error = %v", err)
+	}
+
+	if match, err := hasher.ComparePassword("secret", plainHash, "salt"); err != nil || !match {
+		t.Fatalf("ComparePassword() of hash without pepper = %v, %v, want match", match, err)
+	}
+
+	if !hasher.NeedsRehash(plainHash) {
+		t.Fatalf("NeedsRehash() of hash without pepper = false, want true")
+	}
+
+	oldHash, err := hasher.HashPassword("secret", "salt")
+	if err != nil {
+		t.Fatalf("HashPassword() error = %v", err)
+	}
+
+	if !strings.HasPrefix(oldHash, "$pepper$k=2025$2a$") {
+		t.Fatalf("HashPassword() = %s, want pepper prefix", oldHash)
+	}
+
+	if hasher.NeedsRehash(oldHash) {
+		t.Fatalf("NeedsRehash() of current pepper = true, want false")
+	}
+
+	err = SetPepper("2026", keys)
+	if err != nil {
+		t.Fatalf("SetPepper() error = %v", err)
+	}
+
+	if match, err := hasher.ComparePassword("secret", oldHash, "salt"); err != nil || !match {
+		t.Fatalf("ComparePassword() with old pepper = %v, %v, want match", match, err)
+	}
+
+	if match, _ := hasher.ComparePassword("wrong", oldHash, "salt"); match {
+		t.Fatalf("ComparePassword() with wrong password = true, want false")
+	}
+
+	if !hasher.NeedsRehash(oldHash) {
+		t.Fatalf("NeedsRehash() of old pepper = false, want true")
+	}
+
+	err = SetPepper("2026", map[string][]byte{"2026": keys["2026"]})
+	if err != nil {
+		t.Fatalf("SetPepper() error = %v", err)
+	}
+
+	if _, err := hasher.ComparePassword("secret", oldHash, "salt"); err == nil {
+		t.Fatalf("ComparePassword() with removed pepper expected error")
+	}
+}
+
+func TestSetPepper_Invalid(t *testing.T) {
+	t.Cleanup(func() {
+		_ = SetPepper("", nil)
+	})
+
+	tests := []struct {
+		name      string
+		currentID string
+		keys      map[string][]byte
+	}{
+		{"unknown current", "2026", map[string][]byte{"2025": []byte("0123456789abcdef")}},
+		{"short key", "2025", map[string][]byte{"2025": []byte("short")}},
+		{"dollar in id", "a$b", map[string][]byte{"a$b": []byte("0123456789abcdef")}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := SetPepper(tt.currentID, tt.keys); err == nil {
+				t.Fatalf("SetPepper() expected error")
+			}
+		})
+	}
+}
@@ -23,6 +23,7 @@ import (
 	"errors"
 	"fmt"
 	"golang.org/x/crypto/scrypt"
+	"math/bits"
 	"strings"
 )
 
@@ -42,6 +43,7 @@ func NewScryptHasher(costFactor, blockSize, parallelization, keyLength int) Pass
 	}
 }
 
+// HashPassword hashes the password in the PHC string format: $scrypt$ln=<log2 of cost factor>,r=<block size>,p=<parallelization>$<salt>$<hash>
 func (s scryptHasher) HashPassword(password string, salt string) (string, error) {
 	if len(salt) == 0 {
 		return "", errors.New("salt should not be empty")
@@ -61,7 +63,7 @@ func (s scryptHasher) HashPassword(password string, salt string) (string, error)
 	base64Salt := base64.RawStdEncoding.EncodeToString(saltBytes)
 	base64Hash := base64.RawStdEncoding.EncodeToString(derivedKey)
 
-	encodedHash := fmt.Sprintf("$scrypt$costFactor=%d$blockSize=%d$parallelization=%d$keyLength=%d$%s$%s", s.CostFactor, s.BlockSize, s.Parallelization, s.KeyLength, base64Salt, base64Hash)
+	encodedHash := fmt.Sprintf("$scrypt$ln=%d,r=%d,p=%d$%s$%s", bits.Len(uint(s.CostFactor))-1, s.BlockSize, s.Parallelization, base64Salt, base64Hash)
 	return encodedHash, nil
 }
 
@@ -82,41 +84,72 @@ func (s scryptHasher) ComparePassword(password string, hashedPassword string, sa
 	return false, nil
 }
 
-func (s scryptHasher) decodeHash(encodedHash string) (costFactor, blockSize, parallelization, keyLength int, salt []byte, hash []byte, err error) {
-	parts := strings.Split(encodedHash, "$")
-	if len(parts) != 8 {
-		err = errors.New("invalid hash format")
-		return
-	}
-
-	_, err = fmt.Sscanf(parts[2], "costFactor=%d", &costFactor)
+func (s scryptHasher) NeedsRehash(hashedPassword string) bool {
+	costFactor, blockSize, parallelization, keyLength, _, _, err := s.decodeHash(hashedPassword)
 	if err != nil {
-		return
+		return true
 	}
 
-	_, err = fmt.Sscanf(parts[3], "blockSize=%d", &blockSize)
-	if err != nil {
-		return
-	}
+	legacy := len(strings.Split(hashedPassword, "$")) != 5
 
-	_, err = fmt.Sscanf(parts[4], "parallelization=%d", &parallelization)
-	if err != nil {
+	return legacy || costFactor != s.CostFactor || blockSize != s.BlockSize || parallelization != s.Parallelization || keyLength != s.KeyLength
+}
+
+// decodeHash decodes the PHC string format and the older $scrypt$costFactor=<n>$blockSize=<r>$parallelization=<p>$keyLength=<length>$<salt>$<hash> format.
+func (s scryptHasher) decodeHash(encodedHash string) (costFactor, blockSize, parallelization, keyLength int, salt []byte, hash []byte, err error) {
+	parts := strings.Split(encodedHash, "$")
+
+	switch {
+	case len(parts) == 8 && parts[1] == "scrypt":
+		_, err = fmt.Sscanf(parts[2], "costFactor=%d", &costFactor)
+		if err != nil {
+			return
+		}
+
+		_, err = fmt.Sscanf(parts[3], "blockSize=%d", &blockSize)
+		if err != nil {
+			return
+		}
+
+		_, err = fmt.Sscanf(parts[4], "parallelization=%d", &parallelization)
+		if err != nil {
+			return
+		}
+
+		_, err = fmt.Sscanf(parts[5], "keyLength=%d", &keyLength)
+		if err != nil {
+			return
+		}
+	case len(parts) == 5 && parts[1] == "scrypt":
+		var logCostFactor int
+		_, err = fmt.Sscanf(parts[2], "ln=%d,r=%d,p=%d", &logCostFactor, &blockSize, &parallelization)
+		if err != nil {
+			return
+		}
+
+		if logCostFactor < 1 || logCostFactor > 30 {
+			err = errors.New("invalid scrypt cost factor")
+			return
+		}
+
+		costFactor = 1 << logCostFactor
+	default:
+		err = errors.New("invalid hash format")
 		return
 	}
 
-	_, err = fmt.Sscanf(parts[5], "keyLength=%d", &keyLength)
+	salt, err = base64.RawStdEncoding.DecodeString(parts[len(parts)-2])
 	if err != nil {
 		return
 	}
 
-	salt, err = base64.RawStdEncoding.DecodeString(parts[6])
+	hash, err = base64.RawStdEncoding.DecodeString(parts[len(parts)-1])
 	if err != nil {
 		return
 	}
 
-	hash, err = base64.RawStdEncoding.DecodeString(parts[7])
-	if err != nil {
-		return
+	if keyLength == 0 {
+		keyLength = len(hash)
 	}
 
 	return
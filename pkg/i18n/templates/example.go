@@ -21,8 +21,8 @@ const VerificationTemplateWithLink = `<!DOCTYPE html>
 <head>
     <style>
         body {
-            background-color: #f7fafc;
-            color: #2d3748;
+            background-color: {{.Branding.BackgroundColor}};
+            color: {{.Branding.TextColor}};
             font-family: Arial, sans-serif;
         }
         .container {
@@ -45,17 +45,17 @@ const VerificationTemplateWithLink = `<!DOCTYPE html>
             display: inline-block;
             padding: 10px 20px;
             color: #ffffff;
-            background-color: #4299e1;
+            background-color: {{.Branding.PrimaryColor}};
             border-radius: 4px;
             text-decoration: none;
         }
         .button:hover {
-            background-color: #3182ce;
+            opacity: 0.9;
         }
         .link {
             margin-top: 16px;
             font-size: 14px;
-            color: #4299e1;
+            color: {{.Branding.PrimaryColor}};
             word-break: break-all;
         }
         .footer {
@@ -67,12 +67,14 @@ const VerificationTemplateWithLink = `<!DOCTYPE html>
 </head>
 <body>
     <div class="container">
+        {{if .Branding.LogoURL}}<img src="{{.Branding.LogoURL}}" alt="" style="max-height: 48px; margin-bottom: 16px;">{{end}}
         <h1 class="header">Welcome, {{.DisplayName}}!</h1>
         <p class="content">Thank you for registering. Please verify your email address by clicking the link below:</p>
         <a href="{{.VerificationLink}}" class="button">Verify Email</a>
         <p class="content">If the button above does not work, please copy and paste the following link into your browser:</p>
         <p class="link">{{.VerificationLink}}</p>
         <p class="footer">If you did not register for this account, please ignore this email.</p>
+        {{if .Branding.FooterLinks}}<p class="footer">{{range .Branding.FooterLinks}}<a href="{{.URL}}" style="color: #718096;">{{.Title}}</a> {{end}}</p>{{end}}
     </div>
 </body>
 </html>`
@@ -82,8 +84,8 @@ const VerificationTemplateWithCode = `<!DOCTYPE html>
 <head>
     <style>
         body {
-            background-color: #f7fafc;
-            color: #2d3748;
+            background-color: {{.Branding.BackgroundColor}};
+            color: {{.Branding.TextColor}};
             font-family: Arial, sans-serif;
         }
         .container {
@@ -106,7 +108,7 @@ const VerificationTemplateWithCode = `<!DOCTYPE html>
             display: inline-block;
             padding: 10px 20px;
             color: #ffffff;
-            background-color: #4299e1;
+            background-color: {{.Branding.PrimaryColor}};
             border-radius: 4px;
             font-family: monospace;
             font-size: 18px;
@@ -120,10 +122,12 @@ const VerificationTemplateWithCode = `<!DOCTYPE html>
 </head>
 <body>
     <div class="container">
+        {{if .Branding.LogoURL}}<img src="{{.Branding.LogoURL}}" alt="" style="max-height: 48px; margin-bottom: 16px;">{{end}}
         <h1 class="header">Welcome, {{.DisplayName}}!</h1>
         <p class="content">Thank you for registering. Please verify your email address by using the code below:</p>
         <div class="code">{{.VerificationCode}}</div>
         <p class="footer">If you did not register for this account, please ignore this email.</p>
+        {{if .Branding.FooterLinks}}<p class="footer">{{range .Branding.FooterLinks}}<a href="{{.URL}}" style="color: #718096;">{{.Title}}</a> {{end}}</p>{{end}}
     </div>
 </body>
 </html>`
@@ -133,8 +137,8 @@ const PasswordResetTemplate = `<!DOCTYPE html>
 <head>
     <style>
         body {
-            background-color: #f7fafc;
-            color: #2d3748;
+            background-color: {{.Branding.BackgroundColor}};
+            color: {{.Branding.TextColor}};
             font-family: Arial, sans-serif;
         }
         .container {
@@ -157,17 +161,17 @@ const PasswordResetTemplate = `<!DOCTYPE html>
             display: inline-block;
             padding: 10px 20px;
             color: #ffffff;
-            background-color: #4299e1;
+            background-color: {{.Branding.PrimaryColor}};
             border-radius: 4px;
             text-decoration: none;
         }
         .button:hover {
-            background-color: #3182ce;
+            opacity: 0.9;
         }
         .link {
             margin-top: 16px;
             font-size: 14px;
-            color: #4299e1;
+            color: {{.Branding.PrimaryColor}};
             word-break: break-all;
         }
         .footer {
@@ -179,12 +183,14 @@ const PasswordResetTemplate = `<!DOCTYPE html>
 </head>
 <body>
     <div class="container">
+        {{if .Branding.LogoURL}}<img src="{{.Branding.LogoURL}}" alt="" style="max-height: 48px; margin-bottom: 16px;">{{end}}
         <h1 class="header">Password Reset Request</h1>
         <p class="content">We received a request to reset your password. Click the link below to reset your password:</p>
         <a href="{{.ResetLink}}" class="button">Reset Password</a>
         <p class="content">If the button above does not work, please copy and paste the following link into your browser:</p>
         <p class="link">{{.ResetLink}}</p>
         <p class="footer">If you did not request a password reset, please ignore this email.</p>
+        {{if .Branding.FooterLinks}}<p class="footer">{{range .Branding.FooterLinks}}<a href="{{.URL}}" style="color: #718096;">{{.Title}}</a> {{end}}</p>{{end}}
     </div>
 </body>
 </html>`
@@ -194,8 +200,8 @@ const AccountDeactivationTemplate = `<!DOCTYPE html>
 <head>
     <style>
         body {
-            background-color: #f7fafc;
-            color: #2d3748;
+            background-color: {{.Branding.BackgroundColor}};
+            color: {{.Branding.TextColor}};
             font-family: Arial, sans-serif;
         }
         .container {
@@ -223,9 +229,248 @@ const AccountDeactivationTemplate = `<!DOCTYPE html>
 </head>
 <body>
     <div class="container">
+        {{if .Branding.LogoURL}}<img src="{{.Branding.LogoURL}}" alt="" style="max-height: 48px; margin-bottom: 16px;">{{end}}
         <h1 class="header">Account Deactivation Notice</h1>
         <p class="content">Your account has been deactivated. If you believe this is a mistake, please contact our support team.</p>
         <p class="footer">If you did not request this deactivation, please ignore this email.</p>
+        {{if .Branding.FooterLinks}}<p class="footer">{{range .Branding.FooterLinks}}<a href="{{.URL}}" style="color: #718096;">{{.Title}}</a> {{end}}</p>{{end}}
+    </div>
+</body>
+</html>`
+
+const RecoveryCodeUsedTemplate = `<!DOCTYPE html>
+<html>
+<head>
+    <style>
+        body {
+            background-color: {{.Branding.BackgroundColor}};
+            color: {{.Branding.TextColor}};
+            font-family: Arial, sans-serif;
+        }
+        .container {
+            max-width: 600px;
+            margin: 40px auto;
+            padding: 20px;
+            background-color: #ffffff;
+            border-radius: 8px;
+            box-shadow: 0 4px 6px rgba(0, 0, 0, 0.1);
+        }
+        .header {
+            font-size: 24px;
+            font-weight: bold;
+            margin-bottom: 16px;
+        }
+        .content {
+            margin-bottom: 16px;
+        }
+        .footer {
+            margin-top: 16px;
+            font-size: 12px;
+            color: #718096;
+        }
+    </style>
+</head>
+<body>
+    <div class="container">
+        {{if .Branding.LogoURL}}<img src="{{.Branding.LogoURL}}" alt="" style="max-height: 48px; margin-bottom: 16px;">{{end}}
+        <h1 class="header">Recovery Code Used</h1>
+        <p class="content">Hello {{.DisplayName}}, a recovery code was used to sign in to your account. You have {{.Remaining}} recovery codes left.</p>
+        <p class="footer">If this was not you, please change your password and regenerate your recovery codes immediately.</p>
+        {{if .Branding.FooterLinks}}<p class="footer">{{range .Branding.FooterLinks}}<a href="{{.URL}}" style="color: #718096;">{{.Title}}</a> {{end}}</p>{{end}}
+    </div>
+</body>
+</html>`
+
+const MFACodeTemplate = `<!DOCTYPE html>
+<html>
+<head>
+    <style>
+        body {
+            background-color: {{.Branding.BackgroundColor}};
+            color: {{.Branding.TextColor}};
+            font-family: Arial, sans-serif;
+        }
+        .container {
+            max-width: 600px;
+            margin: 40px auto;
+            padding: 20px;
+            background-color: #ffffff;
+            border-radius: 8px;
+            box-shadow: 0 4px 6px rgba(0, 0, 0, 0.1);
+        }
+        .header {
+            font-size: 24px;
+            font-weight: bold;
+            margin-bottom: 16px;
+        }
+        .content {
+            margin-bottom: 16px;
+        }
+        .code {
+            display: inline-block;
+            padding: 10px 20px;
+            color: #ffffff;
+            background-color: {{.Branding.PrimaryColor}};
+            border-radius: 4px;
+            font-family: monospace;
+            font-size: 18px;
+        }
+        .footer {
+            margin-top: 16px;
+            font-size: 12px;
+            color: #718096;
+        }
+    </style>
+</head>
+<body>
+    <div class="container">
+        {{if .Branding.LogoURL}}<img src="{{.Branding.LogoURL}}" alt="" style="max-height: 48px; margin-bottom: 16px;">{{end}}
+        <h1 class="header">Your Sign In Code</h1>
+        <p class="content">Hello {{.DisplayName}}, please use the following code to sign in. It is valid for {{.ExpiresIn}} minutes.</p>
+        <div class="code">{{.Code}}</div>
+        <p class="footer">If you did not try to sign in, please change your password immediately.</p>
+        {{if .Branding.FooterLinks}}<p class="footer">{{range .Branding.FooterLinks}}<a href="{{.URL}}" style="color: #718096;">{{.Title}}</a> {{end}}</p>{{end}}
+    </div>
+</body>
+</html>`
+
+const MFACodeSMSTemplate = `Your {{.TenantName}} sign in code is {{.Code}}. It is valid for {{.ExpiresIn}} minutes.`
+
+const AccountLockedTemplate = `<!DOCTYPE html>
+<html>
+<head>
+    <style>
+        body {
+            background-color: {{.Branding.BackgroundColor}};
+            color: {{.Branding.TextColor}};
+            font-family: Arial, sans-serif;
+        }
+        .container {
+            max-width: 600px;
+            margin: 40px auto;
+            padding: 20px;
+            background-color: #ffffff;
+            border-radius: 8px;
+            box-shadow: 0 4px 6px rgba(0, 0, 0, 0.1);
+        }
+        .header {
+            font-size: 24px;
+            font-weight: bold;
+            margin-bottom: 16px;
+        }
+        .content {
+            margin-bottom: 16px;
+        }
+        .footer {
+            margin-top: 16px;
+            font-size: 12px;
+            color: #718096;
+        }
+    </style>
+</head>
+<body>
+    <div class="container">
+        {{if .Branding.LogoURL}}<img src="{{.Branding.LogoURL}}" alt="" style="max-height: 48px; margin-bottom: 16px;">{{end}}
+        <h1 class="header">Account Locked</h1>
+        <p class="content">Hello {{.DisplayName}}, your {{.TenantName}} account was locked after {{.Failures}} failed sign in attempts. You can try again after {{.LockedUntil.Format "2006-01-02 15:04 MST"}}.</p>
+        <p class="footer">If this was not you, someone may be trying to guess your password. Please change it once the lock has expired.</p>
+        {{if .Branding.FooterLinks}}<p class="footer">{{range .Branding.FooterLinks}}<a href="{{.URL}}" style="color: #718096;">{{.Title}}</a> {{end}}</p>{{end}}
+    </div>
+</body>
+</html>`
+
+const EmailChangeTemplate = `<!DOCTYPE html>
+<html>
+<head>
+    <style>
+        body {
+            background-color: {{.Branding.BackgroundColor}};
+            color: {{.Branding.TextColor}};
+            font-family: Arial, sans-serif;
+        }
+        .container {
+            max-width: 600px;
+            margin: 40px auto;
+            padding: 20px;
+            background-color: #ffffff;
+            border-radius: 8px;
+            box-shadow: 0 4px 6px rgba(0, 0, 0, 0.1);
+        }
+        .header {
+            font-size: 24px;
+            font-weight: bold;
+            margin-bottom: 16px;
+        }
+        .content {
+            margin-bottom: 16px;
+        }
+        .code {
+            display: inline-block;
+            padding: 10px 20px;
+            color: #ffffff;
+            background-color: {{.Branding.PrimaryColor}};
+            border-radius: 4px;
+            font-family: monospace;
+            font-size: 18px;
+        }
+        .footer {
+            margin-top: 16px;
+            font-size: 12px;
+            color: #718096;
+        }
+    </style>
+</head>
+<body>
+    <div class="container">
+        {{if .Branding.LogoURL}}<img src="{{.Branding.LogoURL}}" alt="" style="max-height: 48px; margin-bottom: 16px;">{{end}}
+        <h1 class="header">Confirm your new Email</h1>
+        <p class="content">Hello {{.DisplayName}}, please use the code below to confirm {{.Email}} as the new email of your {{.TenantName}} account. It is valid for {{.ExpiresIn}} hours.</p>
+        <div class="code">{{.VerificationCode}}</div>
+        <p class="footer">If you did not change your email, please ignore this email.</p>
+        {{if .Branding.FooterLinks}}<p class="footer">{{range .Branding.FooterLinks}}<a href="{{.URL}}" style="color: #718096;">{{.Title}}</a> {{end}}</p>{{end}}
+    </div>
+</body>
+</html>`
+
+const EmailChangedTemplate = `<!DOCTYPE html>
+<html>
+<head>
+    <style>
+        body {
+            background-color: {{.Branding.BackgroundColor}};
+            color: {{.Branding.TextColor}};
+            font-family: Arial, sans-serif;
+        }
+        .container {
+            max-width: 600px;
+            margin: 40px auto;
+            padding: 20px;
+            background-color: #ffffff;
+            border-radius: 8px;
+            box-shadow: 0 4px 6px rgba(0, 0, 0, 0.1);
+        }
+        .header {
+            font-size: 24px;
+            font-weight: bold;
+            margin-bottom: 16px;
+        }
+        .content {
+            margin-bottom: 16px;
+        }
+        .footer {
+            margin-top: 16px;
+            font-size: 12px;
+            color: #718096;
+        }
+    </style>
+</head>
+<body>
+    <div class="container">
+        {{if .Branding.LogoURL}}<img src="{{.Branding.LogoURL}}" alt="" style="max-height: 48px; margin-bottom: 16px;">{{end}}
+        <h1 class="header">Email Changed</h1>
+        <p class="content">Hello {{.DisplayName}}, the email of your {{.TenantName}} account was changed to {{.NewEmail}}. Messages are no longer sent to this address.</p>
+        <p class="footer">If you did not change your email, please contact an administrator immediately.</p>
+        {{if .Branding.FooterLinks}}<p class="footer">{{range .Branding.FooterLinks}}<a href="{{.URL}}" style="color: #718096;">{{.Title}}</a> {{end}}</p>{{end}}
     </div>
 </body>
 </html>`
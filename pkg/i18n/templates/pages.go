@@ -0,0 +1,363 @@
+/*
+ * Copyright (C) 2025 Anthrove
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package templates
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"github.com/anthrove/identity/pkg/object"
+	"html/template"
+	"io"
+	"slices"
+	"strings"
+	"time"
+)
+
+//go:embed pages/*.html
+var pageFiles embed.FS
+
+// pageBase is never executed, so it can still be cloned for pages with custom templates.
+var pageBase = template.Must(template.New("pages").Funcs(template.FuncMap{
+	"scopeDescription": scopeDescription,
+	"formatTime":       formatTime,
+	"profileValue":     profileValue,
+	"profileSelected":  profileSelected,
+	"profileInputType": profileInputType,
+}).ParseFS(pageFiles, "pages/*.html"))
+
+var pages = template.Must(pageBase.Clone())
+
+// PageNames are the names of the hosted pages, a custom template can be configured for each of them.
+var PageNames = []string{"login", "mfa", "password", "consent", "reset", "reset_complete", "account", "error"}
+
+// maxCustomCSS is the size of custom CSS, after which RenderPage ignores it.
+const maxCustomCSS = 20000
+
+// Page holds the data of all hosted pages, every page only uses the fields it needs.
+type Page struct {
+	Title string
+	Error string
+	Info  string
+
+	TenantID        string
+	TenantName      string
+	ApplicationID   string
+	ApplicationName string
+	RequestID       string
+
+	Username        string
+	PasswordEnabled bool
+	SignUpURL       string
+	SignInURL       string
+	TermsURL        string
+
+	MFAs               []object.MFA
+	MFA                object.MFA
+	Pending            bool
+	TrustDeviceAllowed bool
+
+	// WebAuthnOptions are the JSON encoded options of navigator.credentials.get for a webauthn MFA.
+	WebAuthnOptions string
+
+	Scopes []string
+
+	Token string
+	Done  bool
+
+	Account Account
+
+	Branding object.AppliedBranding
+
+	// CustomCSS is set by RenderPage from the branding, after the CSS was checked with ValidateCustomCSS.
+	CustomCSS template.CSS
+
+	// ScriptNonce allows the first-party scripts of the built-in pages, it has to be sent in the content security policy
+	// of the response. RenderPage removes it for custom templates, so they stay without scripts.
+	ScriptNonce string
+}
+
+// Account holds the data of the self-service page of a signed in user.
+type Account struct {
+	User           object.User
+	AvatarURL      string
+	Profile        []object.ProfileFieldValue
+	MFAs           []object.MFA
+	Sessions       []object.UserSession
+	TrustedDevices []object.TrustedDevice
+	Consents       []object.Consent
+
+	// RecoveryCodes are only set right after they were regenerated, they can't be shown again.
+	RecoveryCodes []string
+}
+
+// RenderPage renders the hosted page with the given name. If the branding of the page has a custom template for it,
+// the custom template is used instead of the built-in one.
+func RenderPage(w io.Writer, name string, page Page) error {
+	if !slices.Contains(PageNames, name) {
+		return fmt.Errorf("unknown page: %s", name)
+	}
+
+	tmpl := pages
+
+	if custom := page.Branding.PageTemplates[name]; len(custom) > 0 {
+		var err error
+		tmpl, err = parsePageTemplate(name, custom)
+
+		if err != nil {
+			return err
+		}
+
+		page.ScriptNonce = ""
+	}
+
+	page.CustomCSS = ""
+
+	if ValidateCustomCSS(page.Branding.CustomCSS) == nil {
+		page.CustomCSS = template.CSS(page.Branding.CustomCSS)
+	}
+
+	// render into a buffer first, so a failing template doesn't write half a page
+	var buffer bytes.Buffer
+	err := tmpl.ExecuteTemplate(&buffer, name, page)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = buffer.WriteTo(w)
+	return err
+}
+
+// ValidatePageTemplate checks that the custom template of a hosted page can be parsed and rendered with sample data.
+// The templates are rendered with the contextual escaping of html/template and only have access to the page data,
+// they never get the nonce of the first-party scripts, so scripts are still blocked by the content security policy.
+func ValidatePageTemplate(name string, custom string) error {
+	if !slices.Contains(PageNames, name) {
+		return fmt.Errorf("unknown page: %s", name)
+	}
+
+	tmpl, err := parsePageTemplate(name, custom)
+
+	if err != nil {
+		return errors.Join(fmt.Errorf("problem while parsing template of page %s", name), err)
+	}
+
+	err = tmpl.ExecuteTemplate(io.Discard, name, SamplePage(name))
+
+	if err != nil {
+		return errors.Join(fmt.Errorf("problem while rendering template of page %s", name), err)
+	}
+
+	return nil
+}
+
+// ValidateCustomCSS makes sure the custom CSS can't leave its style element or load other stylesheets and scripts.
+func ValidateCustomCSS(css string) error {
+	if len(css) > maxCustomCSS {
+		return errors.New("custom css is too long")
+	}
+
+	lowerCSS := strings.ToLower(css)
+
+	for _, forbidden := range []string{"<", "@import", "expression(", "javascript:", "behavior:", "-moz-binding"} {
+		if strings.Contains(lowerCSS, forbidden) {
+			return fmt.Errorf("custom css must not contain %q", forbidden)
+		}
+	}
+
+	return nil
+}
+
+// SamplePage returns the data of a hosted page with sample values, it is used to validate and preview custom templates.
+func SamplePage(name string) Page {
+	mfas := []object.MFA{
+		{ID: "sample_totp", DisplayName: "Authenticator App", Type: "totp"},
+		{ID: "sample_email_otp", DisplayName: "Email", Type: "email_otp"},
+	}
+
+	page := Page{
+		Title:              sampleTitles[name],
+		TenantID:           "sample",
+		TenantName:         "Sample Tenant",
+		ApplicationID:      "sample",
+		ApplicationName:    "Sample Application",
+		RequestID:          "sample",
+		Username:           "jane.doe",
+		PasswordEnabled:    true,
+		MFAs:               mfas,
+		MFA:                mfas[0],
+		TrustDeviceAllowed: true,
+		Scopes:             []string{"openid", "profile", "email"},
+		Token:              "sample",
+		Branding:           object.DefaultBranding,
+	}
+
+	if name == "error" {
+		page.Error = "The sign in request is unknown or expired."
+	}
+
+	if name == "account" {
+		page.Account = sampleAccount(mfas)
+	}
+
+	return page
+}
+
+// SampleMessageData returns sample values for all values used by the built-in message templates.
+func SampleMessageData() map[string]any {
+	return map[string]any{
+		"TenantName":       "Sample Tenant",
+		"DisplayName":      "Jane Doe",
+		"Email":            "jane.doe@domain.tld",
+		"NewEmail":         "jane@domain.tld",
+		"Code":             "123456",
+		"VerificationCode": "123456",
+		"VerificationLink": "https://domain.tld/verify?token=sample",
+		"ResetLink":        "https://domain.tld/forgot?token=sample",
+		"ExpiresIn":        15,
+		"Remaining":        9,
+		"Failures":         5,
+		"LockedUntil":      time.Now().Add(15 * time.Minute),
+		"Branding":         object.DefaultBranding,
+	}
+}
+
+var sampleTitles = map[string]string{
+	"login":          "Sign In",
+	"mfa":            "Verify Sign In",
+	"password":       "Change Password",
+	"consent":        "Allow Access",
+	"reset":          "Reset Password",
+	"reset_complete": "Set New Password",
+	"account":        "Your Account",
+	"error":          "Something went wrong",
+}
+
+func sampleAccount(mfas []object.MFA) Account {
+	now := time.Now()
+
+	return Account{
+		User: object.User{
+			Username:      "jane.doe",
+			DisplayName:   "Jane Doe",
+			Email:         "jane.doe@domain.tld",
+			EmailVerified: true,
+			PendingEmail:  "jane@domain.tld",
+		},
+		Profile: []object.ProfileFieldValue{
+			{Field: object.ProfileField{Identifier: "nickname", DisplayName: "Nickname", ModifyBy: object.ModifyTypeSelf}, Value: "Jane", Modifiable: true},
+			{Field: object.ProfileField{Identifier: "employee_id", DisplayName: "Employee ID", ModifyBy: object.ModifyTypeImmutable}, Value: "4711"},
+			{Field: object.ProfileField{Identifier: "birthday", DisplayName: "Birthday", Type: object.ProfileFieldTypeDate, ModifyBy: object.ModifyTypeSelf}, Value: "1990-12-24", Modifiable: true},
+			{Field: object.ProfileField{Identifier: "languages", DisplayName: "Languages", Type: object.ProfileFieldTypeMultiSelect, Options: []string{"English", "German"}, ModifyBy: object.ModifyTypeSelf}, Value: []string{"English"}, Modifiable: true},
+		},
+		MFAs: mfas,
+		Sessions: []object.UserSession{
+			{ID: "sample", ApplicationID: "sample", IPAddress: "192.0.2.1", UserAgent: "Mozilla/5.0", CreatedAt: now, AuthenticatedAt: now, Current: true},
+		},
+		TrustedDevices: []object.TrustedDevice{
+			{ID: "sample", IPAddress: "192.0.2.1", UserAgent: "Mozilla/5.0", LastUsedAt: now, ExpiresAt: now.AddDate(0, 0, 30)},
+		},
+		Consents: []object.Consent{
+			{ApplicationID: "sample", ApplicationName: "Sample Application", Scopes: []string{"openid", "profile"}, UpdatedAt: now},
+		},
+		RecoveryCodes: []string{"correct-horse-battery", "staple-horse-correct"},
+	}
+}
+
+func parsePageTemplate(name string, custom string) (*template.Template, error) {
+	tmpl, err := pageBase.Clone()
+
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tmpl.New(name).Parse(custom)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return tmpl, nil
+}
+
+// scopeDescription describes the standard scopes to the user, other scopes are shown as they are.
+func scopeDescription(scope string) string {
+	switch scope {
+	case "openid":
+		return "Sign you in"
+	case "profile":
+		return "Read your name and profile"
+	case "email":
+		return "Read your email address"
+	case "phone":
+		return "Read your phone number"
+	case "address":
+		return "Read your address"
+	case "offline_access":
+		return "Keep access while you are not using it"
+	default:
+		return scope
+	}
+}
+
+// formatTime formats the times shown on the hosted pages, the zero time is shown as a dash.
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+
+	return t.UTC().Format("2006-01-02 15:04 UTC")
+}
+
+// profileValue formats the value of a profile field for a text input, multi select values are separated by commas.
+func profileValue(value any) string {
+	switch value := value.(type) {
+	case nil:
+		return ""
+	case []string:
+		return strings.Join(value, ", ")
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+// profileSelected tells if an option of an enum, multi select or bool profile field is selected.
+func profileSelected(value any, option string) bool {
+	if values, ok := value.([]string); ok {
+		return slices.Contains(values, option)
+	}
+
+	return value != nil && fmt.Sprint(value) == option
+}
+
+// profileInputType returns the type of the html input for a profile field.
+func profileInputType(fieldType object.ProfileFieldType) string {
+	switch fieldType {
+	case object.ProfileFieldTypeInt:
+		return "number"
+	case object.ProfileFieldTypeDate:
+		return "date"
+	case object.ProfileFieldTypePhone:
+		return "tel"
+	case object.ProfileFieldTypeURL:
+		return "url"
+	default:
+		return "text"
+	}
+}
@@ -0,0 +1,116 @@
+/*
+ * Copyright (C) 2025 Anthrove
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package templates
+
+import (
+	"bytes"
+	"github.com/anthrove/identity/pkg/object"
+	"strings"
+	"testing"
+)
+
+func TestValidateCustomCSS(t *testing.T) {
+	tests := []struct {
+		css     string
+		wantErr bool
+	}{
+		{"", false},
+		{".button { border-radius: 0; }", false},
+		{"body { background: url(https://domain.tld/bg.png); }", false},
+		{"</style><script>alert(1)</script>", true},
+		{"@IMPORT url(https://domain.tld/evil.css);", true},
+		{"body { width: expression(alert(1)); }", true},
+		{"body { background: url(javascript:alert(1)); }", true},
+	}
+
+	for _, test := range tests {
+		if err := ValidateCustomCSS(test.css); (err != nil) != test.wantErr {
+			t.Errorf("ValidateCustomCSS(%q) = %v, want error %t", test.css, err, test.wantErr)
+		}
+	}
+}
+
+func TestValidatePageTemplate(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		wantErr  bool
+	}{
+		{"login", `{{template "header" .}}<p>Welcome to {{.ApplicationName}}</p>{{template "footer" .}}`, false},
+		{"unknown", `<p>Hello</p>`, true},
+		{"login", `{{template "header" .}`, true},
+		{"login", `{{.Unknown}}`, true},
+	}
+
+	for _, test := range tests {
+		if err := ValidatePageTemplate(test.name, test.template); (err != nil) != test.wantErr {
+			t.Errorf("ValidatePageTemplate(%q, %q) = %v, want error %t", test.name, test.template, err, test.wantErr)
+		}
+	}
+}
+
+func TestRenderPage(t *testing.T) {
+	page := SamplePage("login")
+	page.ApplicationName = "<b>App</b>"
+	page.Branding.PrimaryColor = "#123456"
+	page.Branding.CustomCSS = ".button { border-radius: 0; }"
+	page.Branding.PageTemplates = map[string]string{
+		"login": `{{template "header" .}}<p class="custom">{{.ApplicationName}}</p>{{template "footer" .}}`,
+	}
+
+	var buffer bytes.Buffer
+	if err := RenderPage(&buffer, "login", page); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{`<p class="custom">&lt;b&gt;App&lt;/b&gt;</p>`, "#123456", ".button { border-radius: 0; }"} {
+		if !strings.Contains(buffer.String(), want) {
+			t.Errorf("RenderPage() does not contain %q", want)
+		}
+	}
+}
+
+func TestRenderPageScriptNonce(t *testing.T) {
+	page := SamplePage("mfa")
+	page.MFA = object.MFA{ID: "sample_webauthn", DisplayName: "Security Key", Type: "webauthn"}
+	page.WebAuthnOptions = `{"publicKey":{"challenge":"c2FtcGxl"}}`
+	page.ScriptNonce = "sample"
+
+	var buffer bytes.Buffer
+	if err := RenderPage(&buffer, "mfa", page); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{`<script nonce="sample">`, `data-options="{&#34;publicKey&#34;:{&#34;challenge&#34;:&#34;c2FtcGxl&#34;}}"`} {
+		if !strings.Contains(buffer.String(), want) {
+			t.Errorf("RenderPage() does not contain %q", want)
+		}
+	}
+
+	page.Branding.PageTemplates = map[string]string{
+		"mfa": `{{template "header" .}}{{template "webauthn_script" .}}<script nonce="{{.ScriptNonce}}"></script>{{template "footer" .}}`,
+	}
+
+	buffer.Reset()
+	if err := RenderPage(&buffer, "mfa", page); err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(buffer.String(), `nonce="sample"`) || strings.Contains(buffer.String(), "navigator.credentials") {
+		t.Errorf("RenderPage() with a custom template contains the script nonce: %s", buffer.String())
+	}
+}
@@ -19,20 +19,79 @@ package templates
 import (
 	"bytes"
 	"github.com/anthrove/identity/pkg/object"
-	"text/template"
+	htmltemplate "html/template"
+	"io"
+	"maps"
+	"slices"
+	texttemplate "text/template"
 )
 
+// textTemplateTypes are sent as plain text, all other message templates are html mails.
+var textTemplateTypes = []string{object.TemplateTypeMFACodeSMS}
+
+// messageTemplate is a parsed html or text template.
+type messageTemplate interface {
+	Execute(w io.Writer, data any) error
+}
+
+// FillMessageTemplate fills the template with the data. The built-in templates use the Branding value, it is set to the
+// DefaultBranding if the data has none. Values in html templates are escaped, as users choose some of them, e.g. the display name.
 func FillMessageTemplate(templateData object.MessageTemplate, data object.FillMessageTemplate) (string, error) {
-	tmpl, err := template.New("message").Parse(templateData.Template)
+	tmpl, err := parseMessageTemplate(templateData)
 	if err != nil {
 		return "", err
 	}
 
+	values := maps.Clone(data.Data)
+	if values == nil {
+		values = map[string]any{}
+	}
+
+	if _, exists := values["Branding"]; !exists {
+		values["Branding"] = object.DefaultBranding
+	}
+
 	var filledTemplate bytes.Buffer
-	err = tmpl.Execute(&filledTemplate, data.Data)
+	err = tmpl.Execute(&filledTemplate, values)
 	if err != nil {
 		return "", err
 	}
 
 	return filledTemplate.String(), nil
 }
+
+// parseMessageTemplate parses the template with text/template for plain text messages and html/template for all others.
+func parseMessageTemplate(templateData object.MessageTemplate) (messageTemplate, error) {
+	if slices.Contains(textTemplateTypes, templateData.TemplateType) {
+		return texttemplate.New("message").Parse(templateData.Template)
+	}
+
+	return htmltemplate.New("message").Parse(templateData.Template)
+}
+
+var defaultMessageTemplates = map[string]string{
+	object.TemplateTypeRecoveryCodeUsed:  RecoveryCodeUsedTemplate,
+	object.TemplateTypeMFACode:           MFACodeTemplate,
+	object.TemplateTypeMFACodeSMS:        MFACodeSMSTemplate,
+	object.TemplateTypeAccountLocked:     AccountLockedTemplate,
+	object.TemplateTypePasswordReset:     PasswordResetTemplate,
+	object.TemplateTypeEmailVerification: VerificationTemplateWithCode,
+	object.TemplateTypeEmailChange:       EmailChangeTemplate,
+	object.TemplateTypeEmailChanged:      EmailChangedTemplate,
+}
+
+// DefaultMessageTemplate returns the built-in template for a template type.
+// It is used when a tenant has not configured its own template of that type.
+func DefaultMessageTemplate(templateType string) (object.MessageTemplate, bool) {
+	template, exists := defaultMessageTemplates[templateType]
+
+	if !exists {
+		return object.MessageTemplate{}, false
+	}
+
+	return object.MessageTemplate{
+		DisplayName:  templateType,
+		TemplateType: templateType,
+		Template:     template,
+	}, true
+}
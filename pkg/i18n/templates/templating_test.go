@@ -0,0 +1,61 @@
+/*
+ * Copyright (C) 2025 Anthrove
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package templates
+
+import (
+	"github.com/anthrove/identity/pkg/object"
+	"strings"
+	"testing"
+)
+
+func TestFillMessageTemplate(t *testing.T) {
+	data := map[string]any{
+		"DisplayName":      `<a href="https://evil.example">Claim your prize</a>`,
+		"VerificationCode": "123456",
+		"TenantName":       "Tenant <b>",
+		"Code":             "654321",
+		"ExpiresIn":        5,
+	}
+
+	for _, templateType := range []string{object.TemplateTypeEmailVerification, object.TemplateTypeMFACodeSMS} {
+		template, _ := DefaultMessageTemplate(templateType)
+
+		message, err := FillMessageTemplate(template, object.FillMessageTemplate{Data: data})
+		if err != nil {
+			t.Fatalf("%s: FillMessageTemplate() error = %v", templateType, err)
+		}
+
+		if templateType == object.TemplateTypeMFACodeSMS {
+			if !strings.Contains(message, "Your Tenant <b> sign in code is 654321") {
+				t.Errorf("%s: FillMessageTemplate() = %q, want the values as they are", templateType, message)
+			}
+			continue
+		}
+
+		if strings.Contains(message, `<a href="https://evil.example">`) {
+			t.Errorf("%s: FillMessageTemplate() contains the html of the display name", templateType)
+		}
+
+		if !strings.Contains(message, "&lt;a href=") || !strings.Contains(message, "123456") {
+			t.Errorf("%s: FillMessageTemplate() misses the escaped display name or the code", templateType)
+		}
+
+		if !strings.Contains(message, "background-color: "+object.DefaultBranding.BackgroundColor) || strings.Contains(message, "ZgotmplZ") {
+			t.Errorf("%s: FillMessageTemplate() changed the branding colors", templateType)
+		}
+	}
+}
@@ -0,0 +1,222 @@
+/*
+ * Copyright (C) 2025 Anthrove
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/anthrove/identity/pkg/object"
+	"github.com/anthrove/identity/pkg/repository"
+	"gorm.io/gorm"
+	"time"
+)
+
+// FindOwnProfile returns the profile fields a user can see of their own profile, together with the stored values.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancellation, and deadlines.
+//   - tenantID: unique identifier of the tenant to which the user belongs.
+//   - userID: unique identifier of the user.
+//
+// Returns:
+//   - Slice of ProfileFieldValue objects in the order of the tenant profile fields.
+//   - Error if there is any issue during retrieval.
+func (is IdentityService) FindOwnProfile(ctx context.Context, tenantID string, userID string) ([]object.ProfileFieldValue, error) {
+	return is.FindProfile(ctx, tenantID, userID, object.ProfileViewer{UserID: userID})
+}
+
+// UpdateOwnProfile changes the profile of a user in the self-service.
+// Only the given fields are changed, immutable fields can only be set when the profile is created.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancellation, and deadlines.
+//   - tenantID: unique identifier of the tenant to which the user belongs.
+//   - userID: unique identifier of the user.
+//   - updateProfilePage: the changed fields.
+//
+// Returns:
+//   - Error if a field can not be changed, is invalid or there is any issue during saving.
+func (is IdentityService) UpdateOwnProfile(ctx context.Context, tenantID string, userID string, updateProfilePage object.UpdateProfilePage) error {
+	return is.UpdateProfilePage(ctx, tenantID, userID, object.ProfileViewer{UserID: userID}, updateProfilePage)
+}
+
+// FindOwnConsents returns the consents a user gave to applications, together with the application names.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancellation, and deadlines.
+//   - tenantID: unique identifier of the tenant to which the user belongs.
+//   - userID: unique identifier of the user.
+//
+// Returns:
+//   - Slice of Consent objects.
+//   - Error if there is any issue during retrieval.
+func (is IdentityService) FindOwnConsents(ctx context.Context, tenantID string, userID string) ([]object.Consent, error) {
+	dbConn, _ := is.getDBConn(ctx)
+
+	consents, err := repository.FindConsents(ctx, dbConn, tenantID, userID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for i, consent := range consents {
+		application, err := is.FindApplication(ctx, tenantID, consent.ApplicationID)
+
+		if err != nil {
+			consents[i].ApplicationName = consent.ApplicationID
+			continue
+		}
+
+		consents[i].ApplicationName = application.DisplayName
+	}
+
+	return consents, nil
+}
+
+// RevokeConsent deletes the consent a user gave to an application. The tokens the application holds for the user are revoked too,
+// so the application has to ask for consent again.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancellation, and deadlines.
+//   - tenantID: unique identifier of the tenant to which the user belongs.
+//   - userID: unique identifier of the user.
+//   - applicationID: unique identifier of the application.
+//
+// Returns:
+//   - Error if the user has no consent for the application or there is any issue during deletion.
+func (is IdentityService) RevokeConsent(ctx context.Context, tenantID string, userID string, applicationID string) error {
+	dbConn, _ := is.getDBConn(ctx)
+
+	_, err := repository.FindConsent(ctx, dbConn, tenantID, userID, applicationID)
+
+	if err != nil {
+		return errors.Join(fmt.Errorf("failed to find consent"), err)
+	}
+
+	err = dbConn.Transaction(func(tx *gorm.DB) error {
+		txCtx := saveDBConn(ctx, tx)
+
+		err := repository.KillConsent(txCtx, tx, tenantID, userID, applicationID)
+
+		if err != nil {
+			return err
+		}
+
+		return repository.KillUserApplicationTokens(txCtx, tx, tenantID, userID, applicationID)
+	})
+
+	if err != nil {
+		return errors.Join(fmt.Errorf("failed to revoke consent"), err)
+	}
+
+	is.recordAuditEvent(ctx, tenantID, object.AuditEventConsentRevoked, userID, map[string]any{
+		"application_id": applicationID,
+	})
+
+	return nil
+}
+
+// ExportPersonalData collects everything stored about a user, so it can be downloaded in the self-service.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancellation, and deadlines.
+//   - tenantID: unique identifier of the tenant to which the user belongs.
+//   - userID: unique identifier of the user.
+//   - currentSessionID: the session of the request, it is marked as current.
+//
+// Returns:
+//   - PersonalData of the user.
+//   - Error if there is any issue during retrieval.
+func (is IdentityService) ExportPersonalData(ctx context.Context, tenantID string, userID string, currentSessionID string) (object.PersonalData, error) {
+	dbConn, _ := is.getDBConn(ctx)
+
+	user, err := is.FindUser(ctx, tenantID, userID)
+
+	if err != nil {
+		return object.PersonalData{}, err
+	}
+
+	profile, err := is.FindOwnProfile(ctx, tenantID, userID)
+
+	if err != nil {
+		return object.PersonalData{}, err
+	}
+
+	mfas, err := findAllPages(func(pagination object.Pagination) ([]object.MFA, error) {
+		return repository.FindMFAs(ctx, dbConn, tenantID, userID, pagination)
+	})
+
+	if err != nil {
+		return object.PersonalData{}, err
+	}
+
+	trustedDevices, err := findAllPages(func(pagination object.Pagination) ([]object.TrustedDevice, error) {
+		return repository.FindTrustedDevices(ctx, dbConn, tenantID, userID, pagination)
+	})
+
+	if err != nil {
+		return object.PersonalData{}, err
+	}
+
+	consents, err := is.FindOwnConsents(ctx, tenantID, userID)
+
+	if err != nil {
+		return object.PersonalData{}, err
+	}
+
+	auditEvents, err := repository.FindUserAuditEvents(ctx, dbConn, tenantID, userID)
+
+	if err != nil {
+		return object.PersonalData{}, err
+	}
+
+	is.recordAuditEvent(ctx, tenantID, object.AuditEventPersonalDataExport, userID, nil)
+
+	return object.PersonalData{
+		ExportedAt:     time.Now(),
+		User:           user,
+		Profile:        profile,
+		MFAs:           mfas,
+		TrustedDevices: trustedDevices,
+		Sessions:       is.FindUserSessions(ctx, tenantID, userID, currentSessionID),
+		Consents:       consents,
+		AuditEvents:    auditEvents,
+	}, nil
+}
+
+// findAllPages calls a paginated find function until all entries are loaded.
+func findAllPages[T any](find func(pagination object.Pagination) ([]T, error)) ([]T, error) {
+	all := make([]T, 0)
+
+	for page := 1; ; page++ {
+		data, err := find(object.Pagination{
+			Limit: repository.MaxPageSize,
+			Page:  page,
+		})
+
+		if err != nil {
+			return nil, err
+		}
+
+		all = append(all, data...)
+
+		if len(data) < repository.MaxPageSize {
+			return all, nil
+		}
+	}
+}
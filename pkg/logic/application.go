@@ -57,6 +57,12 @@ func (is IdentityService) UpdateApplication(ctx context.Context, tenantID string
 		}
 	}
 
+	err = is.validateBranding(ctx, tenantID, updateApplication.Branding)
+
+	if err != nil {
+		return err
+	}
+
 	return repository.UpdateApplication(ctx, dbConn, tenantID, applicationID, updateApplication)
 }
 
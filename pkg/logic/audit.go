@@ -0,0 +1,65 @@
+/*
+ * Copyright (C) 2025 Anthrove
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logic
+
+import (
+	"context"
+	"errors"
+	"github.com/anthrove/identity/pkg/object"
+	"github.com/anthrove/identity/pkg/repository"
+	"log"
+)
+
+// FindAuditEvents retrieves the audit events of a tenant, newest first and with pagination support.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancelation, and deadlines.
+//   - tenantID: unique identifier of the tenant to which the events belong.
+//   - pagination: object containing pagination details (limit and page).
+//
+// Returns:
+//   - Slice of AuditEvent objects if retrieval is successful.
+//   - Error if there is any issue during retrieval.
+func (is IdentityService) FindAuditEvents(ctx context.Context, tenantID string, pagination object.Pagination) ([]object.AuditEvent, error) {
+	dbConn, _ := is.getDBConn(ctx)
+
+	if len(tenantID) == 0 {
+		return nil, errors.New("tenantID is required")
+	}
+
+	return repository.FindAuditEvents(ctx, dbConn, tenantID, pagination)
+}
+
+// recordAuditEvent stores an audit event together with the client and signed-in user of the current request.
+// Failures are only logged, the action which caused the event should not fail because of them.
+func (is IdentityService) recordAuditEvent(ctx context.Context, tenantID string, eventType string, userID string, details map[string]any) {
+	dbConn, _ := is.getDBConn(ctx)
+	info := requestInfo(ctx)
+
+	_, err := repository.CreateAuditEvent(ctx, dbConn, tenantID, object.CreateAuditEvent{
+		Type:      eventType,
+		UserID:    userID,
+		ActorID:   sessionUserID(ctx),
+		IPAddress: info.IPAddress,
+		UserAgent: truncate(info.UserAgent, 255),
+		Details:   details,
+	})
+
+	if err != nil {
+		log.Printf("problem while recording audit event %s: %v", eventType, err)
+	}
+}
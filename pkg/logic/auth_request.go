@@ -18,12 +18,14 @@ package logic
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"fmt"
 	"github.com/anthrove/identity/pkg/object"
 	"github.com/anthrove/identity/pkg/repository"
 	"github.com/anthrove/identity/pkg/util"
 	"github.com/go-playground/validator/v10"
+	"time"
 )
 
 func (is IdentityService) CreateAuthRequest(ctx context.Context, tenantID string, createAuthRequest object.CreateAuthRequest) (object.AuthRequest, error) {
@@ -77,3 +79,25 @@ func (is IdentityService) FindAuthRequests(ctx context.Context, tenantID string,
 
 	return repository.FindAuthRequests(ctx, dbConn, tenantID, pagination)
 }
+
+// CompleteAuthRequest marks the auth request as authenticated by the user, so the OIDC callback can issue the code or tokens.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancellation, and deadlines.
+//   - tenantID: unique identifier of the tenant to which the auth request belongs.
+//   - authRequestID: unique identifier of the auth request.
+//   - userID: unique identifier of the authenticated user.
+//   - authenticatedAt: time the user signed in, which can be before the auth request was created for an existing session.
+//
+// Returns:
+//   - Error if there is any issue during updating.
+func (is IdentityService) CompleteAuthRequest(ctx context.Context, tenantID string, authRequestID string, userID string, authenticatedAt time.Time) error {
+	return is.UpdateAuthRequest(ctx, tenantID, authRequestID, object.UpdateAuthRequest{
+		UserID: sql.NullString{
+			String: userID,
+			Valid:  true,
+		},
+		Authenticated:   true,
+		AuthenticatedAt: authenticatedAt,
+	})
+}
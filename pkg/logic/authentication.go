@@ -18,11 +18,16 @@ package logic
 
 import (
 	"context"
-	"database/sql"
 	"errors"
+	"fmt"
 	"github.com/anthrove/identity/pkg/object"
 	"github.com/anthrove/identity/pkg/provider/auth"
+	"github.com/anthrove/identity/pkg/provider/mfa"
+	"github.com/anthrove/identity/pkg/util"
+	"github.com/go-playground/validator/v10"
 	gonanoid "github.com/matoous/go-nanoid/v2"
+	"log"
+	"slices"
 	"time"
 )
 
@@ -92,30 +97,38 @@ func (is IdentityService) SignInStart(ctx context.Context, tenantID string, appl
 	})
 }
 
-func (is IdentityService) SignInSubmit(ctx context.Context, tenantID string, applicationID string, signInData object.SignInRequest) (string, object.User, error) {
+func (is IdentityService) SignInSubmit(ctx context.Context, tenantID string, applicationID string, signInData object.SignInRequest) (string, object.SignInResponse, error) {
 	tenant, err := is.FindTenant(ctx, tenantID)
 
 	if err != nil {
-		return "", object.User{}, err
+		return "", object.SignInResponse{}, err
 	}
 
 	// get for making sure application exists and also later its planed you can enable and disable signin and stuff
 	application, err := is.FindApplication(ctx, tenantID, applicationID)
 
 	if err != nil {
-		return "", object.User{}, err
+		return "", object.SignInResponse{}, err
 	}
 
 	user, err := is.FindUserByUsername(ctx, tenantID, signInData.Username)
 
+	// an unknown user is still checked and counted for the IP address
+	lockoutErr := is.checkLockout(ctx, tenantID, object.LockoutScopePassword, user.ID)
+
+	if lockoutErr != nil {
+		return "", object.SignInResponse{}, lockoutErr
+	}
+
 	if err != nil {
-		return "", object.User{}, err
+		is.recordFailure(ctx, tenant, object.LockoutScopePassword, "")
+		return "", object.SignInResponse{}, err
 	}
 
 	userCredentials, err := is.FindCredentialsByUser(ctx, tenantID, user.ID)
 
 	if err != nil {
-		return "", object.User{}, err
+		return "", object.SignInResponse{}, err
 	}
 
 	var selectedCredential object.Credentials
@@ -127,7 +140,7 @@ func (is IdentityService) SignInSubmit(ctx context.Context, tenantID string, app
 	}
 
 	if len(selectedCredential.ID) == 0 {
-		return "", object.User{}, errors.New("no configured credential found")
+		return "", object.SignInResponse{}, errors.New("no configured credential found")
 	}
 
 	var authProviderObj object.Provider
@@ -139,13 +152,13 @@ func (is IdentityService) SignInSubmit(ctx context.Context, tenantID string, app
 	}
 
 	if len(authProviderObj.ID) == 0 {
-		return "", object.User{}, errors.New("no provider was configured with given type")
+		return "", object.SignInResponse{}, errors.New("no provider was configured with given type")
 	}
 
 	authProvider, err := auth.GetAuthProvider(authProviderObj)
 
 	if err != nil {
-		return "", object.User{}, err
+		return "", object.SignInResponse{}, err
 	}
 
 	success, err := authProvider.Submit(ctx, auth.ProviderContext{
@@ -155,16 +168,25 @@ func (is IdentityService) SignInSubmit(ctx context.Context, tenantID string, app
 		SendMail: func(ctx context.Context, data object.SendMailData) {
 			// TODO do nothing  right now.. we need to check to fix it
 		},
+		UpdateCredential: func(ctx context.Context, metadata map[string]any) error {
+			return is.UpdateCredential(ctx, tenantID, selectedCredential.ID, object.UpdateCredential{
+				Metadata: metadata,
+				Enabled:  selectedCredential.Enabled,
+			})
+		},
 	}, signInData.Metadata)
 
 	if !success {
-		return "", object.User{}, errors.New("credential were incorrect")
+		is.recordFailure(ctx, tenant, object.LockoutScopePassword, user.ID)
+		return "", object.SignInResponse{}, errors.New("credential were incorrect")
 	}
 
+	is.resetFailures(ctx, tenantID, object.LockoutScopePassword, user.ID)
+
 	sessionID, err := gonanoid.New(50)
 
 	if err != nil {
-		return "", object.User{}, err
+		return "", object.SignInResponse{}, err
 	}
 
 	session := is.FindSession(ctx, sessionID)
@@ -173,27 +195,356 @@ func (is IdentityService) SignInSubmit(ctx context.Context, tenantID string, app
 		session = map[string]any{}
 	}
 
+	info := requestInfo(ctx)
+
 	session["tenant_id"] = tenantID
 	session["application_id"] = applicationID
 	session["user"] = user
+	session["created_at"] = time.Now()
+	session["ip_address"] = info.IPAddress
+	session["user_agent"] = info.UserAgent
+
+	// the provider may have flagged the credential during the submit, e.g. because the password expired
+	selectedCredential, err = is.FindCredential(ctx, tenantID, selectedCredential.ID)
+
+	if err != nil {
+		return "", object.SignInResponse{}, err
+	}
+
+	if passwordChangeRequired(selectedCredential) {
+		session["password_change_required"] = true
+	}
+
+	mfas, err := is.FindVerifiedMFAs(ctx, tenantID, user.ID)
+
+	if err != nil {
+		return "", object.SignInResponse{}, err
+	}
+
+	// a trusted device skips the second factor, unless the application always wants a fresh one
+	trustedDevice := !application.RequireFreshMFA && is.isTrustedDevice(ctx, tenant, user.ID, signInData.TrustedDeviceToken)
+
+	if len(mfas) > 0 && !trustedDevice {
+		// The session is only usable after the second factor was verified in SignInMFA
+		session["logged_in"] = false
+		session["mfa_required"] = true
+		session["request_id"] = signInData.RequestID
+
+		is.UpdateSession(ctx, sessionID, session)
+
+		return sessionID, object.SignInResponse{
+			Step: object.SignInStepMFARequired,
+			User: user,
+			MFAs: mfas,
+		}, nil
+	}
+
+	step, err := is.finishSignIn(ctx, tenantID, sessionID, session, signInData.RequestID)
+
+	if err != nil {
+		return "", object.SignInResponse{}, err
+	}
+
+	return sessionID, object.SignInResponse{
+		Step: step,
+		User: user,
+	}, nil
+}
+
+// SignInMFA verifies the second factor of a sign in which was started with SignInSubmit.
+// Instead of the MFA data, one of the recovery codes of the MFA can be used. If no MFA is given, all verified MFAs of the user are checked for the recovery code.
+func (is IdentityService) SignInMFA(ctx context.Context, tenantID string, applicationID string, sessionID string, signInData object.SignInMFARequest) (object.SignInResponse, error) {
+	session, user, err := is.findMFASignInSession(ctx, tenantID, applicationID, sessionID)
+
+	if err != nil {
+		return object.SignInResponse{}, err
+	}
+
+	tenant, err := is.FindTenant(ctx, tenantID)
+
+	if err != nil {
+		return object.SignInResponse{}, err
+	}
+
+	err = is.checkLockout(ctx, tenantID, object.LockoutScopeMFA, user.ID)
+
+	if err != nil {
+		return object.SignInResponse{}, err
+	}
+
+	mfas, err := is.FindVerifiedMFAs(ctx, tenantID, user.ID)
+
+	if err != nil {
+		return object.SignInResponse{}, err
+	}
+
+	mfas = slices.DeleteFunc(mfas, func(userMFA object.MFA) bool {
+		return len(signInData.MFAID) > 0 && userMFA.ID != signInData.MFAID
+	})
+
+	if len(mfas) == 0 {
+		return object.SignInResponse{}, errors.New("no verified mfa found")
+	}
+
+	if len(signInData.RecoveryCode) > 0 {
+		err = is.signInRecoveryCode(ctx, tenantID, user, mfas, signInData.RecoveryCode)
+	} else {
+		var success bool
+		success, err = is.MFaVerifyDataFlow(ctx, tenantID, user.ID, mfas[0].ID, signInData.Metadata)
+
+		if errors.Is(err, mfa.ErrApprovalPending) {
+			// The user has not decided yet, the client has to ask again
+			return object.SignInResponse{
+				Step: object.SignInStepMFAPending,
+				User: user,
+			}, nil
+		}
+
+		if err == nil && !success {
+			err = errors.New("mfa validation failed")
+		}
+	}
+
+	if err != nil {
+		is.recordFailure(ctx, tenant, object.LockoutScopeMFA, user.ID)
+		return object.SignInResponse{}, err
+	}
+
+	is.resetFailures(ctx, tenantID, object.LockoutScopeMFA, user.ID)
+
+	requestID, _ := session["request_id"].(string)
+	delete(session, "mfa_required")
+	delete(session, "request_id")
+
+	step, err := is.finishSignIn(ctx, tenantID, sessionID, session, requestID)
+
+	if err != nil {
+		return object.SignInResponse{}, err
+	}
+
+	response := object.SignInResponse{
+		Step: step,
+		User: user,
+	}
+
+	if signInData.TrustDevice {
+		response.TrustedDeviceToken, response.TrustedDeviceUntil, err = is.signInTrustDevice(ctx, tenantID, applicationID, user)
+
+		if err != nil {
+			// The sign in already succeeded, the device is just not remembered
+			log.Printf("problem while trusting device: %v", err)
+		}
+	}
+
+	return response, nil
+}
+
+// signInTrustDevice remembers the device after a successful MFA step, if the tenant allows trusted devices.
+func (is IdentityService) signInTrustDevice(ctx context.Context, tenantID string, applicationID string, user object.User) (string, time.Time, error) {
+	tenant, err := is.FindTenant(ctx, tenantID)
+
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	application, err := is.FindApplication(ctx, tenantID, applicationID)
+
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	if tenant.TrustedDeviceDays <= 0 || application.RequireFreshMFA {
+		return "", time.Time{}, nil
+	}
+
+	return is.trustDevice(ctx, tenant, user.ID)
+}
+
+// SignInMFAInit starts the validation of a MFA during a sign in which was started with SignInSubmit, e.g. by sending a one-time code.
+func (is IdentityService) SignInMFAInit(ctx context.Context, tenantID string, applicationID string, sessionID string, signInData object.SignInMFAInitRequest) (map[string]any, error) {
+	err := validate.Struct(signInData)
+
+	if err != nil {
+		var validateErrs validator.ValidationErrors
+		if errors.As(err, &validateErrs) {
+			return nil, errors.Join(fmt.Errorf("problem while validating sign in mfa data"), util.ConvertValidationError(validateErrs))
+		}
+	}
+
+	_, user, err := is.findMFASignInSession(ctx, tenantID, applicationID, sessionID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	mfaObj, err := is.FindMFA(ctx, tenantID, user.ID, signInData.MFAID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !mfaObj.Verified {
+		return nil, errors.New("mfa is not verified")
+	}
+
+	return is.MfaInitDataflow(ctx, tenantID, user.ID, mfaObj.ID)
+}
+
+// findMFASignInSession returns the session and user of a sign in which is waiting for the second factor.
+func (is IdentityService) findMFASignInSession(ctx context.Context, tenantID string, applicationID string, sessionID string) (map[string]any, object.User, error) {
+	session := is.FindSession(ctx, sessionID)
+
+	if session == nil {
+		return nil, object.User{}, errors.New("no sign in was started")
+	}
+
+	if session["tenant_id"] != tenantID || session["application_id"] != applicationID {
+		return nil, object.User{}, errors.New("sign in was started for another application")
+	}
+
+	if mfaRequired, _ := session["mfa_required"].(bool); !mfaRequired {
+		return nil, object.User{}, errors.New("sign in does not require mfa")
+	}
+
+	user, ok := session["user"].(object.User)
+
+	if !ok {
+		return nil, object.User{}, errors.New("sign in session has no user")
+	}
+
+	return session, user, nil
+}
+
+// signInRecoveryCode consumes the recovery code from the first MFA it belongs to and notifies the user about it.
+func (is IdentityService) signInRecoveryCode(ctx context.Context, tenantID string, user object.User, mfas []object.MFA, recoveryCode string) error {
+	for _, userMFA := range mfas {
+		remaining, err := is.UseRecoveryCode(ctx, tenantID, user.ID, userMFA.ID, recoveryCode)
+
+		if err != nil {
+			continue
+		}
+
+		if len(user.Email) > 0 {
+			err = is.sendTemplateMail(ctx, tenantID, object.TemplateTypeRecoveryCodeUsed, user.Email, "A recovery code was used", map[string]any{
+				"DisplayName": user.DisplayName,
+				"Username":    user.Username,
+				"MFA":         userMFA.DisplayName,
+				"Remaining":   remaining,
+				"UsedAt":      time.Now(),
+			})
+
+			if err != nil {
+				// The sign in should not fail because the notification could not be sent
+				log.Printf("problem while sending recovery code notification: %v", err)
+			}
+		}
+
+		return nil
+	}
+
+	return errors.New("recovery code is invalid")
+}
+
+// SignInChangePassword sets the new password of a sign in which returned the password change required step and completes the sign in.
+func (is IdentityService) SignInChangePassword(ctx context.Context, tenantID string, applicationID string, sessionID string, signInData object.SignInChangePasswordRequest) (object.SignInResponse, error) {
+	err := validate.Struct(signInData)
+
+	if err != nil {
+		var validateErrs validator.ValidationErrors
+		if errors.As(err, &validateErrs) {
+			return object.SignInResponse{}, errors.Join(fmt.Errorf("problem while validating sign in change password data"), util.ConvertValidationError(validateErrs))
+		}
+	}
+
+	session := is.FindSession(ctx, sessionID)
+
+	if session == nil {
+		return object.SignInResponse{}, errors.New("no sign in was started")
+	}
+
+	if session["tenant_id"] != tenantID || session["application_id"] != applicationID {
+		return object.SignInResponse{}, errors.New("sign in was started for another application")
+	}
+
+	if mfaRequired, _ := session["mfa_required"].(bool); mfaRequired {
+		return object.SignInResponse{}, errors.New("sign in requires mfa first")
+	}
+
+	if changeRequired, _ := session["password_change_required"].(bool); !changeRequired {
+		return object.SignInResponse{}, errors.New("sign in does not require a password change")
+	}
+
+	user, ok := session["user"].(object.User)
+
+	if !ok {
+		return object.SignInResponse{}, errors.New("sign in session has no user")
+	}
+
+	tenant, err := is.FindTenant(ctx, tenantID)
+
+	if err != nil {
+		return object.SignInResponse{}, err
+	}
+
+	err = is.setPassword(ctx, tenant, user, signInData.Password, true)
+
+	if err != nil {
+		return object.SignInResponse{}, err
+	}
+
+	requestID, _ := session["request_id"].(string)
+	delete(session, "password_change_required")
+	delete(session, "request_id")
+
+	err = is.completeSignIn(ctx, tenantID, sessionID, session, requestID)
+
+	if err != nil {
+		return object.SignInResponse{}, err
+	}
+
+	return object.SignInResponse{
+		Step: object.SignInStepDone,
+		User: user,
+	}, nil
+}
+
+// finishSignIn completes the sign in after all factors were verified, unless the password has to be changed first.
+// It returns the step the sign in is in afterward.
+func (is IdentityService) finishSignIn(ctx context.Context, tenantID string, sessionID string, session map[string]any, requestID string) (string, error) {
+	if changeRequired, _ := session["password_change_required"].(bool); changeRequired {
+		session["logged_in"] = false
+		session["request_id"] = requestID
+
+		is.UpdateSession(ctx, sessionID, session)
+
+		return object.SignInStepPasswordChangeRequired, nil
+	}
+
+	err := is.completeSignIn(ctx, tenantID, sessionID, session, requestID)
+
+	if err != nil {
+		return "", err
+	}
+
+	return object.SignInStepDone, nil
+}
+
+// completeSignIn marks the session as logged in and finishes the OIDC auth request, if the sign in belongs to one.
+func (is IdentityService) completeSignIn(ctx context.Context, tenantID string, sessionID string, session map[string]any, requestID string) error {
+	user := session["user"].(object.User)
+	authenticatedAt := time.Now()
 	session["logged_in"] = true
+	session["authenticated_at"] = authenticatedAt
 
 	is.UpdateSession(ctx, sessionID, session)
 
-	if signInData.RequestID != "" {
-		err := is.UpdateAuthRequest(ctx, tenantID, signInData.RequestID, object.UpdateAuthRequest{
-			UserID: sql.NullString{
-				String: user.ID,
-				Valid:  true,
-			},
-			Authenticated:   true,
-			AuthenticatedAt: time.Now(),
-		})
+	if requestID != "" {
+		err := is.CompleteAuthRequest(ctx, tenantID, requestID, user.ID, authenticatedAt)
 
 		if err != nil {
-			return "", object.User{}, err
+			return err
 		}
 	}
 
-	return sessionID, user, nil
+	return nil
 }
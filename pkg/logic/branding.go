@@ -0,0 +1,273 @@
+/*
+ * Copyright (C) 2025 Anthrove
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logic
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/anthrove/identity/pkg/i18n/templates"
+	"github.com/anthrove/identity/pkg/object"
+	"gorm.io/gorm"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// FindBranding returns the branding of an application. Values the application doesn't configure are inherited from its
+// tenant and then from the DefaultBranding. Without an application ID, the branding of the tenant is returned.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancellation, and deadlines.
+//   - tenantID: unique identifier of the tenant.
+//   - applicationID: unique identifier of the application, can be empty.
+//
+// Returns:
+//   - AppliedBranding object if retrieval is successful.
+//   - Error if there is any issue during retrieval.
+func (is IdentityService) FindBranding(ctx context.Context, tenantID string, applicationID string) (object.AppliedBranding, error) {
+	tenant, err := is.FindTenant(ctx, tenantID)
+
+	if err != nil {
+		return object.AppliedBranding{}, err
+	}
+
+	branding := object.DefaultBranding
+	branding.PageTemplates = map[string]string{}
+
+	err = is.applyBranding(ctx, tenantID, &branding, tenant.Branding)
+
+	if err != nil {
+		return object.AppliedBranding{}, err
+	}
+
+	if len(applicationID) == 0 {
+		return branding, nil
+	}
+
+	application, err := is.FindApplication(ctx, tenantID, applicationID)
+
+	if err != nil {
+		return object.AppliedBranding{}, err
+	}
+
+	// the logo of the application replaces the one of the tenant, a logo resource of the application is preferred
+	if len(application.Logo) > 0 {
+		branding.LogoURL = application.Logo
+	}
+
+	err = is.applyBranding(ctx, tenantID, &branding, application.Branding)
+
+	if err != nil {
+		return object.AppliedBranding{}, err
+	}
+
+	return branding, nil
+}
+
+// PreviewPage renders a hosted page with sample data and the branding of the tenant or application.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancellation, and deadlines.
+//   - tenantID: unique identifier of the tenant.
+//   - applicationID: unique identifier of the application, can be empty.
+//   - pageName: name of the hosted page, one of templates.PageNames.
+//
+// Returns:
+//   - The rendered page.
+//   - Error if there is any issue during rendering.
+func (is IdentityService) PreviewPage(ctx context.Context, tenantID string, applicationID string, pageName string) (string, error) {
+	if !slices.Contains(templates.PageNames, pageName) {
+		return "", fmt.Errorf("unknown page: %s", pageName)
+	}
+
+	tenant, err := is.FindTenant(ctx, tenantID)
+
+	if err != nil {
+		return "", err
+	}
+
+	branding, err := is.FindBranding(ctx, tenantID, applicationID)
+
+	if err != nil {
+		return "", err
+	}
+
+	page := templates.SamplePage(pageName)
+	page.TenantID = tenant.ID
+	page.TenantName = tenant.DisplayName
+	page.Branding = branding
+
+	if len(applicationID) > 0 {
+		application, err := is.FindApplication(ctx, tenantID, applicationID)
+
+		if err != nil {
+			return "", err
+		}
+
+		page.ApplicationID = application.ID
+		page.ApplicationName = application.DisplayName
+		page.SignUpURL = application.SignUpURL
+		page.TermsURL = application.TermsURL
+	}
+
+	var buffer bytes.Buffer
+	err = templates.RenderPage(&buffer, pageName, page)
+
+	if err != nil {
+		return "", err
+	}
+
+	return buffer.String(), nil
+}
+
+// PreviewMessageTemplate fills the message template of the given type with sample data and the branding of the tenant or application.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancellation, and deadlines.
+//   - tenantID: unique identifier of the tenant.
+//   - applicationID: unique identifier of the application, can be empty.
+//   - templateType: type of the message template.
+//
+// Returns:
+//   - The filled template.
+//   - Error if there is any issue during filling.
+func (is IdentityService) PreviewMessageTemplate(ctx context.Context, tenantID string, applicationID string, templateType string) (string, error) {
+	tenant, err := is.FindTenant(ctx, tenantID)
+
+	if err != nil {
+		return "", err
+	}
+
+	branding, err := is.mailBranding(ctx, tenantID, applicationID)
+
+	if err != nil {
+		return "", err
+	}
+
+	data := templates.SampleMessageData()
+	data["TenantName"] = tenant.DisplayName
+	data["Branding"] = branding
+
+	return is.fillTemplate(ctx, tenantID, templateType, data)
+}
+
+// validateBranding checks the parts of a branding which the struct validation can't check.
+func (is IdentityService) validateBranding(ctx context.Context, tenantID string, branding *object.Branding) error {
+	if branding == nil {
+		return nil
+	}
+
+	if len(branding.LogoResourceID) > 0 {
+		resource, err := is.FindResource(ctx, tenantID, branding.LogoResourceID)
+
+		if err != nil {
+			return errors.Join(errors.New("logo resource not found"), err)
+		}
+
+		if !strings.HasPrefix(resource.MimeType, "image/") {
+			return errors.New("logo resource is not an image")
+		}
+	}
+
+	err := templates.ValidateCustomCSS(branding.CustomCSS)
+
+	if err != nil {
+		return err
+	}
+
+	for pageName, pageTemplate := range branding.PageTemplates {
+		err = templates.ValidatePageTemplate(pageName, pageTemplate)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// applyBranding overrides the values of the applied branding with the configured values of the branding.
+func (is IdentityService) applyBranding(ctx context.Context, tenantID string, applied *object.AppliedBranding, branding object.Branding) error {
+	if len(branding.LogoResourceID) > 0 {
+		resource, err := is.FindResource(ctx, tenantID, branding.LogoResourceID)
+
+		// the logo can be deleted after it was configured, pages and mails are shown without it then
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		if err == nil {
+			applied.LogoURL = resourcePublicURL(resource)
+		}
+	}
+
+	if len(branding.PrimaryColor) > 0 {
+		applied.PrimaryColor = branding.PrimaryColor
+	}
+
+	if len(branding.BackgroundColor) > 0 {
+		applied.BackgroundColor = branding.BackgroundColor
+	}
+
+	if len(branding.TextColor) > 0 {
+		applied.TextColor = branding.TextColor
+	}
+
+	if len(branding.CustomCSS) > 0 {
+		applied.CustomCSS = strings.TrimSpace(applied.CustomCSS + "\n" + branding.CustomCSS)
+	}
+
+	if len(branding.FooterLinks) > 0 {
+		applied.FooterLinks = branding.FooterLinks
+	}
+
+	maps.Copy(applied.PageTemplates, branding.PageTemplates)
+
+	return nil
+}
+
+// mailBranding returns the branding for emails. Mail clients can't load paths of this server, so only absolute logo URLs are kept.
+func (is IdentityService) mailBranding(ctx context.Context, tenantID string, applicationID string) (object.AppliedBranding, error) {
+	branding, err := is.FindBranding(ctx, tenantID, applicationID)
+
+	if err != nil {
+		return object.AppliedBranding{}, err
+	}
+
+	if !strings.HasPrefix(branding.LogoURL, "https://") && !strings.HasPrefix(branding.LogoURL, "http://") {
+		branding.LogoURL = ""
+	}
+
+	return branding, nil
+}
+
+// resourcePublicURL returns the URL under which a resource can be loaded. Resources of the local storage and resources
+// which are not public are served by the CDN endpoint.
+func resourcePublicURL(resource object.Resource) string {
+	if !isPrivateVisibility(resource.Visibility) && (strings.HasPrefix(resource.Url, "https://") || strings.HasPrefix(resource.Url, "http://")) {
+		return resource.Url
+	}
+
+	return resourceCDNPath(resource)
+}
+
+// resourceCDNPath returns the path of a resource on the CDN endpoint.
+func resourceCDNPath(resource object.Resource) string {
+	return "/api/v1/cdn/" + resource.TenantID + "/" + strings.TrimPrefix(resource.FilePath, "/")
+}
@@ -0,0 +1,73 @@
+/*
+ * Copyright (C) 2025 Anthrove
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logic
+
+import (
+	"context"
+	"github.com/anthrove/identity/pkg/object"
+	"strings"
+	"testing"
+)
+
+func TestBrandingWithoutLogo(t *testing.T) {
+	ctx := context.Background()
+	is, tenant := newTestService(t)
+
+	provider, err := is.CreateProvider(ctx, tenant.ID, object.CreateProvider{
+		DisplayName:  "Files",
+		Category:     "storage",
+		ProviderType: "local",
+		Parameter:    []byte(`{"base_path":"files"}`),
+	})
+	if err != nil {
+		t.Fatalf("CreateProvider() error = %v", err)
+	}
+
+	logo, err := is.CreateResource(ctx, tenant.ID, object.CreateResource{
+		ProviderID: provider.ID,
+		Tag:        "logo",
+		FileName:   "logo.png",
+		MimeType:   "image/png",
+	}, strings.NewReader("logo"))
+	if err != nil {
+		t.Fatalf("CreateResource() error = %v", err)
+	}
+
+	updateTestTenant(t, is, tenant, func(updateTenant *object.UpdateTenant) {
+		updateTenant.Branding = &object.Branding{LogoResourceID: logo.ID, PrimaryColor: "#123456"}
+	})
+
+	branding, err := is.FindBranding(ctx, tenant.ID, "")
+	if err != nil || len(branding.LogoURL) == 0 {
+		t.Fatalf("FindBranding() = %+v, %v, want the logo", branding, err)
+	}
+
+	err = is.KillResource(ctx, tenant.ID, logo.ID)
+	if err != nil {
+		t.Fatalf("KillResource() error = %v", err)
+	}
+
+	// mails like the password reset must still be sent without the logo
+	branding, err = is.mailBranding(ctx, tenant.ID, "")
+	if err != nil {
+		t.Fatalf("mailBranding() after deleting the logo error = %v", err)
+	}
+
+	if len(branding.LogoURL) != 0 || branding.PrimaryColor != "#123456" {
+		t.Errorf("mailBranding() = %+v, want the branding without logo", branding)
+	}
+}
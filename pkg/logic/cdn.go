@@ -2,47 +2,159 @@ package logic
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/anthrove/identity/pkg/object"
+	"github.com/anthrove/identity/pkg/provider/storage"
+	"github.com/anthrove/identity/pkg/repository"
+	"io"
 	"path/filepath"
 	"strings"
 )
 
-// ServeResource retrieves a specific resource within a specified tenant.
+// ServeResource retrieves a resource by its path and opens it in the storage provider it was uploaded to.
+// Resources which are not public need a signed URL or a signed-in user who passes their visibility.
 //
 // Parameters:
 //   - ctx: context for managing request-scoped values, cancelation, and deadlines.
 //   - tenantID: unique identifier of the tenant to which the resource belongs.
-//   - filePath: unique identifier of the resource to be retrieved.
+//   - resourcePath: path of the resource inside its storage provider.
+//   - access: the signed-in user and the signature of the request.
 //
 // Returns:
-//   - The local resource path.
-//   - Error if there is any issue during retrieval.
-func (is IdentityService) ServeResource(ctx context.Context, tenantID string, resourcePath string) (string, error) {
-	providers, err := is.FindProviders(ctx, tenantID, object.Pagination{})
+//   - Resource object of the requested file.
+//   - Seekable content of the file, the caller has to close it.
+//   - Error if there is any issue during retrieval, ErrResourceForbidden if the request may not download the resource.
+func (is IdentityService) ServeResource(ctx context.Context, tenantID string, resourcePath string, access object.ResourceAccess) (object.Resource, io.ReadSeekCloser, error) {
+	dbConn, _ := is.getDBConn(ctx)
+
+	resource, err := repository.FindResourceByPath(ctx, dbConn, tenantID, sanitizeFilePath(resourcePath))
+	if err != nil {
+		return object.Resource{}, nil, err
+	}
+
+	err = is.authorizeResource(ctx, resource, access)
 	if err != nil {
-		return "", err
+		return object.Resource{}, nil, err
 	}
-	sanitizedFilePath := sanitizeFilePath(resourcePath)
 
-	var parameters map[string]string
+	provider, err := is.FindProvider(ctx, tenantID, resource.ProviderID)
+	if err != nil {
+		return object.Resource{}, nil, err
+	}
 
-	for _, provider := range providers {
-		if provider.Category == "storage" && provider.ProviderType == "local" {
-			err := json.Unmarshal(provider.Parameter, &parameters)
-			if err != nil {
-				return "", err
+	fileProvider, err := storage.GetStorageProvider(provider)
+	if err != nil {
+		return object.Resource{}, nil, err
+	}
+
+	storagePath := resourceStoragePath(resource)
+
+	seeker := &streamSeeker{
+		open: func(_ int64) (io.ReadCloser, error) {
+			return fileProvider.GetStream(storagePath)
+		},
+		size: resource.FileSize,
+	}
+
+	if rangeProvider, ok := fileProvider.(storage.RangeProvider); ok {
+		seeker.ranged = true
+		seeker.open = func(offset int64) (io.ReadCloser, error) {
+			if offset == 0 {
+				return fileProvider.GetStream(storagePath)
 			}
+
+			return rangeProvider.GetRange(storagePath, offset)
+		}
+	}
+
+	stream, err := seeker.open(0)
+	if err != nil {
+		return object.Resource{}, nil, errors.Join(fmt.Errorf("problem while opening resource %s", resource.ID), err)
+	}
+
+	// the local provider hands out files, remote providers only a body that can be read once
+	if content, ok := stream.(io.ReadSeekCloser); ok {
+		return resource, content, nil
+	}
+
+	seeker.reader = stream
+	return resource, seeker, nil
+}
+
+// streamSeeker makes a stream seekable for range requests. Providers which support ranged downloads open the stream
+// again at the new position. Otherwise seeking forward skips the stream and seeking backward opens it again.
+type streamSeeker struct {
+	open func(offset int64) (io.ReadCloser, error)
+	// ranged is set if open starts the stream at the offset, otherwise it always starts at the beginning of the file.
+	ranged   bool
+	size     int64
+	offset   int64
+	position int64
+	reader   io.ReadCloser
+}
+
+func (s *streamSeeker) Read(p []byte) (int, error) {
+	if s.reader == nil || s.offset < s.position || (s.ranged && s.offset != s.position) {
+		if s.reader != nil {
+			s.reader.Close()
+		}
+
+		start := int64(0)
+		if s.ranged {
+			start = s.offset
 		}
 
-		sanitizedBasePath := sanitizeFilePath(parameters["base_path"])
-		localFilePath := fmt.Sprintf("local_storage_provider/%s/%s/%s", tenantID, sanitizedBasePath, sanitizedFilePath)
+		reader, err := s.open(start)
+		if err != nil {
+			s.reader = nil
+			return 0, err
+		}
+
+		s.reader = reader
+		s.position = start
+	}
+
+	if s.offset > s.position {
+		skipped, err := io.CopyN(io.Discard, s.reader, s.offset-s.position)
+		s.position += skipped
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := s.reader.Read(p)
+	s.position += int64(n)
+	s.offset = s.position
+
+	return n, err
+}
+
+func (s *streamSeeker) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += s.offset
+	case io.SeekEnd:
+		offset += s.size
+	default:
+		return 0, fmt.Errorf("invalid whence %d", whence)
+	}
+
+	if offset < 0 {
+		return 0, fmt.Errorf("negative position %d", offset)
+	}
+
+	s.offset = offset
+	return offset, nil
+}
 
-		return localFilePath, nil
+func (s *streamSeeker) Close() error {
+	if s.reader == nil {
+		return nil
 	}
 
-	return "", fmt.Errorf("no suitable provider found")
+	return s.reader.Close()
 }
 
 func sanitizeFilePath(path string) string {
@@ -0,0 +1,107 @@
+/*
+ * Copyright (C) 2025 Anthrove
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logic
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestStreamSeeker(t *testing.T) {
+	const content = "0123456789"
+
+	tests := []struct {
+		offset int64
+		whence int
+		length int64
+		want   string
+	}{
+		{offset: 0, whence: io.SeekStart, length: 10, want: "0123456789"},
+		{offset: 3, whence: io.SeekStart, length: 4, want: "3456"},
+		{offset: -2, whence: io.SeekEnd, length: 2, want: "89"},
+		{offset: 1, whence: io.SeekStart, length: 3, want: "123"},
+		{offset: 2, whence: io.SeekCurrent, length: 1, want: "6"},
+	}
+
+	opened := 0
+	seeker := &streamSeeker{
+		open: func(_ int64) (io.ReadCloser, error) {
+			opened++
+			return io.NopCloser(strings.NewReader(content)), nil
+		},
+		size: int64(len(content)),
+	}
+
+	for i, test := range tests {
+		if _, err := seeker.Seek(test.offset, test.whence); err != nil {
+			t.Fatalf("test %d: Seek() error = %v", i, err)
+		}
+
+		got, err := io.ReadAll(io.LimitReader(seeker, test.length))
+		if err != nil {
+			t.Fatalf("test %d: Read() error = %v", i, err)
+		}
+
+		if string(got) != test.want {
+			t.Errorf("test %d: Read() = %q, want %q", i, got, test.want)
+		}
+	}
+
+	// reading from the start, forward skips and seeking backward
+	if opened != 3 {
+		t.Errorf("stream opened %d times, want 3", opened)
+	}
+
+	if _, err := seeker.Seek(-1, io.SeekStart); err == nil {
+		t.Errorf("Seek() to a negative position returned no error")
+	}
+}
+
+func TestStreamSeekerRanged(t *testing.T) {
+	const content = "0123456789"
+
+	var offsets []int64
+	seeker := &streamSeeker{
+		open: func(offset int64) (io.ReadCloser, error) {
+			offsets = append(offsets, offset)
+			return io.NopCloser(strings.NewReader(content[offset:])), nil
+		},
+		ranged: true,
+		size:   int64(len(content)),
+	}
+
+	for _, offset := range []int64{7, 2} {
+		if _, err := seeker.Seek(offset, io.SeekStart); err != nil {
+			t.Fatalf("Seek(%d) error = %v", offset, err)
+		}
+
+		got, err := io.ReadAll(io.LimitReader(seeker, 2))
+		if err != nil {
+			t.Fatalf("Read() error = %v", err)
+		}
+
+		if want := content[offset : offset+2]; string(got) != want {
+			t.Errorf("Read() after Seek(%d) = %q, want %q", offset, got, want)
+		}
+	}
+
+	// the stream is opened at the requested positions instead of skipping the content before them
+	if len(offsets) != 2 || offsets[0] != 7 || offsets[1] != 2 {
+		t.Errorf("stream opened at %v, want [7 2]", offsets)
+	}
+}
@@ -0,0 +1,105 @@
+/*
+ * Copyright (C) 2025 Anthrove
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logic
+
+import (
+	"context"
+	"errors"
+	"github.com/anthrove/identity/pkg/object"
+	"github.com/anthrove/identity/pkg/repository"
+	"gorm.io/gorm"
+	"slices"
+)
+
+// ConsentRequired checks if the user has to grant the scopes of the auth request to the application first.
+// It is required if the application asks for consent and the user has not granted all scopes yet, or the auth request prompts for it.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancellation, and deadlines.
+//   - tenantID: unique identifier of the tenant to which the user belongs.
+//   - userID: unique identifier of the user who signs in.
+//   - authRequest: the auth request the user signs in for.
+//
+// Returns:
+//   - True if the consent page has to be shown.
+//   - Error if there is any issue during retrieval.
+func (is IdentityService) ConsentRequired(ctx context.Context, tenantID string, userID string, authRequest object.AuthRequest) (bool, error) {
+	dbConn, _ := is.getDBConn(ctx)
+
+	application, err := is.FindApplication(ctx, tenantID, authRequest.ApplicationID)
+
+	if err != nil {
+		return false, err
+	}
+
+	if slices.Contains(authRequest.Prompt, "consent") {
+		return true, nil
+	}
+
+	if !application.ConsentRequired {
+		return false, nil
+	}
+
+	consent, err := repository.FindConsent(ctx, dbConn, tenantID, userID, application.ID)
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return true, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	for _, scope := range authRequest.Scopes {
+		if !slices.Contains(consent.Scopes, scope) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// GrantConsent stores that the user granted the scopes of the auth request to its application.
+// Scopes which were granted before stay granted.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancellation, and deadlines.
+//   - tenantID: unique identifier of the tenant to which the user belongs.
+//   - userID: unique identifier of the user who granted the scopes.
+//   - authRequest: the auth request with the granted scopes.
+//
+// Returns:
+//   - Error if there is any issue during saving.
+func (is IdentityService) GrantConsent(ctx context.Context, tenantID string, userID string, authRequest object.AuthRequest) error {
+	dbConn, _ := is.getDBConn(ctx)
+
+	scopes := slices.Clone(authRequest.Scopes)
+
+	consent, err := repository.FindConsent(ctx, dbConn, tenantID, userID, authRequest.ApplicationID)
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	for _, scope := range consent.Scopes {
+		if !slices.Contains(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+
+	return repository.SaveConsent(ctx, dbConn, tenantID, userID, authRequest.ApplicationID, scopes)
+}
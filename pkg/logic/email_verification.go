@@ -0,0 +1,332 @@
+/*
+ * Copyright (C) 2025 Anthrove
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logic
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"github.com/anthrove/identity/pkg/object"
+	"github.com/anthrove/identity/pkg/repository"
+	"github.com/anthrove/identity/pkg/util"
+	"github.com/go-playground/validator/v10"
+	"log"
+	"strings"
+	"time"
+)
+
+const (
+	// emailVerificationLifetime is how long an email verification token can be used.
+	emailVerificationLifetime = 24 * time.Hour
+	// emailVerificationMaxAttempts is the number of wrong tokens after which a new verification mail has to be requested.
+	emailVerificationMaxAttempts = 5
+	// emailVerificationResendDelay is the time which has to pass until another verification mail can be sent.
+	emailVerificationResendDelay = time.Minute
+	// emailChangeMaxAuthAge is how long after the sign in the email can be changed without the current password.
+	emailChangeMaxAuthAge = 10 * time.Minute
+)
+
+// SendEmailVerification sends a new email verification token to the user. If the user is changing the email,
+// the token is sent to the pending email, otherwise to the current email.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancellation, and deadlines.
+//   - tenantID: unique identifier of the tenant to which the user belongs.
+//   - userID: unique identifier of the user whose email is verified.
+//
+// Returns:
+//   - Error if the email is already verified, a mail was sent recently or there is any issue during sending.
+func (is IdentityService) SendEmailVerification(ctx context.Context, tenantID string, userID string) error {
+	tenant, err := is.FindTenant(ctx, tenantID)
+
+	if err != nil {
+		return err
+	}
+
+	user, err := is.FindUser(ctx, tenantID, userID)
+
+	if err != nil {
+		return err
+	}
+
+	if user.EmailVerified && len(user.PendingEmail) == 0 {
+		return errors.New("email is already verified")
+	}
+
+	if user.EmailVerificationExpiresAt != nil && time.Now().Before(user.EmailVerificationExpiresAt.Add(emailVerificationResendDelay-emailVerificationLifetime)) {
+		return errors.New("verification mail was sent recently, please wait a moment")
+	}
+
+	return is.startEmailVerification(ctx, tenant, user, user.PendingEmail)
+}
+
+// ChangeEmail starts the change of the email of a user. The verification token is sent to the new email,
+// the current email stays in use until the new one is confirmed with VerifyEmail.
+// The user has to confirm the change with the current password, unless the sign in was recent.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancellation, and deadlines.
+//   - tenantID: unique identifier of the tenant to which the user belongs.
+//   - userID: unique identifier of the user whose email is changed.
+//   - authenticatedAt: when the user signed in to the session which changes the email.
+//   - changeEmail: object containing the new email and the current password.
+//
+// Returns:
+//   - Error if the password is wrong, the email is already in use or there is any issue during validation or sending.
+func (is IdentityService) ChangeEmail(ctx context.Context, tenantID string, userID string, authenticatedAt time.Time, changeEmail object.ChangeEmail) error {
+	err := validate.Struct(changeEmail)
+
+	if err != nil {
+		var validateErrs validator.ValidationErrors
+		if errors.As(err, &validateErrs) {
+			return errors.Join(fmt.Errorf("problem while validating change email data"), util.ConvertValidationError(validateErrs))
+		}
+	}
+
+	tenant, err := is.FindTenant(ctx, tenantID)
+
+	if err != nil {
+		return err
+	}
+
+	user, err := is.FindUser(ctx, tenantID, userID)
+
+	if err != nil {
+		return err
+	}
+
+	// a stolen session must not be enough to take over the account by changing the email
+	if len(changeEmail.CurrentPassword) > 0 {
+		err = is.verifyCurrentPassword(ctx, tenant, user, changeEmail.CurrentPassword)
+
+		if err != nil {
+			return err
+		}
+	} else if time.Since(authenticatedAt) > emailChangeMaxAuthAge {
+		return errors.New("the current password is required to change the email")
+	}
+
+	if strings.EqualFold(user.Email, changeEmail.Email) {
+		return errors.New("email is the current email")
+	}
+
+	err = is.checkEmailAvailable(ctx, tenantID, userID, changeEmail.Email)
+
+	if err != nil {
+		return err
+	}
+
+	return is.startEmailVerification(ctx, tenant, user, changeEmail.Email)
+}
+
+// VerifyEmail confirms the email of a user with the token of the verification mail.
+// If the user is changing the email, the pending email becomes the email of the user and the old email is notified.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancellation, and deadlines.
+//   - tenantID: unique identifier of the tenant to which the user belongs.
+//   - userID: unique identifier of the user whose email is verified.
+//   - verifyEmail: object containing the token of the verification mail.
+//
+// Returns:
+//   - Error if the token is wrong, expired or was guessed too often.
+func (is IdentityService) VerifyEmail(ctx context.Context, tenantID string, userID string, verifyEmail object.VerifyEmail) error {
+	dbConn, _ := is.getDBConn(ctx)
+
+	err := validate.Struct(verifyEmail)
+
+	if err != nil {
+		var validateErrs validator.ValidationErrors
+		if errors.As(err, &validateErrs) {
+			return errors.Join(fmt.Errorf("problem while validating verify email data"), util.ConvertValidationError(validateErrs))
+		}
+	}
+
+	tenant, err := is.FindTenant(ctx, tenantID)
+
+	if err != nil {
+		return err
+	}
+
+	user, err := is.FindUser(ctx, tenantID, userID)
+
+	if err != nil {
+		return err
+	}
+
+	if len(strings.TrimSpace(user.EmailVerificationToken)) == 0 {
+		return errors.New("no email verification pending")
+	}
+
+	// tokens without an expiry were created before tokens expired and have to be requested again
+	if user.EmailVerificationExpiresAt == nil || time.Now().After(*user.EmailVerificationExpiresAt) {
+		return errors.New("email verification token is expired, please request a new one")
+	}
+
+	// every attempt is counted before the token is compared, so parallel guesses can't exceed the attempts
+	counted, err := repository.IncrementEmailVerificationAttempts(ctx, dbConn, tenantID, userID, emailVerificationMaxAttempts)
+
+	if err != nil {
+		return err
+	}
+
+	if !counted {
+		return errors.New("too many wrong email verification tokens, please request a new one")
+	}
+
+	if subtle.ConstantTimeCompare([]byte(strings.TrimSpace(user.EmailVerificationToken)), []byte(verifyEmail.Token)) != 1 {
+		return errors.New("email verification token is wrong")
+	}
+
+	if len(user.PendingEmail) == 0 {
+		err = is.UpdateUserEmail(ctx, tenantID, userID, object.UpdateEmail{
+			Email:         user.Email,
+			EmailVerified: true,
+		})
+
+		if err != nil {
+			return err
+		}
+
+		// users who signed up in the domain mode get the default groups once their address is confirmed
+		policy := tenant.SignUpPolicy
+		if !user.EmailVerified && policy.Mode == object.SignUpModeDomain && emailDomainAllowed(user.Email, policy.AllowedDomains) {
+			err = appendDefaultGroups(ctx, dbConn, tenantID, userID, policy.DefaultGroupIDs)
+
+			if err != nil {
+				return err
+			}
+		}
+
+		is.recordAuditEvent(ctx, tenantID, object.AuditEventEmailVerified, userID, map[string]any{
+			"email": user.Email,
+		})
+
+		return nil
+	}
+
+	// somebody else could have verified the email since the change was started
+	err = is.checkEmailAvailable(ctx, tenantID, userID, user.PendingEmail)
+
+	if err != nil {
+		return err
+	}
+
+	err = is.UpdateUserEmail(ctx, tenantID, userID, object.UpdateEmail{
+		Email:         user.PendingEmail,
+		EmailVerified: true,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	// reset mails were sent to the old email
+	err = repository.ExpireUserPasswordResets(ctx, dbConn, tenantID, userID)
+
+	if err != nil {
+		return err
+	}
+
+	err = is.sendTemplateMail(ctx, tenantID, object.TemplateTypeEmailChanged, user.Email, "Your email was changed", map[string]any{
+		"DisplayName": user.DisplayName,
+		"Username":    user.Username,
+		"TenantName":  tenant.DisplayName,
+		"NewEmail":    user.PendingEmail,
+	})
+
+	if err != nil {
+		log.Printf("problem while notifying old email of user %s: %v", userID, err)
+	}
+
+	is.recordAuditEvent(ctx, tenantID, object.AuditEventEmailChanged, userID, map[string]any{
+		"old_email": user.Email,
+		"new_email": user.PendingEmail,
+	})
+
+	return nil
+}
+
+// startEmailVerification stores a new verification token for the user and mails it. If pendingEmail is set,
+// the token confirms the change to the pending email and is sent there.
+func (is IdentityService) startEmailVerification(ctx context.Context, tenant object.Tenant, user object.User, pendingEmail string) error {
+	token := newEmailVerificationToken()
+	expiresAt := time.Now().Add(emailVerificationLifetime)
+
+	err := is.UpdateUserEmail(ctx, tenant.ID, user.ID, object.UpdateEmail{
+		Email:                      user.Email,
+		EmailVerified:              user.EmailVerified,
+		EmailVerificationToken:     token,
+		EmailVerificationExpiresAt: &expiresAt,
+		PendingEmail:               pendingEmail,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	user.EmailVerificationToken = token
+	user.PendingEmail = pendingEmail
+
+	return is.sendEmailVerification(ctx, tenant, user)
+}
+
+// sendEmailVerification mails the email verification token of the user, to the pending email if the user is changing the email.
+func (is IdentityService) sendEmailVerification(ctx context.Context, tenant object.Tenant, user object.User) error {
+	if len(strings.TrimSpace(user.EmailVerificationToken)) == 0 {
+		return errors.New("user has no email verification pending")
+	}
+
+	data := map[string]any{
+		"DisplayName":      user.DisplayName,
+		"Username":         user.Username,
+		"TenantName":       tenant.DisplayName,
+		"VerificationCode": strings.TrimSpace(user.EmailVerificationToken),
+		"ExpiresIn":        int(emailVerificationLifetime.Hours()),
+	}
+
+	if len(user.PendingEmail) > 0 {
+		data["Email"] = user.PendingEmail
+		return is.sendTemplateMail(ctx, tenant.ID, object.TemplateTypeEmailChange, user.PendingEmail, "Confirm your new email", data)
+	}
+
+	data["Email"] = user.Email
+	return is.sendTemplateMail(ctx, tenant.ID, object.TemplateTypeEmailVerification, user.Email, "Verify your email", data)
+}
+
+// checkEmailAvailable makes sure no other user of the tenant has verified the email.
+func (is IdentityService) checkEmailAvailable(ctx context.Context, tenantID string, userID string, email string) error {
+	users, err := is.FindUsersByEmail(ctx, tenantID, email)
+
+	if err != nil {
+		return err
+	}
+
+	for _, user := range users {
+		if user.ID != userID && user.EmailVerified {
+			return errors.New("email is already verified")
+		}
+	}
+
+	return nil
+}
+
+// newEmailVerificationToken returns a random 6 digit token, leading zeros included.
+func newEmailVerificationToken() string {
+	return fmt.Sprintf("%06d", util.RandomNumber(6))
+}
@@ -0,0 +1,100 @@
+/*
+ * Copyright (C) 2025 Anthrove
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logic
+
+import (
+	"context"
+	"errors"
+	"github.com/anthrove/identity/pkg/object"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVerifyEmailAttempts(t *testing.T) {
+	ctx := context.Background()
+	is, tenant := newTestService(t)
+	user := signUpTestUser(t, is, tenant, "jane")
+
+	expiresAt := time.Now().Add(emailVerificationLifetime)
+	err := is.UpdateUserEmail(ctx, tenant.ID, user.ID, object.UpdateEmail{
+		Email:                      user.Email,
+		EmailVerificationToken:     "123456",
+		EmailVerificationExpiresAt: &expiresAt,
+	})
+	if err != nil {
+		t.Fatalf("UpdateUserEmail() error = %v", err)
+	}
+
+	for i := 0; i < emailVerificationMaxAttempts; i++ {
+		err = is.VerifyEmail(ctx, tenant.ID, user.ID, object.VerifyEmail{Token: "654321"})
+		if err == nil || !strings.Contains(err.Error(), "wrong") {
+			t.Fatalf("VerifyEmail() attempt %d with a wrong token error = %v, want the token to be wrong", i, err)
+		}
+	}
+
+	// the right token doesn't help once the attempts are used up
+	err = is.VerifyEmail(ctx, tenant.ID, user.ID, object.VerifyEmail{Token: "123456"})
+	if err == nil || !strings.Contains(err.Error(), "too many") {
+		t.Errorf("VerifyEmail() after %d wrong tokens error = %v, want too many attempts", emailVerificationMaxAttempts, err)
+	}
+
+	found, err := is.FindUser(ctx, tenant.ID, user.ID)
+	if err != nil || found.EmailVerified {
+		t.Errorf("FindUser() = %+v, %v, want the email to be unverified", found, err)
+	}
+}
+
+func TestChangeEmailReauthentication(t *testing.T) {
+	ctx := context.WithValue(context.Background(), "request_info", object.RequestInfo{IPAddress: "192.0.2.1"})
+	is, tenant := newTestService(t)
+	user := signUpTestUser(t, is, tenant, "jane")
+
+	signedInEarlier := time.Now().Add(-emailChangeMaxAuthAge - time.Minute)
+
+	tests := []struct {
+		name            string
+		authenticatedAt time.Time
+		changeEmail     object.ChangeEmail
+		wantStarted     bool
+	}{
+		{name: "old sign in", authenticatedAt: signedInEarlier, changeEmail: object.ChangeEmail{Email: "old@example.com"}},
+		{name: "recent sign in", authenticatedAt: time.Now(), changeEmail: object.ChangeEmail{Email: "recent@example.com"}, wantStarted: true},
+		{name: "password", authenticatedAt: time.Time{}, changeEmail: object.ChangeEmail{Email: "password@example.com", CurrentPassword: "correct horse battery staple"}, wantStarted: true},
+		{name: "wrong password", authenticatedAt: signedInEarlier, changeEmail: object.ChangeEmail{Email: "wrong@example.com", CurrentPassword: "wrong horse battery staple"}},
+	}
+
+	for _, test := range tests {
+		// the tenant has no email provider, so the verification mail can't be sent after the change was started
+		_ = is.ChangeEmail(ctx, tenant.ID, user.ID, test.authenticatedAt, test.changeEmail)
+
+		found, err := is.FindUser(ctx, tenant.ID, user.ID)
+		if err != nil {
+			t.Fatalf("%s: FindUser() error = %v", test.name, err)
+		}
+
+		if started := found.PendingEmail == test.changeEmail.Email; started != test.wantStarted {
+			t.Errorf("%s: ChangeEmail() pending email = %q, want the change started %t", test.name, found.PendingEmail, test.wantStarted)
+		}
+	}
+
+	// the wrong password is throttled like the one of a sign in
+	err := is.ChangeEmail(ctx, tenant.ID, user.ID, signedInEarlier, object.ChangeEmail{Email: "wrong@example.com", CurrentPassword: "correct horse battery staple"})
+	if !errors.Is(err, ErrTooManyAttempts) {
+		t.Errorf("ChangeEmail() after a wrong password error = %v, want ErrTooManyAttempts", err)
+	}
+}
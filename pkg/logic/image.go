@@ -0,0 +1,269 @@
+/*
+ * Copyright (C) 2025 Anthrove
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logic
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+)
+
+// maxImagePixels protects the image processing against small files which decode to huge images. The decoded image
+// and its RGBA copy take 4 bytes per pixel each, so this keeps an upload below about 130 MiB of memory.
+const maxImagePixels = 4096 * 4096
+
+// imageMimeTypes are the image types which are re-encoded, all other files are stored as they are uploaded.
+var imageMimeTypes = []string{"image/jpeg", "image/png", "image/gif"}
+
+// decodeUploadedImage decodes an uploaded jpeg, png or gif image. The EXIF orientation of jpeg images is applied to the
+// pixels, because the metadata is dropped when the image is encoded again.
+func decodeUploadedImage(data []byte, mimeType string) (*image.RGBA, error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.Join(errors.New("problem while reading the image"), err)
+	}
+
+	if config.Width <= 0 || config.Height <= 0 || config.Width*config.Height > maxImagePixels {
+		return nil, errors.New("image is too large")
+	}
+
+	var img image.Image
+	switch mimeType {
+	case "image/jpeg":
+		img, err = jpeg.Decode(bytes.NewReader(data))
+	case "image/png":
+		img, err = png.Decode(bytes.NewReader(data))
+	case "image/gif":
+		img, err = gif.Decode(bytes.NewReader(data))
+	default:
+		return nil, errors.New("unsupported image type " + mimeType)
+	}
+
+	if err != nil {
+		return nil, errors.Join(errors.New("problem while reading the image"), err)
+	}
+
+	rgba := toRGBA(img)
+
+	if mimeType == "image/jpeg" {
+		rgba = applyOrientation(rgba, jpegOrientation(data))
+	}
+
+	return rgba, nil
+}
+
+// encodeImage encodes an image without any metadata. Jpeg images stay jpeg, all other images are encoded as png.
+// It returns the encoded image, its mime type and the file extension.
+func encodeImage(img image.Image, mimeType string) ([]byte, string, string, error) {
+	var buffer bytes.Buffer
+
+	if mimeType == "image/jpeg" {
+		err := jpeg.Encode(&buffer, img, &jpeg.Options{Quality: 90})
+		return buffer.Bytes(), "image/jpeg", "jpg", err
+	}
+
+	err := png.Encode(&buffer, img)
+	return buffer.Bytes(), "image/png", "png", err
+}
+
+func toRGBA(img image.Image) *image.RGBA {
+	bounds := img.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), img, bounds.Min, draw.Src)
+	return rgba
+}
+
+// cropSquare cuts the largest square out of the center of an image.
+func cropSquare(img *image.RGBA) *image.RGBA {
+	width, height := img.Bounds().Dx(), img.Bounds().Dy()
+	size := min(width, height)
+
+	square := image.NewRGBA(image.Rect(0, 0, size, size))
+	draw.Draw(square, square.Bounds(), img, image.Pt((width-size)/2, (height-size)/2), draw.Src)
+	return square
+}
+
+// resizeImage scales an image down, so it fits into a square of maxSize pixels. Smaller images are not scaled up.
+// Every pixel of the result is the average of the pixels it covers in the original.
+func resizeImage(img *image.RGBA, maxSize int) *image.RGBA {
+	srcWidth, srcHeight := img.Bounds().Dx(), img.Bounds().Dy()
+	if srcWidth <= maxSize && srcHeight <= maxSize {
+		return img
+	}
+
+	dstWidth, dstHeight := maxSize, maxSize
+	if srcWidth > srcHeight {
+		dstHeight = max(1, srcHeight*maxSize/srcWidth)
+	} else {
+		dstWidth = max(1, srcWidth*maxSize/srcHeight)
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dstWidth, dstHeight))
+
+	for y := 0; y < dstHeight; y++ {
+		srcY0 := y * srcHeight / dstHeight
+		srcY1 := max(srcY0+1, (y+1)*srcHeight/dstHeight)
+
+		for x := 0; x < dstWidth; x++ {
+			srcX0 := x * srcWidth / dstWidth
+			srcX1 := max(srcX0+1, (x+1)*srcWidth/dstWidth)
+
+			var sum [4]int
+			for srcY := srcY0; srcY < srcY1; srcY++ {
+				offset := srcY*img.Stride + srcX0*4
+				for srcX := srcX0; srcX < srcX1; srcX++ {
+					for channel := 0; channel < 4; channel++ {
+						sum[channel] += int(img.Pix[offset+channel])
+					}
+					offset += 4
+				}
+			}
+
+			count := (srcY1 - srcY0) * (srcX1 - srcX0)
+			offset := y*dst.Stride + x*4
+			for channel := 0; channel < 4; channel++ {
+				dst.Pix[offset+channel] = uint8(sum[channel] / count)
+			}
+		}
+	}
+
+	return dst
+}
+
+// jpegOrientation reads the EXIF orientation of a jpeg image, 1 is returned if the image has none.
+func jpegOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 1
+	}
+
+	for offset := 2; offset+4 <= len(data); {
+		if data[offset] != 0xFF {
+			return 1
+		}
+
+		marker := data[offset+1]
+		// the image data starts, no metadata follows
+		if marker == 0xDA || marker == 0xD9 {
+			return 1
+		}
+
+		length := int(binary.BigEndian.Uint16(data[offset+2:]))
+		if length < 2 || offset+2+length > len(data) {
+			return 1
+		}
+
+		segment := data[offset+4 : offset+2+length]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return exifOrientation(segment[6:])
+		}
+
+		offset += 2 + length
+	}
+
+	return 1
+}
+
+// exifOrientation reads the orientation tag of the first IFD of the TIFF structure of an EXIF segment.
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd < 8 || ifd+2 > len(tiff) {
+		return 1
+	}
+
+	entries := int(order.Uint16(tiff[ifd:]))
+	for i := 0; i < entries; i++ {
+		entry := ifd + 2 + i*12
+		if entry+12 > len(tiff) {
+			return 1
+		}
+
+		// 0x0112 is the orientation tag, its value is a SHORT stored in the entry
+		if order.Uint16(tiff[entry:]) == 0x0112 {
+			orientation := int(order.Uint16(tiff[entry+8:]))
+			if orientation < 1 || orientation > 8 {
+				return 1
+			}
+
+			return orientation
+		}
+	}
+
+	return 1
+}
+
+// applyOrientation rotates and flips an image, so it is shown upright without its EXIF orientation.
+func applyOrientation(img *image.RGBA, orientation int) *image.RGBA {
+	if orientation <= 1 || orientation > 8 {
+		return img
+	}
+
+	width, height := img.Bounds().Dx(), img.Bounds().Dy()
+
+	dstWidth, dstHeight := width, height
+	if orientation >= 5 {
+		dstWidth, dstHeight = height, width
+	}
+
+	// source returns the pixel of the original image which is shown at x, y
+	source := func(x, y int) (int, int) {
+		switch orientation {
+		case 2:
+			return width - 1 - x, y
+		case 3:
+			return width - 1 - x, height - 1 - y
+		case 4:
+			return x, height - 1 - y
+		case 5:
+			return y, x
+		case 6:
+			return y, height - 1 - x
+		case 7:
+			return width - 1 - y, height - 1 - x
+		default:
+			return width - 1 - y, x
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dstWidth, dstHeight))
+	for y := 0; y < dstHeight; y++ {
+		for x := 0; x < dstWidth; x++ {
+			srcX, srcY := source(x, y)
+			copy(dst.Pix[y*dst.Stride+x*4:y*dst.Stride+x*4+4], img.Pix[srcY*img.Stride+srcX*4:srcY*img.Stride+srcX*4+4])
+		}
+	}
+
+	return dst
+}
@@ -0,0 +1,152 @@
+/*
+ * Copyright (C) 2025 Anthrove
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logic
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"testing"
+)
+
+func TestResizeImage(t *testing.T) {
+	tests := []struct {
+		width, height int
+		maxSize       int
+		wantWidth     int
+		wantHeight    int
+	}{
+		{width: 1024, height: 512, maxSize: 256, wantWidth: 256, wantHeight: 128},
+		{width: 300, height: 900, maxSize: 300, wantWidth: 100, wantHeight: 300},
+		{width: 64, height: 64, maxSize: 128, wantWidth: 64, wantHeight: 64},
+		{width: 1000, height: 1, maxSize: 10, wantWidth: 10, wantHeight: 1},
+	}
+
+	for i, test := range tests {
+		resized := resizeImage(image.NewRGBA(image.Rect(0, 0, test.width, test.height)), test.maxSize)
+
+		if resized.Bounds().Dx() != test.wantWidth || resized.Bounds().Dy() != test.wantHeight {
+			t.Errorf("test %d: resizeImage() = %dx%d, want %dx%d", i, resized.Bounds().Dx(), resized.Bounds().Dy(), test.wantWidth, test.wantHeight)
+		}
+	}
+
+	// the pixels are averaged, a black and white pattern becomes gray
+	pattern := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			if (x+y)%2 == 0 {
+				pattern.Set(x, y, color.White)
+			} else {
+				pattern.Set(x, y, color.Black)
+			}
+		}
+	}
+
+	if got := resizeImage(pattern, 1).RGBAAt(0, 0); got.R != 127 || got.A != 255 {
+		t.Errorf("resizeImage() pixel = %v, want gray", got)
+	}
+}
+
+func TestApplyOrientation(t *testing.T) {
+	// a 2x1 image with a red left and a blue right pixel
+	img := image.NewRGBA(image.Rect(0, 0, 2, 1))
+	img.SetRGBA(0, 0, color.RGBA{R: 255, A: 255})
+	img.SetRGBA(1, 0, color.RGBA{B: 255, A: 255})
+
+	red, blue := color.RGBA{R: 255, A: 255}, color.RGBA{B: 255, A: 255}
+
+	tests := []struct {
+		orientation int
+		want        [][]color.RGBA
+	}{
+		{orientation: 1, want: [][]color.RGBA{{red, blue}}},
+		{orientation: 2, want: [][]color.RGBA{{blue, red}}},
+		{orientation: 3, want: [][]color.RGBA{{blue, red}}},
+		{orientation: 6, want: [][]color.RGBA{{red}, {blue}}},
+		{orientation: 8, want: [][]color.RGBA{{blue}, {red}}},
+	}
+
+	for _, test := range tests {
+		got := applyOrientation(img, test.orientation)
+
+		if got.Bounds().Dy() != len(test.want) || got.Bounds().Dx() != len(test.want[0]) {
+			t.Errorf("orientation %d: applyOrientation() size = %v", test.orientation, got.Bounds())
+			continue
+		}
+
+		for y, row := range test.want {
+			for x, want := range row {
+				if got.RGBAAt(x, y) != want {
+					t.Errorf("orientation %d: pixel %d,%d = %v, want %v", test.orientation, x, y, got.RGBAAt(x, y), want)
+				}
+			}
+		}
+	}
+}
+
+func TestJPEGOrientation(t *testing.T) {
+	var encoded bytes.Buffer
+	if err := jpeg.Encode(&encoded, image.NewRGBA(image.Rect(0, 0, 8, 8)), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// APP1 segment with a big endian TIFF header and one IFD entry: orientation (0x0112), SHORT, count 1, value 6
+	exif := []byte("Exif\x00\x00MM\x00\x2a\x00\x00\x00\x08\x00\x01\x01\x12\x00\x03\x00\x00\x00\x01\x00\x06\x00\x00\x00\x00\x00\x00")
+	segment := append([]byte{0xFF, 0xE1, 0x00, byte(len(exif) + 2)}, exif...)
+	withExif := append(append([]byte{0xFF, 0xD8}, segment...), encoded.Bytes()[2:]...)
+
+	if got := jpegOrientation(withExif); got != 6 {
+		t.Errorf("jpegOrientation() = %d, want 6", got)
+	}
+
+	if got := jpegOrientation(encoded.Bytes()); got != 1 {
+		t.Errorf("jpegOrientation() without exif = %d, want 1", got)
+	}
+
+	img, err := decodeUploadedImage(withExif, "image/jpeg")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, _, _, err := encodeImage(img, "image/jpeg")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if bytes.Contains(data, []byte("Exif")) {
+		t.Error("encodeImage() kept the EXIF metadata")
+	}
+}
+
+func TestUploadFileName(t *testing.T) {
+	tests := map[string]string{
+		"avatar.png":        "avatar.png",
+		"../../etc/passwd":  "passwd",
+		"C:\\Users\\me.jpg": "me.jpg",
+		"my photo (1).jpeg": "my_photo__1_.jpeg",
+		"..":                "file",
+		".hidden":           "hidden",
+		"":                  "file",
+	}
+
+	for name, want := range tests {
+		if got := uploadFileName(name); got != want {
+			t.Errorf("uploadFileName(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
@@ -19,6 +19,8 @@ package logic
 import (
 	"context"
 	"errors"
+	"fmt"
+	"github.com/anthrove/identity/pkg/crypto"
 	"github.com/anthrove/identity/pkg/object"
 	"gorm.io/gorm"
 )
@@ -250,48 +252,48 @@ func (is IdentityService) ImportApplication(ctx context.Context, tenantID string
 	return application, nil
 }
 
-func (is IdentityService) ImportUser(ctx context.Context, tenantID string, importUser object.ImportUser) (object.User, error) {
-	if importUser.ID == "" {
-		user, err := is.CreateUser(ctx, tenantID, object.CreateUser{
-			Username:    importUser.Username,
-			DisplayName: importUser.DisplayName,
-			Email:       importUser.Email,
-			Password:    importUser.Password,
-		})
+// ImportUsers imports every user on its own, so one broken user doesn't stop the migration of the others.
+// The result contains an entry for every user in the same order, with the error if the import failed.
+func (is IdentityService) ImportUsers(ctx context.Context, tenantID string, importUsers []object.ImportUser) ([]object.ImportUserResult, error) {
+	if len(tenantID) == 0 {
+		return nil, errors.New("tenantID is required")
+	}
 
+	results := make([]object.ImportUserResult, 0, len(importUsers))
+	for _, importUser := range importUsers {
+		result := object.ImportUserResult{
+			ID:       importUser.ID,
+			Username: importUser.Username,
+		}
+
+		user, err := is.ImportUser(ctx, tenantID, importUser)
 		if err != nil {
-			return object.User{}, err
+			result.Error = err.Error()
+		} else {
+			result.ID = user.ID
 		}
 
-		err = is.UpdateUserEmail(ctx, tenantID, user.ID, object.UpdateEmail{
-			Email:                  user.Email,
-			EmailVerified:          true,
-			EmailVerificationToken: "",
-		})
-		return user, err
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+func (is IdentityService) ImportUser(ctx context.Context, tenantID string, importUser object.ImportUser) (object.User, error) {
+	credentialMetadata, err := importPasswordMetadata(importUser)
+	if err != nil {
+		return object.User{}, err
+	}
+
+	if importUser.ID == "" {
+		return is.importNewUser(ctx, tenantID, importUser, credentialMetadata)
 	}
 
 	user, err := is.FindUser(ctx, tenantID, importUser.ID)
 
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			user, err = is.CreateUser(ctx, tenantID, object.CreateUser{
-				Username:    importUser.Username,
-				DisplayName: importUser.DisplayName,
-				Email:       importUser.Email,
-				Password:    importUser.Password,
-			}, importUser.ID)
-
-			if err != nil {
-				return object.User{}, err
-			}
-
-			err = is.UpdateUserEmail(ctx, tenantID, user.ID, object.UpdateEmail{
-				Email:                  user.Email,
-				EmailVerified:          true,
-				EmailVerificationToken: "",
-			})
-			return user, err
+			return is.importNewUser(ctx, tenantID, importUser, credentialMetadata, importUser.ID)
 		}
 
 		return object.User{}, err
@@ -311,9 +313,92 @@ func (is IdentityService) ImportUser(ctx context.Context, tenantID string, impor
 		EmailVerificationToken: "",
 	})
 
+	if err != nil {
+		return object.User{}, err
+	}
+
+	if credentialMetadata != nil {
+		err = is.importPasswordCredential(ctx, tenantID, user.ID, credentialMetadata)
+
+		if err != nil {
+			return object.User{}, err
+		}
+	}
+
 	user.DisplayName = importUser.DisplayName
 	user.Email = importUser.Email
 	user.EmailVerified = true
 	user.EmailVerificationToken = ""
 	return user, nil
 }
+
+func (is IdentityService) importNewUser(ctx context.Context, tenantID string, importUser object.ImportUser, credentialMetadata map[string]any, opt ...string) (object.User, error) {
+	user, err := is.createUser(ctx, tenantID, object.CreateUser{
+		Username:    importUser.Username,
+		DisplayName: importUser.DisplayName,
+		Email:       importUser.Email,
+		Password:    importUser.Password,
+	}, credentialMetadata, opt...)
+
+	if err != nil {
+		return object.User{}, err
+	}
+
+	err = is.UpdateUserEmail(ctx, tenantID, user.ID, object.UpdateEmail{
+		Email:                  user.Email,
+		EmailVerified:          true,
+		EmailVerificationToken: "",
+	})
+	return user, err
+}
+
+// importPasswordCredential replaces the hash of the password credential of an existing user with the imported one.
+func (is IdentityService) importPasswordCredential(ctx context.Context, tenantID string, userID string, credentialMetadata map[string]any) error {
+	credentials, err := is.FindCredentialsByUser(ctx, tenantID, userID)
+	if err != nil {
+		return err
+	}
+
+	for _, credential := range credentials {
+		if credential.Type == "password" {
+			return is.UpdateCredential(ctx, tenantID, credential.ID, object.UpdateCredential{
+				Metadata: credentialMetadata,
+				Enabled:  credential.Enabled,
+			})
+		}
+	}
+
+	_, err = is.CreateCredential(ctx, tenantID, object.CreateCredential{
+		UserID:   userID,
+		Type:     "password",
+		Metadata: credentialMetadata,
+		Enabled:  true,
+	})
+	return err
+}
+
+// importPasswordMetadata builds the password credential metadata for an imported hash. Imported hashes have no separate salt,
+// it is part of the hash itself. It returns nil if the user is imported with a plaintext password.
+func importPasswordMetadata(importUser object.ImportUser) (map[string]any, error) {
+	var passwordType, hash string
+	var err error
+
+	switch {
+	case len(importUser.PasswordCredential) > 0 && string(importUser.PasswordCredential) != "null":
+		passwordType, hash, err = crypto.ParseKeycloakCredential(importUser.PasswordCredential)
+	case len(importUser.PasswordHash) > 0:
+		passwordType, hash, err = crypto.ParseForeignHash(importUser.PasswordHash)
+	default:
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("problem while importing password of user %s", importUser.Username), err)
+	}
+
+	return map[string]any{
+		"hash": hash,
+		"salt": "",
+		"type": passwordType,
+	}, nil
+}
@@ -0,0 +1,109 @@
+/*
+ * Copyright (C) 2025 Anthrove
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/anthrove/identity/pkg/object"
+	"github.com/anthrove/identity/pkg/repository"
+	"github.com/anthrove/identity/pkg/util"
+	"github.com/go-playground/validator/v10"
+	"time"
+)
+
+// CreateInvitation creates a new invitation within a specified tenant.
+// The ID of the invitation is the code a user needs to sign up to a tenant with the invite sign up mode.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancelation, and deadlines.
+//   - tenantID: unique identifier of the tenant to which the invitation belongs.
+//   - createInvitation: object containing the details of the invitation to be created.
+//
+// Returns:
+//   - Invitation object if creation is successful.
+//   - Error if there is any issue during validation or creation.
+func (is IdentityService) CreateInvitation(ctx context.Context, tenantID string, createInvitation object.CreateInvitation) (object.Invitation, error) {
+	dbConn, _ := is.getDBConn(ctx)
+
+	if len(tenantID) == 0 {
+		return object.Invitation{}, errors.New("tenantID is required")
+	}
+
+	err := validate.Struct(createInvitation)
+
+	if err != nil {
+		var validateErrs validator.ValidationErrors
+		if errors.As(err, &validateErrs) {
+			return object.Invitation{}, errors.Join(fmt.Errorf("problem while validating create invitation data"), util.ConvertValidationError(validateErrs))
+		}
+	}
+
+	if createInvitation.ExpiresAt.Before(time.Now()) {
+		return object.Invitation{}, errors.New("invitation would already be expired")
+	}
+
+	return repository.CreateInvitation(ctx, dbConn, tenantID, createInvitation)
+}
+
+// KillInvitation deletes an invitation within a specified tenant.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancelation, and deadlines.
+//   - tenantID: unique identifier of the tenant to which the invitation belongs.
+//   - invitationID: unique identifier of the invitation to be deleted.
+//
+// Returns:
+//   - Error if there is any issue during deletion.
+func (is IdentityService) KillInvitation(ctx context.Context, tenantID string, invitationID string) error {
+	dbConn, _ := is.getDBConn(ctx)
+
+	return repository.KillInvitation(ctx, dbConn, tenantID, invitationID)
+}
+
+// FindInvitation retrieves a specific invitation within a specified tenant.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancelation, and deadlines.
+//   - tenantID: unique identifier of the tenant to which the invitation belongs.
+//   - invitationID: unique identifier of the invitation to be retrieved.
+//
+// Returns:
+//   - Invitation object if retrieval is successful.
+//   - Error if there is any issue during retrieval.
+func (is IdentityService) FindInvitation(ctx context.Context, tenantID string, invitationID string) (object.Invitation, error) {
+	dbConn, _ := is.getDBConn(ctx)
+
+	return repository.FindInvitation(ctx, dbConn, tenantID, invitationID)
+}
+
+// FindInvitations retrieves a list of invitations within a specified tenant, with pagination support.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancelation, and deadlines.
+//   - tenantID: unique identifier of the tenant to which the invitations belong.
+//   - pagination: object containing pagination details (limit and page).
+//
+// Returns:
+//   - Slice of Invitation objects if retrieval is successful.
+//   - Error if there is any issue during retrieval.
+func (is IdentityService) FindInvitations(ctx context.Context, tenantID string, pagination object.Pagination) ([]object.Invitation, error) {
+	dbConn, _ := is.getDBConn(ctx)
+
+	return repository.FindInvitations(ctx, dbConn, tenantID, pagination)
+}
@@ -0,0 +1,281 @@
+/*
+ * Copyright (C) 2025 Anthrove
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/anthrove/identity/pkg/object"
+	"github.com/anthrove/identity/pkg/repository"
+	"log"
+	"time"
+)
+
+// ErrTooManyAttempts is returned while a user or IP address is throttled because of failed attempts.
+var ErrTooManyAttempts = errors.New("too many failed attempts")
+
+// FindLockouts retrieves the failure counters of a tenant, with pagination support.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancelation, and deadlines.
+//   - tenantID: unique identifier of the tenant to which the counters belong.
+//   - pagination: object containing pagination details (limit and page).
+//
+// Returns:
+//   - Slice of Lockout objects if retrieval is successful.
+//   - Error if there is any issue during retrieval.
+func (is IdentityService) FindLockouts(ctx context.Context, tenantID string, pagination object.Pagination) ([]object.Lockout, error) {
+	dbConn, _ := is.getDBConn(ctx)
+
+	if len(tenantID) == 0 {
+		return nil, errors.New("tenantID is required")
+	}
+
+	return repository.FindLockouts(ctx, dbConn, tenantID, pagination)
+}
+
+// KillLockout resets a failure counter of a tenant, e.g. to unlock an IP address.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancelation, and deadlines.
+//   - tenantID: unique identifier of the tenant to which the counter belongs.
+//   - lockoutID: unique identifier of the counter to be reset.
+//
+// Returns:
+//   - Error if there is any issue during deletion.
+func (is IdentityService) KillLockout(ctx context.Context, tenantID string, lockoutID string) error {
+	dbConn, _ := is.getDBConn(ctx)
+
+	if len(tenantID) == 0 {
+		return errors.New("tenantID is required")
+	}
+
+	if len(lockoutID) == 0 {
+		return errors.New("lockoutID is required")
+	}
+
+	lockout, err := repository.KillLockout(ctx, dbConn, tenantID, lockoutID)
+
+	if err != nil {
+		return err
+	}
+
+	is.recordAuditEvent(ctx, tenantID, object.AuditEventLockoutReset, lockout.UserID, map[string]any{
+		"key": lockout.Key,
+	})
+
+	return nil
+}
+
+// UnlockUser resets all failure counters of a user, so the user can sign in again immediately.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancelation, and deadlines.
+//   - tenantID: unique identifier of the tenant to which the user belongs.
+//   - userID: unique identifier of the user to be unlocked.
+//
+// Returns:
+//   - Error if there is any issue during deletion.
+func (is IdentityService) UnlockUser(ctx context.Context, tenantID string, userID string) error {
+	dbConn, _ := is.getDBConn(ctx)
+
+	if len(tenantID) == 0 {
+		return errors.New("tenantID is required")
+	}
+
+	if len(userID) == 0 {
+		return errors.New("userID is required")
+	}
+
+	err := repository.KillUserLockouts(ctx, dbConn, tenantID, userID)
+
+	if err != nil {
+		return err
+	}
+
+	is.recordAuditEvent(ctx, tenantID, object.AuditEventLockoutReset, userID, nil)
+
+	return nil
+}
+
+// checkLockout returns ErrTooManyAttempts if the user in the given scope or the client IP address is currently throttled.
+// The userID can be empty, e.g. if the user is not known yet.
+func (is IdentityService) checkLockout(ctx context.Context, tenantID string, scope string, userID string) error {
+	dbConn, _ := is.getDBConn(ctx)
+
+	keys := lockoutKeys(ctx, scope, userID)
+
+	if len(keys) == 0 {
+		return nil
+	}
+
+	lockouts, err := repository.FindActiveLockouts(ctx, dbConn, tenantID, keys)
+
+	if err != nil {
+		return err
+	}
+
+	if len(lockouts) > 0 {
+		retryIn := time.Until(lockouts[0].LockedUntil).Round(time.Second)
+		return errors.Join(ErrTooManyAttempts, fmt.Errorf("try again in %s", max(retryIn, time.Second)))
+	}
+
+	return nil
+}
+
+// recordFailure counts a failed attempt of the user in the given scope and of the client IP address.
+// Both are throttled according to the lockout policy of the tenant, a new lockout is notified and audited.
+// Failures are only logged, the attempt fails anyway.
+func (is IdentityService) recordFailure(ctx context.Context, tenant object.Tenant, scope string, userID string) {
+	info := requestInfo(ctx)
+
+	if len(userID) > 0 {
+		lockout, locked, err := is.recordLockoutFailure(ctx, tenant, object.Lockout{
+			Key:       lockoutKey(scope, userID),
+			Scope:     scope,
+			UserID:    userID,
+			IPAddress: info.IPAddress,
+		}, tenant.LockoutPolicy.MaxFailures)
+
+		if err != nil {
+			log.Printf("problem while recording failed attempt: %v", err)
+		} else if locked {
+			is.notifyUserLocked(ctx, tenant, userID, lockout)
+		}
+	}
+
+	if len(info.IPAddress) > 0 {
+		lockout, locked, err := is.recordLockoutFailure(ctx, tenant, object.Lockout{
+			Key:       lockoutKey(object.LockoutScopeIP, info.IPAddress),
+			Scope:     object.LockoutScopeIP,
+			IPAddress: info.IPAddress,
+		}, tenant.LockoutPolicy.MaxFailuresPerIP)
+
+		if err != nil {
+			log.Printf("problem while recording failed attempt: %v", err)
+		} else if locked {
+			is.recordAuditEvent(ctx, tenant.ID, object.AuditEventIPLocked, "", map[string]any{
+				"failures":     lockout.Failures,
+				"locked_until": lockout.LockedUntil,
+			})
+		}
+	}
+}
+
+// resetFailures forgets the failed attempts of the user in the given scope after a successful attempt.
+// The counter of the IP address is kept, a valid account must not unlock an IP address which tries many others.
+func (is IdentityService) resetFailures(ctx context.Context, tenantID string, scope string, userID string) {
+	dbConn, _ := is.getDBConn(ctx)
+
+	err := repository.KillLockoutByKey(ctx, dbConn, tenantID, lockoutKey(scope, userID))
+
+	if err != nil {
+		log.Printf("problem while resetting failed attempts: %v", err)
+	}
+}
+
+// recordLockoutFailure increments a counter and locks it, either for the backoff delay or for the lockout duration if maxFailures is reached.
+// It returns true if the counter reached maxFailures with this failure.
+func (is IdentityService) recordLockoutFailure(ctx context.Context, tenant object.Tenant, lockout object.Lockout, maxFailures int) (object.Lockout, bool, error) {
+	dbConn, _ := is.getDBConn(ctx)
+	policy := tenant.LockoutPolicy
+
+	if maxFailures == 0 && policy.BackoffBase == 0 {
+		return lockout, false, nil
+	}
+
+	window := time.Duration(policy.LockoutDuration) * time.Second
+
+	lockout, err := repository.RecordLockoutFailure(ctx, dbConn, tenant.ID, lockout, time.Now().Add(-window))
+
+	if err != nil {
+		return lockout, false, err
+	}
+
+	locked := maxFailures > 0 && lockout.Failures >= maxFailures
+
+	if locked {
+		lockout.LockedUntil = lockout.LastFailureAt.Add(window)
+	} else if policy.BackoffBase > 0 {
+		lockout.LockedUntil = lockout.LastFailureAt.Add(backoffDelay(policy, lockout.Failures))
+	} else {
+		return lockout, false, nil
+	}
+
+	err = repository.UpdateLockoutLockedUntil(ctx, dbConn, tenant.ID, lockout.Key, lockout.LockedUntil)
+
+	return lockout, locked && lockout.Failures == maxFailures, err
+}
+
+// notifyUserLocked sends the user an email about the lockout and records it as audit event.
+func (is IdentityService) notifyUserLocked(ctx context.Context, tenant object.Tenant, userID string, lockout object.Lockout) {
+	is.recordAuditEvent(ctx, tenant.ID, object.AuditEventUserLocked, userID, map[string]any{
+		"scope":        lockout.Scope,
+		"failures":     lockout.Failures,
+		"locked_until": lockout.LockedUntil,
+	})
+
+	user, err := is.FindUser(ctx, tenant.ID, userID)
+
+	if err != nil || len(user.Email) == 0 {
+		return
+	}
+
+	err = is.sendTemplateMail(ctx, tenant.ID, object.TemplateTypeAccountLocked, user.Email, "Your account was locked", map[string]any{
+		"TenantName":  tenant.DisplayName,
+		"DisplayName": user.DisplayName,
+		"Username":    user.Username,
+		"Failures":    lockout.Failures,
+		"LockedUntil": lockout.LockedUntil,
+		"IPAddress":   lockout.IPAddress,
+	})
+
+	if err != nil {
+		log.Printf("problem while sending lockout notification: %v", err)
+	}
+}
+
+// backoffDelay returns the delay after the given amount of failures, it doubles with every failure up to BackoffMax.
+func backoffDelay(policy object.LockoutPolicy, failures int) time.Duration {
+	delay := time.Duration(policy.BackoffBase) * time.Second
+	maxDelay := time.Duration(policy.BackoffMax) * time.Second
+
+	for i := 1; i < failures && delay < maxDelay; i++ {
+		delay *= 2
+	}
+
+	return min(delay, maxDelay)
+}
+
+func lockoutKeys(ctx context.Context, scope string, userID string) []string {
+	var keys []string
+
+	if len(userID) > 0 {
+		keys = append(keys, lockoutKey(scope, userID))
+	}
+
+	if ip := requestInfo(ctx).IPAddress; len(ip) > 0 {
+		keys = append(keys, lockoutKey(object.LockoutScopeIP, ip))
+	}
+
+	return keys
+}
+
+func lockoutKey(scope string, id string) string {
+	return scope + ":" + id
+}
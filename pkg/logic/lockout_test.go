@@ -0,0 +1,149 @@
+/*
+ * Copyright (C) 2025 Anthrove
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logic
+
+import (
+	"context"
+	"errors"
+	"github.com/anthrove/identity/pkg/object"
+	"github.com/anthrove/identity/pkg/repository"
+	"testing"
+	"time"
+)
+
+func TestBackoffDelay(t *testing.T) {
+	policy := object.LockoutPolicy{
+		BackoffBase: 1,
+		BackoffMax:  60,
+	}
+
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{3, 4 * time.Second},
+		{6, 32 * time.Second},
+		{7, time.Minute},
+		{1000, time.Minute},
+	}
+
+	for _, test := range tests {
+		if got := backoffDelay(policy, test.failures); got != test.want {
+			t.Errorf("backoffDelay(%d) = %s, want %s", test.failures, got, test.want)
+		}
+	}
+}
+
+func TestRecordFailure(t *testing.T) {
+	is, tenant := newTestService(t)
+
+	ctx := context.WithValue(context.Background(), "request_info", object.RequestInfo{IPAddress: "192.0.2.1"})
+	userID := "BsOOg4igppKxYwhAQQrD3GCRZ"
+
+	if tenant.LockoutPolicy != object.DefaultLockoutPolicy {
+		t.Fatalf("tenant.LockoutPolicy = %+v, want DefaultLockoutPolicy", tenant.LockoutPolicy)
+	}
+
+	err := is.checkLockout(ctx, tenant.ID, object.LockoutScopePassword, userID)
+	if err != nil {
+		t.Fatalf("checkLockout() without failures error = %v", err)
+	}
+
+	// the first failure delays the next attempt by the backoff
+	is.recordFailure(ctx, tenant, object.LockoutScopePassword, userID)
+
+	err = is.checkLockout(ctx, tenant.ID, object.LockoutScopePassword, userID)
+	if !errors.Is(err, ErrTooManyAttempts) {
+		t.Fatalf("checkLockout() after a failure error = %v, want ErrTooManyAttempts", err)
+	}
+
+	for i := 1; i < tenant.LockoutPolicy.MaxFailures; i++ {
+		is.recordFailure(ctx, tenant, object.LockoutScopePassword, userID)
+	}
+
+	lockouts, err := is.FindLockouts(ctx, tenant.ID, object.Pagination{Page: 1, Limit: 10})
+	if err != nil {
+		t.Fatalf("FindLockouts() error = %v", err)
+	}
+
+	for _, lockout := range lockouts {
+		if lockout.Failures != tenant.LockoutPolicy.MaxFailures {
+			t.Errorf("lockout %s failures = %d, want %d", lockout.Key, lockout.Failures, tenant.LockoutPolicy.MaxFailures)
+		}
+
+		// the user is locked for the lockout duration, the IP address only has the backoff
+		lockedFor := time.Until(lockout.LockedUntil)
+		if lockout.Scope == object.LockoutScopePassword && lockedFor < 14*time.Minute {
+			t.Errorf("user locked for %s, want the lockout duration", lockedFor)
+		}
+
+		if lockout.Scope == object.LockoutScopeIP && lockedFor > time.Minute {
+			t.Errorf("IP address locked for %s, want the backoff", lockedFor)
+		}
+	}
+
+	// a successful attempt resets the user, but not the IP address
+	is.resetFailures(ctx, tenant.ID, object.LockoutScopePassword, userID)
+
+	err = is.checkLockout(context.Background(), tenant.ID, object.LockoutScopePassword, userID)
+	if err != nil {
+		t.Errorf("checkLockout() after reset error = %v", err)
+	}
+
+	err = is.checkLockout(ctx, tenant.ID, object.LockoutScopePassword, userID)
+	if !errors.Is(err, ErrTooManyAttempts) {
+		t.Errorf("checkLockout() of the IP address after reset error = %v, want ErrTooManyAttempts", err)
+	}
+}
+
+func TestLockoutPolicyBackfill(t *testing.T) {
+	ctx := context.Background()
+	is, tenant := newTestService(t)
+
+	// tenants created before the lockout policy have no value
+	err := is.db.Exec("UPDATE tenants SET lockout_policy = NULL WHERE id = ?", tenant.ID).Error
+	if err != nil {
+		t.Fatalf("removing the lockout policy: %v", err)
+	}
+
+	err = repository.Migrate(is.db)
+	if err != nil {
+		t.Fatalf("repository.Migrate() error = %v", err)
+	}
+
+	tenant, _ = is.FindTenant(ctx, tenant.ID)
+	if tenant.LockoutPolicy != object.DefaultLockoutPolicy {
+		t.Errorf("backfilled LockoutPolicy = %+v, want DefaultLockoutPolicy", tenant.LockoutPolicy)
+	}
+
+	// a policy without limits disables the throttling on purpose
+	tenant = updateTestTenant(t, is, tenant, func(updateTenant *object.UpdateTenant) {
+		updateTenant.LockoutPolicy = &object.LockoutPolicy{}
+	})
+
+	err = repository.Migrate(is.db)
+	if err != nil {
+		t.Fatalf("repository.Migrate() error = %v", err)
+	}
+
+	tenant, _ = is.FindTenant(ctx, tenant.ID)
+	if tenant.LockoutPolicy != (object.LockoutPolicy{}) {
+		t.Errorf("disabled LockoutPolicy = %+v, want it to be kept", tenant.LockoutPolicy)
+	}
+}
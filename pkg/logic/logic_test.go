@@ -0,0 +1,113 @@
+/*
+ * Copyright (C) 2025 Anthrove
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logic
+
+import (
+	"context"
+	"github.com/anthrove/identity/pkg/object"
+	"github.com/anthrove/identity/pkg/repository"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"path/filepath"
+	"testing"
+)
+
+// newTestService creates an IdentityService with a migrated sqlite database and the admin tenant.
+func newTestService(t *testing.T) (IdentityService, object.Tenant) {
+	t.Helper()
+
+	// the casbin adapters of sqlite databases are stored in the working directory
+	dir := t.TempDir()
+	t.Chdir(dir)
+
+	db, err := gorm.Open(sqlite.Open(filepath.Join(dir, "identity.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("gorm.Open() error = %v", err)
+	}
+
+	err = repository.Migrate(db)
+	if err != nil {
+		t.Fatalf("repository.Migrate() error = %v", err)
+	}
+
+	is := NewIdentityService(db)
+
+	tenant, err := is.SetupAdminTenant(context.Background())
+	if err != nil {
+		t.Fatalf("SetupAdminTenant() error = %v", err)
+	}
+
+	return is, tenant
+}
+
+// updateTestTenant applies changes to a tenant and returns the stored tenant.
+func updateTestTenant(t *testing.T, is IdentityService, tenant object.Tenant, change func(updateTenant *object.UpdateTenant)) object.Tenant {
+	t.Helper()
+
+	updateTenant := object.UpdateTenant{
+		DisplayName:          tenant.DisplayName,
+		PasswordType:         tenant.PasswordType,
+		SigningCertificateID: *tenant.SigningCertificateID,
+		ProfileFields:        tenant.ProfileFields,
+	}
+	change(&updateTenant)
+
+	err := is.UpdateTenant(context.Background(), tenant.ID, updateTenant)
+	if err != nil {
+		t.Fatalf("UpdateTenant() error = %v", err)
+	}
+
+	tenant, err = is.FindTenant(context.Background(), tenant.ID)
+	if err != nil {
+		t.Fatalf("FindTenant() error = %v", err)
+	}
+
+	return tenant
+}
+
+// signUpTestUser opens the sign up of the tenant and signs up a user with the password "correct horse battery staple".
+func signUpTestUser(t *testing.T, is IdentityService, tenant object.Tenant, username string) object.User {
+	t.Helper()
+
+	ctx := context.Background()
+
+	if tenant.SignUpPolicy.Mode != object.SignUpModeOpen {
+		tenant = updateTestTenant(t, is, tenant, func(updateTenant *object.UpdateTenant) {
+			updateTenant.SignUpPolicy = &object.SignUpPolicy{Mode: object.SignUpModeOpen}
+		})
+	}
+
+	applications, err := is.FindApplications(ctx, tenant.ID, object.Pagination{Page: 1, Limit: 1})
+	if err != nil || len(applications) == 0 {
+		t.Fatalf("FindApplications() error = %v", err)
+	}
+
+	user, err := is.SignUp(ctx, tenant.ID, applications[0].ID, object.SignUp{
+		Username:    username,
+		DisplayName: username,
+		Email:       username + "@example.com",
+		Password:    "correct horse battery staple",
+	})
+	if err != nil {
+		t.Fatalf("SignUp() error = %v", err)
+	}
+
+	return user
+}
@@ -17,18 +17,27 @@
 package logic
 
 import (
+	"bytes"
 	"context"
-	"encoding/json"
+	"crypto/subtle"
 	"errors"
 	"fmt"
+	"github.com/anthrove/identity/pkg/crypto"
 	"github.com/anthrove/identity/pkg/object"
 	"github.com/anthrove/identity/pkg/provider/mfa"
 	"github.com/anthrove/identity/pkg/repository"
 	"github.com/anthrove/identity/pkg/util"
 	"github.com/go-playground/validator/v10"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+	"math"
 	"slices"
+	"strings"
+	"time"
 )
 
+const recoveryCodeAmount = 6
+
 // CreateMFA creates a new MFA for a specific User within a specified tenant.
 // It validates the input data using the validator package and returns an error if validation fails.
 // If validation passes, it calls the repository to create the MFA in the database.
@@ -63,24 +72,22 @@ func (is IdentityService) CreateMFA(ctx context.Context, tenantID string, userID
 		return object.MFACreationResponse{}, err
 	}
 
-	mfaData, err := mfaProvider.GenerateUserConfig(userID)
+	providerContext, err := is.mfaProviderContext(ctx, tenantID, userID, object.MFA{})
 	if err != nil {
 		return object.MFACreationResponse{}, err
 	}
 
-	var recoveryCodes []string
-
-	// This could be configurable, but i don't see the reason why. So I left this note here for a future dev to maybe implement.
-	for range 6 {
-		phrase, err := util.RandomPassPhrase(3, "-")
-		if err != nil {
-			return object.MFACreationResponse{}, err
-		}
-		recoveryCodes = append(recoveryCodes, phrase)
+	mfaData, err := mfaProvider.GenerateUserConfig(ctx, providerContext, createMFA.Metadata)
+	if err != nil {
+		return object.MFACreationResponse{}, err
+	}
 
+	recoveryCodes, hashedRecoveryCodes, err := generateRecoveryCodes()
+	if err != nil {
+		return object.MFACreationResponse{}, err
 	}
 
-	createMFA.RecoveryCodes = recoveryCodes
+	createMFA.RecoveryCodes = hashedRecoveryCodes
 	createMFA.Properties = mfaData.Properties
 
 	createdMFA, err := repository.CreateMFA(ctx, is.db, tenantID, userID, createMFA)
@@ -89,16 +96,17 @@ func (is IdentityService) CreateMFA(ctx context.Context, tenantID string, userID
 	}
 
 	return object.MFACreationResponse{
-		ID:          createdMFA.ID,
-		UserID:      createdMFA.UserID,
-		ProviderID:  createdMFA.ProviderID,
-		CreatedAt:   createdMFA.CreatedAt,
-		UpdatedAt:   createdMFA.UpdatedAt,
-		DisplayName: createdMFA.DisplayName,
-		Type:        createdMFA.Type,
-		Priority:    createdMFA.Priority,
-		Verified:    createdMFA.Verified,
-		Properties:  createdMFA.Properties,
+		ID:            createdMFA.ID,
+		UserID:        createdMFA.UserID,
+		ProviderID:    createdMFA.ProviderID,
+		CreatedAt:     createdMFA.CreatedAt,
+		UpdatedAt:     createdMFA.UpdatedAt,
+		DisplayName:   createdMFA.DisplayName,
+		Type:          createdMFA.Type,
+		Priority:      createdMFA.Priority,
+		Verified:      createdMFA.Verified,
+		Properties:    createdMFA.Properties,
+		RecoveryCodes: recoveryCodes,
 	}, nil
 }
 
@@ -181,6 +189,19 @@ func (is IdentityService) FindMFAs(ctx context.Context, tenantID string, userID
 	return repository.FindMFAs(ctx, is.db, tenantID, userID, pagination)
 }
 
+// FindVerifiedMFAs retrieves all verified MFAs of a user, ordered by their priority.
+//
+// Returns:
+//   - Slice of MFA objects if retrieval is successful.
+//   - Error if there is any issue during retrieval.
+func (is IdentityService) FindVerifiedMFAs(ctx context.Context, tenantID string, userID string) ([]object.MFA, error) {
+	if len(userID) == 0 {
+		return nil, errors.New("userID is required")
+	}
+
+	return repository.FindVerifiedMFAs(ctx, is.db, tenantID, userID)
+}
+
 // VerifyMFA updates the verification status of an existing MFA within a specified tenant in the database.
 //
 // Returns:
@@ -194,6 +215,18 @@ func (is IdentityService) VerifyMFA(ctx context.Context, tenantID string, userID
 		return errors.New("userID is required")
 	}
 
+	tenant, err := is.FindTenant(ctx, tenantID)
+
+	if err != nil {
+		return err
+	}
+
+	err = is.checkLockout(ctx, tenantID, object.LockoutScopeMFA, userID)
+
+	if err != nil {
+		return err
+	}
+
 	mfaObj, err := is.FindMFA(ctx, tenantID, userID, mfaID)
 
 	if err != nil {
@@ -209,47 +242,126 @@ func (is IdentityService) VerifyMFA(ctx context.Context, tenantID string, userID
 	mfaProvider, err := mfa.GetMFAProvider(providerObj)
 
 	if err != nil {
-		return errors.New(fmt.Sprintf("mfa provider not found: %s", mfaObj))
+		return errors.Join(fmt.Errorf("mfa provider not found: %s", mfaObj.ProviderID), err)
 	}
 
-	success, err := mfaProvider.ValidateDatFlow(mfaObj.Properties, body)
+	success, err := is.validateMFADataFlow(ctx, tenantID, mfaProvider, mfaObj, body)
 
-	if err != nil {
-		return errors.New(fmt.Sprintf("mfa validation error: %s", mfaObj))
+	if err == nil && !success {
+		err = errors.New("mfa validation failed")
 	}
 
-	if !success {
-		return errors.New("mfa validation failed")
+	if err != nil {
+		is.recordFailure(ctx, tenant, object.LockoutScopeMFA, userID)
+		return err
 	}
 
+	is.resetFailures(ctx, tenantID, object.LockoutScopeMFA, userID)
+
 	return repository.VerifieMFA(ctx, is.db, tenantID, userID, mfaID, true)
 }
 
-func (is IdentityService) UseRecoveryCode(ctx context.Context, tenantID string, userID string, mfaID string, recoveryCode string) (bool, error) {
+// UseRecoveryCode consumes a recovery code of an existing MFA. A recovery code can only be used once.
+//
+// Returns:
+//   - The amount of remaining recovery codes if the code was valid.
+//   - Error if the recovery code is invalid or there is any issue during updating.
+func (is IdentityService) UseRecoveryCode(ctx context.Context, tenantID string, userID string, mfaID string, recoveryCode string) (int, error) {
+	dbConn, _ := is.getDBConn(ctx)
+
+	if len(userID) == 0 {
+		return 0, errors.New("userID is required")
+	}
+
+	if len(mfaID) == 0 {
+		return 0, errors.New("mfaID is required")
+	}
+
+	remaining := 0
+
+	err := dbConn.Transaction(func(tx *gorm.DB) error {
+		// the MFA stays locked until the code is removed, so parallel requests can't use the same code
+		userMFA, err := repository.LockMFA(ctx, tx, tenantID, userID, mfaID)
+		if err != nil {
+			return err
+		}
+
+		recoveryCodeIndex := slices.IndexFunc(userMFA.RecoveryCodes, func(hashedCode string) bool {
+			return compareRecoveryCode(recoveryCode, hashedCode)
+		})
+
+		if recoveryCodeIndex == -1 {
+			return errors.New("recovery code is invalid")
+		}
+
+		// This deletes the uses recovery code
+		// https://stackoverflow.com/questions/37334119/how-to-delete-an-element-from-a-slice-in-golang
+		userMFA.RecoveryCodes[recoveryCodeIndex] = userMFA.RecoveryCodes[len(userMFA.RecoveryCodes)-1]
+		userMFA.RecoveryCodes = userMFA.RecoveryCodes[:len(userMFA.RecoveryCodes)-1]
+		remaining = len(userMFA.RecoveryCodes)
+
+		return repository.UpdateMFARecoveryCodes(ctx, tx, tenantID, userID, mfaID, userMFA.RecoveryCodes)
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	return remaining, nil
+}
+
+// FindRecoveryCodeCount returns how many unused recovery codes are left for an existing MFA.
+//
+// Returns:
+//   - Amount of remaining recovery codes.
+//   - Error if there is any issue during retrieval.
+func (is IdentityService) FindRecoveryCodeCount(ctx context.Context, tenantID string, userID string, mfaID string) (object.MFARecoveryCodeCount, error) {
 	userMFA, err := is.FindMFA(ctx, tenantID, userID, mfaID)
 	if err != nil {
-		return false, err
+		return object.MFARecoveryCodeCount{}, err
 	}
 
-	recoveryCodeIndex := slices.Index(userMFA.RecoveryCodes, recoveryCode)
+	return object.MFARecoveryCodeCount{
+		Remaining: len(userMFA.RecoveryCodes),
+	}, nil
+}
 
-	if recoveryCodeIndex == -1 {
-		return false, errors.New("mfaID is invalid")
+// RegenerateRecoveryCodes replaces all recovery codes of an existing MFA with a new set.
+// The new codes are only returned once, afterward only their hashes are stored.
+//
+// Returns:
+//   - The new recovery codes in plaintext.
+//   - Error if there is any issue during updating.
+func (is IdentityService) RegenerateRecoveryCodes(ctx context.Context, tenantID string, userID string, mfaID string) (object.MFARecoveryCodes, error) {
+	_, err := is.FindMFA(ctx, tenantID, userID, mfaID)
+	if err != nil {
+		return object.MFARecoveryCodes{}, err
 	}
 
-	// This deletes the uses recovery code
-	// https://stackoverflow.com/questions/37334119/how-to-delete-an-element-from-a-slice-in-golang
-	userMFA.RecoveryCodes[recoveryCodeIndex] = userMFA.RecoveryCodes[len(userMFA.RecoveryCodes)-1]
-	userMFA.RecoveryCodes = userMFA.RecoveryCodes[:len(userMFA.RecoveryCodes)-1]
+	recoveryCodes, hashedRecoveryCodes, err := generateRecoveryCodes()
+	if err != nil {
+		return object.MFARecoveryCodes{}, err
+	}
 
-	err = repository.UpdateMFARecoveryCodes(ctx, is.db, tenantID, userID, mfaID, userMFA.RecoveryCodes)
+	err = repository.UpdateMFARecoveryCodes(ctx, is.db, tenantID, userID, mfaID, hashedRecoveryCodes)
 	if err != nil {
-		return false, err
+		return object.MFARecoveryCodes{}, err
 	}
-	return true, nil
+
+	return object.MFARecoveryCodes{
+		RecoveryCodes: recoveryCodes,
+	}, nil
 }
 
-func (is IdentityService) MfaInitDataflow(ctx context.Context, tenantID string, userID string, mfaID string, body json.RawMessage) (map[string]any, error) {
+// MfaInitDataflow starts the validation of an existing MFA, e.g. by sending a one-time code to the user.
+// The changed provider data is saved, so the following MFaVerifyDataFlow can validate against it.
+//
+// Returns:
+//   - Data of the MFA provider for the user, e.g. where the code was sent to.
+//   - Error if there is any issue during the initialisation.
+func (is IdentityService) MfaInitDataflow(ctx context.Context, tenantID string, userID string, mfaID string) (map[string]any, error) {
+	dbConn, _ := is.getDBConn(ctx)
+
 	if len(tenantID) == 0 {
 		return nil, errors.New("tenantID is required")
 	}
@@ -274,13 +386,29 @@ func (is IdentityService) MfaInitDataflow(ctx context.Context, tenantID string,
 
 	mfaProvider, err := mfa.GetMFAProvider(providerObj)
 	if err != nil {
-		return nil, errors.New(fmt.Sprintf("mfa provider not found: %s", mfaObj))
+		return nil, errors.Join(fmt.Errorf("mfa provider not found: %s", mfaObj.ProviderID), err)
+	}
+
+	providerContext, err := is.mfaProviderContext(ctx, tenantID, userID, mfaObj)
+	if err != nil {
+		return nil, err
 	}
 
-	resp, err := mfaProvider.InitDataFlow(body)
+	mfaData, resp, err := mfaProvider.InitDataFlow(ctx, providerContext)
 
 	if err != nil {
-		return nil, errors.New(fmt.Sprintf("mfa init dataflow error: %s", mfaObj))
+		return nil, errors.Join(fmt.Errorf("mfa init dataflow error: %s", mfaObj.ID), err)
+	}
+
+	if !bytes.Equal(mfaData.Properties, mfaObj.Properties) {
+		updated, err := repository.UpdateMFAProperties(ctx, dbConn, tenantID, userID, mfaID, mfaObj.Properties, mfaData.Properties)
+		if err != nil {
+			return nil, err
+		}
+
+		if !updated {
+			return nil, fmt.Errorf("mfa %s was changed by another request", mfaObj.ID)
+		}
 	}
 
 	return resp, nil
@@ -313,14 +441,176 @@ func (is IdentityService) MFaVerifyDataFlow(ctx context.Context, tenantID string
 	mfaProvider, err := mfa.GetMFAProvider(providerObj)
 
 	if err != nil {
-		return false, errors.New(fmt.Sprintf("mfa provider not found: %s", mfaObj))
+		return false, errors.Join(fmt.Errorf("mfa provider not found: %s", mfaObj.ProviderID), err)
+	}
+
+	return is.validateMFADataFlow(ctx, tenantID, mfaProvider, mfaObj, body)
+}
+
+// validateMFADataFlow validates the data against the MFA provider and saves the changed provider data, also if the validation failed.
+// If the provider data was changed by another request in the meantime, e.g. a parallel guess, the validation fails.
+// If the accepted counter can't be stored because it was already used by another request, the validation fails.
+func (is IdentityService) validateMFADataFlow(ctx context.Context, tenantID string, mfaProvider mfa.Provider, mfaObj object.MFA, body map[string]any) (bool, error) {
+	dbConn, _ := is.getDBConn(ctx)
+
+	providerContext, err := is.mfaProviderContext(ctx, tenantID, mfaObj.UserID, mfaObj)
+	if err != nil {
+		return false, err
+	}
+
+	success, mfaData, validateErr := mfaProvider.ValidateDatFlow(ctx, providerContext, body)
+
+	if !bytes.Equal(mfaData.Properties, mfaObj.Properties) {
+		// the properties hold e.g. the remaining attempts and the pending code, a concurrent request may not reuse them
+		updated, err := repository.UpdateMFAProperties(ctx, dbConn, tenantID, mfaObj.UserID, mfaObj.ID, mfaObj.Properties, mfaData.Properties)
+		if err != nil {
+			return false, err
+		}
+
+		if !updated {
+			return false, fmt.Errorf("mfa %s was changed by another request", mfaObj.ID)
+		}
+	}
+
+	if validateErr != nil {
+		return false, errors.Join(fmt.Errorf("mfa validation error: %s", mfaObj.ID), validateErr)
+	}
+
+	if !success || mfaData.LastCounter == mfaObj.LastCounter {
+		return success, nil
+	}
+
+	return repository.UpdateMFALastCounter(ctx, dbConn, tenantID, mfaObj.UserID, mfaObj.ID, mfaData.LastCounter)
+}
+
+// mfaProviderContext collects everything a MFA provider needs to know about the user and how to send codes to them.
+func (is IdentityService) mfaProviderContext(ctx context.Context, tenantID string, userID string, mfaObj object.MFA) (mfa.ProviderContext, error) {
+	tenant, err := is.FindTenant(ctx, tenantID)
+	if err != nil {
+		return mfa.ProviderContext{}, err
+	}
+
+	user, err := is.FindUser(ctx, tenantID, userID)
+	if err != nil {
+		return mfa.ProviderContext{}, err
+	}
+
+	templateData := func(code string, expiresAt time.Time) map[string]any {
+		return map[string]any{
+			"TenantName":  tenant.DisplayName,
+			"DisplayName": user.DisplayName,
+			"Username":    user.Username,
+			"Code":        code,
+			"ExpiresAt":   expiresAt,
+			"ExpiresIn":   int(math.Ceil(time.Until(expiresAt).Minutes())),
+		}
+	}
+
+	return mfa.ProviderContext{
+		Tenant:  tenant,
+		User:    user,
+		MFA:     mfaObj,
+		Request: requestInfo(ctx),
+		SendMail: func(ctx context.Context, to string, code string, expiresAt time.Time) error {
+			return is.sendTemplateMail(ctx, tenantID, object.TemplateTypeMFACode, to, "Your sign in code", templateData(code, expiresAt))
+		},
+		SendSMS: func(ctx context.Context, to string, code string, expiresAt time.Time) error {
+			return is.sendTemplateSMS(ctx, tenantID, object.TemplateTypeMFACodeSMS, to, templateData(code, expiresAt))
+		},
+		PushApprovals: pushApprovalStore{is: is, tenantID: tenantID},
+	}, nil
+}
+
+// DecidePushApproval approves or denies a pending push approval. It is called by the companion app or chat bot
+// which received the approval through the webhook of the push MFA provider.
+//
+// Returns:
+//   - Error if the approval doesn't exist, is expired or was already decided.
+func (is IdentityService) DecidePushApproval(ctx context.Context, tenantID string, approvalID string, decision object.PushApprovalDecision) error {
+	if len(approvalID) == 0 {
+		return errors.New("approvalID is required")
+	}
+
+	err := validate.Struct(decision)
+
+	if err != nil {
+		var validateErrs validator.ValidationErrors
+		if errors.As(err, &validateErrs) {
+			return errors.Join(fmt.Errorf("problem while validating push approval decision"), util.ConvertValidationError(validateErrs))
+		}
 	}
 
-	success, err := mfaProvider.ValidateDatFlow(mfaObj.Properties, body)
+	return mfa.DecidePushApproval(ctx, pushApprovalStore{is: is, tenantID: tenantID}, approvalID, decision.Token, decision.Approve, decision.Number)
+}
+
+// pushApprovalStore keeps the push approvals of a tenant in the database, like the lockout counters they are shared by all replicas.
+type pushApprovalStore struct {
+	is       IdentityService
+	tenantID string
+}
+
+func (store pushApprovalStore) CreatePushApproval(ctx context.Context, approval object.PushApproval) error {
+	dbConn, _ := store.is.getDBConn(ctx)
 
+	err := repository.KillExpiredPushApprovals(ctx, dbConn, store.tenantID, time.Now())
 	if err != nil {
-		return false, errors.New(fmt.Sprintf("mfa validation error: %s", mfaObj))
+		return err
+	}
+
+	return repository.CreatePushApproval(ctx, dbConn, store.tenantID, approval)
+}
+
+func (store pushApprovalStore) FindPushApproval(ctx context.Context, approvalID string) (object.PushApproval, error) {
+	dbConn, _ := store.is.getDBConn(ctx)
+	return repository.FindPushApproval(ctx, dbConn, store.tenantID, approvalID)
+}
+
+func (store pushApprovalStore) UpdatePushApprovalDecision(ctx context.Context, approvalID string, from string, to string) (bool, error) {
+	dbConn, _ := store.is.getDBConn(ctx)
+	return repository.UpdatePushApprovalDecision(ctx, dbConn, store.tenantID, approvalID, from, to)
+}
+
+func (store pushApprovalStore) KillPushApproval(ctx context.Context, approvalID string) error {
+	dbConn, _ := store.is.getDBConn(ctx)
+	return repository.KillPushApproval(ctx, dbConn, store.tenantID, approvalID)
+}
+
+// generateRecoveryCodes creates a new set of diceware recovery codes.
+// It returns the codes in plaintext to show them once to the user and their hashes to store them.
+func generateRecoveryCodes() ([]string, []string, error) {
+	hasher := crypto.NewBcryptHasher(bcrypt.DefaultCost)
+
+	recoveryCodes := make([]string, 0, recoveryCodeAmount)
+	hashedRecoveryCodes := make([]string, 0, recoveryCodeAmount)
+
+	// This could be configurable, but i don't see the reason why. So I left this note here for a future dev to maybe implement.
+	for range recoveryCodeAmount {
+		phrase, err := util.RandomPassPhrase(3, "-")
+		if err != nil {
+			return nil, nil, err
+		}
+
+		hashedPhrase, err := hasher.HashPassword(phrase, "")
+		if err != nil {
+			return nil, nil, err
+		}
+
+		recoveryCodes = append(recoveryCodes, phrase)
+		hashedRecoveryCodes = append(hashedRecoveryCodes, hashedPhrase)
+	}
+
+	return recoveryCodes, hashedRecoveryCodes, nil
+}
+
+// compareRecoveryCode checks a recovery code given by the user against a stored one.
+// Codes created before they were hashed are still stored in plaintext, so those are compared directly.
+func compareRecoveryCode(recoveryCode string, storedCode string) bool {
+	recoveryCode = strings.ToLower(strings.TrimSpace(recoveryCode))
+
+	if !strings.HasPrefix(storedCode, "$2") {
+		return subtle.ConstantTimeCompare([]byte(recoveryCode), []byte(storedCode)) == 1
 	}
 
-	return success, nil
+	success, err := crypto.NewBcryptHasher(bcrypt.DefaultCost).ComparePassword(recoveryCode, storedCode, "")
+	return err == nil && success
 }
@@ -0,0 +1,275 @@
+/*
+ * Copyright (C) 2025 Anthrove
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logic
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/anthrove/identity/pkg/object"
+	"github.com/anthrove/identity/pkg/provider/mfa"
+	"github.com/anthrove/identity/pkg/repository"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPushApprovalStore(t *testing.T) {
+	ctx := context.Background()
+	is, tenant := newTestService(t)
+
+	store := pushApprovalStore{is: is, tenantID: tenant.ID}
+	otherStore := pushApprovalStore{is: is, tenantID: "other"}
+
+	expired := object.PushApproval{ID: "expired", Decision: "pending", ExpiresAt: time.Now().Add(-time.Minute)}
+	approval := object.PushApproval{ID: "approval", Decision: "pending", ExpiresAt: time.Now().Add(time.Minute)}
+
+	for _, pushApproval := range []object.PushApproval{expired, approval} {
+		if err := store.CreatePushApproval(ctx, pushApproval); err != nil {
+			t.Fatalf("CreatePushApproval(%s) error = %v", pushApproval.ID, err)
+		}
+	}
+
+	if _, err := store.FindPushApproval(ctx, expired.ID); err == nil {
+		t.Errorf("FindPushApproval() of an expired approval error = nil, want it to be removed")
+	}
+
+	if _, err := otherStore.FindPushApproval(ctx, approval.ID); err == nil {
+		t.Errorf("FindPushApproval() of another tenant error = nil, want error")
+	}
+
+	// only the first decision is stored, also if it was made on another replica
+	for i, want := range []bool{true, false} {
+		updated, err := store.UpdatePushApprovalDecision(ctx, approval.ID, "pending", "approved")
+		if err != nil || updated != want {
+			t.Errorf("UpdatePushApprovalDecision() #%d = %t, %v, want %t", i, updated, err, want)
+		}
+	}
+
+	found, err := store.FindPushApproval(ctx, approval.ID)
+	if err != nil || found.Decision != "approved" {
+		t.Errorf("FindPushApproval() = %+v, %v, want the approved approval", found, err)
+	}
+
+	if err = store.KillPushApproval(ctx, approval.ID); err != nil {
+		t.Fatalf("KillPushApproval() error = %v", err)
+	}
+
+	if _, err = store.FindPushApproval(ctx, approval.ID); err == nil {
+		t.Errorf("FindPushApproval() after KillPushApproval() error = nil, want error")
+	}
+}
+
+// staleMFAProvider accepts every code once, like the otp provider it removes the code from the properties.
+type staleMFAProvider struct {
+	mfa.Provider
+}
+
+func (p staleMFAProvider) ValidateDatFlow(ctx context.Context, providerContext mfa.ProviderContext, data map[string]any) (bool, object.MFAProviderData, error) {
+	return true, object.MFAProviderData{Properties: json.RawMessage(`{"code":""}`), LastCounter: providerContext.MFA.LastCounter}, nil
+}
+
+func TestValidateMFADataFlowConcurrentChange(t *testing.T) {
+	ctx := context.Background()
+	is, tenant := newTestService(t)
+	user := signUpTestUser(t, is, tenant, "jane")
+
+	mfaObj, err := repository.CreateMFA(ctx, is.db, tenant.ID, user.ID, object.CreateMFA{
+		ProviderID:  "provider",
+		DisplayName: "Email",
+		Type:        "email_otp",
+		Priority:    1,
+		Properties:  json.RawMessage(`{"code":"4711"}`),
+	})
+	if err != nil {
+		t.Fatalf("CreateMFA() error = %v", err)
+	}
+
+	success, err := is.validateMFADataFlow(ctx, tenant.ID, staleMFAProvider{}, mfaObj, nil)
+	if !success || err != nil {
+		t.Fatalf("validateMFADataFlow() = %t, %v, want success", success, err)
+	}
+
+	// a parallel request read the mfa before the code was removed, it must not be accepted a second time
+	success, err = is.validateMFADataFlow(ctx, tenant.ID, staleMFAProvider{}, mfaObj, nil)
+	if success || err == nil {
+		t.Errorf("validateMFADataFlow() with outdated properties = %t, %v, want error", success, err)
+	}
+}
+
+func TestRecoveryCodeHashing(t *testing.T) {
+	recoveryCodes, hashedRecoveryCodes, err := generateRecoveryCodes()
+	if err != nil {
+		t.Fatalf("generateRecoveryCodes() error = %v", err)
+	}
+
+	if len(recoveryCodes) != recoveryCodeAmount || len(hashedRecoveryCodes) != recoveryCodeAmount {
+		t.Fatalf("generateRecoveryCodes() returned %d codes and %d hashes, want %d", len(recoveryCodes), len(hashedRecoveryCodes), recoveryCodeAmount)
+	}
+
+	for i, recoveryCode := range recoveryCodes {
+		if hashedRecoveryCodes[i] == recoveryCode || !strings.HasPrefix(hashedRecoveryCodes[i], "$2") {
+			t.Errorf("generateRecoveryCodes() stored code %d = %q, want a bcrypt hash", i, hashedRecoveryCodes[i])
+		}
+	}
+
+	tests := []struct {
+		recoveryCode string
+		storedCode   string
+		want         bool
+	}{
+		{recoveryCode: recoveryCodes[0], storedCode: hashedRecoveryCodes[0], want: true},
+		{recoveryCode: " " + strings.ToUpper(recoveryCodes[0]) + " ", storedCode: hashedRecoveryCodes[0], want: true},
+		{recoveryCode: recoveryCodes[1], storedCode: hashedRecoveryCodes[0], want: false},
+		{recoveryCode: hashedRecoveryCodes[0], storedCode: hashedRecoveryCodes[0], want: false},
+		// codes from before the hashing are stored in plaintext
+		{recoveryCode: "correct-horse-battery", storedCode: "correct-horse-battery", want: true},
+		{recoveryCode: "correct-horse-staple", storedCode: "correct-horse-battery", want: false},
+	}
+
+	for i, test := range tests {
+		if got := compareRecoveryCode(test.recoveryCode, test.storedCode); got != test.want {
+			t.Errorf("test %d: compareRecoveryCode() = %t, want %t", i, got, test.want)
+		}
+	}
+}
+
+// createRecoveryCodeMFA creates a verified MFA of the user with new recovery codes and returns it with the codes.
+func createRecoveryCodeMFA(t *testing.T, is IdentityService, tenant object.Tenant, user object.User) (object.MFA, []string) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	recoveryCodes, hashedRecoveryCodes, err := generateRecoveryCodes()
+	if err != nil {
+		t.Fatalf("generateRecoveryCodes() error = %v", err)
+	}
+
+	mfaObj, err := repository.CreateMFA(ctx, is.db, tenant.ID, user.ID, object.CreateMFA{
+		ProviderID:    "provider",
+		DisplayName:   "Authenticator App",
+		Type:          "totp",
+		Priority:      1,
+		RecoveryCodes: hashedRecoveryCodes,
+		Properties:    json.RawMessage(`{}`),
+	})
+	if err != nil {
+		t.Fatalf("CreateMFA() error = %v", err)
+	}
+
+	err = repository.VerifieMFA(ctx, is.db, tenant.ID, user.ID, mfaObj.ID, true)
+	if err != nil {
+		t.Fatalf("VerifieMFA() error = %v", err)
+	}
+
+	return mfaObj, recoveryCodes
+}
+
+func TestUseRecoveryCode(t *testing.T) {
+	ctx := context.Background()
+	is, tenant := newTestService(t)
+	user := signUpTestUser(t, is, tenant, "jane")
+	mfaObj, recoveryCodes := createRecoveryCodeMFA(t, is, tenant, user)
+
+	remaining, err := is.UseRecoveryCode(ctx, tenant.ID, user.ID, mfaObj.ID, recoveryCodes[0])
+	if err != nil || remaining != recoveryCodeAmount-1 {
+		t.Fatalf("UseRecoveryCode() = %d, %v, want %d", remaining, err, recoveryCodeAmount-1)
+	}
+
+	if _, err = is.UseRecoveryCode(ctx, tenant.ID, user.ID, mfaObj.ID, recoveryCodes[0]); err == nil {
+		t.Errorf("UseRecoveryCode() with a used code error = nil, want error")
+	}
+
+	if _, err = is.UseRecoveryCode(ctx, tenant.ID, "other", mfaObj.ID, recoveryCodes[1]); err == nil {
+		t.Errorf("UseRecoveryCode() of another user error = nil, want error")
+	}
+
+	count, err := is.FindRecoveryCodeCount(ctx, tenant.ID, user.ID, mfaObj.ID)
+	if err != nil || count.Remaining != recoveryCodeAmount-1 {
+		t.Errorf("FindRecoveryCodeCount() = %d, %v, want %d", count.Remaining, err, recoveryCodeAmount-1)
+	}
+
+	regenerated, err := is.RegenerateRecoveryCodes(ctx, tenant.ID, user.ID, mfaObj.ID)
+	if err != nil || len(regenerated.RecoveryCodes) != recoveryCodeAmount {
+		t.Fatalf("RegenerateRecoveryCodes() = %v, %v, want %d codes", regenerated.RecoveryCodes, err, recoveryCodeAmount)
+	}
+
+	stored, err := is.FindMFA(ctx, tenant.ID, user.ID, mfaObj.ID)
+	if err != nil {
+		t.Fatalf("FindMFA() error = %v", err)
+	}
+
+	if slices.ContainsFunc(stored.RecoveryCodes, func(storedCode string) bool { return slices.Contains(regenerated.RecoveryCodes, storedCode) }) {
+		t.Errorf("RegenerateRecoveryCodes() stored the codes in plaintext")
+	}
+
+	if _, err = is.UseRecoveryCode(ctx, tenant.ID, user.ID, mfaObj.ID, recoveryCodes[1]); err == nil {
+		t.Errorf("UseRecoveryCode() with a replaced code error = nil, want error")
+	}
+
+	// every code can be used until none is left
+	for i, recoveryCode := range regenerated.RecoveryCodes {
+		remaining, err = is.UseRecoveryCode(ctx, tenant.ID, user.ID, mfaObj.ID, recoveryCode)
+		if err != nil || remaining != recoveryCodeAmount-i-1 {
+			t.Errorf("UseRecoveryCode() of new code %d = %d, %v, want %d", i, remaining, err, recoveryCodeAmount-i-1)
+		}
+	}
+}
+
+func TestSignInMFARecoveryCode(t *testing.T) {
+	ctx := context.WithValue(context.Background(), "request_info", object.RequestInfo{IPAddress: "192.0.2.1"})
+	is, tenant := newTestService(t)
+	user := signUpTestUser(t, is, tenant, "jane")
+	_, recoveryCodes := createRecoveryCodeMFA(t, is, tenant, user)
+	otherMFA, otherRecoveryCodes := createRecoveryCodeMFA(t, is, tenant, user)
+
+	applications, err := is.FindApplications(ctx, tenant.ID, object.Pagination{Page: 1, Limit: 1})
+	if err != nil || len(applications) == 0 {
+		t.Fatalf("FindApplications() error = %v", err)
+	}
+
+	signIn := func(sessionID string, signInData object.SignInMFARequest) (object.SignInResponse, error) {
+		is.UpdateSession(ctx, sessionID, map[string]any{
+			"tenant_id":      tenant.ID,
+			"application_id": applications[0].ID,
+			"user":           user,
+			"logged_in":      false,
+			"mfa_required":   true,
+		})
+		t.Cleanup(func() { is.KillSession(ctx, sessionID) })
+
+		return is.SignInMFA(ctx, tenant.ID, applications[0].ID, sessionID, signInData)
+	}
+
+	// without a MFA, the recovery codes of all MFAs are checked
+	response, err := signIn("recovery-all", object.SignInMFARequest{RecoveryCode: otherRecoveryCodes[0]})
+	if err != nil || response.Step != object.SignInStepDone {
+		t.Fatalf("SignInMFA() with a recovery code = %+v, %v, want step %s", response, err, object.SignInStepDone)
+	}
+
+	if session := is.FindSession(ctx, "recovery-all"); session["logged_in"] != true {
+		t.Errorf("SignInMFA() with a recovery code didn't log in the session")
+	}
+
+	if _, err = signIn("recovery-used", object.SignInMFARequest{RecoveryCode: otherRecoveryCodes[0]}); err == nil {
+		t.Errorf("SignInMFA() with a used recovery code error = nil, want error")
+	}
+
+	if _, err = signIn("recovery-other", object.SignInMFARequest{MFAID: otherMFA.ID, RecoveryCode: recoveryCodes[0]}); err == nil {
+		t.Errorf("SignInMFA() with a recovery code of another MFA error = nil, want error")
+	}
+}
@@ -0,0 +1,262 @@
+/*
+ * Copyright (C) 2025 Anthrove
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/anthrove/identity/pkg/object"
+	"github.com/anthrove/identity/pkg/provider/auth"
+	"github.com/anthrove/identity/pkg/util"
+	"github.com/go-playground/validator/v10"
+	"math"
+)
+
+// ChangePassword changes the password of a user after checking the current password.
+// The new password has to follow the rules of the password provider, including its history and minimum age.
+// Wrong current passwords are throttled like failed sign ins, so a stolen session can't be used to guess the password.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancellation, and deadlines.
+//   - tenantID: unique identifier of the tenant to which the user belongs.
+//   - userID: unique identifier of the user.
+//   - changePassword: object containing the current and the new password.
+//
+// Returns:
+//   - Error if the current password is wrong or the new password is rejected, ErrTooManyAttempts if the user is throttled.
+func (is IdentityService) ChangePassword(ctx context.Context, tenantID string, userID string, changePassword object.ChangePassword) error {
+	err := validate.Struct(changePassword)
+
+	if err != nil {
+		var validateErrs validator.ValidationErrors
+		if errors.As(err, &validateErrs) {
+			return errors.Join(fmt.Errorf("problem while validating change password data"), util.ConvertValidationError(validateErrs))
+		}
+	}
+
+	tenant, err := is.FindTenant(ctx, tenantID)
+
+	if err != nil {
+		return err
+	}
+
+	user, err := is.FindUser(ctx, tenantID, userID)
+
+	if err != nil {
+		return err
+	}
+
+	err = is.verifyCurrentPassword(ctx, tenant, user, changePassword.CurrentPassword)
+
+	if err != nil {
+		return err
+	}
+
+	return is.setPassword(ctx, tenant, user, changePassword.NewPassword, false)
+}
+
+// verifyCurrentPassword checks the password of a signed in user before a sensitive change of the account.
+// Wrong passwords are throttled like the ones of a sign in.
+func (is IdentityService) verifyCurrentPassword(ctx context.Context, tenant object.Tenant, user object.User, password string) error {
+	err := is.checkLockout(ctx, tenant.ID, object.LockoutScopePassword, user.ID)
+
+	if err != nil {
+		return err
+	}
+
+	credential, passwordProvider, err := is.findPasswordCredential(ctx, tenant.ID, user.ID)
+
+	if err != nil {
+		return err
+	}
+
+	success, err := passwordProvider.Submit(ctx, auth.ProviderContext{
+		Tenant:     tenant,
+		User:       user,
+		Credential: credential,
+	}, map[string]any{
+		"password": password,
+	})
+
+	if err != nil || !success {
+		is.recordFailure(ctx, tenant, object.LockoutScopePassword, user.ID)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if !success {
+		return errors.New("current password is incorrect")
+	}
+
+	is.resetFailures(ctx, tenant.ID, object.LockoutScopePassword, user.ID)
+
+	return nil
+}
+
+// RequirePasswordChange forces the user to set a new password on the next sign in.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancellation, and deadlines.
+//   - tenantID: unique identifier of the tenant to which the user belongs.
+//   - userID: unique identifier of the user.
+//
+// Returns:
+//   - Error if the user has no password credential.
+func (is IdentityService) RequirePasswordChange(ctx context.Context, tenantID string, userID string) error {
+	credential, _, err := is.findPasswordCredential(ctx, tenantID, userID)
+
+	if err != nil {
+		return err
+	}
+
+	var metadata map[string]any
+	err = json.Unmarshal(credential.Metadata, &metadata)
+
+	if err != nil {
+		return err
+	}
+
+	metadata["change_required"] = true
+	metadata["change_reason"] = "required by administrator"
+
+	err = is.UpdateCredential(ctx, tenantID, credential.ID, object.UpdateCredential{
+		Metadata: metadata,
+		Enabled:  credential.Enabled,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	is.recordAuditEvent(ctx, tenantID, object.AuditEventPasswordChangeRequired, userID, nil)
+	return nil
+}
+
+// setPassword hashes the new password with the password provider of the tenant and replaces the password credential of the user.
+// A required change, like a reset, isn't subject to the minimum password age.
+func (is IdentityService) setPassword(ctx context.Context, tenant object.Tenant, user object.User, password string, required bool) error {
+	credential, passwordProvider, err := is.findPasswordCredential(ctx, tenant.ID, user.ID)
+
+	if err != nil {
+		return err
+	}
+
+	if required {
+		credential.Metadata, err = markChangeRequired(credential.Metadata)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	metadata, err := passwordProvider.Configure(ctx, auth.ProviderContext{
+		Tenant:     tenant,
+		User:       user,
+		Credential: credential,
+	}, map[string]any{
+		"password": password,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	err = is.UpdateCredential(ctx, tenant.ID, credential.ID, object.UpdateCredential{
+		Metadata: metadata,
+		Enabled:  credential.Enabled,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	is.recordAuditEvent(ctx, tenant.ID, object.AuditEventPasswordChanged, user.ID, nil)
+	return nil
+}
+
+// findPasswordCredential returns the password credential of the user and the password provider of the tenant.
+func (is IdentityService) findPasswordCredential(ctx context.Context, tenantID string, userID string) (object.Credentials, auth.Provider, error) {
+	credentials, err := is.FindCredentialsByUser(ctx, tenantID, userID)
+
+	if err != nil {
+		return object.Credentials{}, nil, err
+	}
+
+	var passwordCredential object.Credentials
+	for _, credential := range credentials {
+		if credential.Type == "password" {
+			passwordCredential = credential
+			break
+		}
+	}
+
+	if len(passwordCredential.ID) == 0 {
+		return object.Credentials{}, nil, errors.New("user has no password credential")
+	}
+
+	providers, err := is.FindProviders(ctx, tenantID, object.Pagination{
+		Limit: math.MaxInt,
+		Page:  0,
+	})
+
+	if err != nil {
+		return object.Credentials{}, nil, err
+	}
+
+	for _, provider := range providers {
+		if provider.ProviderType == "password" {
+			passwordProvider, err := auth.GetAuthProvider(provider)
+			return passwordCredential, passwordProvider, err
+		}
+	}
+
+	return object.Credentials{}, nil, errors.New("no password provider configured in tenant")
+}
+
+// passwordChangeRequired reports if the password credential was flagged for a change, because it expired, is too weak
+// or an administrator requires it.
+func passwordChangeRequired(credential object.Credentials) bool {
+	if credential.Type != "password" {
+		return false
+	}
+
+	var metadata struct {
+		ChangeRequired bool `json:"change_required"`
+	}
+
+	err := json.Unmarshal(credential.Metadata, &metadata)
+
+	return err == nil && metadata.ChangeRequired
+}
+
+// markChangeRequired returns the password credential metadata with the change required flag set.
+func markChangeRequired(metadata json.RawMessage) (json.RawMessage, error) {
+	var data map[string]any
+	err := json.Unmarshal(metadata, &data)
+
+	if err != nil {
+		return nil, err
+	}
+
+	data["change_required"] = true
+
+	return json.Marshal(data)
+}
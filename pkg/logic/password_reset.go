@@ -0,0 +1,406 @@
+/*
+ * Copyright (C) 2025 Anthrove
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/anthrove/identity/pkg/object"
+	"github.com/anthrove/identity/pkg/repository"
+	"github.com/anthrove/identity/pkg/util"
+	"github.com/go-jose/go-jose/v4"
+	"github.com/go-playground/validator/v10"
+	"log"
+	"net/url"
+	"time"
+)
+
+const (
+	// passwordResetLifetime is how long a reset token can be used.
+	passwordResetLifetime = 30 * time.Minute
+	// passwordResetLimit is the number of resets a user can request within passwordResetLimitWindow, so nobody can flood the inbox of a user.
+	passwordResetLimit       = 3
+	passwordResetLimitWindow = 15 * time.Minute
+
+	passwordResetPurpose = "password_reset"
+	// passwordResetTokenType is the typ header of reset tokens, so other tokens signed with the key of the tenant,
+	// e.g. OIDC tokens, can't be used as reset token.
+	passwordResetTokenType = "reset+jwt"
+)
+
+// passwordResetClaims are the claims of a signed reset token.
+type passwordResetClaims struct {
+	ID          string `json:"jti"`
+	TenantID    string `json:"tid"`
+	Application string `json:"aud"`
+	Subject     string `json:"sub"`
+	Purpose     string `json:"purpose"`
+	ExpiresAt   int64  `json:"exp"`
+}
+
+// RequestPasswordReset sends a password reset mail to the user with the username or verified email.
+// It doesn't tell if a user was found, so it can't be used to find out which accounts exist.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancellation, and deadlines.
+//   - tenantID: unique identifier of the tenant to which the user belongs.
+//   - applicationID: unique identifier of the application whose forget url is linked in the mail.
+//   - requestPasswordReset: object containing the username or email of the user.
+//
+// Returns:
+//   - Error if the application can't reset passwords or there is any issue during validation.
+func (is IdentityService) RequestPasswordReset(ctx context.Context, tenantID string, applicationID string, requestPasswordReset object.RequestPasswordReset) error {
+	err := validate.Struct(requestPasswordReset)
+
+	if err != nil {
+		var validateErrs validator.ValidationErrors
+		if errors.As(err, &validateErrs) {
+			return errors.Join(fmt.Errorf("problem while validating password reset data"), util.ConvertValidationError(validateErrs))
+		}
+	}
+
+	tenant, err := is.FindTenant(ctx, tenantID)
+
+	if err != nil {
+		return err
+	}
+
+	application, err := is.FindApplication(ctx, tenantID, applicationID)
+
+	if err != nil {
+		return err
+	}
+
+	if len(application.ForgetURL) == 0 {
+		return errors.New("application has no forget url configured")
+	}
+
+	var users []object.User
+	if len(requestPasswordReset.Username) > 0 {
+		user, err := is.FindUserByUsername(ctx, tenantID, requestPasswordReset.Username)
+
+		if err == nil {
+			users = append(users, user)
+		}
+	} else {
+		emailUsers, err := is.FindUsersByEmail(ctx, tenantID, requestPasswordReset.Email)
+
+		if err != nil {
+			return err
+		}
+
+		// an unverified email could belong to somebody else
+		for _, user := range emailUsers {
+			if user.EmailVerified {
+				users = append(users, user)
+			}
+		}
+	}
+
+	for _, user := range users {
+		err = is.sendPasswordReset(ctx, tenant, application, user)
+
+		if err != nil {
+			// the response is the same for every request, problems are only visible in the log
+			log.Printf("problem while sending password reset to user %s: %v", user.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// CompletePasswordReset sets the new password of the user the reset token was sent to.
+// The password has to follow the rules of the password provider. All sessions and tokens of the user are revoked afterward.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancellation, and deadlines.
+//   - tenantID: unique identifier of the tenant to which the user belongs.
+//   - applicationID: unique identifier of the application the reset was requested for.
+//   - completePasswordReset: object containing the reset token and the new password.
+//
+// Returns:
+//   - Error if the token is invalid, expired or already used or the password is rejected.
+func (is IdentityService) CompletePasswordReset(ctx context.Context, tenantID string, applicationID string, completePasswordReset object.CompletePasswordReset) error {
+	dbConn, nested := is.getDBConn(ctx)
+
+	err := validate.Struct(completePasswordReset)
+
+	if err != nil {
+		var validateErrs validator.ValidationErrors
+		if errors.As(err, &validateErrs) {
+			return errors.Join(fmt.Errorf("problem while validating password reset data"), util.ConvertValidationError(validateErrs))
+		}
+	}
+
+	tenant, err := is.FindTenant(ctx, tenantID)
+
+	if err != nil {
+		return err
+	}
+
+	claims, err := is.verifyPasswordResetToken(ctx, tenant, completePasswordReset.Token)
+
+	if err != nil {
+		return errors.Join(errors.New("password reset token is invalid"), err)
+	}
+
+	if claims.Application != applicationID {
+		return errors.New("password reset token belongs to another application")
+	}
+
+	passwordReset, err := is.FindPasswordReset(ctx, tenantID, claims.ID)
+
+	if err != nil || passwordReset.UserID != claims.Subject {
+		return errors.New("password reset token is invalid")
+	}
+
+	user, err := is.FindUser(ctx, tenantID, passwordReset.UserID)
+
+	if err != nil {
+		return err
+	}
+
+	var tx = dbConn
+	var txCtx = ctx
+	if !nested {
+		tx = dbConn.Begin()
+		txCtx = saveDBConn(ctx, tx)
+		defer func() {
+			if r := recover(); r != nil {
+				tx.Rollback()
+			}
+		}()
+	}
+
+	// the reset is only used up if the new password is accepted, otherwise the user can try another password
+	err = repository.UsePasswordReset(txCtx, tx, tenantID, passwordReset.ID)
+
+	if err != nil {
+		if !nested {
+			tx.Rollback()
+		}
+		return errors.New("password reset token was already used or is expired")
+	}
+
+	err = is.setPassword(txCtx, tenant, user, completePasswordReset.Password, true)
+
+	if err != nil {
+		if !nested {
+			tx.Rollback()
+		}
+		return err
+	}
+
+	err = repository.ExpireUserPasswordResets(txCtx, tx, tenantID, user.ID)
+
+	if err != nil {
+		if !nested {
+			tx.Rollback()
+		}
+		return err
+	}
+
+	err = is.KillUserTokens(txCtx, tenantID, user.ID)
+
+	if err != nil {
+		if !nested {
+			tx.Rollback()
+		}
+		return err
+	}
+
+	if !nested {
+		err = tx.Commit().Error
+		if err != nil {
+			return err
+		}
+	}
+
+	is.killUserSessions(tenantID, user.ID)
+	is.resetFailures(ctx, tenantID, object.LockoutScopePassword, user.ID)
+	is.recordAuditEvent(ctx, tenantID, object.AuditEventPasswordReset, user.ID, map[string]any{
+		"application_id": applicationID,
+	})
+
+	return nil
+}
+
+// FindPasswordReset retrieves a password reset of a tenant by its ID.
+func (is IdentityService) FindPasswordReset(ctx context.Context, tenantID string, passwordResetID string) (object.PasswordReset, error) {
+	dbConn, _ := is.getDBConn(ctx)
+
+	return repository.FindPasswordReset(ctx, dbConn, tenantID, passwordResetID)
+}
+
+// sendPasswordReset creates a password reset for the user and mails the link to the forget url of the application.
+func (is IdentityService) sendPasswordReset(ctx context.Context, tenant object.Tenant, application object.Application, user object.User) error {
+	dbConn, _ := is.getDBConn(ctx)
+
+	if len(user.Email) == 0 {
+		return errors.New("user has no email")
+	}
+
+	recentResets, err := repository.CountRecentPasswordResets(ctx, dbConn, tenant.ID, user.ID, time.Now().Add(-passwordResetLimitWindow))
+
+	if err != nil {
+		return err
+	}
+
+	if recentResets >= passwordResetLimit {
+		return errors.New("too many password resets requested")
+	}
+
+	passwordReset, err := repository.CreatePasswordReset(ctx, dbConn, tenant.ID, user.ID, object.CreatePasswordReset{
+		ApplicationID: application.ID,
+		ExpiresAt:     time.Now().Add(passwordResetLifetime),
+		IPAddress:     requestInfo(ctx).IPAddress,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	token, err := is.signPasswordResetToken(ctx, tenant, passwordResetClaims{
+		ID:          passwordReset.ID,
+		TenantID:    tenant.ID,
+		Application: application.ID,
+		Subject:     user.ID,
+		Purpose:     passwordResetPurpose,
+		ExpiresAt:   passwordReset.ExpiresAt.Unix(),
+	})
+
+	if err != nil {
+		return err
+	}
+
+	resetLink, err := url.Parse(application.ForgetURL)
+
+	if err != nil {
+		return err
+	}
+
+	query := resetLink.Query()
+	query.Set("token", token)
+	resetLink.RawQuery = query.Encode()
+
+	branding, err := is.mailBranding(ctx, tenant.ID, application.ID)
+
+	if err != nil {
+		return err
+	}
+
+	return is.sendTemplateMail(ctx, tenant.ID, object.TemplateTypePasswordReset, user.Email, "Reset your password", map[string]any{
+		"DisplayName": user.DisplayName,
+		"Username":    user.Username,
+		"TenantName":  tenant.DisplayName,
+		"ResetLink":   resetLink.String(),
+		"ExpiresAt":   passwordReset.ExpiresAt,
+		"Branding":    branding,
+	})
+}
+
+// signPasswordResetToken signs the claims with the signing certificate of the tenant, the same key which signs the OIDC tokens.
+func (is IdentityService) signPasswordResetToken(ctx context.Context, tenant object.Tenant, claims passwordResetClaims) (string, error) {
+	if tenant.SigningCertificateID == nil {
+		return "", errors.New("tenant has no signing certificate")
+	}
+
+	certificate, err := is.FindCertificate(ctx, tenant.ID, *tenant.SigningCertificateID)
+
+	if err != nil {
+		return "", err
+	}
+
+	signingKey := certificate.ToSigningCert().Key()
+
+	if signingKey == nil {
+		return "", errors.New("signing certificate has no usable private key")
+	}
+
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: certificate.Algorithm(), Key: signingKey}, (&jose.SignerOptions{}).WithHeader(jose.HeaderKey("kid"), certificate.ID()).WithType(passwordResetTokenType))
+
+	if err != nil {
+		return "", err
+	}
+
+	payload, err := json.Marshal(claims)
+
+	if err != nil {
+		return "", err
+	}
+
+	signature, err := signer.Sign(payload)
+
+	if err != nil {
+		return "", err
+	}
+
+	return signature.CompactSerialize()
+}
+
+// verifyPasswordResetToken checks the type, signature and expiry of a reset token and returns its claims.
+func (is IdentityService) verifyPasswordResetToken(ctx context.Context, tenant object.Tenant, token string) (passwordResetClaims, error) {
+	signature, err := jose.ParseSigned(token, []jose.SignatureAlgorithm{jose.RS256, jose.RS384, jose.RS512, jose.ES256, jose.ES384, jose.ES512})
+
+	if err != nil {
+		return passwordResetClaims{}, err
+	}
+
+	if len(signature.Signatures) != 1 {
+		return passwordResetClaims{}, errors.New("token has to have exactly one signature")
+	}
+
+	if tokenType, _ := signature.Signatures[0].Protected.ExtraHeaders[jose.HeaderType].(string); tokenType != passwordResetTokenType {
+		return passwordResetClaims{}, errors.New("token is no password reset token")
+	}
+
+	// the key id allows tokens to be verified after the signing certificate of the tenant was changed
+	certificate, err := is.FindCertificate(ctx, tenant.ID, signature.Signatures[0].Header.KeyID)
+
+	if err != nil {
+		return passwordResetClaims{}, err
+	}
+
+	if signature.Signatures[0].Header.Algorithm != string(certificate.Algorithm()) {
+		return passwordResetClaims{}, errors.New("token was signed with another algorithm")
+	}
+
+	payload, err := signature.Verify(certificate.Key())
+
+	if err != nil {
+		return passwordResetClaims{}, err
+	}
+
+	var claims passwordResetClaims
+	err = json.Unmarshal(payload, &claims)
+
+	if err != nil {
+		return passwordResetClaims{}, err
+	}
+
+	if claims.Purpose != passwordResetPurpose || claims.TenantID != tenant.ID {
+		return passwordResetClaims{}, errors.New("token is no password reset of this tenant")
+	}
+
+	if time.Now().After(time.Unix(claims.ExpiresAt, 0)) {
+		return passwordResetClaims{}, errors.New("token is expired")
+	}
+
+	return claims, nil
+}
@@ -0,0 +1,72 @@
+/*
+ * Copyright (C) 2025 Anthrove
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logic
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/go-jose/go-jose/v4"
+	"testing"
+	"time"
+)
+
+func TestVerifyPasswordResetToken(t *testing.T) {
+	ctx := context.Background()
+	is, tenant := newTestService(t)
+
+	claims := passwordResetClaims{
+		ID:          "reset",
+		TenantID:    tenant.ID,
+		Application: "application",
+		Subject:     "user",
+		Purpose:     passwordResetPurpose,
+		ExpiresAt:   time.Now().Add(time.Minute).Unix(),
+	}
+
+	token, err := is.signPasswordResetToken(ctx, tenant, claims)
+	if err != nil {
+		t.Fatalf("signPasswordResetToken() error = %v", err)
+	}
+
+	got, err := is.verifyPasswordResetToken(ctx, tenant, token)
+	if err != nil || got != claims {
+		t.Errorf("verifyPasswordResetToken() = %+v, %v, want %+v", got, err, claims)
+	}
+
+	// another token signed with the key of the tenant, e.g. an OIDC token, has the same claims but not the type
+	certificate, err := is.FindCertificate(ctx, tenant.ID, *tenant.SigningCertificateID)
+	if err != nil {
+		t.Fatalf("FindCertificate() error = %v", err)
+	}
+
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: certificate.Algorithm(), Key: certificate.ToSigningCert().Key()}, (&jose.SignerOptions{}).WithHeader(jose.HeaderKey("kid"), certificate.ID()).WithType("JWT"))
+	if err != nil {
+		t.Fatalf("jose.NewSigner() error = %v", err)
+	}
+
+	payload, _ := json.Marshal(claims)
+	signature, err := signer.Sign(payload)
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+
+	otherToken, _ := signature.CompactSerialize()
+
+	if _, err = is.verifyPasswordResetToken(ctx, tenant, otherToken); err == nil {
+		t.Errorf("verifyPasswordResetToken() of a token with another type error = nil, want error")
+	}
+}
@@ -0,0 +1,65 @@
+/*
+ * Copyright (C) 2025 Anthrove
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logic
+
+import (
+	"context"
+	"errors"
+	"github.com/anthrove/identity/pkg/object"
+	"testing"
+)
+
+func TestChangePasswordLockout(t *testing.T) {
+	is, tenant := newTestService(t)
+	ctx := context.WithValue(context.Background(), "request_info", object.RequestInfo{IPAddress: "192.0.2.1"})
+
+	tenant = updateTestTenant(t, is, tenant, func(updateTenant *object.UpdateTenant) {
+		updateTenant.SignUpPolicy = &object.SignUpPolicy{Mode: object.SignUpModeOpen}
+	})
+
+	applications, err := is.FindApplications(ctx, tenant.ID, object.Pagination{Page: 1, Limit: 1})
+	if err != nil || len(applications) == 0 {
+		t.Fatalf("FindApplications() error = %v", err)
+	}
+
+	user, err := is.SignUp(ctx, tenant.ID, applications[0].ID, object.SignUp{
+		Username:    "jane",
+		DisplayName: "Jane",
+		Email:       "jane@example.com",
+		Password:    "correct horse battery staple",
+	})
+	if err != nil {
+		t.Fatalf("SignUp() error = %v", err)
+	}
+
+	err = is.ChangePassword(ctx, tenant.ID, user.ID, object.ChangePassword{
+		CurrentPassword: "wrong horse battery staple",
+		NewPassword:     "another horse battery staple",
+	})
+	if err == nil || errors.Is(err, ErrTooManyAttempts) {
+		t.Fatalf("ChangePassword() with a wrong password error = %v, want the wrong password", err)
+	}
+
+	// the failure delays the next attempt, also with the right password
+	err = is.ChangePassword(ctx, tenant.ID, user.ID, object.ChangePassword{
+		CurrentPassword: "correct horse battery staple",
+		NewPassword:     "another horse battery staple",
+	})
+	if !errors.Is(err, ErrTooManyAttempts) {
+		t.Errorf("ChangePassword() after a failure error = %v, want ErrTooManyAttempts", err)
+	}
+}
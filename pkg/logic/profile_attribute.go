@@ -0,0 +1,432 @@
+/*
+ * Copyright (C) 2025 Anthrove
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/anthrove/identity/pkg/object"
+	"github.com/anthrove/identity/pkg/repository"
+	"github.com/anthrove/identity/pkg/util"
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+	"slices"
+	"strings"
+)
+
+// maxProfileDataErrors limits the errors reported when the existing profiles don't match a new schema.
+const maxProfileDataErrors = 10
+
+// FindUsersByProfileAttribute looks up the users by the value of a unique or indexed profile field.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancellation, and deadlines.
+//   - tenantID: unique identifier of the tenant to which the users belong.
+//   - identifier: identifier of the profile field.
+//   - value: the value to look for, multi select fields match users who selected this option.
+//   - pagination: object containing pagination details (limit and page).
+//
+// Returns:
+//   - Slice of User objects if retrieval is successful.
+//   - Error if the field is not indexed, the value is invalid or there is any issue during retrieval.
+func (is IdentityService) FindUsersByProfileAttribute(ctx context.Context, tenantID string, identifier string, value string, pagination object.Pagination) ([]object.User, error) {
+	dbConn, _ := is.getDBConn(ctx)
+
+	tenant, err := is.FindTenant(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	index := slices.IndexFunc(tenant.ProfileFields, func(field object.ProfileField) bool {
+		return field.Identifier == identifier
+	})
+
+	if index < 0 {
+		return nil, fmt.Errorf("profile field %s does not exist", identifier)
+	}
+
+	field := tenant.ProfileFields[index]
+	if !field.Unique && !field.Indexed {
+		return nil, fmt.Errorf("profile field %s is not indexed", identifier)
+	}
+
+	normalized, err := normalizeProfileValue(field, value)
+	if err != nil {
+		return nil, fmt.Errorf("value %s", err.Error())
+	}
+
+	indexValues := profileIndexValues(normalized)
+	if len(indexValues) != 1 {
+		return nil, errors.New("value is required")
+	}
+
+	return repository.FindUsersByProfileAttribute(ctx, dbConn, tenantID, identifier, indexValues[0], pagination)
+}
+
+// RenameProfileField changes the identifier of a profile field, the stored values and the lookup index are moved to the new identifier.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancellation, and deadlines.
+//   - tenantID: unique identifier of the tenant.
+//   - identifier: current identifier of the profile field.
+//   - renameProfileField: object containing the new identifier.
+//
+// Returns:
+//   - Error if the field does not exist, the new identifier is taken or there is any issue during saving.
+func (is IdentityService) RenameProfileField(ctx context.Context, tenantID string, identifier string, renameProfileField object.RenameProfileField) error {
+	err := validate.Struct(renameProfileField)
+	if err != nil {
+		var validateErrs validator.ValidationErrors
+		if errors.As(err, &validateErrs) {
+			return errors.Join(fmt.Errorf("problem while validating rename profile field data"), util.ConvertValidationError(validateErrs))
+		}
+	}
+
+	return is.migrateProfileField(ctx, tenantID, identifier, func(fields []object.ProfileField, index int) ([]object.ProfileField, error) {
+		if slices.ContainsFunc(fields, func(field object.ProfileField) bool {
+			return field.Identifier == renameProfileField.Identifier
+		}) {
+			return nil, fmt.Errorf("profile field %s already exists", renameProfileField.Identifier)
+		}
+
+		fields[index].Identifier = renameProfileField.Identifier
+		return fields, nil
+	}, func(pageFields []object.ProfilePageField) []object.ProfilePageField {
+		for i := range pageFields {
+			if pageFields[i].Identifier == identifier {
+				pageFields[i].Identifier = renameProfileField.Identifier
+			}
+		}
+
+		return pageFields
+	})
+}
+
+// DropProfileField removes a profile field from the tenant, together with the stored values and the lookup index.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancellation, and deadlines.
+//   - tenantID: unique identifier of the tenant.
+//   - identifier: identifier of the profile field.
+//
+// Returns:
+//   - Error if the field does not exist or there is any issue during saving.
+func (is IdentityService) DropProfileField(ctx context.Context, tenantID string, identifier string) error {
+	return is.migrateProfileField(ctx, tenantID, identifier, func(fields []object.ProfileField, index int) ([]object.ProfileField, error) {
+		return slices.Delete(fields, index, index+1), nil
+	}, func(pageFields []object.ProfilePageField) []object.ProfilePageField {
+		return slices.DeleteFunc(pageFields, func(field object.ProfilePageField) bool {
+			return field.Identifier == identifier
+		})
+	})
+}
+
+// migrateProfileField changes a profile field of the tenant and all profiles in one transaction, afterward the lookup index is rebuilt.
+func (is IdentityService) migrateProfileField(ctx context.Context, tenantID string, identifier string, migrateSchema func([]object.ProfileField, int) ([]object.ProfileField, error), migratePage func([]object.ProfilePageField) []object.ProfilePageField) error {
+	dbConn, _ := is.getDBConn(ctx)
+
+	if len(tenantID) == 0 {
+		return errors.New("tenantID is required")
+	}
+
+	tenant, err := is.FindTenant(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+
+	index := slices.IndexFunc(tenant.ProfileFields, func(field object.ProfileField) bool {
+		return field.Identifier == identifier
+	})
+
+	if index < 0 {
+		return fmt.Errorf("profile field %s does not exist", identifier)
+	}
+
+	fields, err := migrateSchema(slices.Clone(tenant.ProfileFields), index)
+	if err != nil {
+		return err
+	}
+
+	return dbConn.Transaction(func(tx *gorm.DB) error {
+		err := repository.UpdateTenantProfileFields(ctx, tx, tenantID, fields)
+		if err != nil {
+			return err
+		}
+
+		pages, err := repository.FindTenantProfilePages(ctx, tx, tenantID)
+		if err != nil {
+			return err
+		}
+
+		for i, page := range pages {
+			if !slices.ContainsFunc(page.Fields, func(field object.ProfilePageField) bool {
+				return field.Identifier == identifier
+			}) {
+				continue
+			}
+
+			pages[i].Fields = migratePage(page.Fields)
+
+			err = repository.UpdatePageProfile(ctx, tx, tenantID, page.UserID, object.UpdateProfilePage{Fields: pages[i].Fields})
+			if err != nil {
+				return err
+			}
+		}
+
+		return rebuildProfileAttributes(ctx, tx, tenantID, fields, pages)
+	})
+}
+
+// checkProfileSchemaChange validates new profile fields of a tenant and checks that the stored profiles match them.
+func (is IdentityService) checkProfileSchemaChange(ctx context.Context, tenantID string, fields []object.ProfileField) ([]object.ProfilePage, error) {
+	dbConn, _ := is.getDBConn(ctx)
+
+	schemaErrors := validateProfileSchema(fields)
+	if len(schemaErrors) > 0 {
+		return nil, errors.New("multiple field errors: " + strings.Join(schemaErrors, ","))
+	}
+
+	tenant, err := is.FindTenant(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	pages, err := repository.FindTenantProfilePages(ctx, dbConn, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	userCount, err := repository.CountUsers(ctx, dbConn, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	dataErrors := checkProfileData(tenant.ProfileFields, fields, pages, userCount)
+	if len(dataErrors) > 0 {
+		return nil, errors.New("existing profiles don't match the profile fields: " + strings.Join(dataErrors, ","))
+	}
+
+	return pages, nil
+}
+
+// saveProfilePage stores the normalized fields of a profile and updates the lookup index.
+// Unique values and referenced resources are checked for the changed fields.
+func (is IdentityService) saveProfilePage(ctx context.Context, tenant object.Tenant, userID string, pageExists bool, fields []object.ProfilePageField, changed []string) error {
+	dbConn, _ := is.getDBConn(ctx)
+
+	fieldErrors := make([]string, 0)
+
+	for _, tenantField := range tenant.ProfileFields {
+		if profileFieldType(tenantField) != object.ProfileFieldTypeResource || !slices.Contains(changed, tenantField.Identifier) {
+			continue
+		}
+
+		resourceID, ok := profileFieldValue(fields, tenantField.Identifier).(string)
+		if !ok {
+			continue
+		}
+
+		_, err := repository.FindResource(ctx, dbConn, tenant.ID, resourceID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			fieldErrors = append(fieldErrors, fmt.Sprintf("field %s references a resource which does not exist", tenantField.Identifier))
+		} else if err != nil {
+			return err
+		}
+	}
+
+	attributes, attributeErrors := profileAttributes(tenant.ProfileFields, fields)
+	fieldErrors = append(fieldErrors, attributeErrors...)
+
+	if len(fieldErrors) > 0 {
+		return errors.New("multiple field errors: " + strings.Join(fieldErrors, ","))
+	}
+
+	return dbConn.Transaction(func(tx *gorm.DB) error {
+		for _, attribute := range attributes {
+			tenantField := tenant.ProfileFields[slices.IndexFunc(tenant.ProfileFields, func(field object.ProfileField) bool {
+				return field.Identifier == attribute.Identifier
+			})]
+
+			if !tenantField.Unique {
+				continue
+			}
+
+			userIDs, err := repository.FindProfileAttributeUserIDs(ctx, tx, tenant.ID, attribute.Identifier, attribute.Value)
+			if err != nil {
+				return err
+			}
+
+			if slices.ContainsFunc(userIDs, func(id string) bool { return id != userID }) {
+				fieldErrors = append(fieldErrors, fmt.Sprintf("field %s is already used by another user", attribute.Identifier))
+			}
+		}
+
+		if len(fieldErrors) > 0 {
+			return errors.New("multiple field errors: " + strings.Join(fieldErrors, ","))
+		}
+
+		var err error
+		if pageExists {
+			err = repository.UpdatePageProfile(ctx, tx, tenant.ID, userID, object.UpdateProfilePage{Fields: fields})
+		} else {
+			_, err = repository.CreateUserProfile(ctx, tx, tenant.ID, userID, object.CreateProfilePage{Fields: fields})
+		}
+
+		if err != nil {
+			return err
+		}
+
+		// another user can store the same value between the check and the insert, the database rejects it then
+		err = repository.ReplaceProfileAttributes(ctx, tx, tenant.ID, userID, attributes)
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return errors.New("multiple field errors: " + strings.Join(uniqueAttributeErrors(attributes, changed), ","))
+		}
+
+		return err
+	})
+}
+
+// uniqueAttributeErrors returns the field errors for a rejected unique value. The database doesn't tell which field
+// was rejected, so all changed unique fields are reported.
+func uniqueAttributeErrors(attributes []object.ProfileAttribute, changed []string) []string {
+	identifiers := make([]string, 0)
+	for _, attribute := range attributes {
+		if attribute.UniqueValue != nil && !slices.Contains(identifiers, attribute.Identifier) {
+			identifiers = append(identifiers, attribute.Identifier)
+		}
+	}
+
+	changedIdentifiers := slices.DeleteFunc(slices.Clone(identifiers), func(identifier string) bool {
+		return !slices.Contains(changed, identifier)
+	})
+
+	if len(changedIdentifiers) > 0 {
+		identifiers = changedIdentifiers
+	}
+
+	fieldErrors := make([]string, 0, len(identifiers))
+	for _, identifier := range identifiers {
+		fieldErrors = append(fieldErrors, fmt.Sprintf("field %s is already used by another user", identifier))
+	}
+
+	return fieldErrors
+}
+
+// rebuildProfileAttributes replaces the lookup index of a tenant with the entries of the given profiles.
+func rebuildProfileAttributes(ctx context.Context, db *gorm.DB, tenantID string, fields []object.ProfileField, pages []object.ProfilePage) error {
+	err := repository.KillTenantProfileAttributes(ctx, db, tenantID)
+	if err != nil {
+		return err
+	}
+
+	for _, page := range pages {
+		attributes, _ := profileAttributes(fields, page.Fields)
+
+		err = repository.ReplaceProfileAttributes(ctx, db, tenantID, page.UserID, attributes)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// profileAttributes returns the lookup index entries of the unique and indexed fields of a profile.
+// Indexed fields without a value are indexed with their default.
+func profileAttributes(tenantFields []object.ProfileField, pageFields []object.ProfilePageField) ([]object.ProfileAttribute, []string) {
+	attributes := make([]object.ProfileAttribute, 0)
+	fieldErrors := make([]string, 0)
+
+	for _, tenantField := range tenantFields {
+		if !tenantField.Unique && !tenantField.Indexed {
+			continue
+		}
+
+		for _, value := range profileIndexValues(storedProfileValue(tenantField, pageFields)) {
+			if len(value) > 255 {
+				fieldErrors = append(fieldErrors, fmt.Sprintf("field %s is too long to be indexed", tenantField.Identifier))
+				continue
+			}
+
+			attribute := object.ProfileAttribute{
+				Identifier: tenantField.Identifier,
+				Value:      value,
+			}
+
+			if tenantField.Unique {
+				attribute.UniqueValue = &attribute.Value
+			}
+
+			attributes = append(attributes, attribute)
+		}
+	}
+
+	return attributes, fieldErrors
+}
+
+// checkProfileData checks the stored profiles against new profile fields. Fields which become required need a value
+// for every user of the tenant, unless they have a default.
+func checkProfileData(previousFields []object.ProfileField, fields []object.ProfileField, pages []object.ProfilePage, userCount int64) []string {
+	dataErrors := make([]string, 0)
+	usedValues := make(map[string]string)
+
+	for _, field := range fields {
+		var withValue int64
+
+		for _, page := range pages {
+			value, err := normalizeProfileValue(field, profileFieldValue(page.Fields, field.Identifier))
+			if err != nil {
+				dataErrors = append(dataErrors, fmt.Sprintf("field %s of user %s %s", field.Identifier, page.UserID, err.Error()))
+				continue
+			}
+
+			if value == nil {
+				continue
+			}
+
+			withValue++
+
+			if !field.Unique {
+				continue
+			}
+
+			for _, indexValue := range profileIndexValues(value) {
+				key := field.Identifier + "\x00" + indexValue
+				if otherUserID, ok := usedValues[key]; ok && otherUserID != page.UserID {
+					dataErrors = append(dataErrors, fmt.Sprintf("field %s of users %s and %s is not unique", field.Identifier, otherUserID, page.UserID))
+				}
+
+				usedValues[key] = page.UserID
+			}
+		}
+
+		wasRequired := slices.ContainsFunc(previousFields, func(previous object.ProfileField) bool {
+			return previous.Identifier == field.Identifier && previous.Required
+		})
+
+		if field.Required && field.Default == nil && !wasRequired && withValue < userCount {
+			dataErrors = append(dataErrors, fmt.Sprintf("field %s is required but %d users have no value, add a default", field.Identifier, userCount-withValue))
+		}
+	}
+
+	if len(dataErrors) > maxProfileDataErrors {
+		dataErrors = append(dataErrors[:maxProfileDataErrors], fmt.Sprintf("and %d more", len(dataErrors)-maxProfileDataErrors))
+	}
+
+	return dataErrors
+}
@@ -0,0 +1,169 @@
+/*
+ * Copyright (C) 2025 Anthrove
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logic
+
+import (
+	"context"
+	"errors"
+	"github.com/anthrove/identity/pkg/object"
+	"github.com/anthrove/identity/pkg/repository"
+	"gorm.io/gorm"
+	"testing"
+)
+
+func TestUniqueProfileAttribute(t *testing.T) {
+	ctx := context.Background()
+	is, tenant := newTestService(t)
+
+	tenant = updateTestTenant(t, is, tenant, func(updateTenant *object.UpdateTenant) {
+		updateTenant.SignUpPolicy = &object.SignUpPolicy{Mode: object.SignUpModeOpen}
+		updateTenant.ProfileFields = []object.ProfileField{{Identifier: "employee_id", Unique: true}}
+	})
+
+	applications, err := is.FindApplications(ctx, tenant.ID, object.Pagination{Page: 1, Limit: 1})
+	if err != nil || len(applications) == 0 {
+		t.Fatalf("FindApplications() error = %v", err)
+	}
+
+	signUp := func(username string, employeeID string) (object.User, error) {
+		return is.SignUp(ctx, tenant.ID, applications[0].ID, object.SignUp{
+			Username:      username,
+			DisplayName:   username,
+			Email:         username + "@example.com",
+			Password:      "correct horse battery staple",
+			ProfileFields: []object.ProfilePageField{{Identifier: "employee_id", Value: employeeID}},
+		})
+	}
+
+	first, err := signUp("first", "42")
+	if err != nil {
+		t.Fatalf("SignUp() error = %v", err)
+	}
+
+	_, err = signUp("second", "42")
+	if err == nil {
+		t.Errorf("SignUp() with a used unique value error = nil, want error")
+	}
+
+	second, err := signUp("third", "43")
+	if err != nil {
+		t.Fatalf("SignUp() error = %v", err)
+	}
+
+	// a concurrent write passes the check before the insert, the database has to reject it
+	value := "42"
+	err = repository.ReplaceProfileAttributes(ctx, is.db, tenant.ID, second.ID, []object.ProfileAttribute{
+		{Identifier: "employee_id", Value: value, UniqueValue: &value},
+	})
+	if !errors.Is(err, gorm.ErrDuplicatedKey) {
+		t.Errorf("ReplaceProfileAttributes() with a used unique value error = %v, want gorm.ErrDuplicatedKey", err)
+	}
+
+	// entries stored before the database enforced unique values are backfilled
+	err = is.db.Model(&object.ProfileAttribute{}).Where("user_id = ?", first.ID).UpdateColumn("unique_value", nil).Error
+	if err != nil {
+		t.Fatalf("removing the unique value: %v", err)
+	}
+
+	err = repository.Migrate(is.db)
+	if err != nil {
+		t.Fatalf("repository.Migrate() error = %v", err)
+	}
+
+	var attribute object.ProfileAttribute
+	err = is.db.Take(&attribute, "user_id = ?", first.ID).Error
+	if err != nil || attribute.UniqueValue == nil || *attribute.UniqueValue != "42" {
+		t.Errorf("backfilled attribute = %+v, %v, want the unique value 42", attribute, err)
+	}
+
+	// values which were used by several users before can't be backfilled, they must not stop the server from starting
+	err = is.db.Create(&object.ProfileAttribute{TenantID: tenant.ID, Identifier: "employee_id", Value: "42", UserID: second.ID}).Error
+	if err == nil {
+		err = is.db.Model(&object.ProfileAttribute{}).Where("user_id = ?", first.ID).UpdateColumn("unique_value", nil).Error
+	}
+	if err != nil {
+		t.Fatalf("storing a used value: %v", err)
+	}
+
+	err = repository.Migrate(is.db)
+	if err != nil {
+		t.Fatalf("repository.Migrate() with used values error = %v", err)
+	}
+
+	var backfilled int64
+	err = is.db.Model(&object.ProfileAttribute{}).Where("unique_value IS NOT NULL").Count(&backfilled).Error
+	if err != nil || backfilled != 0 {
+		t.Errorf("backfilled attributes with used values = %d, %v, want none", backfilled, err)
+	}
+}
+
+func TestUpdateTenantUniqueProfileField(t *testing.T) {
+	ctx := context.Background()
+	is, tenant := newTestService(t)
+
+	tenant = updateTestTenant(t, is, tenant, func(updateTenant *object.UpdateTenant) {
+		updateTenant.ProfileFields = []object.ProfileField{{Identifier: "team", ModifyBy: object.ModifyTypeSelf}}
+	})
+
+	for _, username := range []string{"first", "second"} {
+		user := signUpTestUser(t, is, tenant, username)
+
+		err := is.UpdateProfilePage(ctx, tenant.ID, user.ID, object.ProfileViewer{Admin: true}, object.UpdateProfilePage{
+			Fields: []object.ProfilePageField{{Identifier: "team", Value: "identity"}},
+		})
+		if err != nil {
+			t.Fatalf("UpdateProfilePage() error = %v", err)
+		}
+	}
+
+	tenant, err := is.FindTenant(ctx, tenant.ID)
+	if err != nil {
+		t.Fatalf("FindTenant() error = %v", err)
+	}
+
+	err = is.UpdateTenant(ctx, tenant.ID, object.UpdateTenant{
+		DisplayName:          tenant.DisplayName,
+		PasswordType:         tenant.PasswordType,
+		SigningCertificateID: *tenant.SigningCertificateID,
+		ProfileFields:        []object.ProfileField{{Identifier: "team", ModifyBy: object.ModifyTypeSelf, Unique: true}},
+	})
+	if err == nil {
+		t.Fatalf("UpdateTenant() making a field with used values unique error = nil, want error")
+	}
+
+	tenant, err = is.FindTenant(ctx, tenant.ID)
+	if err != nil || tenant.ProfileFields[0].Unique {
+		t.Errorf("FindTenant() = %+v, %v, want the field to stay not unique", tenant.ProfileFields, err)
+	}
+}
+
+func TestUniqueAttributeErrors(t *testing.T) {
+	value := "value"
+	attributes := []object.ProfileAttribute{
+		{Identifier: "employee_id", Value: value, UniqueValue: &value},
+		{Identifier: "badge", Value: value, UniqueValue: &value},
+		{Identifier: "department", Value: value},
+	}
+
+	if got := uniqueAttributeErrors(attributes, []string{"badge", "department"}); len(got) != 1 || got[0] != "field badge is already used by another user" {
+		t.Errorf("uniqueAttributeErrors() with a changed unique field = %v, want the badge field", got)
+	}
+
+	if got := uniqueAttributeErrors(attributes, []string{"department"}); len(got) != 2 {
+		t.Errorf("uniqueAttributeErrors() without a changed unique field = %v, want all unique fields", got)
+	}
+}
@@ -0,0 +1,488 @@
+/*
+ * Copyright (C) 2025 Anthrove
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logic
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/anthrove/identity/pkg/object"
+	"github.com/anthrove/identity/pkg/repository"
+	"image"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	// MaxAvatarSize is the largest avatar which can be uploaded in bytes.
+	MaxAvatarSize = 5 << 20
+	// defaultMaxProfileFileSize is used for resource fields without MaxFileSize.
+	defaultMaxProfileFileSize = 10 << 20
+	// avatarSize is the size of the stored avatar, smaller variants are created for avatarVariantSizes.
+	avatarSize = 512
+)
+
+var avatarVariantSizes = []int{256, 128, 64}
+
+// activeMimeTypes can run scripts when a browser opens them, fields only accept them if they list the type itself.
+var activeMimeTypes = []string{"text/html", "text/xml", "image/svg+xml"}
+
+// UploadAvatar stores a new avatar for a user in the storage provider of the tenant. The image is cropped to a square,
+// stored without metadata and resized variants are created. A previous avatar of the user is deleted.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancellation, and deadlines.
+//   - tenantID: unique identifier of the tenant to which the user belongs.
+//   - userID: unique identifier of the user.
+//   - fileName: name of the uploaded file.
+//   - file: content of the uploaded file.
+//
+// Returns:
+//   - Resource object of the avatar, including its variants.
+//   - Error if the file is not a supported image, too large or there is any issue during saving.
+func (is IdentityService) UploadAvatar(ctx context.Context, tenantID string, userID string, fileName string, file io.Reader) (object.Resource, error) {
+	dbConn, _ := is.getDBConn(ctx)
+
+	user, err := is.FindUser(ctx, tenantID, userID)
+	if err != nil {
+		return object.Resource{}, err
+	}
+
+	data, err := readUpload(file, MaxAvatarSize)
+	if err != nil {
+		return object.Resource{}, err
+	}
+
+	mimeType := detectMimeType(data)
+	if !slices.Contains(imageMimeTypes, mimeType) {
+		return object.Resource{}, errors.New("avatar needs to be a jpeg, png or gif image")
+	}
+
+	img, err := decodeUploadedImage(data, mimeType)
+	if err != nil {
+		return object.Resource{}, err
+	}
+
+	img = cropSquare(img)
+
+	providerID, err := is.storageProviderID(ctx, tenantID)
+	if err != nil {
+		return object.Resource{}, err
+	}
+
+	resource, err := is.storeImage(ctx, tenantID, object.CreateResource{
+		ProviderID: providerID,
+		Tag:        "avatar",
+		FileName:   fileName,
+		UserID:     userID,
+	}, resizeImage(img, avatarSize), mimeType)
+
+	if err != nil {
+		return object.Resource{}, err
+	}
+
+	for _, size := range avatarVariantSizes {
+		variant, err := is.storeImage(ctx, tenantID, object.CreateResource{
+			ProviderID: providerID,
+			Tag:        "avatar",
+			FileName:   fileName,
+			UserID:     userID,
+			ParentID:   resource.ID,
+			Variant:    strconv.Itoa(size),
+		}, resizeImage(img, size), mimeType)
+
+		if err != nil {
+			return object.Resource{}, errors.Join(err, is.KillResource(ctx, tenantID, resource.ID))
+		}
+
+		resource.Variants = append(resource.Variants, variant)
+	}
+
+	err = repository.UpdateUserAvatar(ctx, dbConn, tenantID, userID, resource.ID)
+	if err != nil {
+		return object.Resource{}, errors.Join(err, is.KillResource(ctx, tenantID, resource.ID))
+	}
+
+	if len(user.AvatarResourceID) > 0 {
+		is.killReplacedUserResource(ctx, tenantID, userID, user.AvatarResourceID)
+	}
+
+	is.recordAuditEvent(ctx, tenantID, object.AuditEventProfileUpdated, userID, map[string]any{
+		"fields": []string{"avatar"},
+	})
+
+	return resource, nil
+}
+
+// KillAvatar removes the avatar of a user and deletes the image.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancellation, and deadlines.
+//   - tenantID: unique identifier of the tenant to which the user belongs.
+//   - userID: unique identifier of the user.
+//
+// Returns:
+//   - Error if there is any issue during deletion.
+func (is IdentityService) KillAvatar(ctx context.Context, tenantID string, userID string) error {
+	dbConn, _ := is.getDBConn(ctx)
+
+	user, err := is.FindUser(ctx, tenantID, userID)
+	if err != nil {
+		return err
+	}
+
+	if len(user.AvatarResourceID) == 0 {
+		return nil
+	}
+
+	err = repository.UpdateUserAvatar(ctx, dbConn, tenantID, userID, "")
+	if err != nil {
+		return err
+	}
+
+	is.killReplacedUserResource(ctx, tenantID, userID, user.AvatarResourceID)
+
+	is.recordAuditEvent(ctx, tenantID, object.AuditEventProfileUpdated, userID, map[string]any{
+		"fields": []string{"avatar"},
+	})
+
+	return nil
+}
+
+// FindAvatarURL returns the URL of the avatar of a user, it is empty if the user has no avatar.
+// URLs of the local storage are relative to this server.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancellation, and deadlines.
+//   - tenantID: unique identifier of the tenant to which the user belongs.
+//   - user: the user.
+//
+// Returns:
+//   - URL of the avatar.
+//   - Error if there is any issue during retrieval.
+func (is IdentityService) FindAvatarURL(ctx context.Context, tenantID string, user object.User) (string, error) {
+	if len(user.AvatarResourceID) == 0 {
+		return "", nil
+	}
+
+	resource, err := is.FindResource(ctx, tenantID, user.AvatarResourceID)
+	if err != nil {
+		return "", err
+	}
+
+	return resourcePublicURL(resource), nil
+}
+
+// FindProfileFileMaxSize returns the size up to which files can be uploaded for a profile field, so the upload can be
+// limited before it is read. Unknown fields get the default size, the upload reports them.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancellation, and deadlines.
+//   - tenantID: unique identifier of the tenant.
+//   - identifier: identifier of the profile field.
+//
+// Returns:
+//   - The largest file size in bytes.
+//   - Error if the tenant can't be found.
+func (is IdentityService) FindProfileFileMaxSize(ctx context.Context, tenantID string, identifier string) (int64, error) {
+	tenant, err := is.FindTenant(ctx, tenantID)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, field := range tenant.ProfileFields {
+		if field.Identifier == identifier {
+			return profileFileMaxSize(field), nil
+		}
+	}
+
+	return defaultMaxProfileFileSize, nil
+}
+
+// UploadProfileFile stores a file for a resource profile field and sets the field to the new resource.
+// The file is checked against the mime types and the size of the field, metadata of jpeg and png images is removed.
+// A previous file of the field is deleted.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancellation, and deadlines.
+//   - tenantID: unique identifier of the tenant to which the user belongs.
+//   - userID: unique identifier of the user whose profile is changed.
+//   - viewer: who changes the profile.
+//   - identifier: identifier of the profile field.
+//   - fileName: name of the uploaded file.
+//   - file: content of the uploaded file.
+//
+// Returns:
+//   - Resource object of the file.
+//   - Error if the field can't be changed, the file is not allowed or there is any issue during saving.
+func (is IdentityService) UploadProfileFile(ctx context.Context, tenantID string, userID string, viewer object.ProfileViewer, identifier string, fileName string, file io.Reader) (object.Resource, error) {
+	values, err := is.FindProfile(ctx, tenantID, userID, viewer)
+	if err != nil {
+		return object.Resource{}, err
+	}
+
+	index := slices.IndexFunc(values, func(value object.ProfileFieldValue) bool {
+		return value.Field.Identifier == identifier && value.Field.Type == object.ProfileFieldTypeResource
+	})
+
+	if index < 0 {
+		return object.Resource{}, fmt.Errorf("file field %s does not exist", identifier)
+	}
+
+	field := values[index].Field
+	if !values[index].Modifiable {
+		return object.Resource{}, fmt.Errorf("field %s can not be changed", identifier)
+	}
+
+	data, err := readUpload(file, profileFileMaxSize(field))
+	if err != nil {
+		return object.Resource{}, err
+	}
+
+	mimeType := detectMimeType(data)
+	if !profileFileAllowed(field, mimeType) {
+		return object.Resource{}, fmt.Errorf("files of type %s are not allowed for field %s", mimeType, identifier)
+	}
+
+	providerID, err := is.storageProviderID(ctx, tenantID)
+	if err != nil {
+		return object.Resource{}, err
+	}
+
+	visibility, groupID := profileFileVisibility(field)
+
+	createResource := object.CreateResource{
+		ProviderID: providerID,
+		Tag:        "profile",
+		FileName:   fileName,
+		UserID:     userID,
+		Visibility: visibility,
+		GroupID:    groupID,
+	}
+
+	var resource object.Resource
+
+	// gif images are kept, they have no EXIF metadata and encoding them again would drop the animation
+	if mimeType == "image/jpeg" || mimeType == "image/png" {
+		img, err := decodeUploadedImage(data, mimeType)
+		if err != nil {
+			return object.Resource{}, err
+		}
+
+		resource, err = is.storeImage(ctx, tenantID, createResource, img, mimeType)
+		if err != nil {
+			return object.Resource{}, err
+		}
+	} else {
+		resource, err = is.storeFile(ctx, tenantID, createResource, data, mimeType)
+		if err != nil {
+			return object.Resource{}, err
+		}
+	}
+
+	err = is.UpdateProfilePage(ctx, tenantID, userID, viewer, object.UpdateProfilePage{
+		Fields: []object.ProfilePageField{{Identifier: identifier, Value: resource.ID}},
+	})
+
+	if err != nil {
+		return object.Resource{}, errors.Join(err, is.KillResource(ctx, tenantID, resource.ID))
+	}
+
+	return resource, nil
+}
+
+// profileFileVisibility maps the ViewBy of a resource field to the visibility of its files. Files of fields which
+// are not public can only be downloaded by the user, others need a signed URL.
+func profileFileVisibility(field object.ProfileField) (object.ResourceVisibility, string) {
+	switch {
+	case field.ViewBy == object.AllowViewPublic:
+		return object.ResourceVisibilityPublic, ""
+	case field.ViewBy == object.AllowViewGroup && len(field.ViewGroups) == 1:
+		return object.ResourceVisibilityGroup, field.ViewGroups[0]
+	default:
+		return object.ResourceVisibilityOwner, ""
+	}
+}
+
+// killUserResources deletes all files a user uploaded through the profile.
+func (is IdentityService) killUserResources(ctx context.Context, tenantID string, userID string) error {
+	dbConn, _ := is.getDBConn(ctx)
+
+	resources, err := repository.FindUserResources(ctx, dbConn, tenantID, userID)
+	if err != nil {
+		return err
+	}
+
+	for _, resource := range resources {
+		err = is.KillResource(ctx, tenantID, resource.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// killReplacedUserResource deletes a file which is no longer used by the profile of a user. Resources which were not
+// uploaded by the user, e.g. one set by an admin, are kept. Problems are only logged, the change of the profile is already done.
+func (is IdentityService) killReplacedUserResource(ctx context.Context, tenantID string, userID string, resourceID string) {
+	resource, err := is.FindResource(ctx, tenantID, resourceID)
+	if err != nil || resource.UserID != userID {
+		return
+	}
+
+	err = is.KillResource(ctx, tenantID, resource.ID)
+	if err != nil {
+		log.Printf("problem while deleting replaced resource %s of user %s: %v", resource.ID, userID, err)
+	}
+}
+
+// storageProviderID returns the storage provider for the uploads of a tenant, which is the configured one or the first
+// storage provider of the tenant.
+func (is IdentityService) storageProviderID(ctx context.Context, tenantID string) (string, error) {
+	dbConn, _ := is.getDBConn(ctx)
+
+	tenant, err := is.FindTenant(ctx, tenantID)
+	if err != nil {
+		return "", err
+	}
+
+	if len(tenant.StorageProviderID) > 0 {
+		return tenant.StorageProviderID, nil
+	}
+
+	providers, err := repository.FindProvidersByCategory(ctx, dbConn, tenantID, "storage")
+	if err != nil {
+		return "", err
+	}
+
+	if len(providers) == 0 {
+		return "", errors.New("no storage provider configured in tenant")
+	}
+
+	return providers[0].ID, nil
+}
+
+// storeImage encodes an image without metadata and stores it as a new resource.
+func (is IdentityService) storeImage(ctx context.Context, tenantID string, createResource object.CreateResource, img image.Image, mimeType string) (object.Resource, error) {
+	data, mimeType, extension, err := encodeImage(img, mimeType)
+	if err != nil {
+		return object.Resource{}, err
+	}
+
+	createResource.FileName = strings.TrimSuffix(createResource.FileName, filepath.Ext(createResource.FileName)) + "." + extension
+
+	return is.storeFile(ctx, tenantID, createResource, data, mimeType)
+}
+
+// storeFile stores an uploaded file as a new resource.
+func (is IdentityService) storeFile(ctx context.Context, tenantID string, createResource object.CreateResource, data []byte, mimeType string) (object.Resource, error) {
+	createResource.FileName = uploadFileName(createResource.FileName)
+	createResource.FileSize = int64(len(data))
+	createResource.MimeType = mimeType
+
+	return is.CreateResource(ctx, tenantID, createResource, bytes.NewReader(data))
+}
+
+// profileFileMaxSize returns the size up to which files can be uploaded for a resource field.
+func profileFileMaxSize(field object.ProfileField) int64 {
+	if field.MaxFileSize == 0 {
+		return defaultMaxProfileFileSize
+	}
+
+	return field.MaxFileSize
+}
+
+// readUpload reads an uploaded file, files larger than maxSize are rejected.
+func readUpload(file io.Reader, maxSize int64) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(file, maxSize+1))
+	if err != nil {
+		return nil, errors.Join(errors.New("problem while reading the upload"), err)
+	}
+
+	if int64(len(data)) > maxSize {
+		return nil, fmt.Errorf("file is larger than %d bytes", maxSize)
+	}
+
+	if len(data) == 0 {
+		return nil, errors.New("file is empty")
+	}
+
+	return data, nil
+}
+
+// detectMimeType detects the mime type from the content of a file, the type sent by the client is not trusted.
+func detectMimeType(data []byte) string {
+	mimeType, _, err := mime.ParseMediaType(http.DetectContentType(data))
+	if err != nil {
+		return "application/octet-stream"
+	}
+
+	return mimeType
+}
+
+// profileFileAllowed checks the mime type of an uploaded file against the mime types of a resource field. Fields without
+// mime types accept every file, except the activeMimeTypes which need to be listed explicitly.
+func profileFileAllowed(field object.ProfileField, mimeType string) bool {
+	if slices.Contains(activeMimeTypes, mimeType) {
+		return slices.Contains(field.MimeTypes, mimeType)
+	}
+
+	return len(field.MimeTypes) == 0 || slices.ContainsFunc(field.MimeTypes, func(allowed string) bool {
+		return matchMimeType(allowed, mimeType)
+	})
+}
+
+// matchMimeType checks a mime type against an allowed type, which can end with a wildcard like "image/*".
+func matchMimeType(allowed string, mimeType string) bool {
+	if prefix, ok := strings.CutSuffix(allowed, "/*"); ok {
+		return strings.HasPrefix(mimeType, prefix+"/")
+	}
+
+	return allowed == mimeType
+}
+
+// uploadFileName removes paths and unusual characters from the name of an uploaded file, so it can be used in storage paths.
+func uploadFileName(name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, "\\", "/"))
+
+	name = strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '.' || r == '-' || r == '_' {
+			return r
+		}
+
+		return '_'
+	}, name)
+
+	name = strings.TrimLeft(name, ".")
+	if len(name) > 100 {
+		name = name[len(name)-100:]
+	}
+
+	if len(name) == 0 {
+		return "file"
+	}
+
+	return name
+}
@@ -0,0 +1,48 @@
+/*
+ * Copyright (C) 2025 Anthrove
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logic
+
+import (
+	"github.com/anthrove/identity/pkg/object"
+	"testing"
+)
+
+func TestProfileFileAllowed(t *testing.T) {
+	tests := []struct {
+		name      string
+		mimeTypes []string
+		mimeType  string
+		want      bool
+	}{
+		{name: "any file", mimeType: "application/pdf", want: true},
+		{name: "html without mime types", mimeType: "text/html", want: false},
+		{name: "xml without mime types", mimeType: "text/xml", want: false},
+		{name: "wildcard", mimeTypes: []string{"image/*"}, mimeType: "image/png", want: true},
+		{name: "svg through wildcard", mimeTypes: []string{"image/*"}, mimeType: "image/svg+xml", want: false},
+		{name: "html through wildcard", mimeTypes: []string{"text/*"}, mimeType: "text/html", want: false},
+		{name: "explicit html", mimeTypes: []string{"text/html"}, mimeType: "text/html", want: true},
+		{name: "not listed", mimeTypes: []string{"application/pdf"}, mimeType: "image/png", want: false},
+	}
+
+	for _, test := range tests {
+		field := object.ProfileField{Type: object.ProfileFieldTypeResource, MimeTypes: test.mimeTypes}
+
+		if got := profileFileAllowed(field, test.mimeType); got != test.want {
+			t.Errorf("%s: profileFileAllowed() = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,293 @@
+/*
+ * Copyright (C) 2025 Anthrove
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logic
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/anthrove/identity/pkg/object"
+	"math"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const profileDateFormat = "2006-01-02"
+
+var profilePhoneRegex = regexp.MustCompile(`^\+[1-9][0-9]{6,14}$`)
+
+// validateProfileSchema checks the profile fields of a tenant on their own, without looking at stored profiles.
+func validateProfileSchema(fields []object.ProfileField) []string {
+	schemaErrors := make([]string, 0)
+	identifiers := make(map[string]bool, len(fields))
+
+	for _, field := range fields {
+		if identifiers[field.Identifier] {
+			schemaErrors = append(schemaErrors, fmt.Sprintf("field %s is defined more than once", field.Identifier))
+		}
+		identifiers[field.Identifier] = true
+
+		fieldType := profileFieldType(field)
+
+		if (fieldType == object.ProfileFieldTypeEnum || fieldType == object.ProfileFieldTypeMultiSelect) && len(field.Options) == 0 {
+			schemaErrors = append(schemaErrors, fmt.Sprintf("field %s needs options", field.Identifier))
+		}
+
+		if field.Min != nil && field.Max != nil && *field.Min > *field.Max {
+			schemaErrors = append(schemaErrors, fmt.Sprintf("min of field %s is greater than max", field.Identifier))
+		}
+
+		if field.MaxLength > 0 && field.MinLength > field.MaxLength {
+			schemaErrors = append(schemaErrors, fmt.Sprintf("min_length of field %s is greater than max_length", field.Identifier))
+		}
+
+		if len(field.Regex) > 0 {
+			if _, err := regexp.Compile(field.Regex); err != nil {
+				schemaErrors = append(schemaErrors, fmt.Sprintf("regex of field %s is invalid", field.Identifier))
+			}
+		}
+
+		if field.Default != nil {
+			if field.Unique {
+				schemaErrors = append(schemaErrors, fmt.Sprintf("unique field %s can't have a default", field.Identifier))
+			} else if _, err := normalizeProfileValue(field, field.Default); err != nil {
+				schemaErrors = append(schemaErrors, fmt.Sprintf("default of field %s is invalid: %s", field.Identifier, err.Error()))
+			}
+		}
+	}
+
+	return schemaErrors
+}
+
+func profileFieldType(field object.ProfileField) object.ProfileFieldType {
+	if len(field.Type) == 0 {
+		return object.ProfileFieldTypeString
+	}
+
+	return field.Type
+}
+
+// normalizeProfileValue checks a value against the type and constraints of a field and converts it to the stored form:
+// int64 for int, bool for bool, []string for multi_select and string for all other types.
+// Strings are accepted for every type, so values of html forms can be used directly. Empty values are returned as nil.
+func normalizeProfileValue(field object.ProfileField, value any) (any, error) {
+	if isEmptyProfileValue(value) {
+		return nil, nil
+	}
+
+	switch profileFieldType(field) {
+	case object.ProfileFieldTypeInt:
+		number, err := profileInt(value)
+		if err != nil {
+			return nil, err
+		}
+
+		if field.Min != nil && number < *field.Min {
+			return nil, fmt.Errorf("needs to be at least %d", *field.Min)
+		}
+
+		if field.Max != nil && number > *field.Max {
+			return nil, fmt.Errorf("needs to be at most %d", *field.Max)
+		}
+
+		return number, nil
+	case object.ProfileFieldTypeBool:
+		switch value := value.(type) {
+		case bool:
+			return value, nil
+		case string:
+			if value == "on" {
+				return true, nil
+			}
+
+			parsed, err := strconv.ParseBool(value)
+			if err != nil {
+				return nil, fmt.Errorf("needs to be a boolean")
+			}
+
+			return parsed, nil
+		default:
+			return nil, fmt.Errorf("needs to be a boolean")
+		}
+	case object.ProfileFieldTypeMultiSelect:
+		var items []string
+
+		switch value := value.(type) {
+		case string:
+			items = []string{value}
+		case []string:
+			items = value
+		case []any:
+			for _, item := range value {
+				str, ok := item.(string)
+				if !ok {
+					return nil, fmt.Errorf("needs to be a list of strings")
+				}
+				items = append(items, str)
+			}
+		default:
+			return nil, fmt.Errorf("needs to be a list of strings")
+		}
+
+		selected := make([]string, 0, len(items))
+		for _, item := range items {
+			if !slices.Contains(field.Options, item) {
+				return nil, fmt.Errorf("%s is not an option", item)
+			}
+
+			if !slices.Contains(selected, item) {
+				selected = append(selected, item)
+			}
+		}
+
+		if len(selected) == 0 {
+			return nil, nil
+		}
+
+		return selected, nil
+	}
+
+	str, ok := value.(string)
+	if !ok {
+		return nil, fmt.Errorf("needs to be a string")
+	}
+
+	switch profileFieldType(field) {
+	case object.ProfileFieldTypeDate:
+		if _, err := time.Parse(profileDateFormat, str); err != nil {
+			return nil, fmt.Errorf("needs to be a date in the format YYYY-MM-DD")
+		}
+	case object.ProfileFieldTypeEnum:
+		if !slices.Contains(field.Options, str) {
+			return nil, fmt.Errorf("%s is not an option", str)
+		}
+	case object.ProfileFieldTypePhone:
+		str = strings.NewReplacer(" ", "", "-", "", "(", "", ")", "").Replace(str)
+
+		if !profilePhoneRegex.MatchString(str) {
+			return nil, fmt.Errorf("needs to be a phone number in the format +<country code><number>")
+		}
+	case object.ProfileFieldTypeURL:
+		parsed, err := url.Parse(str)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || len(parsed.Host) == 0 {
+			return nil, fmt.Errorf("needs to be an absolute http or https url")
+		}
+	case object.ProfileFieldTypeResource:
+		if len(str) != 25 {
+			return nil, fmt.Errorf("needs to be a resource id")
+		}
+	}
+
+	if field.MinLength > 0 && len([]rune(str)) < field.MinLength {
+		return nil, fmt.Errorf("needs to be at least %d characters long", field.MinLength)
+	}
+
+	if field.MaxLength > 0 && len([]rune(str)) > field.MaxLength {
+		return nil, fmt.Errorf("needs to be at most %d characters long", field.MaxLength)
+	}
+
+	if len(field.Regex) > 0 {
+		r, err := regexp.Compile(field.Regex)
+		if err != nil {
+			return nil, fmt.Errorf("has an invalid regex, please contact an administrator")
+		}
+
+		if !r.MatchString(str) {
+			return nil, fmt.Errorf("is invalid")
+		}
+	}
+
+	return str, nil
+}
+
+// profileInt converts the number types of json and go, as well as strings, to an int64.
+func profileInt(value any) (int64, error) {
+	switch value := value.(type) {
+	case int:
+		return int64(value), nil
+	case int64:
+		return value, nil
+	case float64:
+		if value != math.Trunc(value) || math.Abs(value) > math.MaxInt64 {
+			return 0, fmt.Errorf("needs to be an integer")
+		}
+		return int64(value), nil
+	case json.Number:
+		number, err := value.Int64()
+		if err != nil {
+			return 0, fmt.Errorf("needs to be an integer")
+		}
+		return number, nil
+	case string:
+		number, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("needs to be an integer")
+		}
+		return number, nil
+	default:
+		return 0, fmt.Errorf("needs to be an integer")
+	}
+}
+
+// profileIndexValues returns the entries of a normalized value in the lookup index.
+func profileIndexValues(value any) []string {
+	switch value := value.(type) {
+	case nil:
+		return nil
+	case []string:
+		return value
+	case int64:
+		return []string{strconv.FormatInt(value, 10)}
+	case bool:
+		return []string{strconv.FormatBool(value)}
+	default:
+		return []string{fmt.Sprint(value)}
+	}
+}
+
+// normalizeProfilePageFields checks the values of the given tenant fields and returns all page fields in their stored form.
+// Values of fields which are not in tenantFields are kept as they are.
+func normalizeProfilePageFields(tenantFields []object.ProfileField, pageFields []object.ProfilePageField) ([]object.ProfilePageField, []string) {
+	fields := make([]object.ProfilePageField, len(pageFields))
+	copy(fields, pageFields)
+
+	fieldErrors := make([]string, 0)
+
+	for _, tenantField := range tenantFields {
+		value, err := normalizeProfileValue(tenantField, profileFieldValue(fields, tenantField.Identifier))
+		if err != nil {
+			fieldErrors = append(fieldErrors, fmt.Sprintf("field %s %s", tenantField.Identifier, err.Error()))
+			continue
+		}
+
+		if value == nil && tenantField.Required && tenantField.Default == nil {
+			fieldErrors = append(fieldErrors, fmt.Sprintf("field %s is required", tenantField.Identifier))
+			continue
+		}
+
+		for i := range fields {
+			if fields[i].Identifier == tenantField.Identifier {
+				fields[i].Value = value
+			}
+		}
+	}
+
+	return fields, fieldErrors
+}
@@ -0,0 +1,127 @@
+/*
+ * Copyright (C) 2025 Anthrove
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logic
+
+import (
+	"github.com/anthrove/identity/pkg/object"
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeProfileValue(t *testing.T) {
+	minAge, maxAge := int64(18), int64(120)
+
+	tests := []struct {
+		field   object.ProfileField
+		value   any
+		want    any
+		wantErr bool
+	}{
+		{field: object.ProfileField{}, value: "Jane", want: "Jane"},
+		{field: object.ProfileField{}, value: "", want: nil},
+		{field: object.ProfileField{}, value: 42, wantErr: true},
+		{field: object.ProfileField{MaxLength: 3}, value: "Jane", wantErr: true},
+		{field: object.ProfileField{Regex: "^[0-9]+$"}, value: "4711", want: "4711"},
+		{field: object.ProfileField{Regex: "^[0-9]+$"}, value: "abc", wantErr: true},
+		{field: object.ProfileField{Type: object.ProfileFieldTypeInt, Min: &minAge, Max: &maxAge}, value: float64(42), want: int64(42)},
+		{field: object.ProfileField{Type: object.ProfileFieldTypeInt, Min: &minAge, Max: &maxAge}, value: "42", want: int64(42)},
+		{field: object.ProfileField{Type: object.ProfileFieldTypeInt, Min: &minAge, Max: &maxAge}, value: float64(12), wantErr: true},
+		{field: object.ProfileField{Type: object.ProfileFieldTypeInt}, value: 4.2, wantErr: true},
+		{field: object.ProfileField{Type: object.ProfileFieldTypeBool}, value: "on", want: true},
+		{field: object.ProfileField{Type: object.ProfileFieldTypeBool}, value: "false", want: false},
+		{field: object.ProfileField{Type: object.ProfileFieldTypeBool}, value: "maybe", wantErr: true},
+		{field: object.ProfileField{Type: object.ProfileFieldTypeDate}, value: "1990-12-24", want: "1990-12-24"},
+		{field: object.ProfileField{Type: object.ProfileFieldTypeDate}, value: "24.12.1990", wantErr: true},
+		{field: object.ProfileField{Type: object.ProfileFieldTypeEnum, Options: []string{"a", "b"}}, value: "b", want: "b"},
+		{field: object.ProfileField{Type: object.ProfileFieldTypeEnum, Options: []string{"a", "b"}}, value: "c", wantErr: true},
+		{field: object.ProfileField{Type: object.ProfileFieldTypeMultiSelect, Options: []string{"a", "b"}}, value: []any{"b", "a", "b"}, want: []string{"b", "a"}},
+		{field: object.ProfileField{Type: object.ProfileFieldTypeMultiSelect, Options: []string{"a", "b"}}, value: []any{}, want: nil},
+		{field: object.ProfileField{Type: object.ProfileFieldTypeMultiSelect, Options: []string{"a", "b"}}, value: []any{"c"}, wantErr: true},
+		{field: object.ProfileField{Type: object.ProfileFieldTypePhone}, value: "+49 151 234-5678", want: "+491512345678"},
+		{field: object.ProfileField{Type: object.ProfileFieldTypePhone}, value: "0151 2345678", wantErr: true},
+		{field: object.ProfileField{Type: object.ProfileFieldTypeURL}, value: "https://example.com/me", want: "https://example.com/me"},
+		{field: object.ProfileField{Type: object.ProfileFieldTypeURL}, value: "javascript:alert(1)", wantErr: true},
+		{field: object.ProfileField{Type: object.ProfileFieldTypeResource}, value: "BsOOg4igppKxYwhAQQrD3GCRZ", want: "BsOOg4igppKxYwhAQQrD3GCRZ"},
+		{field: object.ProfileField{Type: object.ProfileFieldTypeResource}, value: "short", wantErr: true},
+	}
+
+	for i, test := range tests {
+		got, err := normalizeProfileValue(test.field, test.value)
+
+		if (err != nil) != test.wantErr {
+			t.Errorf("test %d: normalizeProfileValue() error = %v, want error %t", i, err, test.wantErr)
+			continue
+		}
+
+		if !test.wantErr && !reflect.DeepEqual(got, test.want) {
+			t.Errorf("test %d: normalizeProfileValue() = %#v, want %#v", i, got, test.want)
+		}
+	}
+}
+
+func TestValidateProfileSchema(t *testing.T) {
+	minValue, maxValue := int64(10), int64(1)
+
+	tests := []struct {
+		fields    []object.ProfileField
+		wantValid bool
+	}{
+		{fields: []object.ProfileField{{Identifier: "nickname"}, {Identifier: "age", Type: object.ProfileFieldTypeInt, Default: float64(18)}}, wantValid: true},
+		{fields: []object.ProfileField{{Identifier: "nickname"}, {Identifier: "nickname"}}},
+		{fields: []object.ProfileField{{Identifier: "team", Type: object.ProfileFieldTypeEnum}}},
+		{fields: []object.ProfileField{{Identifier: "age", Type: object.ProfileFieldTypeInt, Min: &minValue, Max: &maxValue}}},
+		{fields: []object.ProfileField{{Identifier: "code", Regex: "("}}},
+		{fields: []object.ProfileField{{Identifier: "age", Type: object.ProfileFieldTypeInt, Default: "old"}}},
+		{fields: []object.ProfileField{{Identifier: "employee_number", Unique: true, Default: "1"}}},
+	}
+
+	for i, test := range tests {
+		schemaErrors := validateProfileSchema(test.fields)
+
+		if (len(schemaErrors) == 0) != test.wantValid {
+			t.Errorf("test %d: validateProfileSchema() = %v, want valid %t", i, schemaErrors, test.wantValid)
+		}
+	}
+}
+
+func TestCheckProfileData(t *testing.T) {
+	pages := []object.ProfilePage{
+		{UserID: "jane", Fields: []object.ProfilePageField{{Identifier: "employee_number", Value: "1"}, {Identifier: "age", Value: float64(30)}}},
+		{UserID: "john", Fields: []object.ProfilePageField{{Identifier: "employee_number", Value: "1"}, {Identifier: "age", Value: "unknown"}}},
+	}
+
+	tests := []struct {
+		previous   []object.ProfileField
+		fields     []object.ProfileField
+		wantErrors int
+	}{
+		{fields: []object.ProfileField{{Identifier: "employee_number"}}},
+		{fields: []object.ProfileField{{Identifier: "employee_number", Unique: true}}, wantErrors: 1},
+		{fields: []object.ProfileField{{Identifier: "age", Type: object.ProfileFieldTypeInt}}, wantErrors: 1},
+		{fields: []object.ProfileField{{Identifier: "team", Required: true}}, wantErrors: 1},
+		{fields: []object.ProfileField{{Identifier: "team", Required: true, Default: "none"}}},
+		{previous: []object.ProfileField{{Identifier: "team", Required: true}}, fields: []object.ProfileField{{Identifier: "team", Required: true}}},
+	}
+
+	for i, test := range tests {
+		dataErrors := checkProfileData(test.previous, test.fields, pages, 3)
+
+		if len(dataErrors) != test.wantErrors {
+			t.Errorf("test %d: checkProfileData() = %v, want %d errors", i, dataErrors, test.wantErrors)
+		}
+	}
+}
@@ -23,8 +23,10 @@ import (
 	"github.com/anthrove/identity/pkg/object"
 	"github.com/anthrove/identity/pkg/provider"
 	"github.com/anthrove/identity/pkg/provider/auth"
+	"github.com/anthrove/identity/pkg/provider/captcha"
 	"github.com/anthrove/identity/pkg/provider/email"
 	"github.com/anthrove/identity/pkg/provider/mfa"
+	"github.com/anthrove/identity/pkg/provider/sms"
 	"github.com/anthrove/identity/pkg/provider/storage"
 	"github.com/anthrove/identity/pkg/repository"
 	"github.com/anthrove/identity/pkg/util"
@@ -112,7 +114,7 @@ func (is IdentityService) FindProviders(ctx context.Context, tenantID string, pa
 }
 
 func (is IdentityService) FindProviderCategories(ctx context.Context, tenantID string) ([]string, error) {
-	return []string{"email", "storage", "auth", "mfa"}, nil
+	return []string{"email", "storage", "auth", "mfa", "sms", "captcha"}, nil
 }
 
 func (is IdentityService) FindProviderTypes(ctx context.Context, tenantID string, category string) []string {
@@ -138,6 +140,10 @@ func validateProvider(providerObj object.Provider) error {
 		provider, err = mfa.GetMFAProvider(providerObj)
 	case "auth":
 		provider, err = auth.GetAuthProvider(providerObj)
+	case "sms":
+		provider, err = sms.GetSMSProvider(providerObj)
+	case "captcha":
+		provider, err = captcha.GetCaptchaProvider(providerObj)
 	default:
 		return errors.New("invalid provider category")
 	}
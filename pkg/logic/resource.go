@@ -26,7 +26,11 @@ import (
 	"github.com/anthrove/identity/pkg/repository"
 	"github.com/anthrove/identity/pkg/util"
 	"github.com/go-playground/validator/v10"
+	"github.com/qor/oss"
+	"gorm.io/gorm"
 	"io"
+	"log"
+	"os"
 )
 
 // CreateResource creates a new resource within a specified tenant.
@@ -53,6 +57,13 @@ func (is IdentityService) CreateResource(ctx context.Context, tenantId string, c
 		}
 	}
 
+	if len(createResource.GroupID) > 0 {
+		_, err = repository.FindGroup(ctx, dbConn, tenantId, createResource.GroupID)
+		if err != nil {
+			return object.Resource{}, errors.Join(fmt.Errorf("problem while finding group %s", createResource.GroupID), err)
+		}
+	}
+
 	provider, err := is.FindProvider(ctx, tenantId, createResource.ProviderID)
 	if err != nil {
 		return object.Resource{}, err
@@ -80,16 +91,109 @@ func (is IdentityService) CreateResource(ctx context.Context, tenantId string, c
 
 	resourcePath := fmt.Sprintf("%s/%s", createResource.Tag, filenameWithPrefix)
 
-	hash, err := util.HashFileMD5(file)
+	// the content is spooled to a temporary file, so it is hashed while reading and only uploaded if it is new
+	spool, err := os.CreateTemp("", "resource-*")
+	if err != nil {
+		return object.Resource{}, err
+	}
+	defer os.Remove(spool.Name())
+	defer spool.Close()
+
+	hash, err := util.HashFileSHA256(io.TeeReader(file, spool))
+	if err != nil {
+		return object.Resource{}, err
+	}
+
+	spoolInfo, err := spool.Stat()
+	if err != nil {
+		return object.Resource{}, err
+	}
+
+	createResource.FileSize = spoolInfo.Size()
+
+	var resource object.Resource
+	var blobPath string
+
+	// the tenant stays locked until the resource is stored, so parallel uploads can't exceed the quota together.
+	// The duplicate stays locked until the new resource references its content, so it can't be deleted in between.
+	err = dbConn.Transaction(func(tx *gorm.DB) error {
+		txCtx := saveDBConn(ctx, tx)
+
+		err := repository.LockTenant(txCtx, tx, tenantId)
+		if err != nil {
+			return err
+		}
+
+		duplicate, err := repository.LockResourceByHash(txCtx, tx, tenantId, provider.ID, hash)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = is.checkStorageQuota(txCtx, tenantId, createResource.FileSize, false)
+			if err != nil {
+				return err
+			}
+
+			// the resource reserves the quota for the upload, the hash is only set once the content is stored,
+			// so no other upload references the content before
+			resource, err = repository.CreateResource(txCtx, tx, tenantId, createResource, resourcePath, resourcePath, "", "")
+			return err
+		}
+
+		if err != nil {
+			return err
+		}
+
+		err = is.checkStorageQuota(txCtx, tenantId, createResource.FileSize, true)
+		if err != nil {
+			return err
+		}
+
+		blobPath = resourceStoragePath(duplicate)
+
+		resource, err = repository.CreateResource(txCtx, tx, tenantId, createResource, resourcePath, blobPath, duplicate.Url, hash)
+		return err
+	})
+
+	if err != nil {
+		return object.Resource{}, err
+	}
+
+	if len(blobPath) > 0 {
+		return resource, is.syncBlobAccess(ctx, fileProvider, tenantId, provider.ID, blobPath)
+	}
+
+	resourceObject, err := uploadSpool(fileProvider, resourcePath, spool, createResource.Visibility)
 	if err != nil {
+		// the failed upload must not keep the reserved quota
+		killErr := repository.KillResource(ctx, dbConn, tenantId, resource.ID)
+		if killErr != nil {
+			log.Printf("problem while deleting resource %s after the upload failed: %v", resource.ID, killErr)
+		}
+
 		return object.Resource{}, err
 	}
 
-	resourceObject, err := fileProvider.Put(resourcePath, file)
+	resource.Url = storedResourceURL(bucket, resourceObject)
+	resource.Hash = hash
+
+	err = repository.UpdateResourceContent(ctx, dbConn, tenantId, resource.ID, resource.Url, resource.Hash)
 	if err != nil {
 		return object.Resource{}, err
 	}
 
+	return resource, nil
+}
+
+// uploadSpool stores the spooled content of a new resource in the storage provider.
+func uploadSpool(fileProvider storage.Provider, resourcePath string, spool *os.File, visibility object.ResourceVisibility) (*oss.Object, error) {
+	_, err := spool.Seek(0, io.SeekStart)
+	if err != nil {
+		return nil, err
+	}
+
+	return putResource(fileProvider, resourcePath, spool, visibility)
+}
+
+// storedResourceURL returns the URL of content stored in a storage provider.
+func storedResourceURL(bucket string, resourceObject *oss.Object) string {
 	// TODO: the URL is not the full URL of the file, including the gin path
 	resourceURL := resourceObject.Path
 
@@ -98,10 +202,11 @@ func (is IdentityService) CreateResource(ctx context.Context, tenantId string, c
 		resourceURL = fmt.Sprintf("%s/%s/%s", resourceObject.StorageInterface.GetEndpoint(), bucket, resourceObject.Path)
 	}
 
-	return repository.CreateResource(ctx, dbConn, tenantId, createResource, resourcePath, resourceURL, hash)
+	return resourceURL
 }
 
-// KillResource deletes an existing resource within a specified tenant.
+// KillResource deletes an existing resource within a specified tenant, together with its variants.
+// The content is only deleted from the storage provider once no other resource references it.
 //
 // Parameters:
 //   - ctx: context for managing request-scoped values, cancelation, and deadlines.
@@ -118,6 +223,18 @@ func (is IdentityService) KillResource(ctx context.Context, tenantID string, res
 		return err
 	}
 
+	variants, err := repository.FindResourceVariants(ctx, dbConn, tenantID, resourceID)
+	if err != nil {
+		return err
+	}
+
+	for _, variant := range variants {
+		err = is.KillResource(ctx, tenantID, variant.ID)
+		if err != nil {
+			return err
+		}
+	}
+
 	provider, err := is.FindProvider(ctx, tenantID, resource.ProviderID)
 	if err != nil {
 		return err
@@ -128,14 +245,38 @@ func (is IdentityService) KillResource(ctx context.Context, tenantID string, res
 		return err
 	}
 
-	err = fileProvider.Delete(resource.FilePath)
+	blobPath := resourceStoragePath(resource)
+
+	var references int64
+
+	// the references stay locked until the resource is gone, so no upload can reuse the content in between
+	err = dbConn.Transaction(func(tx *gorm.DB) error {
+		err := repository.LockBlobResources(ctx, tx, tenantID, resource.ProviderID, blobPath)
+		if err != nil {
+			return err
+		}
+
+		err = repository.KillResource(ctx, tx, tenantID, resourceID)
+		if err != nil {
+			return err
+		}
+
+		references, err = repository.CountBlobResources(ctx, tx, tenantID, resource.ProviderID, blobPath)
+		return err
+	})
+
 	if err != nil {
 		return err
 	}
 
-	err = repository.KillResource(ctx, dbConn, tenantID, resourceID)
+	if references > 0 {
+		return is.syncBlobAccess(ctx, fileProvider, tenantID, resource.ProviderID, blobPath)
+	}
+
+	// the content is deleted after the last resource which references it is gone, the resource is deleted anyway
+	err = fileProvider.Delete(blobPath)
 	if err != nil {
-		return err
+		log.Printf("problem while deleting the content %s of resource %s: %v", blobPath, resourceID, err)
 	}
 
 	return nil
@@ -0,0 +1,287 @@
+/*
+ * Copyright (C) 2025 Anthrove
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logic
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"github.com/anthrove/identity/pkg/object"
+	"github.com/anthrove/identity/pkg/provider/storage"
+	"github.com/anthrove/identity/pkg/repository"
+	"github.com/anthrove/identity/pkg/util"
+	"github.com/go-playground/validator/v10"
+	"github.com/qor/oss"
+	"io"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// ErrResourceForbidden is returned when a request may not download a resource which is not public.
+var ErrResourceForbidden = errors.New("resource is not accessible")
+
+// defaultResourceURLExpiry is the lifetime of signed URLs without an explicit expiry.
+const defaultResourceURLExpiry = time.Hour
+
+// UpdateResourceVisibility changes who can download a resource and its variants.
+// Files of providers which can be downloaded directly, e.g. S3, are made private or public as well. Content shared
+// with other resources stays public as long as one of them is public.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancelation, and deadlines.
+//   - tenantID: unique identifier of the tenant to which the resource belongs.
+//   - resourceID: unique identifier of the resource to be updated.
+//   - updateVisibility: object containing the new visibility.
+//
+// Returns:
+//   - Error if there is any issue during validation or updating.
+func (is IdentityService) UpdateResourceVisibility(ctx context.Context, tenantID string, resourceID string, updateVisibility object.UpdateResourceVisibility) error {
+	dbConn, _ := is.getDBConn(ctx)
+
+	err := validate.Struct(updateVisibility)
+	if err != nil {
+		var validateErrs validator.ValidationErrors
+		if errors.As(err, &validateErrs) {
+			return errors.Join(fmt.Errorf("problem while validating update resource visibility data"), util.ConvertValidationError(validateErrs))
+		}
+	}
+
+	if updateVisibility.Visibility != object.ResourceVisibilityGroup {
+		updateVisibility.GroupID = ""
+	}
+
+	if len(updateVisibility.GroupID) > 0 {
+		_, err = repository.FindGroup(ctx, dbConn, tenantID, updateVisibility.GroupID)
+		if err != nil {
+			return errors.Join(fmt.Errorf("problem while finding group %s", updateVisibility.GroupID), err)
+		}
+	}
+
+	resource, err := is.FindResource(ctx, tenantID, resourceID)
+	if err != nil {
+		return err
+	}
+
+	variants, err := repository.FindResourceVariants(ctx, dbConn, tenantID, resourceID)
+	if err != nil {
+		return err
+	}
+
+	err = repository.UpdateResourceVisibility(ctx, dbConn, tenantID, resourceID, updateVisibility)
+	if err != nil {
+		return err
+	}
+
+	provider, err := is.FindProvider(ctx, tenantID, resource.ProviderID)
+	if err != nil {
+		return err
+	}
+
+	fileProvider, err := storage.GetStorageProvider(provider)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range append(variants, resource) {
+		err = is.syncBlobAccess(ctx, fileProvider, tenantID, file.ProviderID, resourceStoragePath(file))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// CreateResourceURL creates a download URL of a resource which works without a session until it expires.
+// Providers which can be downloaded directly, e.g. S3, create a presigned URL, other resources get a signed CDN URL.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancelation, and deadlines.
+//   - tenantID: unique identifier of the tenant to which the resource belongs.
+//   - resourceID: unique identifier of the resource.
+//   - createURL: object containing the lifetime of the URL.
+//
+// Returns:
+//   - ResourceURL object with the URL and its expiry.
+//   - Error if there is any issue during validation or signing.
+func (is IdentityService) CreateResourceURL(ctx context.Context, tenantID string, resourceID string, createURL object.CreateResourceURL) (object.ResourceURL, error) {
+	err := validate.Struct(createURL)
+	if err != nil {
+		var validateErrs validator.ValidationErrors
+		if errors.As(err, &validateErrs) {
+			return object.ResourceURL{}, errors.Join(fmt.Errorf("problem while validating create resource url data"), util.ConvertValidationError(validateErrs))
+		}
+	}
+
+	resource, err := is.FindResource(ctx, tenantID, resourceID)
+	if err != nil {
+		return object.ResourceURL{}, err
+	}
+
+	expiry := defaultResourceURLExpiry
+	if createURL.ExpiresIn > 0 {
+		expiry = time.Duration(createURL.ExpiresIn) * time.Second
+	}
+
+	expiresAt := time.Now().Add(expiry).Truncate(time.Second)
+
+	provider, err := is.FindProvider(ctx, tenantID, resource.ProviderID)
+	if err != nil {
+		return object.ResourceURL{}, err
+	}
+
+	fileProvider, err := storage.GetStorageProvider(provider)
+	if err != nil {
+		return object.ResourceURL{}, err
+	}
+
+	if privateProvider, ok := fileProvider.(storage.PrivateProvider); ok {
+		presignedURL, err := privateProvider.PresignURL(resourceStoragePath(resource), expiry)
+		if err != nil {
+			return object.ResourceURL{}, errors.Join(fmt.Errorf("problem while presigning resource %s", resource.ID), err)
+		}
+
+		return object.ResourceURL{URL: presignedURL, ExpiresAt: expiresAt}, nil
+	}
+
+	key, err := is.resourceSigningKey(ctx, tenantID)
+	if err != nil {
+		return object.ResourceURL{}, err
+	}
+
+	expires := strconv.FormatInt(expiresAt.Unix(), 10)
+	query := url.Values{
+		"expires":   {expires},
+		"signature": {signResource(key, resource, expires)},
+	}
+
+	return object.ResourceURL{
+		URL:       resourceCDNPath(resource) + "?" + query.Encode(),
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// authorizeResource checks if a CDN request may download a resource. Public resources can be downloaded by everyone,
+// other resources need a valid signed URL, the user who uploaded them or a signed-in user who passes their visibility.
+func (is IdentityService) authorizeResource(ctx context.Context, resource object.Resource, access object.ResourceAccess) error {
+	if !isPrivateVisibility(resource.Visibility) {
+		return nil
+	}
+
+	if len(access.Signature) > 0 {
+		key, err := is.resourceSigningKey(ctx, resource.TenantID)
+		if err != nil {
+			return err
+		}
+
+		if verifyResourceSignature(key, resource, access.Expires, access.Signature, time.Now()) {
+			return nil
+		}
+	}
+
+	if len(access.UserID) == 0 {
+		return ErrResourceForbidden
+	}
+
+	// the user who uploaded a resource can always download it
+	if resource.UserID == access.UserID {
+		return nil
+	}
+
+	switch resource.Visibility {
+	case object.ResourceVisibilityTenant:
+		return nil
+	case object.ResourceVisibilityGroup:
+		dbConn, _ := is.getDBConn(ctx)
+
+		member, err := repository.IsUserInGroup(ctx, dbConn, resource.TenantID, access.UserID, resource.GroupID)
+		if err != nil {
+			return err
+		}
+
+		if member {
+			return nil
+		}
+	}
+
+	return ErrResourceForbidden
+}
+
+// resourceSigningKey returns the key which signs the resource URLs of a tenant. The key is created on first use.
+func (is IdentityService) resourceSigningKey(ctx context.Context, tenantID string) ([]byte, error) {
+	dbConn, _ := is.getDBConn(ctx)
+
+	tenant, err := repository.FindTenant(ctx, dbConn, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tenant.ResourceSigningKey) == 0 {
+		key, err := util.RandomString(64)
+		if err != nil {
+			return nil, err
+		}
+
+		err = repository.UpdateTenantResourceSigningKey(ctx, dbConn, tenantID, key)
+		if err != nil {
+			return nil, errors.Join(fmt.Errorf("problem while creating resource signing key"), err)
+		}
+
+		// a concurrent request could have stored its key first
+		tenant, err = repository.FindTenant(ctx, dbConn, tenantID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return []byte(tenant.ResourceSigningKey), nil
+}
+
+// signResource signs the path of a resource together with the expiry of the URL.
+func signResource(key []byte, resource object.Resource, expires string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(resource.TenantID + "\n" + resource.FilePath + "\n" + expires))
+
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// verifyResourceSignature reports if a signature of a resource is valid and not expired at now.
+func verifyResourceSignature(key []byte, resource object.Resource, expires string, signature string, now time.Time) bool {
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || now.Unix() > expiresAt {
+		return false
+	}
+
+	return hmac.Equal([]byte(signResource(key, resource, expires)), []byte(signature))
+}
+
+// putResource uploads a file, providers which can be downloaded directly store files which are not public as private.
+func putResource(fileProvider storage.Provider, path string, reader io.Reader, visibility object.ResourceVisibility) (*oss.Object, error) {
+	if privateProvider, ok := fileProvider.(storage.PrivateProvider); ok && isPrivateVisibility(visibility) {
+		return privateProvider.PutPrivate(path, reader)
+	}
+
+	return fileProvider.Put(path, reader)
+}
+
+func isPrivateVisibility(visibility object.ResourceVisibility) bool {
+	return len(visibility) > 0 && visibility != object.ResourceVisibilityPublic
+}
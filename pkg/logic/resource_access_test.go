@@ -0,0 +1,64 @@
+/*
+ * Copyright (C) 2025 Anthrove
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logic
+
+import (
+	"github.com/anthrove/identity/pkg/object"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestVerifyResourceSignature(t *testing.T) {
+	key := []byte("0123456789abcdef0123456789abcdef")
+	now := time.Unix(1735689600, 0)
+	resource := object.Resource{TenantID: "BsOOg4igppKxYwhAQQrD3GCRZ", FilePath: "profile/a1b2c3d4e5_cv.pdf"}
+
+	expires := strconv.FormatInt(now.Add(time.Hour).Unix(), 10)
+	signature := signResource(key, resource, expires)
+
+	otherPath := resource
+	otherPath.FilePath = "profile/a1b2c3d4e5_other.pdf"
+
+	otherTenant := resource
+	otherTenant.TenantID = "CsOOg4igppKxYwhAQQrD3GCRZ"
+
+	tests := []struct {
+		name      string
+		key       []byte
+		resource  object.Resource
+		expires   string
+		signature string
+		now       time.Time
+		want      bool
+	}{
+		{name: "valid", key: key, resource: resource, expires: expires, signature: signature, now: now, want: true},
+		{name: "expired", key: key, resource: resource, expires: expires, signature: signature, now: now.Add(2 * time.Hour), want: false},
+		{name: "other path", key: key, resource: otherPath, expires: expires, signature: signature, now: now, want: false},
+		{name: "other tenant", key: key, resource: otherTenant, expires: expires, signature: signature, now: now, want: false},
+		{name: "other key", key: []byte("fedcba9876543210fedcba9876543210"), resource: resource, expires: expires, signature: signature, now: now, want: false},
+		{name: "extended expiry", key: key, resource: resource, expires: strconv.FormatInt(now.Add(48*time.Hour).Unix(), 10), signature: signature, now: now, want: false},
+		{name: "invalid expiry", key: key, resource: resource, expires: "tomorrow", signature: signature, now: now, want: false},
+		{name: "missing signature", key: key, resource: resource, expires: expires, signature: "", now: now, want: false},
+	}
+
+	for _, test := range tests {
+		if got := verifyResourceSignature(test.key, test.resource, test.expires, test.signature, test.now); got != test.want {
+			t.Errorf("%s: verifyResourceSignature() = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,468 @@
+/*
+ * Copyright (C) 2025 Anthrove
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logic
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/anthrove/identity/pkg/object"
+	"github.com/anthrove/identity/pkg/provider/storage"
+	"github.com/anthrove/identity/pkg/repository"
+	"github.com/anthrove/identity/pkg/util"
+	"github.com/go-playground/validator/v10"
+	gonanoid "github.com/matoous/go-nanoid/v2"
+	"gorm.io/gorm"
+	"hash"
+	"io"
+	"log"
+	"sync"
+	"time"
+)
+
+// ErrResourceMigrationRunning is returned when a storage migration of the tenant is already running.
+var ErrResourceMigrationRunning = errors.New("a storage migration is already running")
+
+const (
+	// maxMigrationErrors is the number of errors kept in the progress of a storage migration.
+	maxMigrationErrors = 50
+
+	// resourceMigrationLockTimeout is how long the lock of a migration is kept without being refreshed, e.g. after a crash.
+	resourceMigrationLockTimeout = 5 * time.Minute
+	resourceMigrationLockRefresh = time.Minute
+)
+
+// resourceMigrations holds the progress of the last storage migration of each tenant. Like the sessions it only
+// lives in memory, a migration which was interrupted by a restart is resumed by starting it again.
+// Whether a migration is running is decided by the lock in the database, which is shared with the CLI.
+var resourceMigrations = make(map[string]object.ResourceMigrationProgress)
+var resourceMigrationMutex sync.RWMutex
+
+// resourceMigrationTarget is the storage provider resources are migrated to.
+type resourceMigrationTarget struct {
+	provider     object.Provider
+	fileProvider storage.Provider
+	bucket       string
+}
+
+// StartResourceMigration validates a storage migration and runs it in the background.
+// The progress can be retrieved with FindResourceMigration.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancelation, and deadlines.
+//   - tenantID: unique identifier of the tenant whose resources are migrated.
+//   - migrate: object containing the source and target storage provider.
+//
+// Returns:
+//   - ResourceMigrationProgress object of the started migration.
+//   - Error if there is any issue during validation, ErrResourceMigrationRunning if a migration is already running.
+func (is IdentityService) StartResourceMigration(ctx context.Context, tenantID string, migrate object.MigrateResources) (object.ResourceMigrationProgress, error) {
+	_, _, err := is.resourceMigrationProviders(ctx, tenantID, migrate)
+	if err != nil {
+		return object.ResourceMigrationProgress{}, err
+	}
+
+	release, err := is.lockResourceMigration(ctx, tenantID)
+	if err != nil {
+		return object.ResourceMigrationProgress{}, err
+	}
+
+	progress := object.ResourceMigrationProgress{
+		SourceProviderID: migrate.SourceProviderID,
+		TargetProviderID: migrate.TargetProviderID,
+		Running:          true,
+		StartedAt:        time.Now(),
+	}
+
+	resourceMigrationMutex.Lock()
+	resourceMigrations[tenantID] = progress
+	resourceMigrationMutex.Unlock()
+
+	// the migration outlives the request, so it doesn't use its context
+	go func() {
+		defer release()
+
+		_, err := is.migrateResources(context.Background(), tenantID, migrate, func(progress object.ResourceMigrationProgress) {
+			resourceMigrationMutex.Lock()
+			resourceMigrations[tenantID] = progress
+			resourceMigrationMutex.Unlock()
+		})
+
+		if err != nil {
+			log.Printf("problem while migrating the resources of tenant %s: %v", tenantID, err)
+		}
+	}()
+
+	return progress, nil
+}
+
+// FindResourceMigration retrieves the progress of the last storage migration of a tenant.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancelation, and deadlines.
+//   - tenantID: unique identifier of the tenant.
+//
+// Returns:
+//   - ResourceMigrationProgress object of the last migration.
+//   - Error if the tenant has no migration since the last restart.
+func (is IdentityService) FindResourceMigration(ctx context.Context, tenantID string) (object.ResourceMigrationProgress, error) {
+	resourceMigrationMutex.RLock()
+	defer resourceMigrationMutex.RUnlock()
+
+	progress, ok := resourceMigrations[tenantID]
+	if !ok {
+		return object.ResourceMigrationProgress{}, errors.New("no storage migration found")
+	}
+
+	return progress, nil
+}
+
+// MigrateResources moves all resources of a tenant from one storage provider to another. The content is streamed
+// to the target provider and verified against its hash before the resources are updated, the CDN path of the
+// resources stays the same. Content which failed is left in the source provider, running the migration again only
+// moves the remaining resources, also after the context was canceled. The storage provider of the tenant should point
+// to the target provider first, so new uploads don't end up in the source provider. Only one migration of a tenant
+// can run at a time, also across processes.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancelation, and deadlines.
+//   - tenantID: unique identifier of the tenant whose resources are migrated.
+//   - migrate: object containing the source and target storage provider.
+//   - report: called with the progress after each migrated content, can be nil.
+//
+// Returns:
+//   - ResourceMigrationProgress object of the finished migration.
+//   - Error if there is any issue during validation or while listing the resources, ErrResourceMigrationRunning if a
+//     migration is already running.
+func (is IdentityService) MigrateResources(ctx context.Context, tenantID string, migrate object.MigrateResources, report func(progress object.ResourceMigrationProgress)) (object.ResourceMigrationProgress, error) {
+	release, err := is.lockResourceMigration(ctx, tenantID)
+	if err != nil {
+		return object.ResourceMigrationProgress{}, err
+	}
+	defer release()
+
+	return is.migrateResources(ctx, tenantID, migrate, report)
+}
+
+// migrateResources runs a storage migration, the caller has to hold the lock of the migration.
+func (is IdentityService) migrateResources(ctx context.Context, tenantID string, migrate object.MigrateResources, report func(progress object.ResourceMigrationProgress)) (object.ResourceMigrationProgress, error) {
+	dbConn, _ := is.getDBConn(ctx)
+
+	progress := object.ResourceMigrationProgress{
+		SourceProviderID: migrate.SourceProviderID,
+		TargetProviderID: migrate.TargetProviderID,
+		Running:          true,
+		StartedAt:        time.Now(),
+	}
+
+	if report == nil {
+		report = func(object.ResourceMigrationProgress) {}
+	}
+
+	finish := func(err error) (object.ResourceMigrationProgress, error) {
+		finishedAt := time.Now()
+		progress.Running = false
+		progress.FinishedAt = &finishedAt
+
+		if err != nil {
+			progress.Errors = append(progress.Errors, err.Error())
+		}
+
+		report(progress)
+		return progress, err
+	}
+
+	source, target, err := is.resourceMigrationProviders(ctx, tenantID, migrate)
+	if err != nil {
+		return finish(err)
+	}
+
+	resources, err := repository.FindProviderResources(ctx, dbConn, tenantID, migrate.SourceProviderID)
+	if err != nil {
+		return finish(err)
+	}
+
+	progress.Total = len(resources)
+	report(progress)
+
+	// resources sharing their content are moved together
+	references := make(map[string]int)
+	for _, resource := range resources {
+		references[resourceStoragePath(resource)]++
+	}
+
+	migrated := make(map[string]bool)
+
+	for _, resource := range resources {
+		// the migrated resources are kept, running the migration again moves the remaining ones
+		if ctx.Err() != nil {
+			return finish(ctx.Err())
+		}
+
+		blobPath := resourceStoragePath(resource)
+		if migrated[blobPath] {
+			continue
+		}
+
+		migrated[blobPath] = true
+
+		moved, err := is.migrateBlob(ctx, tenantID, source, target, resource, migrate.DeleteSource)
+		if err != nil {
+			progress.Failed += references[blobPath]
+
+			if len(progress.Errors) < maxMigrationErrors {
+				progress.Errors = append(progress.Errors, fmt.Sprintf("resource %s: %v", resource.ID, err))
+			}
+		} else {
+			progress.Migrated += int(moved)
+			progress.Bytes += resource.FileSize
+		}
+
+		report(progress)
+	}
+
+	return finish(nil)
+}
+
+// lockResourceMigration takes the migration lock of the tenant and refreshes it in the background. The returned
+// function releases the lock.
+func (is IdentityService) lockResourceMigration(ctx context.Context, tenantID string) (func(), error) {
+	dbConn, _ := is.getDBConn(ctx)
+
+	lockID, err := gonanoid.New(25)
+	if err != nil {
+		return nil, err
+	}
+
+	taken, err := repository.CreateResourceMigrationLock(ctx, dbConn, tenantID, lockID, time.Now().Add(-resourceMigrationLockTimeout))
+	if err != nil {
+		return nil, err
+	}
+
+	if !taken {
+		return nil, ErrResourceMigrationRunning
+	}
+
+	done := make(chan struct{})
+
+	// the lock is refreshed independent of the caller's context, it is released by the returned function
+	go func() {
+		ticker := time.NewTicker(resourceMigrationLockRefresh)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				err := repository.RefreshResourceMigrationLock(context.Background(), dbConn, tenantID, lockID)
+				if err != nil {
+					log.Printf("problem while refreshing the storage migration lock of tenant %s: %v", tenantID, err)
+				}
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+
+		err := repository.KillResourceMigrationLock(context.Background(), dbConn, tenantID, lockID)
+		if err != nil {
+			log.Printf("problem while releasing the storage migration lock of tenant %s: %v", tenantID, err)
+		}
+	}, nil
+}
+
+// resourceMigrationProviders validates a storage migration and returns the source and target storage provider.
+func (is IdentityService) resourceMigrationProviders(ctx context.Context, tenantID string, migrate object.MigrateResources) (storage.Provider, resourceMigrationTarget, error) {
+	err := validate.Struct(migrate)
+	if err != nil {
+		var validateErrs validator.ValidationErrors
+		if errors.As(err, &validateErrs) {
+			return nil, resourceMigrationTarget{}, errors.Join(fmt.Errorf("problem while validating migrate resources data"), util.ConvertValidationError(validateErrs))
+		}
+	}
+
+	_, source, err := is.migrationStorageProvider(ctx, tenantID, migrate.SourceProviderID)
+	if err != nil {
+		return nil, resourceMigrationTarget{}, err
+	}
+
+	provider, fileProvider, err := is.migrationStorageProvider(ctx, tenantID, migrate.TargetProviderID)
+	if err != nil {
+		return nil, resourceMigrationTarget{}, err
+	}
+
+	var parameters map[string]string
+	err = json.Unmarshal(provider.Parameter, &parameters)
+	if err != nil {
+		return nil, resourceMigrationTarget{}, err
+	}
+
+	return source, resourceMigrationTarget{
+		provider:     provider,
+		fileProvider: fileProvider,
+		bucket:       parameters["bucket"],
+	}, nil
+}
+
+// migrationStorageProvider returns a storage provider of the tenant for a storage migration.
+func (is IdentityService) migrationStorageProvider(ctx context.Context, tenantID string, providerID string) (object.Provider, storage.Provider, error) {
+	provider, err := is.FindProvider(ctx, tenantID, providerID)
+	if err != nil {
+		return object.Provider{}, nil, errors.Join(fmt.Errorf("storage provider %s not found", providerID), err)
+	}
+
+	if provider.Category != "storage" {
+		return object.Provider{}, nil, fmt.Errorf("provider %s category not storage", providerID)
+	}
+
+	fileProvider, err := storage.GetStorageProvider(provider)
+	if err != nil {
+		return object.Provider{}, nil, err
+	}
+
+	return provider, fileProvider, nil
+}
+
+// migrateBlob copies the content of a resource to the target provider and moves every resource which shares the
+// content. Content which is already in the target provider is not copied again.
+func (is IdentityService) migrateBlob(ctx context.Context, tenantID string, source storage.Provider, target resourceMigrationTarget, resource object.Resource, deleteSource bool) (int64, error) {
+	dbConn, _ := is.getDBConn(ctx)
+
+	blobPath := resourceStoragePath(resource)
+	targetPath := blobPath
+	var targetURL string
+
+	var existing object.Resource
+	var err error
+	found := false
+
+	if len(resource.Hash) > 0 {
+		existing, err = repository.FindResourceByHash(ctx, dbConn, tenantID, target.provider.ID, resource.Hash)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, err
+		}
+
+		found = err == nil
+	}
+
+	if found {
+		targetPath = resourceStoragePath(existing)
+		targetURL = existing.Url
+	} else {
+		targetURL, err = copyBlob(source, target, blobPath, resource.Hash)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	moved, err := repository.UpdateBlobStorage(ctx, dbConn, tenantID, resource.ProviderID, blobPath, target.provider.ID, targetPath, targetURL)
+	if err != nil {
+		return 0, err
+	}
+
+	// the resources were deleted while the content was copied
+	if moved == 0 {
+		if !found {
+			return 0, target.fileProvider.Delete(targetPath)
+		}
+
+		return 0, nil
+	}
+
+	err = is.syncBlobAccess(ctx, target.fileProvider, tenantID, target.provider.ID, targetPath)
+	if err != nil {
+		return moved, err
+	}
+
+	if deleteSource {
+		err = source.Delete(blobPath)
+		if err != nil {
+			log.Printf("problem while deleting migrated content %s: %v", blobPath, err)
+		}
+	}
+
+	return moved, nil
+}
+
+// copyBlob streams content from the source to the target provider and verifies the copy. The content is stored
+// private, the access is synced once the resources are moved.
+func copyBlob(source storage.Provider, target resourceMigrationTarget, blobPath string, expectedHash string) (string, error) {
+	stream, err := source.GetStream(blobPath)
+	if err != nil {
+		return "", errors.Join(fmt.Errorf("problem while reading %s", blobPath), err)
+	}
+	defer stream.Close()
+
+	sourceHasher := contentHasher(expectedHash)
+
+	resourceObject, err := putResource(target.fileProvider, blobPath, io.TeeReader(stream, sourceHasher), object.ResourceVisibilityOwner)
+	if err != nil {
+		return "", errors.Join(fmt.Errorf("problem while copying %s", blobPath), err)
+	}
+
+	sourceHash := hex.EncodeToString(sourceHasher.Sum(nil))
+
+	copied, err := target.fileProvider.GetStream(blobPath)
+	if err != nil {
+		return "", errors.Join(fmt.Errorf("problem while reading the copy of %s", blobPath), err)
+	}
+	defer copied.Close()
+
+	err = verifyContent(copied, sourceHash, expectedHash)
+	if err != nil {
+		return "", errors.Join(err, target.fileProvider.Delete(blobPath))
+	}
+
+	return storedResourceURL(target.bucket, resourceObject), nil
+}
+
+// contentHasher returns the hash function of a resource hash, resources from before deduplication have MD5 hashes.
+func contentHasher(resourceHash string) hash.Hash {
+	if len(resourceHash) == hex.EncodedLen(md5.Size) {
+		return md5.New()
+	}
+
+	return sha256.New()
+}
+
+// verifyContent checks that content has the hash of the source stream and, if known, the hash of the resource.
+func verifyContent(content io.Reader, sourceHash string, expectedHash string) error {
+	hasher := contentHasher(expectedHash)
+
+	_, err := io.Copy(hasher, content)
+	if err != nil {
+		return err
+	}
+
+	contentHash := hex.EncodeToString(hasher.Sum(nil))
+
+	if len(expectedHash) > 0 && sourceHash != expectedHash {
+		return fmt.Errorf("source content does not match the hash %s", expectedHash)
+	}
+
+	if contentHash != sourceHash {
+		return fmt.Errorf("copied content does not match the hash %s", sourceHash)
+	}
+
+	return nil
+}
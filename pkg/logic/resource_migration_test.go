@@ -0,0 +1,179 @@
+/*
+ * Copyright (C) 2025 Anthrove
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logic
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"github.com/anthrove/identity/pkg/object"
+	"github.com/anthrove/identity/pkg/repository"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVerifyContent(t *testing.T) {
+	md5Sum := md5.Sum([]byte("content"))
+	sha256Sum := sha256.Sum256([]byte("content"))
+	otherSum := sha256.Sum256([]byte("other"))
+
+	md5Hash := hex.EncodeToString(md5Sum[:])
+	sha256Hash := hex.EncodeToString(sha256Sum[:])
+	otherHash := hex.EncodeToString(otherSum[:])
+
+	tests := []struct {
+		name         string
+		content      string
+		sourceHash   string
+		expectedHash string
+		wantErr      bool
+	}{
+		{name: "sha256 resource", content: "content", sourceHash: sha256Hash, expectedHash: sha256Hash},
+		{name: "legacy md5 resource", content: "content", sourceHash: md5Hash, expectedHash: md5Hash},
+		{name: "unknown hash", content: "content", sourceHash: sha256Hash},
+		{name: "changed source", content: "other", sourceHash: otherHash, expectedHash: sha256Hash, wantErr: true},
+		{name: "corrupted copy", content: "corrupted", sourceHash: sha256Hash, expectedHash: sha256Hash, wantErr: true},
+	}
+
+	for _, test := range tests {
+		err := verifyContent(strings.NewReader(test.content), test.sourceHash, test.expectedHash)
+
+		if (err != nil) != test.wantErr {
+			t.Errorf("%s: verifyContent() error = %v, wantErr %v", test.name, err, test.wantErr)
+		}
+	}
+}
+
+func TestMigrateResourcesResume(t *testing.T) {
+	ctx := context.Background()
+	is, tenant := newTestService(t)
+
+	var providers []object.Provider
+	for _, basePath := range []string{"source", "target"} {
+		provider, err := is.CreateProvider(ctx, tenant.ID, object.CreateProvider{
+			DisplayName:  basePath,
+			Category:     "storage",
+			ProviderType: "local",
+			Parameter:    []byte(`{"base_path":"` + basePath + `"}`),
+		})
+		if err != nil {
+			t.Fatalf("CreateProvider() error = %v", err)
+		}
+
+		providers = append(providers, provider)
+	}
+
+	contents := []string{"first", "second", "third"}
+	var resources []object.Resource
+
+	for _, content := range contents {
+		resource, err := is.CreateResource(ctx, tenant.ID, object.CreateResource{
+			ProviderID: providers[0].ID,
+			Tag:        "document",
+			FileName:   content + ".txt",
+			MimeType:   "text/plain",
+		}, strings.NewReader(content))
+		if err != nil {
+			t.Fatalf("CreateResource() error = %v", err)
+		}
+
+		resources = append(resources, resource)
+	}
+
+	migrate := object.MigrateResources{
+		SourceProviderID: providers[0].ID,
+		TargetProviderID: providers[1].ID,
+		DeleteSource:     true,
+	}
+
+	// the migration is interrupted after the first content
+	interruptCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	progress, err := is.MigrateResources(interruptCtx, tenant.ID, migrate, func(progress object.ResourceMigrationProgress) {
+		if progress.Migrated == 1 {
+			cancel()
+		}
+	})
+	if !errors.Is(err, context.Canceled) || progress.Migrated != 1 {
+		t.Fatalf("interrupted MigrateResources() = %d migrated, %v, want 1 migrated and context.Canceled", progress.Migrated, err)
+	}
+
+	progress, err = is.MigrateResources(ctx, tenant.ID, migrate, nil)
+	if err != nil || progress.Total != 2 || progress.Migrated != 2 || progress.Failed != 0 {
+		t.Fatalf("resumed MigrateResources() = %+v, %v, want the remaining 2 resources", progress, err)
+	}
+
+	remaining, err := repository.FindProviderResources(ctx, is.db, tenant.ID, providers[0].ID)
+	if err != nil || len(remaining) != 0 {
+		t.Errorf("resources in the source provider = %d, %v, want none", len(remaining), err)
+	}
+
+	for i, resource := range resources {
+		_, content, err := is.ServeResource(ctx, tenant.ID, resource.FilePath, object.ResourceAccess{})
+		if err != nil {
+			t.Fatalf("ServeResource(%s) error = %v", resource.FilePath, err)
+		}
+
+		data, _ := io.ReadAll(content)
+		content.Close()
+
+		if string(data) != contents[i] {
+			t.Errorf("ServeResource(%s) = %q, want %q", resource.FilePath, data, contents[i])
+		}
+	}
+}
+
+func TestResourceMigrationLock(t *testing.T) {
+	ctx := context.Background()
+	is, tenant := newTestService(t)
+
+	release, err := is.lockResourceMigration(ctx, tenant.ID)
+	if err != nil {
+		t.Fatalf("lockResourceMigration() error = %v", err)
+	}
+
+	// another process, e.g. the CLI, can't start a migration of the same tenant
+	_, err = is.MigrateResources(ctx, tenant.ID, object.MigrateResources{SourceProviderID: "source", TargetProviderID: "target"}, nil)
+	if !errors.Is(err, ErrResourceMigrationRunning) {
+		t.Errorf("MigrateResources() while locked error = %v, want ErrResourceMigrationRunning", err)
+	}
+
+	release()
+
+	_, err = is.lockResourceMigration(ctx, tenant.ID)
+	if err != nil {
+		t.Fatalf("lockResourceMigration() after the release error = %v", err)
+	}
+
+	// a lock which is not refreshed anymore was left by a stopped process
+	err = is.db.Model(&object.ResourceMigrationLock{}).Where("tenant_id = ?", tenant.ID).UpdateColumn("updated_at", time.Now().Add(-time.Hour)).Error
+	if err != nil {
+		t.Fatalf("outdating the lock: %v", err)
+	}
+
+	release, err = is.lockResourceMigration(ctx, tenant.ID)
+	if err != nil {
+		t.Fatalf("lockResourceMigration() of a stale lock error = %v", err)
+	}
+
+	release()
+}
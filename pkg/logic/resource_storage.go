@@ -0,0 +1,117 @@
+/*
+ * Copyright (C) 2025 Anthrove
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/anthrove/identity/pkg/object"
+	"github.com/anthrove/identity/pkg/provider/storage"
+	"github.com/anthrove/identity/pkg/repository"
+	"slices"
+)
+
+// ErrStorageQuotaExceeded is returned when an upload would exceed the storage quota of the tenant.
+var ErrStorageQuotaExceeded = errors.New("storage quota exceeded")
+
+// FindStorageUsage retrieves the storage used by the resources of a tenant together with its quota.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancelation, and deadlines.
+//   - tenantID: unique identifier of the tenant.
+//
+// Returns:
+//   - StorageUsage object if retrieval is successful.
+//   - Error if there is any issue during retrieval.
+func (is IdentityService) FindStorageUsage(ctx context.Context, tenantID string) (object.StorageUsage, error) {
+	dbConn, _ := is.getDBConn(ctx)
+
+	tenant, err := repository.FindTenant(ctx, dbConn, tenantID)
+	if err != nil {
+		return object.StorageUsage{}, err
+	}
+
+	usage, err := repository.FindStorageUsage(ctx, dbConn, tenantID)
+	if err != nil {
+		return object.StorageUsage{}, err
+	}
+
+	usage.MaxBytes = tenant.StorageQuota.MaxBytes
+	usage.MaxFiles = tenant.StorageQuota.MaxFiles
+
+	return usage, nil
+}
+
+// checkStorageQuota checks if a tenant can store another resource of the given size. Deduplicated content is
+// already stored, so it only counts as a file.
+func (is IdentityService) checkStorageQuota(ctx context.Context, tenantID string, size int64, deduplicated bool) error {
+	usage, err := is.FindStorageUsage(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+
+	return exceedsStorageQuota(usage, size, deduplicated)
+}
+
+func exceedsStorageQuota(usage object.StorageUsage, size int64, deduplicated bool) error {
+	if usage.MaxFiles > 0 && usage.Files+1 > usage.MaxFiles {
+		return errors.Join(ErrStorageQuotaExceeded, fmt.Errorf("the tenant can't store more than %d files", usage.MaxFiles))
+	}
+
+	if usage.MaxBytes > 0 && !deduplicated && usage.Bytes+size > usage.MaxBytes {
+		return errors.Join(ErrStorageQuotaExceeded, fmt.Errorf("the tenant can't store more than %d bytes, %d bytes are used", usage.MaxBytes, usage.Bytes))
+	}
+
+	return nil
+}
+
+// syncBlobAccess makes content which is shared by several resources private in providers which can be downloaded
+// directly, unless one of the resources is public.
+func (is IdentityService) syncBlobAccess(ctx context.Context, fileProvider storage.Provider, tenantID string, providerID string, blobPath string) error {
+	privateProvider, ok := fileProvider.(storage.PrivateProvider)
+	if !ok {
+		return nil
+	}
+
+	dbConn, _ := is.getDBConn(ctx)
+
+	references, err := repository.FindBlobResources(ctx, dbConn, tenantID, providerID, blobPath)
+	if err != nil || len(references) == 0 {
+		return err
+	}
+
+	private := !slices.ContainsFunc(references, func(reference object.Resource) bool {
+		return !isPrivateVisibility(reference.Visibility)
+	})
+
+	err = privateProvider.SetPrivate(blobPath, private)
+	if err != nil {
+		return errors.Join(fmt.Errorf("problem while changing the access of %s", blobPath), err)
+	}
+
+	return nil
+}
+
+// resourceStoragePath returns the path of the content of a resource in its storage provider.
+func resourceStoragePath(resource object.Resource) string {
+	if len(resource.BlobPath) > 0 {
+		return resource.BlobPath
+	}
+
+	return resource.FilePath
+}
@@ -0,0 +1,195 @@
+/*
+ * Copyright (C) 2025 Anthrove
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logic
+
+import (
+	"context"
+	"errors"
+	"github.com/anthrove/identity/pkg/object"
+	"github.com/anthrove/identity/pkg/provider/storage"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExceedsStorageQuota(t *testing.T) {
+	tests := []struct {
+		name         string
+		usage        object.StorageUsage
+		size         int64
+		deduplicated bool
+		wantErr      bool
+	}{
+		{name: "no quota", usage: object.StorageUsage{Bytes: 1 << 40, Files: 1 << 20}, size: 1 << 30},
+		{name: "below quota", usage: object.StorageUsage{Bytes: 900, Files: 9, MaxBytes: 1000, MaxFiles: 10}, size: 100},
+		{name: "too many files", usage: object.StorageUsage{Files: 10, MaxFiles: 10}, size: 1, wantErr: true},
+		{name: "too many bytes", usage: object.StorageUsage{Bytes: 901, MaxBytes: 1000}, size: 100, wantErr: true},
+		{name: "deduplicated content is stored", usage: object.StorageUsage{Bytes: 1000, MaxBytes: 1000}, size: 100, deduplicated: true},
+		{name: "deduplicated content is a file", usage: object.StorageUsage{Files: 10, MaxFiles: 10}, size: 100, deduplicated: true, wantErr: true},
+	}
+
+	for _, test := range tests {
+		err := exceedsStorageQuota(test.usage, test.size, test.deduplicated)
+
+		if (err != nil) != test.wantErr {
+			t.Errorf("%s: exceedsStorageQuota() error = %v, wantErr %v", test.name, err, test.wantErr)
+		}
+
+		if err != nil && !errors.Is(err, ErrStorageQuotaExceeded) {
+			t.Errorf("%s: exceedsStorageQuota() error = %v, want ErrStorageQuotaExceeded", test.name, err)
+		}
+	}
+}
+
+func TestKillSharedResource(t *testing.T) {
+	ctx := context.Background()
+	is, tenant := newTestService(t)
+
+	provider, err := is.CreateProvider(ctx, tenant.ID, object.CreateProvider{
+		DisplayName:  "Files",
+		Category:     "storage",
+		ProviderType: "local",
+		Parameter:    []byte(`{"base_path":"files"}`),
+	})
+	if err != nil {
+		t.Fatalf("CreateProvider() error = %v", err)
+	}
+
+	var resources []object.Resource
+	for i := 0; i < 2; i++ {
+		resource, err := is.CreateResource(ctx, tenant.ID, object.CreateResource{
+			ProviderID: provider.ID,
+			Tag:        "logo",
+			FileName:   "logo.txt",
+			MimeType:   "text/plain",
+		}, strings.NewReader("shared content"))
+		if err != nil {
+			t.Fatalf("CreateResource() error = %v", err)
+		}
+
+		resources = append(resources, resource)
+	}
+
+	if resources[0].BlobPath != resources[1].BlobPath {
+		t.Fatalf("CreateResource() blob paths = %s and %s, want the content to be shared", resources[0].BlobPath, resources[1].BlobPath)
+	}
+
+	err = is.KillResource(ctx, tenant.ID, resources[0].ID)
+	if err != nil {
+		t.Fatalf("KillResource() error = %v", err)
+	}
+
+	// the second resource still references the content
+	_, content, err := is.ServeResource(ctx, tenant.ID, resources[1].FilePath, object.ResourceAccess{})
+	if err != nil {
+		t.Fatalf("ServeResource() after deleting the first resource error = %v", err)
+	}
+
+	data, _ := io.ReadAll(content)
+	content.Close()
+
+	if string(data) != "shared content" {
+		t.Errorf("ServeResource() = %q, want the shared content", data)
+	}
+
+	err = is.KillResource(ctx, tenant.ID, resources[1].ID)
+	if err != nil {
+		t.Fatalf("KillResource() error = %v", err)
+	}
+
+	fileProvider, err := storage.GetStorageProvider(provider)
+	if err != nil {
+		t.Fatalf("GetStorageProvider() error = %v", err)
+	}
+
+	_, err = fileProvider.Get(resources[1].BlobPath)
+	if err == nil {
+		t.Errorf("content of the last deleted resource still exists")
+	}
+}
+
+func TestCreateResourceQuota(t *testing.T) {
+	ctx := context.Background()
+	is, tenant := newTestService(t)
+
+	tenant = updateTestTenant(t, is, tenant, func(updateTenant *object.UpdateTenant) {
+		updateTenant.StorageQuota = &object.StorageQuota{MaxFiles: 1}
+	})
+
+	// the base path of the broken provider is a file, so no content can be stored in it
+	err := os.MkdirAll(filepath.Join("local_storage_provider", tenant.ID), 0700)
+	if err == nil {
+		err = os.WriteFile(filepath.Join("local_storage_provider", tenant.ID, "broken"), nil, 0600)
+	}
+	if err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	providers := make([]object.Provider, 0, 2)
+	for _, basePath := range []string{"broken", "files"} {
+		provider, err := is.CreateProvider(ctx, tenant.ID, object.CreateProvider{
+			DisplayName:  basePath,
+			Category:     "storage",
+			ProviderType: "local",
+			Parameter:    []byte(`{"base_path":"` + basePath + `"}`),
+		})
+		if err != nil {
+			t.Fatalf("CreateProvider() error = %v", err)
+		}
+
+		providers = append(providers, provider)
+	}
+
+	createResource := func(provider object.Provider, content string) (object.Resource, error) {
+		return is.CreateResource(ctx, tenant.ID, object.CreateResource{
+			ProviderID: provider.ID,
+			Tag:        "logo",
+			FileName:   "logo.txt",
+			MimeType:   "text/plain",
+		}, strings.NewReader(content))
+	}
+
+	if _, err = createResource(providers[0], "first content"); err == nil {
+		t.Fatalf("CreateResource() in a broken provider error = nil, want error")
+	}
+
+	// the failed upload doesn't keep the file it reserved
+	usage, err := is.FindStorageUsage(ctx, tenant.ID)
+	if err != nil || usage.Files != 0 {
+		t.Fatalf("FindStorageUsage() after a failed upload = %+v, %v, want no files", usage, err)
+	}
+
+	resource, err := createResource(providers[1], "first content")
+	if err != nil {
+		t.Fatalf("CreateResource() error = %v", err)
+	}
+
+	if len(resource.Hash) == 0 || len(resource.Url) == 0 {
+		t.Errorf("CreateResource() = %+v, want the hash and url of the content", resource)
+	}
+
+	stored, err := is.FindResource(ctx, tenant.ID, resource.ID)
+	if err != nil || stored.Hash != resource.Hash || stored.Url != resource.Url {
+		t.Errorf("FindResource() = %+v, %v, want the hash and url of the content", stored, err)
+	}
+
+	if _, err = createResource(providers[1], "second content"); !errors.Is(err, ErrStorageQuotaExceeded) {
+		t.Errorf("CreateResource() above the quota error = %v, want ErrStorageQuotaExceeded", err)
+	}
+}
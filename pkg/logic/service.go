@@ -20,9 +20,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"github.com/anthrove/identity/pkg/i18n/templates"
 	"github.com/anthrove/identity/pkg/object"
 	"github.com/anthrove/identity/pkg/provider/email"
+	"github.com/anthrove/identity/pkg/provider/sms"
+	"github.com/anthrove/identity/pkg/repository"
 	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
 )
 
 func (is IdentityService) SendMail(ctx context.Context, tenantID string, providerID string, mailData object.SendMailData) error {
@@ -55,3 +59,89 @@ func (is IdentityService) SendMail(ctx context.Context, tenantID string, provide
 
 	return nil
 }
+
+// sendTemplateMail fills the message template of the given type and sends it through the first email provider of the tenant.
+// If the tenant has no template of that type configured, the built-in default template is used. The template gets the
+// branding of the tenant, unless the data already contains a branding.
+func (is IdentityService) sendTemplateMail(ctx context.Context, tenantID string, templateType string, to string, subject string, data map[string]any) error {
+	dbConn, _ := is.getDBConn(ctx)
+
+	// mails which belong to an application already have its branding set
+	if _, exists := data["Branding"]; !exists {
+		branding, err := is.mailBranding(ctx, tenantID, "")
+		if err != nil {
+			return err
+		}
+
+		data["Branding"] = branding
+	}
+
+	body, err := is.fillTemplate(ctx, tenantID, templateType, data)
+	if err != nil {
+		return err
+	}
+
+	providers, err := repository.FindProvidersByCategory(ctx, dbConn, tenantID, "email")
+	if err != nil {
+		return err
+	}
+
+	if len(providers) == 0 {
+		return errors.New("no email provider configured in tenant")
+	}
+
+	return is.SendMail(ctx, tenantID, providers[0].ID, object.SendMailData{
+		To:      to,
+		Subject: subject,
+		Body:    body,
+	})
+}
+
+// sendTemplateSMS fills the message template of the given type and sends it through the first sms provider of the tenant.
+// If the tenant has no template of that type configured, the built-in default template is used.
+func (is IdentityService) sendTemplateSMS(ctx context.Context, tenantID string, templateType string, to string, data map[string]any) error {
+	dbConn, _ := is.getDBConn(ctx)
+
+	message, err := is.fillTemplate(ctx, tenantID, templateType, data)
+	if err != nil {
+		return err
+	}
+
+	providers, err := repository.FindProvidersByCategory(ctx, dbConn, tenantID, "sms")
+	if err != nil {
+		return err
+	}
+
+	if len(providers) == 0 {
+		return errors.New("no sms provider configured in tenant")
+	}
+
+	provider, err := sms.GetSMSProvider(providers[0])
+	if err != nil {
+		return err
+	}
+
+	return provider.SendSMS(to, message)
+}
+
+// fillTemplate fills the message template of the given type, falling back to the built-in default template.
+func (is IdentityService) fillTemplate(ctx context.Context, tenantID string, templateType string, data map[string]any) (string, error) {
+	dbConn, _ := is.getDBConn(ctx)
+
+	template, err := repository.FindMessageTemplateByType(ctx, dbConn, tenantID, templateType)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", err
+		}
+
+		var exists bool
+		template, exists = templates.DefaultMessageTemplate(templateType)
+		if !exists {
+			return "", errors.New("no message template found for type: " + templateType)
+		}
+	}
+
+	return templates.FillMessageTemplate(template, object.FillMessageTemplate{
+		Data: data,
+	})
+}
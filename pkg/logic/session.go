@@ -18,7 +18,14 @@ package logic
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"github.com/anthrove/identity/pkg/object"
+	"slices"
+	"strings"
 	"sync"
+	"time"
 )
 
 var sessions map[string]map[string]any
@@ -52,3 +59,103 @@ func (is IdentityService) FindSession(ctx context.Context, sessionID string) map
 
 	return nil
 }
+
+// killUserSessions deletes every session of a user, e.g. after the password was reset.
+func (is IdentityService) killUserSessions(tenantID string, userID string) {
+	sessionMutex.Lock()
+	defer sessionMutex.Unlock()
+
+	for sessionID, session := range sessions {
+		user, _ := session["user"].(object.User)
+
+		if session["tenant_id"] == tenantID && user.ID == userID {
+			delete(sessions, sessionID)
+		}
+	}
+}
+
+// FindUserSessions returns the signed in sessions of a user, newest first.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancellation, and deadlines.
+//   - tenantID: unique identifier of the tenant to which the user belongs.
+//   - userID: unique identifier of the user.
+//   - currentSessionID: the session of the request, it is marked as current.
+//
+// Returns:
+//   - Slice of UserSession objects.
+func (is IdentityService) FindUserSessions(ctx context.Context, tenantID string, userID string, currentSessionID string) []object.UserSession {
+	sessionMutex.RLock()
+	defer sessionMutex.RUnlock()
+
+	userSessions := make([]object.UserSession, 0)
+
+	for sessionID, session := range sessions {
+		user, _ := session["user"].(object.User)
+		loggedIn, _ := session["logged_in"].(bool)
+
+		if session["tenant_id"] != tenantID || user.ID != userID || !loggedIn {
+			continue
+		}
+
+		userSession := object.UserSession{
+			ID:      sessionHandle(sessionID),
+			Current: sessionID == currentSessionID,
+		}
+		userSession.ApplicationID, _ = session["application_id"].(string)
+		userSession.IPAddress, _ = session["ip_address"].(string)
+		userSession.UserAgent, _ = session["user_agent"].(string)
+		userSession.CreatedAt, _ = session["created_at"].(time.Time)
+		userSession.AuthenticatedAt, _ = session["authenticated_at"].(time.Time)
+
+		userSessions = append(userSessions, userSession)
+	}
+
+	slices.SortFunc(userSessions, func(a, b object.UserSession) int {
+		return b.CreatedAt.Compare(a.CreatedAt)
+	})
+
+	return userSessions
+}
+
+// KillUserSession signs out one session of a user.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancellation, and deadlines.
+//   - tenantID: unique identifier of the tenant to which the user belongs.
+//   - userID: unique identifier of the user.
+//   - userSessionID: ID of the session as returned by FindUserSessions.
+//
+// Returns:
+//   - Error if the user has no such session.
+func (is IdentityService) KillUserSession(ctx context.Context, tenantID string, userID string, userSessionID string) error {
+	sessionMutex.Lock()
+
+	killed := false
+
+	for sessionID, session := range sessions {
+		user, _ := session["user"].(object.User)
+
+		if session["tenant_id"] == tenantID && user.ID == userID && sessionHandle(sessionID) == strings.ToLower(userSessionID) {
+			delete(sessions, sessionID)
+			killed = true
+			break
+		}
+	}
+
+	sessionMutex.Unlock()
+
+	if !killed {
+		return errors.New("session not found")
+	}
+
+	is.recordAuditEvent(ctx, tenantID, object.AuditEventSessionRevoked, userID, nil)
+
+	return nil
+}
+
+// sessionHandle derives the public ID of a session, the session ID itself works like a password and is never shown.
+func sessionHandle(sessionID string) string {
+	hash := sha256.Sum256([]byte(sessionID))
+	return hex.EncodeToString(hash[:16])
+}
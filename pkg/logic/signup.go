@@ -0,0 +1,361 @@
+/*
+ * Copyright (C) 2025 Anthrove
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/anthrove/identity/pkg/object"
+	"github.com/anthrove/identity/pkg/provider/captcha"
+	"github.com/anthrove/identity/pkg/repository"
+	"github.com/anthrove/identity/pkg/util"
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+	"log"
+	"slices"
+	"strings"
+	"time"
+)
+
+// SignUp registers a new user with an application, if the sign up policy of the tenant allows it.
+// The profile fields are validated against the profile fields the user can change and a verification mail is sent to the email of the user.
+// In the domain mode the user is added to the default groups once the email is verified, as the domain alone proves nothing.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancellation, and deadlines.
+//   - tenantID: unique identifier of the tenant the user signs up to.
+//   - applicationID: unique identifier of the application the user signs up with.
+//   - signUp: object containing the details of the new user.
+//
+// Returns:
+//   - User object if the sign up is successful.
+//   - Error if the sign up isn't allowed or there is any issue during validation or creation.
+func (is IdentityService) SignUp(ctx context.Context, tenantID string, applicationID string, signUp object.SignUp) (object.User, error) {
+	dbConn, nested := is.getDBConn(ctx)
+
+	err := validate.Struct(signUp)
+
+	if err != nil {
+		var validateErrs validator.ValidationErrors
+		if errors.As(err, &validateErrs) {
+			return object.User{}, errors.Join(fmt.Errorf("problem while validating sign up data"), util.ConvertValidationError(validateErrs))
+		}
+	}
+
+	tenant, err := is.FindTenant(ctx, tenantID)
+
+	if err != nil {
+		return object.User{}, err
+	}
+
+	_, err = is.FindApplication(ctx, tenantID, applicationID)
+
+	if err != nil {
+		return object.User{}, err
+	}
+
+	policy := tenant.SignUpPolicy
+
+	switch policy.Mode {
+	case object.SignUpModeOpen:
+	case object.SignUpModeDomain:
+		if !emailDomainAllowed(signUp.Email, policy.AllowedDomains) {
+			return object.User{}, errors.New("sign up is not allowed for this email domain")
+		}
+	case object.SignUpModeInvite:
+		err = is.checkInvitation(ctx, tenantID, signUp)
+
+		if err != nil {
+			return object.User{}, err
+		}
+	default:
+		return object.User{}, errors.New("sign up is disabled")
+	}
+
+	if policy.RequireTerms && !signUp.AcceptTerms {
+		return object.User{}, errors.New("terms have to be accepted")
+	}
+
+	if len(policy.CaptchaProviderID) > 0 {
+		err = is.verifyCaptcha(ctx, tenantID, policy.CaptchaProviderID, signUp.CaptchaResponse)
+
+		if err != nil {
+			return object.User{}, err
+		}
+	}
+
+	signUpFields := signUpProfileFields(tenant.ProfileFields)
+
+	profileErrors := make([]string, 0)
+	for _, field := range signUp.ProfileFields {
+		if !slices.ContainsFunc(signUpFields, func(signUpField object.ProfileField) bool {
+			return signUpField.Identifier == field.Identifier
+		}) {
+			profileErrors = append(profileErrors, fmt.Sprintf("field %s does not exist", field.Identifier))
+		}
+	}
+
+	if len(profileErrors) > 0 {
+		return object.User{}, errors.New("multiple field errors: " + strings.Join(profileErrors, ","))
+	}
+
+	profileFields, profileErrors := normalizeProfilePageFields(signUpFields, signUp.ProfileFields)
+	if len(profileErrors) > 0 {
+		return object.User{}, errors.New("multiple field errors: " + strings.Join(profileErrors, ","))
+	}
+
+	var tx = dbConn
+	var txCtx = ctx
+	if !nested {
+		tx = dbConn.Begin()
+		txCtx = saveDBConn(ctx, tx)
+		defer func() {
+			if r := recover(); r != nil {
+				tx.Rollback()
+			}
+		}()
+	}
+
+	user, err := is.createUser(txCtx, tenantID, object.CreateUser{
+		Username:    signUp.Username,
+		DisplayName: signUp.DisplayName,
+		Email:       signUp.Email,
+		Password:    signUp.Password,
+	}, nil)
+
+	if err != nil {
+		if !nested {
+			tx.Rollback()
+		}
+		return object.User{}, err
+	}
+
+	if len(profileFields) > 0 {
+		err = is.saveProfilePage(txCtx, tenant, user.ID, false, profileFields, profileFieldIdentifiers(profileFields))
+
+		if err != nil {
+			if !nested {
+				tx.Rollback()
+			}
+			return object.User{}, err
+		}
+	}
+
+	// the email isn't verified yet, in the domain mode the default groups are only added by VerifyEmail
+	if policy.Mode != object.SignUpModeDomain {
+		err = appendDefaultGroups(txCtx, tx, tenantID, user.ID, policy.DefaultGroupIDs)
+
+		if err != nil {
+			if !nested {
+				tx.Rollback()
+			}
+			return object.User{}, err
+		}
+	}
+
+	if policy.Mode == object.SignUpModeInvite {
+		err = repository.UseInvitation(txCtx, tx, tenantID, signUp.InvitationID, user.ID)
+
+		if err != nil {
+			if !nested {
+				tx.Rollback()
+			}
+			return object.User{}, errors.New("invitation was already used or is expired")
+		}
+	}
+
+	if !nested {
+		err = tx.Commit().Error
+		if err != nil {
+			return object.User{}, err
+		}
+	}
+
+	// reload the user, as the email verification token is set after the user was created
+	user, err = is.FindUser(ctx, tenantID, user.ID)
+
+	if err != nil {
+		return object.User{}, err
+	}
+
+	err = is.sendEmailVerification(ctx, tenant, user)
+
+	if err != nil {
+		// the user can request the verification mail again, so the sign up is still successful
+		log.Printf("problem while sending email verification to user %s: %v", user.ID, err)
+	}
+
+	is.recordAuditEvent(ctx, tenantID, object.AuditEventUserSignedUp, user.ID, map[string]any{
+		"application_id": applicationID,
+		"mode":           policy.Mode,
+		"terms_accepted": signUp.AcceptTerms,
+	})
+
+	return user, nil
+}
+
+// FindSignUpConfiguration returns what the sign up form of an application has to show, e.g. the profile fields and the captcha.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancellation, and deadlines.
+//   - tenantID: unique identifier of the tenant the user signs up to.
+//   - applicationID: unique identifier of the application the user signs up with.
+//
+// Returns:
+//   - SignUpConfiguration object if retrieval is successful.
+//   - Error if there is any issue during retrieval.
+func (is IdentityService) FindSignUpConfiguration(ctx context.Context, tenantID string, applicationID string) (object.SignUpConfiguration, error) {
+	tenant, err := is.FindTenant(ctx, tenantID)
+
+	if err != nil {
+		return object.SignUpConfiguration{}, err
+	}
+
+	application, err := is.FindApplication(ctx, tenantID, applicationID)
+
+	if err != nil {
+		return object.SignUpConfiguration{}, err
+	}
+
+	configuration := object.SignUpConfiguration{
+		Mode:          tenant.SignUpPolicy.Mode,
+		ProfileFields: signUpProfileFields(tenant.ProfileFields),
+	}
+
+	if len(configuration.Mode) == 0 {
+		configuration.Mode = object.SignUpModeDisabled
+	}
+
+	if tenant.SignUpPolicy.RequireTerms {
+		configuration.TermsURL = application.TermsURL
+	}
+
+	if len(tenant.SignUpPolicy.CaptchaProviderID) > 0 {
+		captchaProvider, err := is.FindProvider(ctx, tenantID, tenant.SignUpPolicy.CaptchaProviderID)
+
+		if err != nil {
+			return object.SignUpConfiguration{}, err
+		}
+
+		provider, err := captcha.GetCaptchaProvider(captchaProvider)
+
+		if err != nil {
+			return object.SignUpConfiguration{}, err
+		}
+
+		configuration.CaptchaType = captchaProvider.ProviderType
+		configuration.CaptchaSiteKey = provider.SiteKey()
+	}
+
+	return configuration, nil
+}
+
+// checkInvitation makes sure the invitation of the sign up exists, is still open and was issued for the email.
+// The invitation is only marked as used together with the creation of the user.
+func (is IdentityService) checkInvitation(ctx context.Context, tenantID string, signUp object.SignUp) error {
+	if len(signUp.InvitationID) == 0 {
+		return errors.New("sign up requires an invitation")
+	}
+
+	invitation, err := is.FindInvitation(ctx, tenantID, signUp.InvitationID)
+
+	if err != nil || invitation.UsedAt != nil || time.Now().After(invitation.ExpiresAt) {
+		return errors.New("invitation was already used or is expired")
+	}
+
+	if len(invitation.Email) > 0 && !strings.EqualFold(invitation.Email, signUp.Email) {
+		return errors.New("invitation was issued for another email")
+	}
+
+	return nil
+}
+
+// verifyCaptcha checks the captcha response with the captcha provider of the sign up policy.
+func (is IdentityService) verifyCaptcha(ctx context.Context, tenantID string, providerID string, response string) error {
+	captchaProvider, err := is.FindProvider(ctx, tenantID, providerID)
+
+	if err != nil {
+		return err
+	}
+
+	if captchaProvider.Category != "captcha" {
+		return errors.New("sign up captcha provider is no captcha provider")
+	}
+
+	provider, err := captcha.GetCaptchaProvider(captchaProvider)
+
+	if err != nil {
+		return err
+	}
+
+	err = provider.Verify(response, requestInfo(ctx).IPAddress)
+
+	if err != nil {
+		return errors.Join(errors.New("captcha is invalid"), err)
+	}
+
+	return nil
+}
+
+// signUpProfileFields returns the profile fields a new user can fill in, which are the fields the user could change on
+// the own profile before it exists. Fields like admin attributes are left to the admins.
+func signUpProfileFields(tenantFields []object.ProfileField) []object.ProfileField {
+	// the user doesn't exist yet, any id works as long as the user is the owner of the profile
+	const newUserID = "sign_up"
+
+	fields := make([]object.ProfileField, 0, len(tenantFields))
+	for _, field := range tenantFields {
+		if canModifyProfileField(field, newUserID, object.ProfileViewer{UserID: newUserID}, true) {
+			fields = append(fields, field)
+		}
+	}
+
+	return fields
+}
+
+// appendDefaultGroups adds a new user to the default groups of the sign up policy.
+func appendDefaultGroups(ctx context.Context, db *gorm.DB, tenantID string, userID string, groupIDs []string) error {
+	for _, groupID := range groupIDs {
+		_, err := repository.FindGroup(ctx, db, tenantID, groupID)
+
+		if err == nil {
+			err = repository.AppendUserToGroup(ctx, db, tenantID, userID, groupID)
+		}
+
+		if err != nil {
+			return errors.Join(fmt.Errorf("problem while adding user to default group %s", groupID), err)
+		}
+	}
+
+	return nil
+}
+
+// emailDomainAllowed checks if the domain of the email is one of the allowed domains.
+func emailDomainAllowed(email string, allowedDomains []string) bool {
+	at := strings.LastIndex(email, "@")
+
+	if at < 0 {
+		return false
+	}
+
+	domain := strings.ToLower(email[at+1:])
+
+	return slices.ContainsFunc(allowedDomains, func(allowedDomain string) bool {
+		return strings.ToLower(allowedDomain) == domain
+	})
+}
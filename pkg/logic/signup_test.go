@@ -0,0 +1,173 @@
+/*
+ * Copyright (C) 2025 Anthrove
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logic
+
+import (
+	"context"
+	"fmt"
+	"github.com/anthrove/identity/pkg/object"
+	"github.com/anthrove/identity/pkg/repository"
+	"testing"
+)
+
+func TestEmailDomainAllowed(t *testing.T) {
+	allowedDomains := []string{"example.com", "Corp.Example"}
+
+	tests := []struct {
+		email string
+		want  bool
+	}{
+		{"user@example.com", true},
+		{"user@EXAMPLE.com", true},
+		{"user@corp.example", true},
+		{"user@sub.example.com", false},
+		{"user@example.com.evil", false},
+		{"user", false},
+	}
+
+	for _, test := range tests {
+		if got := emailDomainAllowed(test.email, allowedDomains); got != test.want {
+			t.Errorf("emailDomainAllowed(%q) = %t, want %t", test.email, got, test.want)
+		}
+	}
+}
+
+func TestSignUpDomainDefaultGroups(t *testing.T) {
+	ctx := context.Background()
+	is, tenant := newTestService(t)
+
+	group, err := is.CreateGroup(ctx, tenant.ID, object.CreateGroup{DisplayName: "Employees"})
+	if err != nil {
+		t.Fatalf("CreateGroup() error = %v", err)
+	}
+
+	tenant = updateTestTenant(t, is, tenant, func(updateTenant *object.UpdateTenant) {
+		updateTenant.SignUpPolicy = &object.SignUpPolicy{
+			Mode:            object.SignUpModeDomain,
+			AllowedDomains:  []string{"corp.example"},
+			DefaultGroupIDs: []string{group.ID},
+		}
+	})
+
+	applications, err := is.FindApplications(ctx, tenant.ID, object.Pagination{Page: 1, Limit: 1})
+	if err != nil || len(applications) == 0 {
+		t.Fatalf("FindApplications() error = %v", err)
+	}
+
+	_, err = is.SignUp(ctx, tenant.ID, applications[0].ID, object.SignUp{
+		Username:    "outsider",
+		DisplayName: "Outsider",
+		Email:       "outsider@other.example",
+		Password:    "correct horse battery staple",
+	})
+	if err == nil {
+		t.Errorf("SignUp() with another domain error = nil, want error")
+	}
+
+	user, err := is.SignUp(ctx, tenant.ID, applications[0].ID, object.SignUp{
+		Username:    "employee",
+		DisplayName: "Employee",
+		Email:       "employee@corp.example",
+		Password:    "correct horse battery staple",
+	})
+	if err != nil {
+		t.Fatalf("SignUp() error = %v", err)
+	}
+
+	// nobody confirmed the address yet, so the domain proves nothing
+	member, err := repository.IsUserInGroup(ctx, is.db, tenant.ID, user.ID, group.ID)
+	if err != nil || member {
+		t.Fatalf("IsUserInGroup() before verification = %v, %v, want false", member, err)
+	}
+
+	err = is.VerifyEmail(ctx, tenant.ID, user.ID, object.VerifyEmail{Token: "wrong"})
+	if err == nil {
+		t.Errorf("VerifyEmail() with a wrong token error = nil, want error")
+	}
+
+	member, _ = repository.IsUserInGroup(ctx, is.db, tenant.ID, user.ID, group.ID)
+	if member {
+		t.Fatalf("IsUserInGroup() after a wrong token = true, want false")
+	}
+
+	user, err = is.FindUser(ctx, tenant.ID, user.ID)
+	if err != nil {
+		t.Fatalf("FindUser() error = %v", err)
+	}
+
+	err = is.VerifyEmail(ctx, tenant.ID, user.ID, object.VerifyEmail{Token: user.EmailVerificationToken})
+	if err != nil {
+		t.Fatalf("VerifyEmail() error = %v", err)
+	}
+
+	member, err = repository.IsUserInGroup(ctx, is.db, tenant.ID, user.ID, group.ID)
+	if err != nil || !member {
+		t.Errorf("IsUserInGroup() after verification = %v, %v, want true", member, err)
+	}
+}
+
+func TestSignUpProfileFields(t *testing.T) {
+	ctx := context.Background()
+	is, tenant := newTestService(t)
+
+	tenant = updateTestTenant(t, is, tenant, func(updateTenant *object.UpdateTenant) {
+		updateTenant.SignUpPolicy = &object.SignUpPolicy{Mode: object.SignUpModeOpen}
+		updateTenant.ProfileFields = []object.ProfileField{
+			{Identifier: "nickname", ViewBy: object.AllowViewPublic, ModifyBy: object.ModifyTypeSelf},
+			{Identifier: "employee_id", ModifyBy: object.ModifyTypeImmutable},
+			{Identifier: "salary_band", ViewBy: object.AllowViewAdmin},
+		}
+	})
+
+	applications, err := is.FindApplications(ctx, tenant.ID, object.Pagination{Page: 1, Limit: 1})
+	if err != nil || len(applications) == 0 {
+		t.Fatalf("FindApplications() error = %v", err)
+	}
+
+	configuration, err := is.FindSignUpConfiguration(ctx, tenant.ID, applications[0].ID)
+	if err != nil {
+		t.Fatalf("FindSignUpConfiguration() error = %v", err)
+	}
+
+	if len(configuration.ProfileFields) != 2 {
+		t.Errorf("FindSignUpConfiguration() profile fields = %v, want nickname and employee_id", configuration.ProfileFields)
+	}
+
+	tests := []struct {
+		name    string
+		fields  []object.ProfilePageField
+		wantErr bool
+	}{
+		{name: "admin field", fields: []object.ProfilePageField{{Identifier: "salary_band", Value: "E5"}}, wantErr: true},
+		{name: "unknown field", fields: []object.ProfilePageField{{Identifier: "unknown", Value: "value"}}, wantErr: true},
+		{name: "user fields", fields: []object.ProfilePageField{{Identifier: "nickname", Value: "nick"}, {Identifier: "employee_id", Value: "42"}}},
+	}
+
+	for i, test := range tests {
+		_, err := is.SignUp(ctx, tenant.ID, applications[0].ID, object.SignUp{
+			Username:      fmt.Sprintf("user%d", i),
+			DisplayName:   "User",
+			Email:         fmt.Sprintf("user%d@example.com", i),
+			Password:      "correct horse battery staple",
+			ProfileFields: test.fields,
+		})
+
+		if (err != nil) != test.wantErr {
+			t.Errorf("%s: SignUp() error = %v, wantErr %v", test.name, err, test.wantErr)
+		}
+	}
+}
@@ -281,7 +281,35 @@ func (is IdentityService) UpdateTenant(ctx context.Context, tenantID string, upd
 		return errors.New("password type does not match any known types")
 	}
 
-	return repository.UpdateTenant(ctx, dbConn, tenantID, updateTenant)
+	err = is.validateBranding(ctx, tenantID, updateTenant.Branding)
+	if err != nil {
+		return err
+	}
+
+	if updateTenant.StorageProviderID != nil && len(*updateTenant.StorageProviderID) > 0 {
+		provider, err := is.FindProvider(ctx, tenantID, *updateTenant.StorageProviderID)
+		if err != nil {
+			return errors.Join(errors.New("storage provider not found"), err)
+		}
+
+		if provider.Category != "storage" {
+			return errors.New("provider category not storage")
+		}
+	}
+
+	pages, err := is.checkProfileSchemaChange(ctx, tenantID, updateTenant.ProfileFields)
+	if err != nil {
+		return err
+	}
+
+	return dbConn.Transaction(func(tx *gorm.DB) error {
+		err := repository.UpdateTenant(ctx, tx, tenantID, updateTenant)
+		if err != nil {
+			return err
+		}
+
+		return rebuildProfileAttributes(ctx, tx, tenantID, updateTenant.ProfileFields, pages)
+	})
 }
 
 // KillTenant deletes an existing tenant from the system.
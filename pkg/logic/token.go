@@ -76,3 +76,9 @@ func (is IdentityService) FindUserTokens(ctx context.Context, tenantID string, a
 
 	return repository.FindUserTokens(ctx, dbConn, tenantID, applicationID, userID)
 }
+
+func (is IdentityService) KillUserTokens(ctx context.Context, tenantID string, userID string) error {
+	dbConn, _ := is.getDBConn(ctx)
+
+	return repository.KillUserTokens(ctx, dbConn, tenantID, userID)
+}
@@ -0,0 +1,139 @@
+/*
+ * Copyright (C) 2025 Anthrove
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logic
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"github.com/anthrove/identity/pkg/object"
+	"github.com/anthrove/identity/pkg/repository"
+	gonanoid "github.com/matoous/go-nanoid/v2"
+	"time"
+)
+
+// FindTrustedDevices retrieves the trusted devices of a user, with pagination support.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancelation, and deadlines.
+//   - tenantID: unique identifier of the tenant to which the user belongs.
+//   - userID: unique identifier of the user who trusts the devices.
+//   - pagination: object containing pagination details (limit and page).
+//
+// Returns:
+//   - Slice of TrustedDevice objects if retrieval is successful.
+//   - Error if there is any issue during retrieval.
+func (is IdentityService) FindTrustedDevices(ctx context.Context, tenantID string, userID string, pagination object.Pagination) ([]object.TrustedDevice, error) {
+	dbConn, _ := is.getDBConn(ctx)
+
+	if len(userID) == 0 {
+		return nil, errors.New("userID is required")
+	}
+
+	return repository.FindTrustedDevices(ctx, dbConn, tenantID, userID, pagination)
+}
+
+// KillTrustedDevice revokes a trusted device of a user, the next sign in from it requires the second factor again.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancelation, and deadlines.
+//   - tenantID: unique identifier of the tenant to which the user belongs.
+//   - userID: unique identifier of the user who trusts the device.
+//   - trustedDeviceID: unique identifier of the trusted device to be revoked.
+//
+// Returns:
+//   - Error if there is any issue during deletion.
+func (is IdentityService) KillTrustedDevice(ctx context.Context, tenantID string, userID string, trustedDeviceID string) error {
+	dbConn, _ := is.getDBConn(ctx)
+
+	if len(userID) == 0 {
+		return errors.New("userID is required")
+	}
+
+	if len(trustedDeviceID) == 0 {
+		return errors.New("trustedDeviceID is required")
+	}
+
+	return repository.KillTrustedDevice(ctx, dbConn, tenantID, userID, trustedDeviceID)
+}
+
+// trustDevice marks the client of the current request as trusted for the configured days of the tenant.
+// It returns the device token which has to be stored by the browser, only its hash is stored.
+func (is IdentityService) trustDevice(ctx context.Context, tenant object.Tenant, userID string) (string, time.Time, error) {
+	dbConn, _ := is.getDBConn(ctx)
+
+	if tenant.TrustedDeviceDays <= 0 {
+		return "", time.Time{}, errors.New("trusted devices are disabled for this tenant")
+	}
+
+	token, err := gonanoid.New(50)
+
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	info := requestInfo(ctx)
+	expiresAt := time.Now().AddDate(0, 0, tenant.TrustedDeviceDays)
+
+	_, err = repository.CreateTrustedDevice(ctx, dbConn, tenant.ID, userID, object.CreateTrustedDevice{
+		TokenHash: hashDeviceToken(token),
+		UserAgent: truncate(info.UserAgent, 255),
+		IPAddress: info.IPAddress,
+		ExpiresAt: expiresAt,
+	})
+
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	return token, expiresAt, nil
+}
+
+// isTrustedDevice checks if the device token belongs to a not expired trusted device of the user and records its usage.
+func (is IdentityService) isTrustedDevice(ctx context.Context, tenant object.Tenant, userID string, token string) bool {
+	dbConn, _ := is.getDBConn(ctx)
+
+	if tenant.TrustedDeviceDays <= 0 || len(token) == 0 {
+		return false
+	}
+
+	trustedDevice, err := repository.FindTrustedDeviceByToken(ctx, dbConn, tenant.ID, userID, hashDeviceToken(token))
+
+	if err != nil {
+		return false
+	}
+
+	// the usage is only informational, the sign in should not fail because of it
+	_ = repository.UpdateTrustedDeviceLastUsed(ctx, dbConn, tenant.ID, userID, trustedDevice.ID, requestInfo(ctx).IPAddress)
+
+	return true
+}
+
+// hashDeviceToken hashes a device token for storing and looking it up, the token is random so a fast hash is sufficient.
+func hashDeviceToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+func truncate(value string, length int) string {
+	if len(value) > length {
+		return value[:length]
+	}
+
+	return value
+}
@@ -27,7 +27,7 @@ import (
 	"github.com/go-playground/validator/v10"
 	"gorm.io/gorm"
 	"math"
-	"strconv"
+	"time"
 )
 
 // CreateUser creates a new user within a specified tenant.
@@ -43,13 +43,24 @@ import (
 //   - User object if creation is successful.
 //   - Error if there is any issue during validation or creation.
 func (is IdentityService) CreateUser(ctx context.Context, tenantID string, createUser object.CreateUser, opt ...string) (object.User, error) {
+	return is.createUser(ctx, tenantID, createUser, nil, opt...)
+}
+
+// createUser creates the user like CreateUser. If credentialMetadata is set it is stored as the password credential
+// instead of hashing createUser.Password, this is used to import users with hashes from other systems.
+func (is IdentityService) createUser(ctx context.Context, tenantID string, createUser object.CreateUser, credentialMetadata map[string]any, opt ...string) (object.User, error) {
 	dbConn, nested := is.getDBConn(ctx)
 
 	if len(tenantID) == 0 {
 		return object.User{}, errors.New("tenantID is required")
 	}
 
-	err := validate.Struct(createUser)
+	var err error
+	if credentialMetadata == nil {
+		err = validate.Struct(createUser)
+	} else {
+		err = validate.StructExcept(createUser, "Password")
+	}
 
 	if err != nil {
 		var validateErrs validator.ValidationErrors
@@ -92,7 +103,8 @@ func (is IdentityService) CreateUser(ctx context.Context, tenantID string, creat
 		return object.User{}, err
 	}
 
-	emailVerificationToken := util.RandomNumber(6)
+	emailVerificationToken := newEmailVerificationToken()
+	emailVerificationExpiresAt := time.Now().Add(emailVerificationLifetime)
 
 	var tx *gorm.DB
 	if !nested {
@@ -115,9 +127,10 @@ func (is IdentityService) CreateUser(ctx context.Context, tenantID string, creat
 	}
 
 	err = is.UpdateUserEmail(ctx, tenantID, user.ID, object.UpdateEmail{
-		Email:                  user.Email,
-		EmailVerified:          false,
-		EmailVerificationToken: strconv.Itoa(emailVerificationToken),
+		Email:                      user.Email,
+		EmailVerified:              false,
+		EmailVerificationToken:     emailVerificationToken,
+		EmailVerificationExpiresAt: &emailVerificationExpiresAt,
 	})
 
 	if err != nil {
@@ -147,20 +160,23 @@ func (is IdentityService) CreateUser(ctx context.Context, tenantID string, creat
 		return object.User{}, err
 	}
 
-	metadata, err := passwordProvider.Configure(ctx, auth.ProviderContext{
-		Tenant:     userTenant,
-		User:       user,
-		Credential: object.Credentials{},
-		SendMail:   nil,
-	}, map[string]any{
-		"password": createUser.Password,
-	})
+	metadata := credentialMetadata
+	if metadata == nil {
+		metadata, err = passwordProvider.Configure(ctx, auth.ProviderContext{
+			Tenant:     userTenant,
+			User:       user,
+			Credential: object.Credentials{},
+			SendMail:   nil,
+		}, map[string]any{
+			"password": createUser.Password,
+		})
 
-	if err != nil {
-		if !nested {
-			tx.Rollback()
+		if err != nil {
+			if !nested {
+				tx.Rollback()
+			}
+			return object.User{}, err
 		}
-		return object.User{}, err
 	}
 
 	_, err = is.CreateCredential(ctx, tenantID, object.CreateCredential{
@@ -264,6 +280,11 @@ func (is IdentityService) KillUser(ctx context.Context, tenantID string, userID
 		return errors.New("userID is required")
 	}
 
+	err := is.killUserResources(ctx, tenantID, userID)
+	if err != nil {
+		return err
+	}
+
 	return repository.KillUser(ctx, dbConn, tenantID, userID)
 }
 
@@ -24,7 +24,9 @@ import (
 	"github.com/anthrove/identity/pkg/repository"
 	"github.com/anthrove/identity/pkg/util"
 	"github.com/go-playground/validator/v10"
-	"regexp"
+	"gorm.io/gorm"
+	"reflect"
+	"slices"
 	"strings"
 )
 
@@ -48,23 +50,33 @@ func (is IdentityService) CreateProfilePage(ctx context.Context, tenantID string
 		return object.ProfilePage{}, err
 	}
 
-	profileErrors := validateProfilePageFields(tenant.ProfileFields, createProfilePage.Fields)
+	fields, profileErrors := normalizeProfilePageFields(tenant.ProfileFields, createProfilePage.Fields)
 	if len(profileErrors) > 0 {
 		return object.ProfilePage{}, errors.New("multiple field errors: " + strings.Join(profileErrors, ","))
 	}
 
-	profilePage, err := repository.CreateUserProfile(ctx, dbConn, tenantID, userID, createProfilePage)
-
+	err = is.saveProfilePage(ctx, tenant, userID, false, fields, profileFieldIdentifiers(fields))
 	if err != nil {
 		return object.ProfilePage{}, err
 	}
 
-	return profilePage, nil
+	return repository.FindProfilePage(ctx, dbConn, tenantID, userID)
 }
 
-func (is IdentityService) UpdateProfilePage(ctx context.Context, tenantID string, userID string, profilePage object.UpdateProfilePage) error {
-	dbConn, _ := is.getDBConn(ctx)
-
+// UpdateProfilePage changes the given fields of a profile, the profile is created if the user has none yet.
+// The viewer decides which fields can be changed: admins can change every field, the user only the fields they can see
+// and which are not immutable. Immutable fields can only be set by the user during the sign up.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancellation, and deadlines.
+//   - tenantID: unique identifier of the tenant to which the user belongs.
+//   - userID: unique identifier of the user whose profile is changed.
+//   - viewer: who changes the profile.
+//   - profilePage: the changed fields.
+//
+// Returns:
+//   - Error if a field can not be changed, is invalid or there is any issue during saving.
+func (is IdentityService) UpdateProfilePage(ctx context.Context, tenantID string, userID string, viewer object.ProfileViewer, profilePage object.UpdateProfilePage) error {
 	if len(tenantID) == 0 {
 		return errors.New("tenantID is required")
 	}
@@ -73,26 +85,67 @@ func (is IdentityService) UpdateProfilePage(ctx context.Context, tenantID string
 	if err != nil {
 		var validateErrs validator.ValidationErrors
 		if errors.As(err, &validateErrs) {
-			return errors.Join(fmt.Errorf("problem while validating create user data"), util.ConvertValidationError(validateErrs))
+			return errors.Join(fmt.Errorf("problem while validating update profile data"), util.ConvertValidationError(validateErrs))
 		}
 	}
 
+	// the profile pages are not scoped by tenant, so the user has to be checked first
+	_, err = is.FindUser(ctx, tenantID, userID)
+	if err != nil {
+		return err
+	}
+
 	tenant, err := is.FindTenant(ctx, tenantID)
 	if err != nil {
 		return err
 	}
 
-	profileErrors := validateProfilePageFields(tenant.ProfileFields, profilePage.Fields)
+	currentPage, err := is.FindProfilePage(ctx, tenantID, userID)
+	pageExists := err == nil
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	fields, changed, err := mergeProfileFields(tenant.ProfileFields, currentPage.Fields, false, userID, viewer, profilePage.Fields)
+	if err != nil {
+		return err
+	}
+
+	// fields the viewer can't change must not block the change of the others
+	modifiableFields := make([]object.ProfileField, 0, len(tenant.ProfileFields))
+	for _, tenantField := range tenant.ProfileFields {
+		if canModifyProfileField(tenantField, userID, viewer, false) {
+			modifiableFields = append(modifiableFields, tenantField)
+		}
+	}
+
+	fields, profileErrors := normalizeProfilePageFields(modifiableFields, fields)
 	if len(profileErrors) > 0 {
 		return errors.New("multiple field errors: " + strings.Join(profileErrors, ","))
 	}
 
-	err = repository.UpdatePageProfile(ctx, dbConn, tenantID, userID, profilePage)
-
+	err = is.saveProfilePage(ctx, tenant, userID, pageExists, fields, changed)
 	if err != nil {
 		return err
 	}
 
+	if len(changed) > 0 {
+		is.recordAuditEvent(ctx, tenantID, object.AuditEventProfileUpdated, userID, map[string]any{
+			"fields": changed,
+		})
+	}
+
+	for _, tenantField := range tenant.ProfileFields {
+		if tenantField.Type != object.ProfileFieldTypeResource || !slices.Contains(changed, tenantField.Identifier) {
+			continue
+		}
+
+		if previous, ok := profileFieldValue(currentPage.Fields, tenantField.Identifier).(string); ok && len(previous) > 0 {
+			is.killReplacedUserResource(ctx, tenantID, userID, previous)
+		}
+	}
+
 	return nil
 }
 
@@ -126,54 +179,255 @@ func (is IdentityService) FindProfilePage(ctx context.Context, tenantID string,
 	return repository.FindProfilePage(ctx, dbConn, tenantID, userID)
 }
 
-func validateProfilePageFields(tenantFields []object.ProfileField, profileFields []object.ProfilePageField) []string {
-	fieldErrors := make([]string, 0)
+// FindProfile returns the profile fields of a user which the viewer can see, together with the stored values.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancellation, and deadlines.
+//   - tenantID: unique identifier of the tenant to which the user belongs.
+//   - userID: unique identifier of the user whose profile is read.
+//   - viewer: who reads the profile.
+//
+// Returns:
+//   - Slice of ProfileFieldValue objects in the order of the tenant profile fields.
+//   - Error if the user does not exist or there is any issue during retrieval.
+func (is IdentityService) FindProfile(ctx context.Context, tenantID string, userID string, viewer object.ProfileViewer) ([]object.ProfileFieldValue, error) {
+	// the profile pages are not scoped by tenant, so the user has to be checked first
+	_, err := is.FindUser(ctx, tenantID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	tenant, err := is.FindTenant(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	profilePage, err := is.FindProfilePage(ctx, tenantID, userID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	return profileValues(tenant.ProfileFields, profilePage.Fields, userID, viewer), nil
+}
+
+// FindVisibleProfile returns the profile fields of a user which another user of the tenant can see.
+// Without a viewer, only the public fields are returned.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancellation, and deadlines.
+//   - tenantID: unique identifier of the tenant to which the user belongs.
+//   - userID: unique identifier of the user whose profile is read.
+//   - viewerUserID: unique identifier of the signed in user who reads the profile, empty for anonymous viewers.
+//
+// Returns:
+//   - Slice of ProfileFieldValue objects.
+//   - Error if the user does not exist or there is any issue during retrieval.
+func (is IdentityService) FindVisibleProfile(ctx context.Context, tenantID string, userID string, viewerUserID string) ([]object.ProfileFieldValue, error) {
+	var viewer object.ProfileViewer
+
+	if len(viewerUserID) > 0 {
+		viewerUser, err := is.FindUser(ctx, tenantID, viewerUserID)
+
+		if err != nil {
+			return nil, err
+		}
+
+		viewer.UserID = viewerUser.ID
+
+		for _, group := range viewerUser.Groups {
+			viewer.GroupIDs = append(viewer.GroupIDs, group.ID)
+		}
+	}
+
+	return is.FindProfile(ctx, tenantID, userID, viewer)
+}
+
+// ProfileClaims returns the profile fields which are shared with an application, keyed by their identifier.
+// Fields without a value are left out.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancellation, and deadlines.
+//   - tenantID: unique identifier of the tenant to which the user belongs.
+//   - userID: unique identifier of the user.
+//   - applicationID: unique identifier of the application which reads the claims.
+//
+// Returns:
+//   - Map of the field identifiers to their values.
+//   - Error if there is any issue during retrieval.
+func (is IdentityService) ProfileClaims(ctx context.Context, tenantID string, userID string, applicationID string) (map[string]any, error) {
+	values, err := is.FindProfile(ctx, tenantID, userID, object.ProfileViewer{ApplicationID: applicationID})
+
+	if err != nil {
+		return nil, err
+	}
+
+	claims := make(map[string]any)
+
+	for _, value := range values {
+		if !isEmptyProfileValue(value.Value) {
+			claims[value.Field.Identifier] = value.Value
+		}
+	}
+
+	return claims, nil
+}
+
+// profileValues returns the tenant profile fields the viewer can see, together with the stored values.
+func profileValues(tenantFields []object.ProfileField, pageFields []object.ProfilePageField, ownerID string, viewer object.ProfileViewer) []object.ProfileFieldValue {
+	values := make([]object.ProfileFieldValue, 0, len(tenantFields))
 
-out:
 	for _, tenantField := range tenantFields {
-		if !tenantField.Required && len(tenantField.Regex) == 0 {
+		if !canViewProfileField(tenantField, ownerID, viewer) {
 			continue
 		}
 
-		for _, profileField := range profileFields {
-			if tenantField.Identifier == profileField.Identifier {
-				if tenantField.Required && profileField.Value == nil {
-					fieldErrors = append(fieldErrors, fmt.Sprintf("field %s is required", profileField.Identifier))
-					continue out
-				}
+		values = append(values, object.ProfileFieldValue{
+			Field:      tenantField,
+			Value:      storedProfileValue(tenantField, pageFields),
+			Modifiable: canModifyProfileField(tenantField, ownerID, viewer, false),
+		})
+	}
 
-				if len(tenantField.Regex) > 0 {
-					r, err := regexp.Compile(tenantField.Regex)
+	return values
+}
 
-					if err != nil {
-						fieldErrors = append(fieldErrors, fmt.Sprintf("regex from field %s is invalid. please contact an administrator", tenantField.Identifier))
-						continue out
-					}
+// mergeProfileFields applies the changes of the viewer to the stored profile fields, creating tells if the user is just
+// being created. It returns the new fields and the identifiers of the fields which changed.
+func mergeProfileFields(tenantFields []object.ProfileField, pageFields []object.ProfilePageField, creating bool, ownerID string, viewer object.ProfileViewer, updateFields []object.ProfilePageField) ([]object.ProfilePageField, []string, error) {
+	fields := make([]object.ProfilePageField, len(pageFields))
+	copy(fields, pageFields)
 
-					strValue, ok := profileField.Value.(string)
+	changed := make([]string, 0)
+	fieldErrors := make([]string, 0)
 
-					if !ok {
-						fieldErrors = append(fieldErrors, fmt.Sprintf("field %s needs to be a string to be validated by a regex", profileField.Identifier))
-						continue out
-					}
+update:
+	for _, updateField := range updateFields {
+		for _, tenantField := range tenantFields {
+			// fields the viewer can't see are reported like unknown ones, so their existence isn't revealed
+			if tenantField.Identifier != updateField.Identifier || !canViewProfileField(tenantField, ownerID, viewer) {
+				continue
+			}
 
-					success := r.MatchString(strValue)
+			value, err := normalizeProfileValue(tenantField, updateField.Value)
+			if err != nil {
+				fieldErrors = append(fieldErrors, fmt.Sprintf("field %s %s", updateField.Identifier, err.Error()))
+				continue update
+			}
 
-					if !success {
-						fieldErrors = append(fieldErrors, fmt.Sprintf("field %s is invalid", profileField.Identifier))
-						continue out
-					}
-				}
+			current, err := normalizeProfileValue(tenantField, profileFieldValue(fields, updateField.Identifier))
+			if err == nil && reflect.DeepEqual(current, value) {
+				continue update
+			}
 
-				continue out
+			if !canModifyProfileField(tenantField, ownerID, viewer, creating) {
+				fieldErrors = append(fieldErrors, fmt.Sprintf("field %s can not be changed", updateField.Identifier))
+				continue update
 			}
+
+			fields = setProfileFieldValue(fields, updateField.Identifier, value)
+			changed = append(changed, updateField.Identifier)
+
+			continue update
 		}
 
-		if tenantField.Required {
-			fieldErrors = append(fieldErrors, fmt.Sprintf("field %s is required", tenantField.Identifier))
-			continue out
+		fieldErrors = append(fieldErrors, fmt.Sprintf("field %s does not exist", updateField.Identifier))
+	}
+
+	if len(fieldErrors) > 0 {
+		return nil, nil, errors.New("multiple field errors: " + strings.Join(fieldErrors, ","))
+	}
+
+	return fields, changed, nil
+}
+
+// canViewProfileField checks the ViewBy of a field, fields without ViewBy are only visible to the user.
+func canViewProfileField(field object.ProfileField, ownerID string, viewer object.ProfileViewer) bool {
+	if viewer.Admin {
+		return true
+	}
+
+	self := len(viewer.UserID) > 0 && viewer.UserID == ownerID
+
+	switch field.ViewBy {
+	case object.AllowViewPublic:
+		return true
+	case object.AllowViewApplication:
+		return self || len(viewer.ApplicationID) > 0
+	case object.AllowViewGroup:
+		return self || slices.ContainsFunc(viewer.GroupIDs, func(groupID string) bool {
+			return slices.Contains(field.ViewGroups, groupID)
+		})
+	case object.AllowViewAdmin:
+		return false
+	default:
+		return self
+	}
+}
+
+// canModifyProfileField checks the ModifyBy of a field. Immutable fields can only be set by the user while the user is
+// created, a missing profile page doesn't count as that.
+func canModifyProfileField(field object.ProfileField, ownerID string, viewer object.ProfileViewer, creating bool) bool {
+	if viewer.Admin {
+		return true
+	}
+
+	if len(viewer.UserID) == 0 || viewer.UserID != ownerID || !canViewProfileField(field, ownerID, viewer) {
+		return false
+	}
+
+	return field.ModifyBy != object.ModifyTypeImmutable || creating
+}
+
+// storedProfileValue returns the value of a field in its normalized form, or the default if the user has no value.
+// Values which don't match the field anymore are returned as they are.
+func storedProfileValue(field object.ProfileField, pageFields []object.ProfilePageField) any {
+	value := profileFieldValue(pageFields, field.Identifier)
+
+	normalized, err := normalizeProfileValue(field, value)
+	if err != nil {
+		return value
+	}
+
+	if normalized == nil && field.Default != nil {
+		normalized, _ = normalizeProfileValue(field, field.Default)
+	}
+
+	return normalized
+}
+
+func profileFieldIdentifiers(fields []object.ProfilePageField) []string {
+	identifiers := make([]string, 0, len(fields))
+	for _, field := range fields {
+		identifiers = append(identifiers, field.Identifier)
+	}
+
+	return identifiers
+}
+
+func profileFieldValue(fields []object.ProfilePageField, identifier string) any {
+	for _, field := range fields {
+		if field.Identifier == identifier {
+			return field.Value
+		}
+	}
+
+	return nil
+}
+
+func setProfileFieldValue(fields []object.ProfilePageField, identifier string, value any) []object.ProfilePageField {
+	for i, field := range fields {
+		if field.Identifier == identifier {
+			fields[i].Value = value
+			return fields
 		}
 	}
 
-	return fieldErrors
+	return append(fields, object.ProfilePageField{
+		Identifier: identifier,
+		Value:      value,
+	})
+}
+
+func isEmptyProfileValue(value any) bool {
+	return value == nil || value == ""
 }
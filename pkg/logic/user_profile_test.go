@@ -0,0 +1,127 @@
+/*
+ * Copyright (C) 2025 Anthrove
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logic
+
+import (
+	"github.com/anthrove/identity/pkg/object"
+	"reflect"
+	"testing"
+)
+
+func TestMergeProfileFields(t *testing.T) {
+	tenantFields := []object.ProfileField{
+		{Identifier: "nickname", ModifyBy: object.ModifyTypeSelf, ViewBy: object.AllowViewPublic},
+		{Identifier: "employee_id", ModifyBy: object.ModifyTypeImmutable, ViewBy: object.AllowViewSelf},
+		{Identifier: "salary_band", ModifyBy: object.ModifyTypeSelf, ViewBy: object.AllowViewAdmin},
+	}
+	pageFields := []object.ProfilePageField{
+		{Identifier: "nickname", Value: "Jane"},
+		{Identifier: "employee_id", Value: "4711"},
+	}
+
+	self := object.ProfileViewer{UserID: "owner"}
+	admin := object.ProfileViewer{Admin: true}
+
+	tests := []struct {
+		viewer      object.ProfileViewer
+		creating    bool
+		update      []object.ProfilePageField
+		wantFields  []object.ProfilePageField
+		wantChanged []string
+		wantErr     bool
+	}{
+		{
+			viewer:      self,
+			creating:    false,
+			update:      []object.ProfilePageField{{Identifier: "nickname", Value: "Janie"}, {Identifier: "employee_id", Value: "4711"}},
+			wantFields:  []object.ProfilePageField{{Identifier: "nickname", Value: "Janie"}, {Identifier: "employee_id", Value: "4711"}},
+			wantChanged: []string{"nickname"},
+		},
+		{viewer: self, creating: false, update: []object.ProfilePageField{{Identifier: "employee_id", Value: "1337"}}, wantErr: true},
+		{
+			viewer:      self,
+			creating:    true,
+			update:      []object.ProfilePageField{{Identifier: "employee_id", Value: "1337"}},
+			wantFields:  []object.ProfilePageField{{Identifier: "nickname", Value: "Jane"}, {Identifier: "employee_id", Value: "1337"}},
+			wantChanged: []string{"employee_id"},
+		},
+		{viewer: self, creating: false, update: []object.ProfilePageField{{Identifier: "salary_band", Value: "B"}}, wantErr: true},
+		{viewer: self, creating: false, update: []object.ProfilePageField{{Identifier: "unknown", Value: "value"}}, wantErr: true},
+		{viewer: object.ProfileViewer{UserID: "other"}, creating: false, update: []object.ProfilePageField{{Identifier: "nickname", Value: "Janie"}}, wantErr: true},
+		{
+			viewer:      admin,
+			creating:    false,
+			update:      []object.ProfilePageField{{Identifier: "employee_id", Value: "1337"}, {Identifier: "salary_band", Value: "B"}},
+			wantFields:  []object.ProfilePageField{{Identifier: "nickname", Value: "Jane"}, {Identifier: "employee_id", Value: "1337"}, {Identifier: "salary_band", Value: "B"}},
+			wantChanged: []string{"employee_id", "salary_band"},
+		},
+	}
+
+	for i, test := range tests {
+		fields, changed, err := mergeProfileFields(tenantFields, pageFields, test.creating, "owner", test.viewer, test.update)
+
+		if (err != nil) != test.wantErr {
+			t.Errorf("test %d: mergeProfileFields() error = %v, want error %t", i, err, test.wantErr)
+			continue
+		}
+
+		if test.wantErr {
+			continue
+		}
+
+		if !reflect.DeepEqual(fields, test.wantFields) || !reflect.DeepEqual(changed, test.wantChanged) {
+			t.Errorf("test %d: mergeProfileFields() = %v, %v, want %v, %v", i, fields, changed, test.wantFields, test.wantChanged)
+		}
+	}
+
+	// a user without a profile page is not being created, so immutable fields stay locked
+	_, _, err := mergeProfileFields(tenantFields, nil, false, "owner", self, []object.ProfilePageField{{Identifier: "employee_id", Value: "1337"}})
+	if err == nil {
+		t.Errorf("mergeProfileFields() allowed a user without profile page to set an immutable field")
+	}
+
+	if pageFields[1].Value != "4711" {
+		t.Errorf("mergeProfileFields changed the stored fields")
+	}
+}
+
+func TestCanViewProfileField(t *testing.T) {
+	groupField := object.ProfileField{ViewBy: object.AllowViewGroup, ViewGroups: []string{"hr"}}
+
+	tests := []struct {
+		field  object.ProfileField
+		viewer object.ProfileViewer
+		want   bool
+	}{
+		{object.ProfileField{ViewBy: object.AllowViewPublic}, object.ProfileViewer{}, true},
+		{object.ProfileField{ViewBy: object.AllowViewSelf}, object.ProfileViewer{}, false},
+		{object.ProfileField{ViewBy: object.AllowViewSelf}, object.ProfileViewer{UserID: "owner"}, true},
+		{object.ProfileField{}, object.ProfileViewer{UserID: "other"}, false},
+		{object.ProfileField{ViewBy: object.AllowViewApplication}, object.ProfileViewer{ApplicationID: "app"}, true},
+		{object.ProfileField{ViewBy: object.AllowViewSelf}, object.ProfileViewer{ApplicationID: "app"}, false},
+		{groupField, object.ProfileViewer{UserID: "other", GroupIDs: []string{"hr"}}, true},
+		{groupField, object.ProfileViewer{UserID: "other", GroupIDs: []string{"sales"}}, false},
+		{object.ProfileField{ViewBy: object.AllowViewAdmin}, object.ProfileViewer{UserID: "owner"}, false},
+		{object.ProfileField{ViewBy: object.AllowViewAdmin}, object.ProfileViewer{Admin: true}, true},
+	}
+
+	for _, test := range tests {
+		if got := canViewProfileField(test.field, "owner", test.viewer); got != test.want {
+			t.Errorf("canViewProfileField(%v, %+v) = %t, want %t", test.field.ViewBy, test.viewer, got, test.want)
+		}
+	}
+}
@@ -18,6 +18,7 @@ package logic
 
 import (
 	"context"
+	"github.com/anthrove/identity/pkg/object"
 	"gorm.io/gorm"
 )
 
@@ -42,3 +43,16 @@ func (is IdentityService) getDBConn(ctx context.Context) (*gorm.DB, bool) {
 
 	return dbVal, true
 }
+
+// requestInfo returns the information about the client which was set by the api for the current request.
+func requestInfo(ctx context.Context) object.RequestInfo {
+	info, _ := ctx.Value("request_info").(object.RequestInfo)
+	return info
+}
+
+// sessionUserID returns the ID of the signed-in user of the current request, if the api authorized one.
+func sessionUserID(ctx context.Context) string {
+	session, _ := ctx.Value("session").(map[string]any)
+	user, _ := session["user"].(object.User)
+	return user.ID
+}
@@ -43,6 +43,15 @@ type Application struct {
 
 	RedirectURLs []string `json:"redirect_urls" gorm:"serializer:json"`
 
+	// RequireFreshMFA forces the second factor on every sign in, even from a trusted device.
+	RequireFreshMFA bool `json:"require_fresh_mfa"`
+
+	// ConsentRequired shows the consent page of the hosted login to the user, until the requested scopes were granted once.
+	ConsentRequired bool `json:"consent_required"`
+
+	// Branding overrides the branding of the tenant for this application.
+	Branding Branding `json:"branding" gorm:"serializer:json"`
+
 	Tokens       []Token    `json:"-" swaggerignore:"true"`
 	AuthProvider []Provider `json:"auth_provider" gorm:"many2many:auth_application_provider;"`
 }
@@ -184,6 +193,9 @@ type CreateApplication struct {
 	TermsURL  string `json:"terms_url"`
 
 	RedirectURLs []string `json:"redirect_urls"`
+
+	RequireFreshMFA bool `json:"require_fresh_mfa"`
+	ConsentRequired bool `json:"consent_required"`
 }
 
 type UpdateApplication struct {
@@ -196,4 +208,10 @@ type UpdateApplication struct {
 	TermsURL  string `json:"terms_url"`
 
 	RedirectURLs []string `json:"redirect_urls"`
+
+	RequireFreshMFA bool `json:"require_fresh_mfa"`
+	ConsentRequired bool `json:"consent_required"`
+
+	// Branding is optional, updates keep the current branding if it is omitted.
+	Branding *Branding `json:"branding"`
 }
@@ -0,0 +1,82 @@
+/*
+ * Copyright (C) 2025 Anthrove
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package object
+
+import (
+	gonanoid "github.com/matoous/go-nanoid/v2"
+	"gorm.io/gorm"
+	"time"
+)
+
+const (
+	AuditEventUserLocked   = "user_locked"
+	AuditEventIPLocked     = "ip_locked"
+	AuditEventLockoutReset = "lockout_reset"
+
+	AuditEventPasswordChanged        = "password_changed"
+	AuditEventPasswordChangeRequired = "password_change_required"
+	AuditEventPasswordReset          = "password_reset"
+
+	AuditEventUserSignedUp  = "user_signed_up"
+	AuditEventEmailVerified = "email_verified"
+	AuditEventEmailChanged  = "email_changed"
+
+	AuditEventProfileUpdated     = "profile_updated"
+	AuditEventSessionRevoked     = "session_revoked"
+	AuditEventConsentRevoked     = "consent_revoked"
+	AuditEventPersonalDataExport = "personal_data_exported"
+)
+
+// AuditEvent records a security relevant event within a tenant.
+type AuditEvent struct {
+	ID       string `json:"id" gorm:"primaryKey;type:char(25)" example:"BsOOg4igppKxYwhAQQrD3GCRZ"`
+	TenantID string `json:"tenant_id" gorm:"type:char(25);index" example:"BsOOg4igppKxYwhAQQrD3GCRZ"`
+
+	CreatedAt time.Time `json:"created_at" format:"date-time" example:"2025-01-01T00:00:00Z"`
+
+	Type string `json:"type" gorm:"type:varchar(100);index" example:"user_locked"`
+	// UserID is the user the event is about, if any.
+	UserID string `json:"user_id,omitempty" gorm:"type:varchar(25)" example:"BsOOg4igppKxYwhAQQrD3GCRZ"`
+	// ActorID is the signed-in user who caused the event, if any.
+	ActorID   string `json:"actor_id,omitempty" gorm:"type:varchar(25)" example:"BsOOg4igppKxYwhAQQrD3GCRZ"`
+	IPAddress string `json:"ip_address,omitempty" gorm:"type:varchar(45)" example:"192.0.2.1"`
+	UserAgent string `json:"user_agent,omitempty" gorm:"type:varchar(255)" example:"Mozilla/5.0 (X11; Linux x86_64)"`
+
+	Details map[string]any `json:"details,omitempty" gorm:"serializer:json"`
+}
+
+func (base *AuditEvent) BeforeCreate(db *gorm.DB) error {
+	if base.ID == "" {
+		id, err := gonanoid.New(25)
+		if err != nil {
+			return err
+		}
+
+		base.ID = id
+	}
+
+	return nil
+}
+
+type CreateAuditEvent struct {
+	Type      string
+	UserID    string
+	ActorID   string
+	IPAddress string
+	UserAgent string
+	Details   map[string]any
+}
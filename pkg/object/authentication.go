@@ -16,9 +16,63 @@
 
 package object
 
+import "time"
+
 type SignInRequest struct {
 	RequestID string         `json:"request_id"`
 	Username  string         `json:"username"`
 	Type      string         `json:"type"`
 	Metadata  map[string]any `json:"metadata"`
+
+	// TrustedDeviceToken is read from the trusted device cookie and not from the body.
+	TrustedDeviceToken string `json:"-" swaggerignore:"true"`
+}
+
+type SignInMFARequest struct {
+	MFAID        string         `json:"mfa_id"`
+	RecoveryCode string         `json:"recovery_code"`
+	Metadata     map[string]any `json:"metadata"`
+	// TrustDevice marks the current browser as trusted, so later sign ins from it skip the second factor.
+	TrustDevice bool `json:"trust_device"`
+}
+
+// SignInChangePasswordRequest sets a new password during a sign in which requires a password change.
+type SignInChangePasswordRequest struct {
+	Password string `json:"password" validate:"required,max=100"`
+}
+
+// ChangePassword changes the password of the signed in user.
+type ChangePassword struct {
+	CurrentPassword string `json:"current_password" validate:"required,max=100"`
+	NewPassword     string `json:"new_password" validate:"required,max=100"`
+}
+
+type SignInMFAInitRequest struct {
+	MFAID string `json:"mfa_id" validate:"required"`
+}
+
+type SignInResponse struct {
+	Step string `json:"step" example:"done"`
+	User User   `json:"user"`
+	MFAs []MFA  `json:"mfas,omitempty"`
+
+	// TrustedDeviceToken is set by the api as cookie and not returned in the body.
+	TrustedDeviceToken string    `json:"-" swaggerignore:"true"`
+	TrustedDeviceUntil time.Time `json:"-" swaggerignore:"true"`
+}
+
+const (
+	SignInStepDone        = "done"
+	SignInStepMFARequired = "mfa_required"
+	SignInStepMFAPending  = "mfa_pending"
+	// SignInStepPasswordChangeRequired means the password expired or an administrator requires a change, the session is
+	// only usable after a new password was set with SignInChangePassword.
+	SignInStepPasswordChangeRequired = "password_change_required"
+)
+
+// RequestInfo describes the client of the current request, it is used to tie sign in steps to the client which started them.
+type RequestInfo struct {
+	IPAddress     string `json:"ip_address" example:"127.0.0.1"`
+	UserAgent     string `json:"user_agent" example:"Mozilla/5.0"`
+	ApplicationID string `json:"application_id" example:"BsOOg4igppKxYwhAQQrD3GCRZ"`
 }
@@ -0,0 +1,62 @@
+/*
+ * Copyright (C) 2025 Anthrove
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package object
+
+// Branding customizes the hosted pages and the emails of a tenant. An application can override the branding of its
+// tenant, empty values of the application are inherited from the tenant.
+type Branding struct {
+	// LogoResourceID references an uploaded image resource of the tenant.
+	LogoResourceID string `json:"logo_resource_id" validate:"omitempty,len=25" maxLength:"25" example:"BsOOg4igppKxYwhAQQrD3GCRZ"`
+
+	PrimaryColor    string `json:"primary_color" validate:"omitempty,hexcolor" example:"#4299e1"`
+	BackgroundColor string `json:"background_color" validate:"omitempty,hexcolor" example:"#f7fafc"`
+	TextColor       string `json:"text_color" validate:"omitempty,hexcolor" example:"#2d3748"`
+
+	// CustomCSS is added to the stylesheet of the hosted pages, the CSS of an application is added after the one of its tenant.
+	CustomCSS string `json:"custom_css" validate:"max=20000" maxLength:"20000"`
+
+	FooterLinks []FooterLink `json:"footer_links" validate:"max=10,dive"`
+
+	// PageTemplates replace single hosted pages, the key is the name of the page. The templates get the same data as the
+	// built-in pages and can use their "header" and "footer" templates.
+	PageTemplates map[string]string `json:"page_templates" validate:"max=10,dive,max=65536"`
+}
+
+// FooterLink is shown in the footer of the hosted pages and the emails.
+type FooterLink struct {
+	Title string `json:"title" validate:"required,max=100" maxLength:"100" example:"Privacy Policy"`
+	URL   string `json:"url" validate:"required,http_url,max=255" maxLength:"255" example:"https://domain.tld/privacy"`
+}
+
+// AppliedBranding is the branding which is used for an application, after the inheritance from its tenant and the defaults
+// were applied.
+type AppliedBranding struct {
+	LogoURL         string            `json:"logo_url" example:"https://domain.tld/files/logo.png"`
+	PrimaryColor    string            `json:"primary_color" example:"#4299e1"`
+	BackgroundColor string            `json:"background_color" example:"#f7fafc"`
+	TextColor       string            `json:"text_color" example:"#2d3748"`
+	CustomCSS       string            `json:"custom_css"`
+	FooterLinks     []FooterLink      `json:"footer_links"`
+	PageTemplates   map[string]string `json:"page_templates"`
+}
+
+// DefaultBranding is used for every value which neither the application nor its tenant configured.
+var DefaultBranding = AppliedBranding{
+	PrimaryColor:    "#4299e1",
+	BackgroundColor: "#f7fafc",
+	TextColor:       "#2d3748",
+}
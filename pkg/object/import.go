@@ -62,4 +62,15 @@ type ImportUser struct {
 	DisplayName string `json:"display_name"`
 	Email       string `json:"email"`
 	Password    string `json:"password"`
+
+	// PasswordHash is a hash exported from another system, used instead of Password. See crypto.ParseForeignHash for the supported formats.
+	PasswordHash string `json:"password_hash"`
+	// PasswordCredential is a password credential of a Keycloak realm export, used instead of Password.
+	PasswordCredential json.RawMessage `json:"password_credential"`
+}
+
+type ImportUserResult struct {
+	ID       string `json:"id"`
+	Username string `json:"username"`
+	Error    string `json:"error,omitempty"`
 }
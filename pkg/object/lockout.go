@@ -0,0 +1,86 @@
+/*
+ * Copyright (C) 2025 Anthrove
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package object
+
+import (
+	gonanoid "github.com/matoous/go-nanoid/v2"
+	"gorm.io/gorm"
+	"time"
+)
+
+// LockoutPolicy configures how a tenant throttles failed sign in and MFA attempts.
+// Every failure delays the next attempt exponentially, after MaxFailures the user or IP address is locked for LockoutDuration.
+type LockoutPolicy struct {
+	// MaxFailures is the amount of failed attempts of a user until it is locked. 0 disables the lockout of users.
+	MaxFailures int `json:"max_failures" validate:"min=0" example:"5"`
+	// MaxFailuresPerIP is the amount of failed attempts from an IP address until it is locked. 0 disables the lockout of IP addresses.
+	MaxFailuresPerIP int `json:"max_failures_per_ip" validate:"min=0" example:"50"`
+	// LockoutDuration is the time in seconds a lockout lasts. Failures older than it are forgotten.
+	LockoutDuration int `json:"lockout_duration" validate:"required_with=MaxFailures MaxFailuresPerIP,min=0" example:"900"`
+	// BackoffBase is the delay in seconds after the first failure, it doubles with every further failure. 0 disables the backoff.
+	BackoffBase int `json:"backoff_base" validate:"min=0" example:"1"`
+	// BackoffMax is the maximal delay in seconds between two attempts.
+	BackoffMax int `json:"backoff_max" validate:"gtefield=BackoffBase" example:"60"`
+}
+
+// DefaultLockoutPolicy is used for tenants which are created without a lockout policy, the migration sets it for
+// tenants which were created before tenants had one.
+var DefaultLockoutPolicy = LockoutPolicy{
+	MaxFailures:      5,
+	MaxFailuresPerIP: 50,
+	LockoutDuration:  900,
+	BackoffBase:      1,
+	BackoffMax:       60,
+}
+
+const (
+	LockoutScopePassword = "password"
+	LockoutScopeMFA      = "mfa"
+	LockoutScopeIP       = "ip"
+)
+
+// Lockout counts the failed attempts of a user or IP address. It is stored in the database, so all replicas share the counters.
+type Lockout struct {
+	ID       string `json:"id" gorm:"primaryKey;type:char(25)" example:"BsOOg4igppKxYwhAQQrD3GCRZ"`
+	TenantID string `json:"tenant_id" gorm:"type:char(25);uniqueIndex:idx_lockout_key" example:"BsOOg4igppKxYwhAQQrD3GCRZ"`
+
+	CreatedAt time.Time `json:"created_at" format:"date-time" example:"2025-01-01T00:00:00Z"`
+	UpdatedAt time.Time `json:"updated_at" format:"date-time" example:"2025-01-01T00:00:00Z"`
+
+	// Key identifies the counter within the tenant, e.g. the scope and the user ID.
+	Key       string `json:"key" gorm:"type:varchar(100);uniqueIndex:idx_lockout_key" example:"password:BsOOg4igppKxYwhAQQrD3GCRZ"`
+	Scope     string `json:"scope" gorm:"type:varchar(25)" example:"password"`
+	UserID    string `json:"user_id,omitempty" gorm:"type:varchar(25);index" example:"BsOOg4igppKxYwhAQQrD3GCRZ"`
+	IPAddress string `json:"ip_address,omitempty" gorm:"type:varchar(45)" example:"192.0.2.1"`
+
+	Failures      int       `json:"failures" example:"3"`
+	LastFailureAt time.Time `json:"last_failure_at" format:"date-time" example:"2025-01-01T00:00:00Z"`
+	LockedUntil   time.Time `json:"locked_until" format:"date-time" example:"2025-01-01T00:15:00Z"`
+}
+
+func (base *Lockout) BeforeCreate(db *gorm.DB) error {
+	if base.ID == "" {
+		id, err := gonanoid.New(25)
+		if err != nil {
+			return err
+		}
+
+		base.ID = id
+	}
+
+	return nil
+}
@@ -36,6 +36,9 @@ type MFACreationResponse struct {
 	Priority    int             `json:"priority" example:"1"`
 	Verified    bool            `json:"verified" example:"true"`
 	Properties  json.RawMessage `json:"properties"`
+
+	// RecoveryCodes are only returned once in plaintext, they are stored hashed afterward.
+	RecoveryCodes []string `json:"recovery_codes" example:"correct-horse-battery"`
 }
 
 type MFA struct {
@@ -52,6 +55,8 @@ type MFA struct {
 	Verified      bool            `json:"verified" example:"true"`
 	RecoveryCodes []string        `json:"-" swaggerignore:"true" gorm:"type:text[]; serializer:json"`
 	Properties    json.RawMessage `json:"-" validate:"required"`
+	// LastCounter is the last accepted counter (e.g. the TOTP time step), used to reject replayed codes.
+	LastCounter int64 `json:"-" swaggerignore:"true"`
 }
 
 func (base *MFA) BeforeCreate(db *gorm.DB) error {
@@ -74,6 +79,8 @@ type CreateMFA struct {
 	Priority      int             `json:"priority" validate:"required" example:"1"`
 	RecoveryCodes []string        `json:"-" swaggerignore:"true"`
 	Properties    json.RawMessage `json:"-" swaggerignore:"true"`
+	// Metadata is passed to the MFA provider for the setup, e.g. the phone number for sms_otp.
+	Metadata map[string]any `json:"metadata"`
 }
 
 type UpdateMFA struct {
@@ -82,5 +89,51 @@ type UpdateMFA struct {
 }
 
 type MFAProviderData struct {
-	Properties json.RawMessage `json:"secret" validate:"required"`
+	Properties  json.RawMessage `json:"secret" validate:"required"`
+	LastCounter int64           `json:"last_counter"`
+}
+
+type MFARecoveryCodes struct {
+	RecoveryCodes []string `json:"recovery_codes" example:"correct-horse-battery"`
+}
+
+type MFARecoveryCodeCount struct {
+	Remaining int `json:"remaining" example:"6"`
+}
+
+type PushApprovalDecision struct {
+	Token   string `json:"token" validate:"required"`
+	Approve bool   `json:"approve" example:"true"`
+	// Number is the number the user selected, it has to match the number shown on the login page.
+	Number int `json:"number" example:"42"`
+}
+
+// PushApproval is a pending approval of a push MFA. It is stored in the database, so the decision of the companion app
+// reaches the sign in on every replica.
+type PushApproval struct {
+	ID       string `json:"id" gorm:"primaryKey;type:char(32)" example:"V1StGXR8_Z5jdHi6B-myTV1StGXR8_Z5"`
+	TenantID string `json:"tenant_id" gorm:"type:char(25);index" example:"BsOOg4igppKxYwhAQQrD3GCRZ"`
+	MFAID    string `json:"mfa_id" gorm:"type:char(25)" example:"BsOOg4igppKxYwhAQQrD3GCRZ"`
+
+	CreatedAt time.Time `json:"created_at" format:"date-time" example:"2025-01-01T00:00:00Z"`
+	ExpiresAt time.Time `json:"expires_at" gorm:"index" format:"date-time" example:"2025-01-01T00:02:00Z"`
+
+	TokenHash string `json:"-" gorm:"type:varchar(255)"`
+	// Number is the right one of the number choices, the user has to select it to approve.
+	Number   int    `json:"-"`
+	Decision string `json:"decision" gorm:"type:varchar(25)" example:"pending"`
+
+	// IPAddress, UserAgent and ApplicationID belong to the client which started the sign in, only it can use the approval.
+	IPAddress     string `json:"ip_address" gorm:"type:varchar(45)" example:"192.0.2.1"`
+	UserAgent     string `json:"user_agent" gorm:"type:text" example:"Mozilla/5.0 (X11; Linux x86_64)"`
+	ApplicationID string `json:"application_id" gorm:"type:varchar(25)" example:"BsOOg4igppKxYwhAQQrD3GCRZ"`
+}
+
+// RequestInfo returns the request info of the client which started the sign in.
+func (approval PushApproval) RequestInfo() RequestInfo {
+	return RequestInfo{
+		IPAddress:     approval.IPAddress,
+		UserAgent:     approval.UserAgent,
+		ApplicationID: approval.ApplicationID,
+	}
 }
@@ -0,0 +1,69 @@
+/*
+ * Copyright (C) 2025 Anthrove
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package object
+
+import (
+	gonanoid "github.com/matoous/go-nanoid/v2"
+	"gorm.io/gorm"
+	"time"
+)
+
+// PasswordReset is a requested password reset. The reset token sent to the user is signed with the signing certificate
+// of the tenant and references this record, which makes sure every token can only be used once.
+type PasswordReset struct {
+	ID            string `json:"id" gorm:"primaryKey;type:char(25)" example:"BsOOg4igppKxYwhAQQrD3GCRZ"`
+	TenantID      string `json:"tenant_id" gorm:"type:char(25)" example:"BsOOg4igppKxYwhAQQrD3GCRZ"`
+	ApplicationID string `json:"application_id" gorm:"type:char(25)" example:"BsOOg4igppKxYwhAQQrD3GCRZ"`
+	UserID        string `json:"user_id" gorm:"type:char(25);index" example:"BsOOg4igppKxYwhAQQrD3GCRZ"`
+
+	CreatedAt time.Time  `json:"created_at" format:"date-time" example:"2025-01-01T00:00:00Z"`
+	ExpiresAt time.Time  `json:"expires_at" format:"date-time" example:"2025-01-01T00:30:00Z"`
+	UsedAt    *time.Time `json:"used_at" format:"date-time" example:"2025-01-01T00:10:00Z"`
+
+	IPAddress string `json:"ip_address" gorm:"type:varchar(45)" example:"192.0.2.1"`
+}
+
+func (base *PasswordReset) BeforeCreate(db *gorm.DB) error {
+	if base.ID == "" {
+		id, err := gonanoid.New(25)
+		if err != nil {
+			return err
+		}
+
+		base.ID = id
+	}
+
+	return nil
+}
+
+type CreatePasswordReset struct {
+	ApplicationID string
+	ExpiresAt     time.Time
+	IPAddress     string
+}
+
+// RequestPasswordReset starts a password reset for the user with the username or the verified email.
+type RequestPasswordReset struct {
+	Username string `json:"username" validate:"required_without=Email,max=100"`
+	Email    string `json:"email" validate:"required_without=Username,omitempty,max=100,email"`
+}
+
+// CompletePasswordReset sets a new password with the token of the reset mail.
+type CompletePasswordReset struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required,max=100"`
+}
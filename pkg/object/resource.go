@@ -36,9 +36,41 @@ type Resource struct {
 	FileSize   int64  `json:"file_size" example:"1024"`
 	Format     string `json:"format" example:"png"`
 	Url        string `json:"url" example:"https://domain.tld/files/file.png"`
-	Hash       string `json:"hash" example:"d41d8cd98f00b204e9800998ecf8427e"`
+	Hash       string `json:"hash" example:"e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"`
+
+	// BlobPath is the path of the content in the storage provider, resources with the same content share it.
+	// It is empty for resources which were uploaded before deduplication, their content is stored at FilePath.
+	BlobPath string `json:"blob_path,omitempty" example:"logo/a1b2c3d4e5_logo.png"`
+
+	// UserID is the user who uploaded the resource through the profile, it is deleted together with the user.
+	UserID string `json:"user_id,omitempty" gorm:"type:char(25);index" example:"BsOOg4igppKxYwhAQQrD3GCRZ"`
+	// ParentID is set for resized variants of an image, they are deleted together with their parent.
+	ParentID string `json:"parent_id,omitempty" gorm:"type:char(25);index" example:"BsOOg4igppKxYwhAQQrD3GCRZ"`
+	// Variant is the name of a resized variant, e.g. "128" for an image which fits into 128x128 pixels.
+	Variant string `json:"variant,omitempty" example:"128"`
+
+	// Visibility decides who can download the resource through the CDN, resources without it are public.
+	Visibility ResourceVisibility `json:"visibility" gorm:"type:varchar(10);default:public" enums:"public,tenant,owner,group" example:"public"`
+	// GroupID is the group whose members can download a resource with the visibility group.
+	GroupID string `json:"group_id,omitempty" gorm:"type:char(25)" example:"BsOOg4igppKxYwhAQQrD3GCRZ"`
+
+	Variants []Resource `json:"variants,omitempty" gorm:"-"`
 }
 
+// ResourceVisibility decides who can download a resource through the CDN.
+type ResourceVisibility string
+
+const (
+	// ResourceVisibilityPublic resources can be downloaded by everyone.
+	ResourceVisibilityPublic ResourceVisibility = "public"
+	// ResourceVisibilityTenant resources can be downloaded by every signed-in user of the tenant.
+	ResourceVisibilityTenant ResourceVisibility = "tenant"
+	// ResourceVisibilityOwner resources can only be downloaded by the user who uploaded them.
+	ResourceVisibilityOwner ResourceVisibility = "owner"
+	// ResourceVisibilityGroup resources can be downloaded by the members of the group of the resource.
+	ResourceVisibilityGroup ResourceVisibility = "group"
+)
+
 func (base *Resource) BeforeCreate(db *gorm.DB) error {
 	if base.ID == "" {
 		id, err := gonanoid.New(25)
@@ -58,4 +90,91 @@ type CreateResource struct {
 	FileName   string `json:"file_name" example:"file.png"`
 	FileSize   int64  `json:"file_size" example:"1024"`
 	MimeType   string `json:"mime_type" example:"image/png"`
+
+	Visibility ResourceVisibility `json:"visibility" validate:"omitempty,oneof=public tenant owner group" enums:"public,tenant,owner,group" example:"public"`
+	GroupID    string             `json:"group_id" validate:"required_if=Visibility group,omitempty,len=25" example:"BsOOg4igppKxYwhAQQrD3GCRZ"`
+
+	// UserID, ParentID and Variant are set for the uploads of the profile.
+	UserID   string `json:"-"`
+	ParentID string `json:"-"`
+	Variant  string `json:"-"`
+}
+
+// UpdateResourceVisibility represents the data required to change who can download a resource.
+type UpdateResourceVisibility struct {
+	Visibility ResourceVisibility `json:"visibility" validate:"required,oneof=public tenant owner group" enums:"public,tenant,owner,group" example:"owner"`
+	GroupID    string             `json:"group_id" validate:"required_if=Visibility group,omitempty,len=25" example:"BsOOg4igppKxYwhAQQrD3GCRZ"`
+}
+
+// CreateResourceURL represents the data required to create a signed download URL of a resource.
+type CreateResourceURL struct {
+	// ExpiresIn is the lifetime of the URL in seconds, it defaults to one hour.
+	ExpiresIn int `json:"expires_in" validate:"omitempty,min=1,max=604800" example:"3600"`
+}
+
+// ResourceURL is a signed download URL which expires at ExpiresAt.
+type ResourceURL struct {
+	URL       string    `json:"url" example:"/api/v1/cdn/BsOOg4igppKxYwhAQQrD3GCRZ/profile/a1b2c3d4e5_cv.pdf?expires=1735689600&signature=..."`
+	ExpiresAt time.Time `json:"expires_at" format:"date-time" example:"2025-01-01T00:00:00Z"`
+}
+
+// ResourceAccess holds what a CDN request can show to download a resource which is not public.
+type ResourceAccess struct {
+	// UserID is the signed-in user of the tenant, if any.
+	UserID string
+	// Expires and Signature are the query parameters of a signed URL.
+	Expires   string
+	Signature string
+}
+
+// StorageUsage is the storage used by the resources of a tenant together with its quota.
+type StorageUsage struct {
+	// Bytes is the size of the stored content, content shared by several resources is counted once.
+	Bytes int64 `json:"bytes" example:"1048576"`
+	Files int64 `json:"files" example:"42"`
+
+	MaxBytes int64 `json:"max_bytes" example:"1073741824"`
+	MaxFiles int64 `json:"max_files" example:"10000"`
+}
+
+// MigrateResources represents the data required to move the resources of a tenant to another storage provider.
+type MigrateResources struct {
+	SourceProviderID string `json:"source_provider_id" validate:"required,len=25" maxLength:"25" minLength:"25" example:"BsOOg4igppKxYwhAQQrD3GCRZ"`
+	TargetProviderID string `json:"target_provider_id" validate:"required,len=25,nefield=SourceProviderID" maxLength:"25" minLength:"25" example:"CsOOg4igppKxYwhAQQrD3GCRZ"`
+
+	// DeleteSource deletes the content from the source provider once it was copied, otherwise it is kept.
+	DeleteSource bool `json:"delete_source" example:"false"`
+}
+
+// ResourceMigrationProgress reports the progress of a storage migration. Resources which share their content are
+// migrated together.
+type ResourceMigrationProgress struct {
+	SourceProviderID string `json:"source_provider_id" example:"BsOOg4igppKxYwhAQQrD3GCRZ"`
+	TargetProviderID string `json:"target_provider_id" example:"CsOOg4igppKxYwhAQQrD3GCRZ"`
+
+	// Total is the number of resources in the source provider when the migration started.
+	Total    int `json:"total" example:"120"`
+	Migrated int `json:"migrated" example:"80"`
+	Failed   int `json:"failed" example:"1"`
+	// Bytes is the size of the content copied so far.
+	Bytes int64 `json:"bytes" example:"10485760"`
+
+	// Errors holds the first errors of resources which could not be migrated, they stay in the source provider.
+	Errors []string `json:"errors,omitempty"`
+
+	Running    bool       `json:"running" example:"true"`
+	StartedAt  time.Time  `json:"started_at" format:"date-time" example:"2025-01-01T00:00:00Z"`
+	FinishedAt *time.Time `json:"finished_at,omitempty" format:"date-time" example:"2025-01-01T00:10:00Z"`
+}
+
+// ResourceMigrationLock marks the storage migration of a tenant as running. It is stored in the database, so migrations
+// started through the API and the CLI can't run at the same time. The running migration refreshes the lock, a lock
+// which is not refreshed anymore was left by a stopped process and is taken over by the next migration.
+type ResourceMigrationLock struct {
+	TenantID string `json:"tenant_id" gorm:"primaryKey;type:char(25)" example:"BsOOg4igppKxYwhAQQrD3GCRZ"`
+	// LockID identifies the migration holding the lock, only it can refresh or release the lock.
+	LockID string `json:"lock_id" gorm:"type:char(25)" example:"BsOOg4igppKxYwhAQQrD3GCRZ"`
+
+	CreatedAt time.Time `json:"created_at" format:"date-time" example:"2025-01-01T00:00:00Z"`
+	UpdatedAt time.Time `json:"updated_at" format:"date-time" example:"2025-01-01T00:00:00Z"`
 }
@@ -0,0 +1,46 @@
+/*
+ * Copyright (C) 2025 Anthrove
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package object
+
+import "time"
+
+// UserSession describes a signed in session of a user. The ID is derived from the session cookie, the cookie itself is never returned.
+type UserSession struct {
+	ID            string `json:"id" example:"5f2b1c0e9d8a7b6c5f2b1c0e9d8a7b6c"`
+	ApplicationID string `json:"application_id" example:"BsOOg4igppKxYwhAQQrD3GCRZ"`
+	IPAddress     string `json:"ip_address" example:"192.0.2.1"`
+	UserAgent     string `json:"user_agent" example:"Mozilla/5.0 (X11; Linux x86_64)"`
+
+	CreatedAt       time.Time `json:"created_at" format:"date-time" example:"2025-01-01T00:00:00Z"`
+	AuthenticatedAt time.Time `json:"authenticated_at" format:"date-time" example:"2025-01-01T00:00:00Z"`
+
+	// Current marks the session of the request.
+	Current bool `json:"current"`
+}
+
+// PersonalData contains everything the identity server stores about a user, it is downloaded in the self-service.
+type PersonalData struct {
+	ExportedAt time.Time `json:"exported_at" format:"date-time" example:"2025-01-01T00:00:00Z"`
+
+	User           User                `json:"user"`
+	Profile        []ProfileFieldValue `json:"profile"`
+	MFAs           []MFA               `json:"mfas"`
+	TrustedDevices []TrustedDevice     `json:"trusted_devices"`
+	Sessions       []UserSession       `json:"sessions"`
+	Consents       []Consent           `json:"consents"`
+	AuditEvents    []AuditEvent        `json:"audit_events"`
+}
@@ -0,0 +1,103 @@
+/*
+ * Copyright (C) 2025 Anthrove
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package object
+
+import (
+	gonanoid "github.com/matoous/go-nanoid/v2"
+	"gorm.io/gorm"
+	"time"
+)
+
+const (
+	// SignUpModeDisabled only allows admins to create users, it is the default of new tenants.
+	SignUpModeDisabled = "disabled"
+	// SignUpModeOpen allows everyone to sign up.
+	SignUpModeOpen = "open"
+	// SignUpModeInvite requires an invitation created by an admin.
+	SignUpModeInvite = "invite"
+	// SignUpModeDomain only allows emails of the allowed domains.
+	SignUpModeDomain = "domain"
+)
+
+// SignUpPolicy configures the self-service registration of a tenant.
+type SignUpPolicy struct {
+	Mode string `json:"mode" validate:"omitempty,oneof=disabled open invite domain" example:"open"`
+	// AllowedDomains are the email domains which can sign up in the domain mode.
+	AllowedDomains []string `json:"allowed_domains" validate:"required_if=Mode domain,dive,fqdn" example:"example.com"`
+	// DefaultGroupIDs are the groups every new user is added to, in the domain mode once the email is verified.
+	DefaultGroupIDs []string `json:"default_group_ids" validate:"dive,len=25" example:"BsOOg4igppKxYwhAQQrD3GCRZ"`
+	// CaptchaProviderID is the captcha provider which has to be solved to sign up. Empty disables the captcha.
+	CaptchaProviderID string `json:"captcha_provider_id" validate:"omitempty,len=25" example:"BsOOg4igppKxYwhAQQrD3GCRZ"`
+	// RequireTerms requires new users to accept the terms url of the application.
+	RequireTerms bool `json:"require_terms" example:"true"`
+}
+
+// SignUp represents the data a user sends to register with an application.
+type SignUp struct {
+	Username    string `json:"username" validate:"required,max=100"`
+	DisplayName string `json:"display_name" validate:"required,max=100"`
+	Email       string `json:"email" validate:"required,max=100,email"`
+	Password    string `json:"password" validate:"required,max=100"`
+
+	ProfileFields []ProfilePageField `json:"profile_fields"`
+
+	InvitationID    string `json:"invitation_id" validate:"omitempty,len=25"`
+	CaptchaResponse string `json:"captcha_response"`
+	AcceptTerms     bool   `json:"accept_terms"`
+}
+
+// SignUpConfiguration describes what a sign up form of an application has to show.
+type SignUpConfiguration struct {
+	Mode          string         `json:"mode" example:"open"`
+	ProfileFields []ProfileField `json:"profile_fields"`
+	TermsURL      string         `json:"terms_url,omitempty" example:"https://domain.tld/terms"`
+
+	CaptchaType    string `json:"captcha_type,omitempty" example:"turnstile"`
+	CaptchaSiteKey string `json:"captcha_site_key,omitempty"`
+}
+
+// Invitation allows a user to sign up to a tenant with the invite mode. The ID is the invitation code.
+type Invitation struct {
+	ID       string `json:"id" gorm:"primaryKey;type:char(25)" example:"BsOOg4igppKxYwhAQQrD3GCRZ"`
+	TenantID string `json:"tenant_id" gorm:"type:char(25);index" example:"BsOOg4igppKxYwhAQQrD3GCRZ"`
+
+	CreatedAt time.Time  `json:"created_at" format:"date-time" example:"2025-01-01T00:00:00Z"`
+	ExpiresAt time.Time  `json:"expires_at" format:"date-time" example:"2025-01-08T00:00:00Z"`
+	UsedAt    *time.Time `json:"used_at" format:"date-time" example:"2025-01-02T00:00:00Z"`
+
+	// Email restricts the invitation to a single email, empty allows any email.
+	Email  string  `json:"email" gorm:"type:varchar(100)" example:"user@example.com"`
+	UserID *string `json:"user_id" gorm:"type:char(25)" example:"BsOOg4igppKxYwhAQQrD3GCRZ"`
+}
+
+func (base *Invitation) BeforeCreate(db *gorm.DB) error {
+	if base.ID == "" {
+		id, err := gonanoid.New(25)
+		if err != nil {
+			return err
+		}
+
+		base.ID = id
+	}
+
+	return nil
+}
+
+type CreateInvitation struct {
+	Email     string    `json:"email" validate:"omitempty,max=100,email" example:"user@example.com"`
+	ExpiresAt time.Time `json:"expires_at" validate:"required" format:"date-time" example:"2025-01-08T00:00:00Z"`
+}
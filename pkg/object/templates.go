@@ -22,6 +22,17 @@ import (
 	"time"
 )
 
+const (
+	TemplateTypeRecoveryCodeUsed  = "recovery_code_used"
+	TemplateTypeMFACode           = "mfa_code"
+	TemplateTypeMFACodeSMS        = "mfa_code_sms"
+	TemplateTypeAccountLocked     = "account_locked"
+	TemplateTypePasswordReset     = "password_reset"
+	TemplateTypeEmailVerification = "email_verification"
+	TemplateTypeEmailChange       = "email_change"
+	TemplateTypeEmailChanged      = "email_changed"
+)
+
 type MessageTemplate struct {
 	ID       string `json:"id" gorm:"primaryKey;type:char(25)" `
 	TenantID string `json:"tenant_id"`
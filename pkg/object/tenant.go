@@ -37,6 +37,26 @@ type Tenant struct {
 
 	ProfileFields []ProfileField `json:"profile_fields" gorm:"serializer:json"`
 
+	// TrustedDeviceDays is the number of days a device stays trusted after a MFA step. 0 disables trusted devices.
+	TrustedDeviceDays int `json:"trusted_device_days" example:"30"`
+
+	LockoutPolicy LockoutPolicy `json:"lockout_policy" gorm:"serializer:json"`
+
+	PasswordHashParameters PasswordHashParameters `json:"password_hash_parameters" gorm:"serializer:json"`
+
+	SignUpPolicy SignUpPolicy `json:"sign_up_policy" gorm:"serializer:json"`
+
+	Branding Branding `json:"branding" gorm:"serializer:json"`
+
+	// StorageProviderID is the storage provider for the uploads of the users, e.g. avatars. Without it, the first storage
+	// provider of the tenant is used.
+	StorageProviderID string `json:"storage_provider_id" gorm:"type:char(25)" example:"BsOOg4igppKxYwhAQQrD3GCRZ"`
+
+	StorageQuota StorageQuota `json:"storage_quota" gorm:"serializer:json"`
+
+	// ResourceSigningKey signs the download URLs of resources which are not public. It is created on first use.
+	ResourceSigningKey string `json:"-" gorm:"type:varchar(64)" swaggerignore:"true"`
+
 	Groups       []Group           `json:"-" swaggerignore:"true"`
 	Providers    []Provider        `json:"-" swaggerignore:"true"`
 	Templates    []MessageTemplate `json:"-" swaggerignore:"true"`
@@ -72,7 +92,18 @@ func (base *Tenant) BeforeCreate(db *gorm.DB) error {
 type CreateTenant struct {
 	DisplayName   string         `json:"display_name" validate:"required,max=100" maxLength:"100"`
 	PasswordType  string         `json:"password_type" validate:"required,max=100" maxLength:"100"`
-	ProfileFields []ProfileField `json:"profile_fields" validate:"required"`
+	ProfileFields []ProfileField `json:"profile_fields" validate:"required,dive"`
+
+	TrustedDeviceDays int `json:"trusted_device_days" validate:"min=0,max=365" example:"30"`
+
+	// LockoutPolicy is optional, new tenants use the DefaultLockoutPolicy and updates keep the current policy if it is omitted.
+	LockoutPolicy *LockoutPolicy `json:"lockout_policy"`
+
+	// PasswordHashParameters is optional, zero values use the defaults of the password hasher.
+	PasswordHashParameters *PasswordHashParameters `json:"password_hash_parameters"`
+
+	// SignUpPolicy is optional, new tenants have sign up disabled and updates keep the current policy if it is omitted.
+	SignUpPolicy *SignUpPolicy `json:"sign_up_policy"`
 }
 
 // UpdateTenant represents the data required to update an existing tenant.
@@ -81,7 +112,54 @@ type UpdateTenant struct {
 	DisplayName          string         `json:"display_name" validate:"required,max=100" maxLength:"100"`
 	PasswordType         string         `json:"password_type" validate:"required,max=100" maxLength:"100"`
 	SigningCertificateID string         `json:"signing_certificate_id" validate:"required,max=25" maxLength:"25"`
-	ProfileFields        []ProfileField `json:"profile_fields" validate:"required"`
+	ProfileFields        []ProfileField `json:"profile_fields" validate:"required,dive"`
+
+	TrustedDeviceDays int `json:"trusted_device_days" validate:"min=0,max=365" example:"30"`
+
+	// LockoutPolicy is optional, new tenants use the DefaultLockoutPolicy and updates keep the current policy if it is omitted.
+	LockoutPolicy *LockoutPolicy `json:"lockout_policy"`
+
+	// PasswordHashParameters is optional, zero values use the defaults of the password hasher.
+	PasswordHashParameters *PasswordHashParameters `json:"password_hash_parameters"`
+
+	// SignUpPolicy is optional, new tenants have sign up disabled and updates keep the current policy if it is omitted.
+	SignUpPolicy *SignUpPolicy `json:"sign_up_policy"`
+
+	// Branding is optional, updates keep the current branding if it is omitted.
+	Branding *Branding `json:"branding"`
+
+	// StorageProviderID is optional, updates keep the current storage provider if it is omitted and an empty value resets it.
+	StorageProviderID *string `json:"storage_provider_id" validate:"omitempty,max=25" example:"BsOOg4igppKxYwhAQQrD3GCRZ"`
+
+	// StorageQuota is optional, new tenants have no quota and updates keep the current quota if it is omitted.
+	StorageQuota *StorageQuota `json:"storage_quota"`
+}
+
+// StorageQuota limits the resources of a tenant, zero values mean no limit.
+// Uploads with the same content as an existing resource only count as a file, their content is stored once.
+type StorageQuota struct {
+	// MaxBytes is the size of the stored content in bytes.
+	MaxBytes int64 `json:"max_bytes" validate:"min=0" example:"1073741824"`
+	// MaxFiles is the number of resources.
+	MaxFiles int64 `json:"max_files" validate:"min=0" example:"10000"`
+}
+
+// PasswordHashParameters configures the costs of the password hashers of a tenant. Zero values use the defaults of the hasher.
+// Existing passwords are rehashed with the current parameters on their next successful sign in.
+type PasswordHashParameters struct {
+	BcryptCost int `json:"bcrypt_cost" validate:"omitempty,min=10,max=31" example:"12"`
+
+	// Argon2Memory is the memory in KiB used by argon2id.
+	Argon2Memory      uint32 `json:"argon2_memory" validate:"omitempty,min=19456" example:"65536"`
+	Argon2Iterations  uint32 `json:"argon2_iterations" example:"3"`
+	Argon2Parallelism uint8  `json:"argon2_parallelism" example:"4"`
+
+	PBKDF2Iterations int `json:"pbkdf2_iterations" validate:"omitempty,min=100000" example:"600000"`
+
+	// ScryptCost is the base 2 logarithm of the scrypt CPU/memory cost N.
+	ScryptCost            int `json:"scrypt_cost" validate:"omitempty,min=14,max=24" example:"17"`
+	ScryptBlockSize       int `json:"scrypt_block_size" example:"8"`
+	ScryptParallelization int `json:"scrypt_parallelization" example:"1"`
 }
 
 type ProfileField struct {
@@ -89,20 +167,83 @@ type ProfileField struct {
 	DisplayName string     `json:"display_name"`
 	Regex       string     `json:"regex"`
 	Required    bool       `json:"required"`
-	ModifyBy    ModifyType `json:"modify_by"`
-	ViewBy      AllowView  `json:"view_by"`
+	ModifyBy    ModifyType `json:"modify_by" validate:"omitempty,oneof=immutable self" enums:"immutable,self"`
+	ViewBy      AllowView  `json:"view_by" validate:"omitempty,oneof=public self application group admin" enums:"public,self,application,group,admin"`
+
+	// ViewGroups are the groups whose members can see a field with ViewBy group.
+	ViewGroups []string `json:"view_groups,omitempty" validate:"required_if=ViewBy group,dive,len=25" example:"BsOOg4igppKxYwhAQQrD3GCRZ"`
+
+	// Type decides how values are validated and stored, fields without type are strings.
+	Type ProfileFieldType `json:"type,omitempty" validate:"omitempty,oneof=string int bool date enum multi_select phone url resource" enums:"string,int,bool,date,enum,multi_select,phone,url,resource"`
+
+	// MinLength and MaxLength limit the length of string, phone and url values, zero means no limit.
+	MinLength int `json:"min_length,omitempty" validate:"min=0"`
+	MaxLength int `json:"max_length,omitempty" validate:"min=0"`
+	// Min and Max limit int values.
+	Min *int64 `json:"min,omitempty"`
+	Max *int64 `json:"max,omitempty"`
+	// Options are the allowed values of enum and multi_select fields.
+	Options []string `json:"options,omitempty" validate:"dive,required,max=100"`
+
+	// Default is used for users without a value.
+	Default any `json:"default,omitempty"`
+	// Unique fields can't have the same value for two users of the tenant.
+	Unique bool `json:"unique,omitempty"`
+	// Indexed fields can be used to look up users by their value.
+	Indexed bool `json:"indexed,omitempty"`
+
+	// MimeTypes limit the files which can be uploaded for resource fields, e.g. "application/pdf" or "image/*".
+	// Without them, every file can be uploaded except html, xml and svg, which need to be listed explicitly.
+	MimeTypes []string `json:"mime_types,omitempty" validate:"dive,required,max=100" example:"image/*"`
+	// MaxFileSize limits the size of files uploaded for resource fields in bytes, zero uses the default of 10 MiB.
+	MaxFileSize int64 `json:"max_file_size,omitempty" validate:"min=0" example:"1048576"`
+}
+
+// ProfileFieldType is the type of the values of a profile field.
+type ProfileFieldType string
+
+const (
+	ProfileFieldTypeString ProfileFieldType = "string"
+	ProfileFieldTypeInt    ProfileFieldType = "int"
+	ProfileFieldTypeBool   ProfileFieldType = "bool"
+	// ProfileFieldTypeDate values are dates in the format YYYY-MM-DD.
+	ProfileFieldTypeDate ProfileFieldType = "date"
+	// ProfileFieldTypeEnum values are one of the options of the field.
+	ProfileFieldTypeEnum ProfileFieldType = "enum"
+	// ProfileFieldTypeMultiSelect values are lists of options of the field.
+	ProfileFieldTypeMultiSelect ProfileFieldType = "multi_select"
+	// ProfileFieldTypePhone values are phone numbers in the E.164 format.
+	ProfileFieldTypePhone ProfileFieldType = "phone"
+	// ProfileFieldTypeURL values are absolute http or https urls.
+	ProfileFieldTypeURL ProfileFieldType = "url"
+	// ProfileFieldTypeResource values are IDs of resources of the tenant.
+	ProfileFieldTypeResource ProfileFieldType = "resource"
+)
+
+// RenameProfileField represents the data required to rename a profile field, the stored values are kept.
+type RenameProfileField struct {
+	Identifier string `json:"identifier" validate:"required,max=100" maxLength:"100" example:"employee_number"`
 }
 
+// ModifyType decides who can change a profile field. Admins can change every field.
 type ModifyType string
 
 const (
+	// ModifyTypeImmutable fields can only be set by the user when the profile is created.
 	ModifyTypeImmutable ModifyType = "immutable"
 	ModifyTypeSelf      ModifyType = "self"
 )
 
+// AllowView decides who can see a profile field. Admins can see every field, the user every field except admin ones.
 type AllowView string
 
 const (
 	AllowViewPublic AllowView = "public"
 	AllowViewSelf   AllowView = "self"
+	// AllowViewApplication fields are also shared with the applications the user signs in to.
+	AllowViewApplication AllowView = "application"
+	// AllowViewGroup fields are also visible to the members of the ViewGroups of the field.
+	AllowViewGroup AllowView = "group"
+	// AllowViewAdmin fields are only visible to admins, e.g. for internal attributes.
+	AllowViewAdmin AllowView = "admin"
 )
@@ -0,0 +1,62 @@
+/*
+ * Copyright (C) 2025 Anthrove
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package object
+
+import (
+	gonanoid "github.com/matoous/go-nanoid/v2"
+	"gorm.io/gorm"
+	"time"
+)
+
+// TrustedDevice is a browser which a user marked as trusted after a successful MFA step.
+// Sign ins from a trusted device skip the second factor until the device expires.
+type TrustedDevice struct {
+	ID       string `json:"id" gorm:"primaryKey;type:char(25)" example:"BsOOg4igppKxYwhAQQrD3GCRZ"`
+	TenantID string `json:"tenant_id" gorm:"type:char(25)" example:"BsOOg4igppKxYwhAQQrD3GCRZ"`
+	UserID   string `json:"user_id" gorm:"type:char(25);index" example:"BsOOg4igppKxYwhAQQrD3GCRZ"`
+
+	CreatedAt time.Time `json:"created_at" format:"date-time" example:"2025-01-01T00:00:00Z"`
+	UpdatedAt time.Time `json:"updated_at" format:"date-time" example:"2025-01-01T00:00:00Z"`
+
+	// TokenHash is the SHA-256 hash of the device token, the token itself is only known by the browser.
+	TokenHash string `json:"-" swaggerignore:"true" gorm:"type:char(64);uniqueIndex"`
+
+	UserAgent  string    `json:"user_agent" gorm:"type:varchar(255)" example:"Mozilla/5.0 (X11; Linux x86_64)"`
+	IPAddress  string    `json:"ip_address" gorm:"type:varchar(45)" example:"192.0.2.1"`
+	LastUsedAt time.Time `json:"last_used_at" format:"date-time" example:"2025-01-01T00:00:00Z"`
+	ExpiresAt  time.Time `json:"expires_at" format:"date-time" example:"2025-01-31T00:00:00Z"`
+}
+
+func (base *TrustedDevice) BeforeCreate(db *gorm.DB) error {
+	if base.ID == "" {
+		id, err := gonanoid.New(25)
+		if err != nil {
+			return err
+		}
+
+		base.ID = id
+	}
+
+	return nil
+}
+
+type CreateTrustedDevice struct {
+	TokenHash string
+	UserAgent string
+	IPAddress string
+	ExpiresAt time.Time
+}
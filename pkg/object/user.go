@@ -38,6 +38,14 @@ type User struct {
 	EmailVerified          bool   `json:"email_verified"`
 	EmailVerificationToken string `json:"-" gorm:"type:char(6)"`
 
+	// PendingEmail is the new email of the user until it is confirmed with the email verification token.
+	PendingEmail               string     `json:"pending_email,omitempty" gorm:"type:varchar(100)"`
+	EmailVerificationExpiresAt *time.Time `json:"-"`
+	EmailVerificationAttempts  int        `json:"-"`
+
+	// AvatarResourceID references the uploaded avatar of the user, the resource has variants in smaller sizes.
+	AvatarResourceID string `json:"avatar_resource_id,omitempty" gorm:"type:char(25)"`
+
 	Groups []Group `json:"groups" gorm:"many2many:user_groups;"`
 }
 
@@ -78,9 +86,23 @@ type UpdateUser struct {
 }
 
 type UpdateEmail struct {
-	Email                  string `json:"email"`
-	EmailVerified          bool   `json:"email_verified"`
-	EmailVerificationToken string `json:"email_verification_token"`
+	Email                      string     `json:"email"`
+	EmailVerified              bool       `json:"email_verified"`
+	EmailVerificationToken     string     `json:"email_verification_token"`
+	EmailVerificationExpiresAt *time.Time `json:"email_verification_expires_at"`
+	PendingEmail               string     `json:"pending_email"`
+}
+
+// ChangeEmail starts the change of the email of a user, the new email is used after it was confirmed with VerifyEmail.
+type ChangeEmail struct {
+	Email string `json:"email" validate:"required,max=100,email"`
+	// CurrentPassword is required unless the user signed in recently.
+	CurrentPassword string `json:"current_password" validate:"max=100"`
+}
+
+// VerifyEmail confirms the email or the pending email of a user with the code of the verification mail.
+type VerifyEmail struct {
+	Token string `json:"token" validate:"required,len=6,numeric"`
 }
 
 // UpdateUserPassword represents the data required to update an existing user's password.
@@ -17,6 +17,8 @@
 package object
 
 import (
+	gonanoid "github.com/matoous/go-nanoid/v2"
+	"gorm.io/gorm"
 	"time"
 )
 
@@ -43,3 +45,50 @@ type CreateProfilePage struct {
 type UpdateProfilePage struct {
 	Fields []ProfilePageField `json:"fields" validate:"required"`
 }
+
+// ProfileFieldValue is a profile field of the tenant together with the value of a user.
+type ProfileFieldValue struct {
+	Field ProfileField `json:"field"`
+	Value any          `json:"value"`
+	// Modifiable tells if the user can change the value in the self-service.
+	Modifiable bool `json:"modifiable"`
+}
+
+// ProfileViewer describes who reads or changes a profile, it decides which fields are visible and modifiable.
+// The zero value is an anonymous viewer, who only sees public fields.
+type ProfileViewer struct {
+	// Admin viewers see and change all fields.
+	Admin bool
+	// UserID is the signed in user who views the profile.
+	UserID string
+	// GroupIDs are the groups of the signed in user.
+	GroupIDs []string
+	// ApplicationID is set if an application reads the profile, e.g. for the claims of a token.
+	ApplicationID string
+}
+
+// ProfileAttribute is an entry of the lookup index of the profile fields which are unique or indexed.
+// Multi select values have one entry per selected option.
+type ProfileAttribute struct {
+	ID         string `json:"id" gorm:"primaryKey;type:char(25)"`
+	TenantID   string `json:"tenant_id" gorm:"type:char(25);index:idx_profile_attribute_lookup;uniqueIndex:idx_profile_attribute_unique"`
+	Identifier string `json:"identifier" gorm:"type:varchar(100);index:idx_profile_attribute_lookup;uniqueIndex:idx_profile_attribute_unique"`
+	Value      string `json:"value" gorm:"type:varchar(255);index:idx_profile_attribute_lookup"`
+	UserID     string `json:"user_id" gorm:"type:char(25);index"`
+
+	// UniqueValue repeats the value of unique fields, so the database rejects a second user with the same value.
+	// It is empty for indexed fields.
+	UniqueValue *string `json:"-" gorm:"type:varchar(255);uniqueIndex:idx_profile_attribute_unique"`
+}
+
+func (base *ProfileAttribute) BeforeCreate(db *gorm.DB) error {
+	if base.ID == "" {
+		id, err := gonanoid.New(25)
+		if err != nil {
+			return err
+		}
+		base.ID = id
+	}
+
+	return nil
+}
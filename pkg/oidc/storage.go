@@ -30,6 +30,7 @@ import (
 	"github.com/zitadel/oidc/v3/pkg/op"
 	"gorm.io/gorm"
 	"math"
+	"net/url"
 	"slices"
 	"strings"
 	"sync"
@@ -377,6 +378,10 @@ func (s *storage) SetIntrospectionFromToken(ctx context.Context, introspection *
 	return nil
 }
 
+// profileFieldsClaim holds the profile fields which are shared with applications, they are nested so they can't
+// overwrite the standard claims.
+const profileFieldsClaim = "profile_fields"
+
 // setUserinfo sets the info based on the user, scopes and if necessary the clientID
 func (s *storage) setUserinfo(ctx context.Context, userInfo *oidc.UserInfo, userID, clientID string, scopes []string) (err error) {
 	user, err := s.service.FindUser(ctx, s.tenant.ID, userID)
@@ -393,6 +398,22 @@ func (s *storage) setUserinfo(ctx context.Context, userInfo *oidc.UserInfo, user
 		case oidc.ScopeProfile:
 			userInfo.PreferredUsername = user.Username
 			userInfo.Name = user.DisplayName
+
+			picture, err := s.pictureClaim(ctx, user)
+			if err != nil {
+				return err
+			}
+
+			userInfo.Picture = picture
+
+			profileClaims, err := s.service.ProfileClaims(ctx, s.tenant.ID, user.ID, clientID)
+			if err != nil {
+				return err
+			}
+
+			if len(profileClaims) > 0 {
+				userInfo.AppendClaims(profileFieldsClaim, profileClaims)
+			}
 		case oidc.ScopePhone:
 			//TODO setup phone scope
 		}
@@ -400,6 +421,27 @@ func (s *storage) setUserinfo(ctx context.Context, userInfo *oidc.UserInfo, user
 	return nil
 }
 
+// pictureClaim returns the absolute URL of the avatar of a user. Avatars of the local storage are served by this server,
+// so their URL is resolved against the issuer.
+func (s *storage) pictureClaim(ctx context.Context, user object.User) (string, error) {
+	picture, err := s.service.FindAvatarURL(ctx, s.tenant.ID, user)
+	if err != nil || len(picture) == 0 {
+		return "", err
+	}
+
+	issuer, err := url.Parse(op.IssuerFromContext(ctx))
+	if err != nil || len(issuer.Host) == 0 {
+		return picture, nil
+	}
+
+	pictureURL, err := issuer.Parse(picture)
+	if err != nil {
+		return "", err
+	}
+
+	return pictureURL.String(), nil
+}
+
 // GetPrivateClaimsFromScopes implements the op.Storage interface
 // it will be called for the creation of a JWT access token to assert claims for custom scopes
 func (s *storage) GetPrivateClaimsFromScopes(ctx context.Context, userID, clientID string, scopes []string) (map[string]any, error) {
@@ -419,6 +461,24 @@ func (s *storage) GetPrivateClaimsFromScopes(ctx context.Context, userID, client
 		case oidc.ScopeProfile:
 			claims["preferred_username"] = user.Username
 			claims["name"] = user.DisplayName
+
+			picture, err := s.pictureClaim(ctx, user)
+			if err != nil {
+				return nil, err
+			}
+
+			if len(picture) > 0 {
+				claims["picture"] = picture
+			}
+
+			profileClaims, err := s.service.ProfileClaims(ctx, s.tenant.ID, user.ID, clientID)
+			if err != nil {
+				return nil, err
+			}
+
+			if len(profileClaims) > 0 {
+				claims[profileFieldsClaim] = profileClaims
+			}
 		case oidc.ScopePhone:
 			//TODO setup phone scope
 		}
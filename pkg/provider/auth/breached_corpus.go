@@ -0,0 +1,181 @@
+/*
+ * Copyright (C) 2025 Anthrove
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package auth
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/anthrove/identity/internal/config"
+	"github.com/caarlos0/env/v11"
+	"io"
+	"os"
+	"strconv"
+)
+
+// breachedPrefixLength is the number of hex characters of the SHA-1 hash used to index the corpus, the same as the Pwned Passwords range API.
+const breachedPrefixLength = 5
+
+type breachedCorpus struct {
+	file *os.File
+	// offsets contains the offset of the first line of every prefix, the lines of a prefix end at the offset of the next prefix
+	offsets []int64
+}
+
+// breachedPasswords is the corpus of the server, it is nil until LoadBreachedPasswords loaded a configured corpus.
+var breachedPasswords *breachedCorpus
+
+// LoadBreachedPasswords opens the breached password corpus configured in the environment variable BREACHED_PASSWORD_FILE.
+// Tenants can only turn the screening on, the corpus itself is part of the server configuration.
+func LoadBreachedPasswords() error {
+	breachedConfig, err := env.ParseAs[config.BreachedPasswords]()
+	if err != nil {
+		return err
+	}
+
+	if len(breachedConfig.File) == 0 {
+		breachedPasswords = nil
+		return nil
+	}
+
+	corpus, err := loadBreachedCorpus(breachedConfig.File)
+	if err != nil {
+		return err
+	}
+
+	breachedPasswords = corpus
+	return nil
+}
+
+// loadBreachedCorpus opens a breached password corpus and indexes it.
+//
+// The corpus is a text file with one upper or lower case SHA-1 hex hash per line, optionally followed by ":<count>",
+// sorted by hash. This is the format of the Pwned Passwords "ordered by hash" download. Only an index of the offsets
+// of every hash prefix is kept in memory, lookups read the lines of a single prefix from disk.
+func loadBreachedCorpus(path string) (*breachedCorpus, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	stat, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	offsets, err := indexBreachedCorpus(file, stat.Size())
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return &breachedCorpus{
+		file:    file,
+		offsets: offsets,
+	}, nil
+}
+
+func indexBreachedCorpus(reader io.Reader, size int64) ([]int64, error) {
+	prefixCount := 1 << (4 * breachedPrefixLength)
+
+	offsets := make([]int64, prefixCount+1)
+	for i := range offsets {
+		offsets[i] = -1
+	}
+	offsets[prefixCount] = size
+
+	bufferedReader := bufio.NewReader(reader)
+	lastPrefix := -1
+	var offset int64
+
+	for {
+		line, err := bufferedReader.ReadBytes('\n')
+		if len(line) > 0 {
+			hash := bytes.TrimSpace(line)
+
+			if len(hash) >= breachedPrefixLength {
+				prefix, parseErr := strconv.ParseUint(string(hash[:breachedPrefixLength]), 16, 32)
+				if parseErr != nil {
+					return nil, fmt.Errorf("invalid hash at offset %d", offset)
+				}
+
+				if int(prefix) < lastPrefix {
+					return nil, errors.New("breached password file is not sorted by hash")
+				}
+
+				if int(prefix) != lastPrefix {
+					offsets[prefix] = offset
+					lastPrefix = int(prefix)
+				}
+			}
+
+			offset += int64(len(line))
+		}
+
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Prefixes without hashes start and end where the next prefix starts
+	for i := prefixCount - 1; i >= 0; i-- {
+		if offsets[i] < 0 {
+			offsets[i] = offsets[i+1]
+		}
+	}
+
+	return offsets, nil
+}
+
+// Contains reports if the SHA-1 hash of the password is part of the corpus.
+func (c *breachedCorpus) Contains(password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := hex.EncodeToString(sum[:])
+
+	prefix, err := strconv.ParseUint(hash[:breachedPrefixLength], 16, 32)
+	if err != nil {
+		return false, err
+	}
+
+	start, end := c.offsets[prefix], c.offsets[prefix+1]
+	if start == end {
+		return false, nil
+	}
+
+	section := make([]byte, end-start)
+	_, err = c.file.ReadAt(section, start)
+	if err != nil && err != io.EOF {
+		return false, err
+	}
+
+	for _, line := range bytes.Split(section, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) >= len(hash) && bytes.EqualFold(line[:len(hash)], []byte(hash)) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
@@ -30,6 +30,8 @@ type ProviderContext struct {
 	Credential object.Credentials
 
 	SendMail func(ctx context.Context, data object.SendMailData)
+	// UpdateCredential persists new metadata of the credential, e.g. after a password was rehashed.
+	UpdateCredential func(ctx context.Context, metadata map[string]any) error
 }
 
 type Provider interface {
@@ -25,7 +25,9 @@ import (
 	"github.com/anthrove/identity/pkg/object"
 	"github.com/anthrove/identity/pkg/util"
 	"github.com/go-playground/validator/v10"
+	"log"
 	"strconv"
+	"time"
 	"unicode"
 )
 
@@ -36,6 +38,40 @@ type passwordConfiguration struct {
 	MinUppercaseLetter int `json:"min_uppercase_letter"`
 	MinDigitLetter     int `json:"min_digit_letter"`
 	MinSpecialLetter   int `json:"min_special_letter"`
+
+	// RejectBreached rejects passwords which are part of the breached password corpus of the server, see LoadBreachedPasswords.
+	RejectBreached bool `json:"reject_breached"`
+	// MinStrengthScore is the minimum score between 0 (too guessable) and 4 (very unguessable) of estimatePasswordStrength.
+	MinStrengthScore int `json:"min_strength_score" validate:"min=0,max=4"`
+	// DisallowUserFields rejects passwords containing the username, email or display name of the user.
+	DisallowUserFields bool `json:"disallow_user_fields"`
+	// RequireChangeOnWeak flags the credential of users who sign in with a password that violates the rules, so they have to change it.
+	RequireChangeOnWeak bool `json:"require_change_on_weak"`
+
+	// MaxAgeDays is the number of days after which a password expires and has to be changed on the next sign in.
+	MaxAgeDays int `json:"max_age_days" validate:"min=0"`
+	// MinAgeHours is the number of hours before a password can be changed again, unless a change is required.
+	MinAgeHours int `json:"min_age_hours" validate:"min=0"`
+	// HistoryDepth is the number of last passwords, including the current one, which can't be used again.
+	HistoryDepth int `json:"history_depth" validate:"min=0,max=24"`
+}
+
+// passwordMetadata is the metadata of a password credential.
+type passwordMetadata struct {
+	Hash string `json:"hash"`
+	Salt string `json:"salt"`
+	Type string `json:"type"`
+
+	ChangedAt      *time.Time             `json:"changed_at"`
+	ChangeRequired bool                   `json:"change_required"`
+	History        []passwordHistoryEntry `json:"history"`
+}
+
+// passwordHistoryEntry is a previous password of a credential, it is kept as hash to forbid its reuse.
+type passwordHistoryEntry struct {
+	Hash string `json:"hash"`
+	Salt string `json:"salt"`
+	Type string `json:"type"`
 }
 
 type passwordAuth struct {
@@ -74,6 +110,34 @@ func (p passwordAuth) GetConfigurationFields() []object.ProviderConfigurationFie
 			FieldKey:  "min_special_letter",
 			FieldType: "int",
 		},
+		{
+			FieldKey:  "reject_breached",
+			FieldType: "bool",
+		},
+		{
+			FieldKey:  "min_strength_score",
+			FieldType: "int",
+		},
+		{
+			FieldKey:  "disallow_user_fields",
+			FieldType: "bool",
+		},
+		{
+			FieldKey:  "require_change_on_weak",
+			FieldType: "bool",
+		},
+		{
+			FieldKey:  "max_age_days",
+			FieldType: "int",
+		},
+		{
+			FieldKey:  "min_age_hours",
+			FieldType: "int",
+		},
+		{
+			FieldKey:  "history_depth",
+			FieldType: "int",
+		},
 	}
 }
 
@@ -95,6 +159,10 @@ func (p passwordAuth) ValidateConfigurationFields() error {
 		}
 	}
 
+	if passwordConfig.RejectBreached && breachedPasswords == nil {
+		return errors.New("no breached password corpus is configured on the server")
+	}
+
 	return nil
 }
 
@@ -104,7 +172,7 @@ func (p passwordAuth) Configure(_ context.Context, providerContext ProviderConte
 		return nil, err
 	}
 
-	passwordHasher, err := crypto.GetPasswordHasher(providerContext.Tenant.PasswordType)
+	passwordHasher, err := crypto.GetPasswordHasherWithParameters(providerContext.Tenant.PasswordType, providerContext.Tenant.PasswordHashParameters)
 	if err != nil {
 		return nil, err
 	}
@@ -128,19 +196,113 @@ func (p passwordAuth) Configure(_ context.Context, providerContext ProviderConte
 		return nil, err
 	}
 
-	if len(passwordStr) < passwordConfig.MinPasswordLength {
-		return nil, errors.New("password is too short")
+	err = validatePassword(passwordConfig, providerContext.User, passwordStr)
+	if err != nil {
+		return nil, err
+	}
+
+	// The credential is empty for new users, otherwise the password is changed
+	var current passwordMetadata
+	if len(providerContext.Credential.Metadata) > 0 {
+		err = json.Unmarshal(providerContext.Credential.Metadata, &current)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = checkPasswordAge(passwordConfig, current)
+	if err != nil {
+		return nil, err
+	}
+
+	history, err := checkPasswordHistory(passwordConfig, current, passwordStr)
+	if err != nil {
+		return nil, err
+	}
+
+	passwordHash, err := passwordHasher.HashPassword(passwordStr, passwordSalt)
+	if err != nil {
+		return nil, err
+	}
+
+	metadata := map[string]any{
+		"hash":       passwordHash,
+		"salt":       passwordSalt,
+		"type":       providerContext.Tenant.PasswordType,
+		"changed_at": time.Now().UTC(),
+	}
+
+	if len(history) > 0 {
+		metadata["history"] = history
+	}
+
+	return metadata, nil
+}
+
+// checkPasswordAge rejects a change of a password which was changed less than the minimum age ago.
+// A required change, e.g. because the password expired, is always allowed.
+func checkPasswordAge(passwordConfig passwordConfiguration, current passwordMetadata) error {
+	if passwordConfig.MinAgeHours <= 0 || current.ChangedAt == nil || current.ChangeRequired {
+		return nil
+	}
+
+	changeableAt := current.ChangedAt.Add(time.Duration(passwordConfig.MinAgeHours) * time.Hour)
+	if time.Now().Before(changeableAt) {
+		return fmt.Errorf("password can't be changed before %s", changeableAt.Format(time.RFC3339))
+	}
+
+	return nil
+}
+
+// checkPasswordHistory rejects a password which is the current or one of the previous passwords.
+// It returns the history to store with the new password, the current password becomes its newest entry.
+func checkPasswordHistory(passwordConfig passwordConfiguration, current passwordMetadata, password string) ([]passwordHistoryEntry, error) {
+	if passwordConfig.HistoryDepth <= 0 || len(current.Hash) == 0 {
+		return nil, nil
+	}
+
+	entries := append([]passwordHistoryEntry{{
+		Hash: current.Hash,
+		Salt: current.Salt,
+		Type: current.Type,
+	}}, current.History...)
+
+	if len(entries) > passwordConfig.HistoryDepth {
+		entries = entries[:passwordConfig.HistoryDepth]
+	}
+
+	for _, entry := range entries {
+		verifier, err := crypto.GetPasswordVerifier(entry.Type)
+		if err != nil {
+			// an entry which can't be verified anymore can't be reused either
+			continue
+		}
+
+		used, err := verifier.ComparePassword(password, entry.Hash, entry.Salt)
+		if err == nil && used {
+			return nil, fmt.Errorf("password was used within the last %d passwords", passwordConfig.HistoryDepth)
+		}
 	}
 
-	if len(passwordStr) >= passwordConfig.MaxPasswordLength {
-		return nil, errors.New("password is too long")
+	// the new password takes one place of the history depth
+	return entries[:min(len(entries), passwordConfig.HistoryDepth-1)], nil
+}
+
+// validatePassword checks a new password against the rules of the provider configuration.
+func validatePassword(passwordConfig passwordConfiguration, user object.User, password string) error {
+	if len(password) < passwordConfig.MinPasswordLength {
+		return errors.New("password is too short")
+	}
+
+	if len(password) >= passwordConfig.MaxPasswordLength {
+		return errors.New("password is too long")
 	}
 
 	lowercase := 0
 	uppercase := 0
 	digit := 0
 	special := 0
-	for _, char := range passwordStr {
+	for _, char := range password {
 		if unicode.IsUpper(char) {
 			uppercase += 1
 		} else if unicode.IsLower(char) {
@@ -153,33 +315,48 @@ func (p passwordAuth) Configure(_ context.Context, providerContext ProviderConte
 	}
 
 	if lowercase < passwordConfig.MinLowercaseLength {
-		return nil, errors.New("password requires multiple (" + strconv.Itoa(passwordConfig.MinLowercaseLength) + ") lowercase letters")
+		return errors.New("password requires multiple (" + strconv.Itoa(passwordConfig.MinLowercaseLength) + ") lowercase letters")
 	}
 
 	if uppercase < passwordConfig.MinUppercaseLetter {
-		return nil, errors.New("password requires multiple (" + strconv.Itoa(passwordConfig.MinUppercaseLetter) + ") uppercase letters")
+		return errors.New("password requires multiple (" + strconv.Itoa(passwordConfig.MinUppercaseLetter) + ") uppercase letters")
 	}
 
 	if digit < passwordConfig.MinDigitLetter {
-		return nil, errors.New("password requires multiple (" + strconv.Itoa(passwordConfig.MinDigitLetter) + ") digit letters")
+		return errors.New("password requires multiple (" + strconv.Itoa(passwordConfig.MinDigitLetter) + ") digit letters")
 	}
 
 	if special < passwordConfig.MinSpecialLetter {
-		return nil, errors.New("password requires multiple (" + strconv.Itoa(passwordConfig.MinSpecialLetter) + ") special letters")
+		return errors.New("password requires multiple (" + strconv.Itoa(passwordConfig.MinSpecialLetter) + ") special letters")
 	}
 
-	passwordHash, err := passwordHasher.HashPassword(passwordStr, passwordSalt)
-	if err != nil {
-		return nil, err
+	userInputs := passwordUserInputs(user)
+
+	if passwordConfig.DisallowUserFields && containsUserInput(password, userInputs) {
+		return errors.New("password must not contain the username, email or display name")
 	}
 
-	metadata := map[string]any{
-		"hash": passwordHash,
-		"salt": passwordSalt,
-		"type": providerContext.Tenant.PasswordType,
+	if passwordConfig.MinStrengthScore > 0 && estimatePasswordStrength(password, userInputs) < passwordConfig.MinStrengthScore {
+		return errors.New("password is too easy to guess")
 	}
 
-	return metadata, nil
+	// the corpus may have been removed from the server configuration after the provider was saved
+	if passwordConfig.RejectBreached && breachedPasswords == nil {
+		log.Printf("breached password screening is enabled, but no breached password corpus is configured")
+	}
+
+	if passwordConfig.RejectBreached && breachedPasswords != nil {
+		breached, err := breachedPasswords.Contains(password)
+		if err != nil {
+			return errors.Join(fmt.Errorf("problem while checking breached passwords"), err)
+		}
+
+		if breached {
+			return errors.New("password was found in a data breach")
+		}
+	}
+
+	return nil
 }
 
 func (p passwordAuth) Validate(ctx context.Context, providerContext ProviderContext, data map[string]any) (bool, map[string]any, error) {
@@ -203,7 +380,7 @@ func (p passwordAuth) Begin(_ context.Context, _ ProviderContext) (map[string]an
 	return make(map[string]any), nil
 }
 
-func (p passwordAuth) Submit(_ context.Context, providerContext ProviderContext, data map[string]any) (bool, error) {
+func (p passwordAuth) Submit(ctx context.Context, providerContext ProviderContext, data map[string]any) (bool, error) {
 	var dataMap map[string]any
 	err := json.Unmarshal(providerContext.Credential.Metadata, &dataMap)
 
@@ -215,7 +392,7 @@ func (p passwordAuth) Submit(_ context.Context, providerContext ProviderContext,
 	salt := dataMap["salt"].(string)
 	hashType := dataMap["type"].(string)
 
-	hasher, err := crypto.GetPasswordHasher(hashType)
+	verifier, err := crypto.GetPasswordVerifier(hashType)
 
 	if err != nil {
 		return false, err
@@ -233,10 +410,106 @@ func (p passwordAuth) Submit(_ context.Context, providerContext ProviderContext,
 		return false, errors.New("password is not a string")
 	}
 
-	success, err := hasher.ComparePassword(passwordStr, hash, salt)
+	success, err := verifier.ComparePassword(passwordStr, hash, salt)
 	if err != nil {
 		return false, err
 	}
 
+	if success && providerContext.UpdateCredential != nil {
+		// The password was correct, a failed rehash just keeps the old hash until the next sign in
+		rehashed, err := p.rehash(providerContext, passwordStr, dataMap)
+		if err != nil {
+			log.Printf("problem while rehashing password: %v", err)
+		}
+
+		flagged := p.flagPasswordChange(providerContext, passwordStr, dataMap)
+
+		if rehashed || flagged {
+			err = providerContext.UpdateCredential(ctx, dataMap)
+			if err != nil {
+				log.Printf("problem while updating password credential: %v", err)
+			}
+		}
+	}
+
 	return success, nil
 }
+
+// rehash hashes the password again if the tenant changed its password type or hash parameters since it was hashed.
+// The plaintext password is only available during a sign in, so this is the only time the hash can be upgraded.
+// It updates the metadata and reports if it was changed.
+func (p passwordAuth) rehash(providerContext ProviderContext, password string, metadata map[string]any) (bool, error) {
+	tenant := providerContext.Tenant
+
+	hasher, err := crypto.GetPasswordHasherWithParameters(tenant.PasswordType, tenant.PasswordHashParameters)
+	if err != nil {
+		return false, err
+	}
+
+	if metadata["type"] == tenant.PasswordType && !hasher.NeedsRehash(metadata["hash"].(string)) {
+		return false, nil
+	}
+
+	salt, err := util.RandomSaltString(25)
+	if err != nil {
+		return false, err
+	}
+
+	newHash, err := hasher.HashPassword(password, salt)
+	if err != nil {
+		return false, err
+	}
+
+	metadata["hash"] = newHash
+	metadata["salt"] = salt
+	metadata["type"] = tenant.PasswordType
+
+	return true, nil
+}
+
+// flagPasswordChange marks the credential as requiring a change if the password expired or violates the current rules of the provider.
+// Passwords are only checked when they are set, so this catches passwords which were set before the rules got stricter.
+// It updates the metadata and reports if it was changed.
+func (p passwordAuth) flagPasswordChange(providerContext ProviderContext, password string, metadata map[string]any) bool {
+	passwordConfig := passwordConfiguration{}
+
+	err := json.Unmarshal(p.provider.Parameter, &passwordConfig)
+	if err != nil {
+		return false
+	}
+
+	if changeRequired, _ := metadata["change_required"].(bool); changeRequired {
+		return false
+	}
+
+	if passwordConfig.MaxAgeDays > 0 {
+		changedAtStr, _ := metadata["changed_at"].(string)
+		changedAt, err := time.Parse(time.RFC3339, changedAtStr)
+
+		if err != nil {
+			// passwords set before the expiry was configured start to age now
+			metadata["changed_at"] = time.Now().UTC()
+			return true
+		}
+
+		if time.Since(changedAt) > time.Duration(passwordConfig.MaxAgeDays)*24*time.Hour {
+			metadata["change_required"] = true
+			metadata["change_reason"] = "password expired"
+			return true
+		}
+	}
+
+	if !passwordConfig.RequireChangeOnWeak {
+		return false
+	}
+
+	err = validatePassword(passwordConfig, providerContext.User, password)
+	if err == nil {
+		return false
+	}
+
+	metadata["change_required"] = true
+	metadata["change_reason"] = err.Error()
+
+	return true
+}
@@ -0,0 +1,285 @@
+/*
+ * Copyright (C) 2025 Anthrove
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package auth
+
+import (
+	"github.com/anthrove/identity/pkg/object"
+	"math"
+	"strings"
+	"unicode"
+)
+
+// commonPasswords are the most used passwords and password words ordered by how common they are.
+// The position is the rank used by estimatePasswordStrength, so more common words are cheaper to guess.
+var commonPasswords = []string{
+	"password", "123456", "qwerty", "letmein", "welcome", "admin", "iloveyou", "monkey", "dragon", "football",
+	"baseball", "master", "sunshine", "shadow", "princess", "superman", "batman", "trustno1", "abc123", "login",
+	"passw0rd", "starwars", "whatever", "freedom", "hello", "charlie", "donald", "secret", "access", "flower",
+	"mustang", "michael", "jordan", "jennifer", "hunter", "ranger", "buster", "soccer", "hockey", "killer",
+	"george", "summer", "winter", "spring", "autumn", "love", "pepper", "ginger", "cookie", "cheese",
+	"computer", "internet", "service", "server", "root", "user", "guest", "test", "default", "changeme",
+	"matrix", "maggie", "thomas", "andrew", "daniel", "jessica", "michelle", "ashley", "nicole", "robert",
+	"samsung", "apple", "google", "yahoo", "microsoft", "windows", "linux", "orange", "banana", "chocolate",
+	"purple", "silver", "golden", "diamond", "angel", "heaven", "happy", "lucky", "money", "family",
+	"friend", "tigger", "pokemon", "naruto", "pussy", "bailey", "harley", "yankees", "liverpool", "chelsea",
+	"arsenal", "london", "berlin", "paris", "america", "qazwsx", "zaq12wsx", "asdfgh", "zxcvbn", "monday",
+	"january", "august", "october", "december", "mother", "father", "sister", "brother", "baby", "darling",
+	"forever", "ninja", "wizard", "knight", "phoenix", "tiger", "eagle", "falcon", "panther", "thunder",
+	"company", "office", "identity", "account", "security", "system", "network", "database", "backup", "private",
+}
+
+var commonPasswordRanks = func() map[string]int {
+	ranks := make(map[string]int, len(commonPasswords))
+	for i, word := range commonPasswords {
+		ranks[word] = i + 1
+	}
+	return ranks
+}()
+
+// bruteforceBits is the entropy of a character which isn't part of a pattern. Like zxcvbn it is counted as
+// 10 guesses, higher values overrate short random looking passwords.
+var bruteforceBits = math.Log2(10)
+
+var keyboardRows = []string{"1234567890", "qwertyuiop", "asdfghjkl", "zxcvbnm", "qwertzuiop", "yxcvbnm", "azertyuiop"}
+
+var leetSubstitutions = map[rune][]rune{
+	'4': {'a'},
+	'@': {'a'},
+	'3': {'e'},
+	'1': {'i', 'l'},
+	'!': {'i'},
+	'0': {'o'},
+	'$': {'s'},
+	'5': {'s'},
+	'7': {'t'},
+	'+': {'t'},
+}
+
+// estimatePasswordStrength estimates how many guesses are needed for the password and maps them to a score from 0 to 4,
+// using the same thresholds as zxcvbn. The password is split into the cheapest sequence of dictionary words (including
+// the user inputs), keyboard patterns, sequences, repeats and years, everything else is counted as brute force.
+func estimatePasswordStrength(password string, userInputs []string) int {
+	bits := estimatePasswordEntropy([]rune(password), userInputs)
+
+	switch {
+	case bits < math.Log2(1e3):
+		return 0
+	case bits < math.Log2(1e6):
+		return 1
+	case bits < math.Log2(1e8):
+		return 2
+	case bits < math.Log2(1e10):
+		return 3
+	default:
+		return 4
+	}
+}
+
+// estimatePasswordEntropy returns log2 of the estimated guesses by finding the cheapest segmentation of the password.
+func estimatePasswordEntropy(password []rune, userInputs []string) float64 {
+	best := make([]float64, len(password)+1)
+
+	for end := 1; end <= len(password); end++ {
+		best[end] = best[end-1] + bruteforceBits
+
+		for start := 0; start <= end-3; start++ {
+			bits, found := patternBits(password[start:end], userInputs)
+			if found && best[start]+bits < best[end] {
+				best[end] = best[start] + bits
+			}
+		}
+	}
+
+	return best[len(password)]
+}
+
+// patternBits returns the entropy of a segment if it matches a guessable pattern.
+func patternBits(segment []rune, userInputs []string) (float64, bool) {
+	bits := math.Inf(1)
+	lower := strings.ToLower(string(segment))
+
+	for _, candidate := range unleet(segment) {
+		word := strings.ToLower(string(candidate))
+
+		extra := caseBits(segment)
+		if word != lower {
+			extra++
+		}
+
+		for i, variant := range []string{word, reverse(word)} {
+			variantBits := extra
+			if i == 1 {
+				variantBits++
+			}
+
+			if rank, exists := commonPasswordRanks[variant]; exists {
+				bits = math.Min(bits, math.Log2(float64(rank))+variantBits)
+			}
+
+			for _, input := range userInputs {
+				if variant == input {
+					bits = math.Min(bits, 1+variantBits)
+				}
+			}
+		}
+	}
+
+	if isRepeat(segment) {
+		bits = math.Min(bits, bruteforceBits+math.Log2(float64(len(segment))))
+	}
+
+	if isSequence(segment) {
+		bits = math.Min(bits, math.Log2(26)+math.Log2(float64(len(segment)))+caseBits(segment))
+	}
+
+	for _, row := range keyboardRows {
+		if strings.Contains(row, lower) || strings.Contains(reverse(row), lower) {
+			bits = math.Min(bits, math.Log2(float64(len(segment)*len(keyboardRows)))+caseBits(segment))
+		}
+	}
+
+	if len(segment) == 4 && (strings.HasPrefix(lower, "19") || strings.HasPrefix(lower, "20")) && strings.Trim(lower, "0123456789") == "" {
+		bits = math.Min(bits, math.Log2(150))
+	}
+
+	return bits, !math.IsInf(bits, 1)
+}
+
+// caseBits returns the extra entropy of upper case letters in a lower case word, a capitalized word only adds one bit.
+func caseBits(segment []rune) float64 {
+	upper := 0
+	for _, char := range segment {
+		if unicode.IsUpper(char) {
+			upper++
+		}
+	}
+
+	switch {
+	case upper == 0:
+		return 0
+	case upper == 1 && unicode.IsUpper(segment[0]):
+		return 1
+	case upper == len(segment):
+		return 1
+	default:
+		return float64(upper) + 1
+	}
+}
+
+// unleet returns the segment with common leet substitutions reverted, one candidate for every ambiguous substitution.
+func unleet(segment []rune) [][]rune {
+	candidates := [][]rune{make([]rune, 0, len(segment))}
+
+	for _, char := range segment {
+		replacements, exists := leetSubstitutions[char]
+		if !exists {
+			replacements = []rune{char}
+		}
+
+		next := make([][]rune, 0, len(candidates)*len(replacements))
+		for _, candidate := range candidates {
+			for _, replacement := range replacements {
+				next = append(next, append(append(make([]rune, 0, len(segment)), candidate...), replacement))
+			}
+		}
+
+		// Every ambiguous substitution doubles the candidates, so they are capped to keep long passwords cheap
+		if len(next) > 8 {
+			next = next[:8]
+		}
+		candidates = next
+	}
+
+	return candidates
+}
+
+func isRepeat(segment []rune) bool {
+	for _, char := range segment {
+		if char != segment[0] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isSequence(segment []rune) bool {
+	delta := segment[1] - segment[0]
+	if delta != 1 && delta != -1 {
+		return false
+	}
+
+	for i := 2; i < len(segment); i++ {
+		if segment[i]-segment[i-1] != delta {
+			return false
+		}
+	}
+
+	return true
+}
+
+func reverse(value string) string {
+	runes := []rune(value)
+	for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+		runes[i], runes[j] = runes[j], runes[i]
+	}
+
+	return string(runes)
+}
+
+// passwordUserInputs returns the lower case values of the user which shouldn't be part of the password.
+func passwordUserInputs(user object.User) []string {
+	inputs := make([]string, 0)
+
+	add := func(value string) {
+		value = strings.ToLower(strings.TrimSpace(value))
+		if len(value) >= 3 {
+			inputs = append(inputs, value)
+		}
+	}
+
+	add(user.Username)
+	add(user.Email)
+
+	localPart, _, _ := strings.Cut(user.Email, "@")
+	add(localPart)
+
+	add(strings.ReplaceAll(user.DisplayName, " ", ""))
+	for _, name := range strings.Fields(user.DisplayName) {
+		add(name)
+	}
+
+	return inputs
+}
+
+// containsUserInput reports if the password contains one of the user inputs, also with leet substitutions.
+func containsUserInput(password string, userInputs []string) bool {
+	candidates := []string{strings.ToLower(password)}
+	for _, candidate := range unleet([]rune(password)) {
+		candidates = append(candidates, strings.ToLower(string(candidate)))
+	}
+
+	for _, input := range userInputs {
+		for _, candidate := range candidates {
+			if strings.Contains(candidate, input) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
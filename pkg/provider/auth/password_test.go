@@ -0,0 +1,204 @@
+/*
+ * Copyright (C) 2025 Anthrove
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package auth
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/anthrove/identity/pkg/object"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEstimatePasswordStrength(t *testing.T) {
+	tests := []struct {
+		password string
+		maxScore int
+		minScore int
+	}{
+		{"password", 0, 0},
+		{"Password1!", 1, 0},
+		{"P@ssw0rd", 0, 0},
+		{"qwerty123", 1, 0},
+		{"aaaaaaaaaaaa", 0, 0},
+		{"abcdefgh2024", 1, 0},
+		{"drowssap", 0, 0},
+		{"Xk9#mQ2$vL7!pR", 4, 4},
+		{"correct-horse-battery-staple", 4, 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.password, func(t *testing.T) {
+			score := estimatePasswordStrength(tt.password, nil)
+			if score > tt.maxScore || score < tt.minScore {
+				t.Fatalf("estimatePasswordStrength(%q) = %d, want between %d and %d", tt.password, score, tt.minScore, tt.maxScore)
+			}
+		})
+	}
+}
+
+func TestValidatePassword_UserFields(t *testing.T) {
+	user := object.User{Username: "jdoe", Email: "john.doe@example.com", DisplayName: "John Doe"}
+	config := passwordConfiguration{MaxPasswordLength: 100, DisallowUserFields: true}
+
+	for _, password := range []string{"jdoe-is-great", "J0hn.D0e@example.com!", "xx-D0E-1984"} {
+		if err := validatePassword(config, user, password); err == nil {
+			t.Fatalf("validatePassword(%q) expected error", password)
+		}
+	}
+
+	if err := validatePassword(config, user, "purple-tractor-sings"); err != nil {
+		t.Fatalf("validatePassword() error = %v", err)
+	}
+}
+
+func TestBreachedCorpus(t *testing.T) {
+	breached := []string{"Password1!", "hunter2", "letmein"}
+
+	lines := []string{"0000000A2DA1EE5A5F0B08A9A7E9B3E35B0B9C4B:3"}
+	for _, password := range breached {
+		sum := sha1.Sum([]byte(password))
+		lines = append(lines, strings.ToUpper(hex.EncodeToString(sum[:]))+":42")
+	}
+	lines = append(lines, "FFFFF00000000000000000000000000000000000:1")
+	sort.Strings(lines)
+
+	path := filepath.Join(t.TempDir(), "breached.txt")
+	err := os.WriteFile(path, []byte(strings.Join(lines, "\r\n")+"\r\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	corpus, err := loadBreachedCorpus(path)
+	if err != nil {
+		t.Fatalf("loadBreachedCorpus() error = %v", err)
+	}
+
+	for _, password := range breached {
+		if found, err := corpus.Contains(password); err != nil || !found {
+			t.Fatalf("Contains(%q) = %v, %v, want true", password, found, err)
+		}
+	}
+
+	for _, password := range []string{"Password1", "purple-tractor-sings"} {
+		if found, err := corpus.Contains(password); err != nil || found {
+			t.Fatalf("Contains(%q) = %v, %v, want false", password, found, err)
+		}
+	}
+
+	if _, err := indexBreachedCorpus(strings.NewReader("FFFFF0\n000000\n"), 14); err == nil {
+		t.Fatalf("indexBreachedCorpus() expected error for unsorted file")
+	}
+}
+
+func TestRejectBreached(t *testing.T) {
+	sum := sha1.Sum([]byte("hunter2"))
+	path := filepath.Join(t.TempDir(), "breached.txt")
+
+	err := os.WriteFile(path, []byte(strings.ToUpper(hex.EncodeToString(sum[:]))+":42\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	provider := passwordAuth{provider: object.Provider{Parameter: []byte(`{"reject_breached":true}`)}}
+
+	t.Setenv("BREACHED_PASSWORD_FILE", "")
+	if err = LoadBreachedPasswords(); err != nil {
+		t.Fatalf("LoadBreachedPasswords() without a corpus error = %v", err)
+	}
+
+	if err = provider.ValidateConfigurationFields(); err == nil {
+		t.Errorf("ValidateConfigurationFields() without a corpus on the server error = nil, want error")
+	}
+
+	t.Setenv("BREACHED_PASSWORD_FILE", path)
+	if err = LoadBreachedPasswords(); err != nil {
+		t.Fatalf("LoadBreachedPasswords() error = %v", err)
+	}
+	t.Cleanup(func() { breachedPasswords = nil })
+
+	if err = provider.ValidateConfigurationFields(); err != nil {
+		t.Errorf("ValidateConfigurationFields() error = %v", err)
+	}
+
+	if err = validatePassword(passwordConfiguration{RejectBreached: true}, object.User{}, "hunter2"); err == nil {
+		t.Errorf("validatePassword() of a breached password error = nil, want error")
+	}
+}
+
+func TestPasswordAuth_HistoryAndAge(t *testing.T) {
+	provider := passwordAuth{provider: object.Provider{
+		Parameter: []byte(`{"min_password_length":4,"max_password_length":100,"history_depth":3,"min_age_hours":24}`),
+	}}
+	providerContext := ProviderContext{Tenant: object.Tenant{PasswordType: "bcrypt", PasswordHashParameters: object.PasswordHashParameters{BcryptCost: 10}}}
+
+	setPassword := func(password string) error {
+		metadata, err := provider.Configure(context.Background(), providerContext, map[string]any{"password": password})
+		if err != nil {
+			return err
+		}
+
+		// the minimum age is tested at the end, every other change pretends the last one is a day old
+		metadata["changed_at"] = time.Now().Add(-25 * time.Hour)
+
+		providerContext.Credential.Metadata, err = json.Marshal(metadata)
+		return err
+	}
+
+	for _, password := range []string{"first-pass", "second-pass", "third-pass"} {
+		if err := setPassword(password); err != nil {
+			t.Fatalf("Configure(%q) error = %v", password, err)
+		}
+	}
+
+	for _, password := range []string{"first-pass", "second-pass", "third-pass"} {
+		if err := setPassword(password); err == nil {
+			t.Fatalf("Configure(%q) expected history error", password)
+		}
+	}
+
+	if err := setPassword("fourth-pass"); err != nil {
+		t.Fatalf("Configure() error = %v", err)
+	}
+
+	// first-pass dropped out of the last three passwords
+	if err := setPassword("first-pass"); err != nil {
+		t.Fatalf("Configure() of password outside of the history error = %v", err)
+	}
+
+	metadata, err := provider.Configure(context.Background(), providerContext, map[string]any{"password": "fifth-pass"})
+	if err != nil {
+		t.Fatalf("Configure() error = %v", err)
+	}
+
+	providerContext.Credential.Metadata, _ = json.Marshal(metadata)
+	if _, err := provider.Configure(context.Background(), providerContext, map[string]any{"password": "sixth-pass"}); err == nil {
+		t.Fatalf("Configure() expected minimum age error")
+	}
+
+	metadata["change_required"] = true
+	providerContext.Credential.Metadata, _ = json.Marshal(metadata)
+	if _, err := provider.Configure(context.Background(), providerContext, map[string]any{"password": "sixth-pass"}); err != nil {
+		t.Fatalf("Configure() of required change error = %v", err)
+	}
+}
@@ -0,0 +1,62 @@
+/*
+ * Copyright (C) 2025 Anthrove
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package captcha
+
+import (
+	"errors"
+	"github.com/anthrove/identity/pkg/object"
+	"maps"
+	"slices"
+)
+
+type Provider interface {
+	GetConfigurationFields() []object.ProviderConfigurationField
+	ValidateConfigurationFields() error
+	// SiteKey is the public key the frontend needs to render the challenge.
+	SiteKey() string
+	// Verify checks the response token the frontend got from the challenge.
+	Verify(response string, remoteIP string) error
+}
+
+var providerMap = map[string]func(provider object.Provider) (Provider, error){
+	"recaptcha": newSiteVerifyProvider("https://www.google.com/recaptcha/api/siteverify"),
+	"hcaptcha":  newSiteVerifyProvider("https://api.hcaptcha.com/siteverify"),
+	"turnstile": newSiteVerifyProvider("https://challenges.cloudflare.com/turnstile/v0/siteverify"),
+}
+
+func GetCaptchaProvider(provider object.Provider) (Provider, error) {
+	newFunc, exists := providerMap[provider.ProviderType]
+
+	if !exists {
+		return nil, errors.New("unknown captcha provider: " + provider.ProviderType)
+	}
+
+	return newFunc(provider)
+}
+
+func ConfigurationFields(providerType string) []object.ProviderConfigurationField {
+	switch providerType {
+	case "recaptcha", "hcaptcha", "turnstile":
+		return siteVerifyProvider{}.GetConfigurationFields()
+	}
+
+	return nil
+}
+
+func GetCaptchaTypes() []string {
+	return slices.Collect(maps.Keys(providerMap))
+}
@@ -0,0 +1,144 @@
+/*
+ * Copyright (C) 2025 Anthrove
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package captcha
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/anthrove/identity/pkg/object"
+	"github.com/go-playground/validator/v10"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const siteVerifyTimeout = 10 * time.Second
+
+type siteVerifyConfiguration struct {
+	SiteKey   string `json:"site_key" validate:"required,max=200"`
+	SecretKey string `json:"secret_key" validate:"required,max=200"`
+}
+
+// siteVerifyResponse is the answer of the verify endpoint, reCAPTCHA, hCaptcha and Turnstile share the same format.
+type siteVerifyResponse struct {
+	Success    bool     `json:"success"`
+	ErrorCodes []string `json:"error-codes"`
+}
+
+// siteVerifyProvider verifies challenges with the siteverify API, which is implemented by reCAPTCHA, hCaptcha and Turnstile.
+type siteVerifyProvider struct {
+	provider  object.Provider
+	verifyURL string
+}
+
+func newSiteVerifyProvider(verifyURL string) func(provider object.Provider) (Provider, error) {
+	return func(provider object.Provider) (Provider, error) {
+		return siteVerifyProvider{provider: provider, verifyURL: verifyURL}, nil
+	}
+}
+
+func (s siteVerifyProvider) GetConfigurationFields() []object.ProviderConfigurationField {
+	return []object.ProviderConfigurationField{
+		{
+			FieldKey:  "site_key",
+			FieldType: "text",
+		},
+		{
+			FieldKey:  "secret_key",
+			FieldType: "secret",
+		},
+	}
+}
+
+func (s siteVerifyProvider) ValidateConfigurationFields() error {
+	siteVerifyConfig := siteVerifyConfiguration{}
+
+	err := json.Unmarshal(s.provider.Parameter, &siteVerifyConfig)
+	if err != nil {
+		return err
+	}
+
+	validate := validator.New(validator.WithRequiredStructEnabled())
+	err = validate.Struct(siteVerifyConfig)
+	if err != nil {
+		var validateErrs validator.ValidationErrors
+		if errors.As(err, &validateErrs) {
+			return errors.Join(fmt.Errorf("problem while validating captcha configuration"), validateErrs)
+		}
+	}
+
+	return nil
+}
+
+func (s siteVerifyProvider) SiteKey() string {
+	siteVerifyConfig := siteVerifyConfiguration{}
+
+	err := json.Unmarshal(s.provider.Parameter, &siteVerifyConfig)
+	if err != nil {
+		return ""
+	}
+
+	return siteVerifyConfig.SiteKey
+}
+
+func (s siteVerifyProvider) Verify(response string, remoteIP string) error {
+	siteVerifyConfig := siteVerifyConfiguration{}
+
+	err := json.Unmarshal(s.provider.Parameter, &siteVerifyConfig)
+	if err != nil {
+		return err
+	}
+
+	if len(response) == 0 {
+		return errors.New("captcha response is required")
+	}
+
+	form := url.Values{}
+	form.Set("secret", siteVerifyConfig.SecretKey)
+	form.Set("response", response)
+	if len(remoteIP) > 0 {
+		form.Set("remoteip", remoteIP)
+	}
+
+	client := http.Client{
+		Timeout: siteVerifyTimeout,
+	}
+
+	httpResponse, err := client.PostForm(s.verifyURL, form)
+	if err != nil {
+		return err
+	}
+	defer httpResponse.Body.Close()
+
+	if httpResponse.StatusCode != http.StatusOK {
+		return fmt.Errorf("captcha verification responded with status %d", httpResponse.StatusCode)
+	}
+
+	var verifyResponse siteVerifyResponse
+	err = json.NewDecoder(httpResponse.Body).Decode(&verifyResponse)
+	if err != nil {
+		return err
+	}
+
+	if !verifyResponse.Success {
+		return fmt.Errorf("captcha verification failed: %s", strings.Join(verifyResponse.ErrorCodes, ", "))
+	}
+
+	return nil
+}
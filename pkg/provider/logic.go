@@ -19,8 +19,10 @@ package provider
 import (
 	"github.com/anthrove/identity/pkg/object"
 	"github.com/anthrove/identity/pkg/provider/auth"
+	"github.com/anthrove/identity/pkg/provider/captcha"
 	"github.com/anthrove/identity/pkg/provider/email"
 	"github.com/anthrove/identity/pkg/provider/mfa"
+	"github.com/anthrove/identity/pkg/provider/sms"
 	"github.com/anthrove/identity/pkg/provider/storage"
 )
 
@@ -34,6 +36,10 @@ func ConfigurationFields(category string, providerType string) []object.Provider
 		return storage.ConfigurationFields(providerType)
 	case "mfa":
 		return mfa.ConfigurationFields(providerType)
+	case "sms":
+		return sms.ConfigurationFields(providerType)
+	case "captcha":
+		return captcha.ConfigurationFields(providerType)
 	}
 
 	return nil
@@ -49,6 +55,10 @@ func Types(category string) []string {
 		return storage.GetStorageTypes()
 	case "mfa":
 		return mfa.GetMfaTypes()
+	case "sms":
+		return sms.GetSMSTypes()
+	case "captcha":
+		return captcha.GetCaptchaTypes()
 	}
 
 	return nil
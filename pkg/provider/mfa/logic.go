@@ -17,23 +17,58 @@
 package mfa
 
 import (
-	"encoding/json"
+	"context"
 	"errors"
 	"github.com/anthrove/identity/pkg/object"
 	"maps"
 	"slices"
+	"time"
 )
 
+type ProviderContext struct {
+	Tenant  object.Tenant
+	User    object.User
+	MFA     object.MFA
+	Request object.RequestInfo
+
+	// SendMail and SendSMS deliver a one-time code to the user through the providers of the tenant.
+	SendMail func(ctx context.Context, to string, code string, expiresAt time.Time) error
+	SendSMS  func(ctx context.Context, to string, code string, expiresAt time.Time) error
+
+	// PushApprovals stores the approvals of the push provider of the tenant.
+	PushApprovals PushApprovalStore
+}
+
+// PushApprovalStore persists the push approvals of a tenant, so the decision of the companion app can be made on another
+// replica than the one which waits for it.
+type PushApprovalStore interface {
+	// CreatePushApproval stores a new approval, expired approvals may be removed by it.
+	CreatePushApproval(ctx context.Context, approval object.PushApproval) error
+	FindPushApproval(ctx context.Context, approvalID string) (object.PushApproval, error)
+	// UpdatePushApprovalDecision changes the decision of the approval from the given one to the new one. It returns false,
+	// if the approval doesn't exist or has another decision, e.g. because it was decided in the meantime.
+	UpdatePushApprovalDecision(ctx context.Context, approvalID string, from string, to string) (bool, error)
+	KillPushApproval(ctx context.Context, approvalID string) error
+}
+
 type Provider interface {
 	GetConfigurationFields() []object.ProviderConfigurationField
 	ValidateConfigurationFields() error
-	GenerateUserConfig(username string) (object.MFAProviderData, error)
-	InitDataFlow(mfaConfig json.RawMessage) (map[string]any, error)
-	ValidateDatFlow(mfaConfig json.RawMessage, data map[string]any) (bool, error)
+	// GenerateUserConfig is used to set up a new MFA. The data is given by the user, e.g. the phone number for sms.
+	GenerateUserConfig(ctx context.Context, providerContext ProviderContext, data map[string]any) (object.MFAProviderData, error)
+	// InitDataFlow starts a validation, e.g. by sending a code. It returns the provider data which has to be saved and the data for the user.
+	InitDataFlow(ctx context.Context, providerContext ProviderContext) (object.MFAProviderData, map[string]any, error)
+	// ValidateDatFlow validates the data send by the user. It returns the provider data which has to be saved, also if the validation failed.
+	// Counter based providers reject everything up to the LastCounter of the MFA and return the new counter on success.
+	ValidateDatFlow(ctx context.Context, providerContext ProviderContext, data map[string]any) (bool, object.MFAProviderData, error)
 }
 
 var providerMap = map[string]func(provider object.Provider) (Provider, error){
-	"totp": newTOTPProvider,
+	"totp":      newTOTPProvider,
+	"email_otp": newEmailOTPProvider,
+	"sms_otp":   newSMSOTPProvider,
+	"webauthn":  newWebauthnProvider,
+	"push":      newPushProvider,
 }
 
 func GetMFAProvider(provider object.Provider) (Provider, error) {
@@ -50,6 +85,12 @@ func ConfigurationFields(providerType string) []object.ProviderConfigurationFiel
 	switch providerType {
 	case "totp":
 		return totpProvider{}.GetConfigurationFields()
+	case "email_otp", "sms_otp":
+		return otpProvider{}.GetConfigurationFields()
+	case "webauthn":
+		return webauthnProvider{}.GetConfigurationFields()
+	case "push":
+		return pushProvider{}.GetConfigurationFields()
 	}
 
 	return nil
@@ -58,3 +99,11 @@ func ConfigurationFields(providerType string) []object.ProviderConfigurationFiel
 func GetMfaTypes() []string {
 	return slices.Collect(maps.Keys(providerMap))
 }
+
+// providerData returns the currently stored provider data of an MFA.
+func providerData(userMFA object.MFA) object.MFAProviderData {
+	return object.MFAProviderData{
+		Properties:  userMFA.Properties,
+		LastCounter: userMFA.LastCounter,
+	}
+}
@@ -0,0 +1,341 @@
+/*
+ * Copyright (C) 2025 Anthrove
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mfa
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/anthrove/identity/pkg/object"
+	"github.com/anthrove/identity/pkg/util"
+	"github.com/go-playground/validator/v10"
+	"strings"
+	"time"
+)
+
+const (
+	otpChannelEmail = "email"
+	otpChannelSMS   = "sms"
+
+	otpDefaultCodeLength  = 6
+	otpDefaultExpiry      = 300
+	otpDefaultMaxAttempts = 5
+)
+
+type otpConfiguration struct {
+	CodeLength  int `json:"code_length" validate:"omitempty,min=4,max=10"`
+	Expiry      int `json:"expiry" validate:"omitempty,min=30,max=3600"`
+	MaxAttempts int `json:"max_attempts" validate:"omitempty,min=1,max=20"`
+}
+
+type otpProperties struct {
+	Recipient string `json:"recipient"`
+
+	// The code is only stored as hash, it is removed after it was used, expired or had too many attempts.
+	CodeHash  string    `json:"code_hash,omitempty"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Attempts  int       `json:"attempts,omitempty"`
+}
+
+type otpSetupData struct {
+	PhoneNumber string `json:"phone_number" validate:"required,e164"`
+}
+
+type otpBodyData struct {
+	OTP string `json:"otp" validate:"required"`
+}
+
+// otpProvider sends a one-time code to the user, which has to be entered to validate. The code is either send by email or sms.
+type otpProvider struct {
+	provider    object.Provider
+	channel     string
+	codeLength  int
+	expiry      time.Duration
+	maxAttempts int
+}
+
+func newEmailOTPProvider(provider object.Provider) (Provider, error) {
+	return newOTPProvider(provider, otpChannelEmail)
+}
+
+func newSMSOTPProvider(provider object.Provider) (Provider, error) {
+	return newOTPProvider(provider, otpChannelSMS)
+}
+
+func newOTPProvider(provider object.Provider, channel string) (Provider, error) {
+	otpConfig := otpConfiguration{}
+
+	if len(provider.Parameter) > 0 {
+		err := json.Unmarshal(provider.Parameter, &otpConfig)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	otpProvider := otpProvider{
+		provider:    provider,
+		channel:     channel,
+		codeLength:  otpDefaultCodeLength,
+		expiry:      otpDefaultExpiry * time.Second,
+		maxAttempts: otpDefaultMaxAttempts,
+	}
+
+	if otpConfig.CodeLength > 0 {
+		otpProvider.codeLength = otpConfig.CodeLength
+	}
+
+	if otpConfig.Expiry > 0 {
+		otpProvider.expiry = time.Duration(otpConfig.Expiry) * time.Second
+	}
+
+	if otpConfig.MaxAttempts > 0 {
+		otpProvider.maxAttempts = otpConfig.MaxAttempts
+	}
+
+	return otpProvider, nil
+}
+
+func (o otpProvider) GenerateUserConfig(_ context.Context, providerContext ProviderContext, data map[string]any) (object.MFAProviderData, error) {
+	var recipient string
+
+	switch o.channel {
+	case otpChannelEmail:
+		recipient = providerContext.User.Email
+
+		if len(recipient) == 0 {
+			return object.MFAProviderData{}, errors.New("user has no email address")
+		}
+	case otpChannelSMS:
+		var setupData otpSetupData
+
+		jsonData, err := json.Marshal(data)
+		if err != nil {
+			return object.MFAProviderData{}, err
+		}
+
+		err = json.Unmarshal(jsonData, &setupData)
+		if err != nil {
+			return object.MFAProviderData{}, err
+		}
+
+		validate := validator.New(validator.WithRequiredStructEnabled())
+		err = validate.Struct(setupData)
+		if err != nil {
+			var validateErrs validator.ValidationErrors
+			if errors.As(err, &validateErrs) {
+				return object.MFAProviderData{}, errors.Join(fmt.Errorf("problem while validating sms otp data"), util.ConvertValidationError(validateErrs))
+			}
+		}
+
+		recipient = setupData.PhoneNumber
+	}
+
+	propertiesJson, err := json.Marshal(otpProperties{
+		Recipient: recipient,
+	})
+
+	if err != nil {
+		return object.MFAProviderData{}, err
+	}
+
+	return object.MFAProviderData{
+		Properties: propertiesJson,
+	}, nil
+}
+
+// InitDataFlow generates a new code and sends it to the recipient. A previous code becomes invalid.
+func (o otpProvider) InitDataFlow(ctx context.Context, providerContext ProviderContext) (object.MFAProviderData, map[string]any, error) {
+	var otpProperties otpProperties
+
+	mfaData := providerData(providerContext.MFA)
+
+	err := json.Unmarshal(mfaData.Properties, &otpProperties)
+	if err != nil {
+		return mfaData, nil, err
+	}
+
+	code, err := util.RandomDigits(o.codeLength)
+	if err != nil {
+		return mfaData, nil, err
+	}
+
+	expiresAt := time.Now().Add(o.expiry)
+
+	var sendCode func(ctx context.Context, to string, code string, expiresAt time.Time) error
+
+	switch o.channel {
+	case otpChannelEmail:
+		sendCode = providerContext.SendMail
+	case otpChannelSMS:
+		sendCode = providerContext.SendSMS
+	}
+
+	if sendCode == nil {
+		return mfaData, nil, errors.New("no sender configured for channel: " + o.channel)
+	}
+
+	err = sendCode(ctx, otpProperties.Recipient, code, expiresAt)
+	if err != nil {
+		return mfaData, nil, errors.Join(fmt.Errorf("problem while sending otp"), err)
+	}
+
+	otpProperties.CodeHash = hashSecret(code)
+	otpProperties.ExpiresAt = expiresAt
+	otpProperties.Attempts = 0
+
+	mfaData.Properties, err = json.Marshal(otpProperties)
+	if err != nil {
+		return providerData(providerContext.MFA), nil, err
+	}
+
+	return mfaData, map[string]any{
+		"channel":    o.channel,
+		"recipient":  maskRecipient(otpProperties.Recipient),
+		"expires_at": expiresAt,
+	}, nil
+}
+
+// ValidateDatFlow checks the code which was sent in InitDataFlow. Every failed attempt is counted,
+// after too many attempts or after the expiry the code is removed and a new one has to be requested.
+func (o otpProvider) ValidateDatFlow(_ context.Context, providerContext ProviderContext, data map[string]any) (bool, object.MFAProviderData, error) {
+	var parameters otpBodyData
+	var otpProperties otpProperties
+
+	mfaData := providerData(providerContext.MFA)
+
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		return false, mfaData, err
+	}
+
+	err = json.Unmarshal(jsonData, &parameters)
+	if err != nil {
+		return false, mfaData, err
+	}
+
+	err = json.Unmarshal(mfaData.Properties, &otpProperties)
+	if err != nil {
+		return false, mfaData, err
+	}
+
+	if len(parameters.OTP) == 0 {
+		return false, mfaData, errors.New("missing otp")
+	}
+
+	if len(otpProperties.CodeHash) == 0 {
+		return false, mfaData, errors.New("no otp was requested")
+	}
+
+	success := false
+
+	switch {
+	case time.Now().After(otpProperties.ExpiresAt):
+		otpProperties.CodeHash = ""
+		err = errors.New("otp is expired")
+	case subtle.ConstantTimeCompare([]byte(hashSecret(strings.TrimSpace(parameters.OTP))), []byte(otpProperties.CodeHash)) == 1:
+		// A code can only be used once
+		otpProperties.CodeHash = ""
+		success = true
+	default:
+		otpProperties.Attempts++
+
+		if otpProperties.Attempts >= o.maxAttempts {
+			otpProperties.CodeHash = ""
+			err = errors.New("too many invalid attempts, a new otp has to be requested")
+		}
+	}
+
+	var marshalErr error
+	mfaData.Properties, marshalErr = json.Marshal(otpProperties)
+	if marshalErr != nil {
+		return false, providerData(providerContext.MFA), marshalErr
+	}
+
+	return success, mfaData, err
+}
+
+func (o otpProvider) GetConfigurationFields() []object.ProviderConfigurationField {
+	return []object.ProviderConfigurationField{
+		{
+			FieldKey:  "code_length",
+			FieldType: "int",
+		},
+		{
+			FieldKey:  "expiry",
+			FieldType: "int",
+		},
+		{
+			FieldKey:  "max_attempts",
+			FieldType: "int",
+		},
+	}
+}
+
+func (o otpProvider) ValidateConfigurationFields() error {
+	otpConfig := otpConfiguration{}
+
+	if len(o.provider.Parameter) == 0 {
+		// All fields are optional and fall back to their defaults
+		return nil
+	}
+
+	err := json.Unmarshal(o.provider.Parameter, &otpConfig)
+	if err != nil {
+		return err
+	}
+
+	// use a single instance of Validate, it caches struct info
+	validate := validator.New(validator.WithRequiredStructEnabled())
+	err = validate.Struct(otpConfig)
+	if err != nil {
+		var validateErrs validator.ValidationErrors
+		if errors.As(err, &validateErrs) {
+			return errors.Join(fmt.Errorf("problem while validating otp configuration"), validateErrs)
+		}
+	}
+
+	return nil
+}
+
+// hashSecret hashes short-lived secrets like codes or tokens, which are only stored until they are used or expired.
+func hashSecret(secret string) string {
+	hash := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(hash[:])
+}
+
+// maskRecipient hides most of an email address or phone number, so it can be shown to a user who is not fully signed in.
+func maskRecipient(recipient string) string {
+	local, domain, isEmail := strings.Cut(recipient, "@")
+
+	if isEmail {
+		if len(local) <= 1 {
+			return "*@" + domain
+		}
+
+		return local[:1] + strings.Repeat("*", len(local)-1) + "@" + domain
+	}
+
+	if len(recipient) <= 4 {
+		return strings.Repeat("*", len(recipient))
+	}
+
+	return strings.Repeat("*", len(recipient)-4) + recipient[len(recipient)-4:]
+}
@@ -0,0 +1,185 @@
+/*
+ * Copyright (C) 2025 Anthrove
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mfa
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/anthrove/identity/pkg/object"
+	"testing"
+	"time"
+)
+
+func newTestOTPFlow(t *testing.T, providerType string, parameter json.RawMessage, data map[string]any) (Provider, ProviderContext, *string) {
+	provider, err := GetMFAProvider(object.Provider{
+		ID:           "test",
+		TenantID:     "test",
+		DisplayName:  "Test",
+		Category:     "mfa",
+		ProviderType: providerType,
+		Parameter:    parameter,
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var sentCode string
+	sendCode := func(ctx context.Context, to string, code string, expiresAt time.Time) error {
+		sentCode = code
+		return nil
+	}
+
+	providerContext := ProviderContext{
+		Tenant:   object.Tenant{ID: "test", DisplayName: "Test"},
+		User:     object.User{ID: "test", Username: "testuser", Email: "testuser@example.com"},
+		SendMail: sendCode,
+		SendSMS:  sendCode,
+	}
+
+	mfaData, err := provider.GenerateUserConfig(context.Background(), providerContext, data)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	providerContext.MFA = object.MFA{Properties: mfaData.Properties}
+
+	return provider, providerContext, &sentCode
+}
+
+func TestEmailOTPProviderFlow(t *testing.T) {
+	provider, providerContext, sentCode := newTestOTPFlow(t, "email_otp", nil, nil)
+
+	mfaData, response, err := provider.InitDataFlow(context.Background(), providerContext)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(*sentCode) != otpDefaultCodeLength {
+		t.Fatalf("unexpected code: %s", *sentCode)
+	}
+
+	if response["recipient"] != "t*******@example.com" {
+		t.Fatalf("unexpected recipient: %v", response["recipient"])
+	}
+
+	providerContext.MFA.Properties = mfaData.Properties
+
+	success, mfaData, err := provider.ValidateDatFlow(context.Background(), providerContext, map[string]any{
+		"otp": *sentCode,
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !success {
+		t.Fatal("invalid otp")
+	}
+
+	providerContext.MFA.Properties = mfaData.Properties
+
+	success, _, err = provider.ValidateDatFlow(context.Background(), providerContext, map[string]any{
+		"otp": *sentCode,
+	})
+
+	if err == nil || success {
+		t.Fatal("otp was accepted twice")
+	}
+}
+
+func TestSMSOTPProviderAttemptLimit(t *testing.T) {
+	provider, providerContext, sentCode := newTestOTPFlow(t, "sms_otp", json.RawMessage(`{"max_attempts":2}`), map[string]any{
+		"phone_number": "+4915112345678",
+	})
+
+	mfaData, _, err := provider.InitDataFlow(context.Background(), providerContext)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for range 2 {
+		providerContext.MFA.Properties = mfaData.Properties
+
+		var success bool
+		success, mfaData, _ = provider.ValidateDatFlow(context.Background(), providerContext, map[string]any{
+			"otp": "wrong",
+		})
+
+		if success {
+			t.Fatal("wrong otp was accepted")
+		}
+	}
+
+	providerContext.MFA.Properties = mfaData.Properties
+
+	success, _, _ := provider.ValidateDatFlow(context.Background(), providerContext, map[string]any{
+		"otp": *sentCode,
+	})
+
+	if success {
+		t.Fatal("otp was accepted after too many attempts")
+	}
+}
+
+func TestOTPProviderExpiry(t *testing.T) {
+	provider, providerContext, sentCode := newTestOTPFlow(t, "email_otp", nil, nil)
+
+	mfaData, _, err := provider.InitDataFlow(context.Background(), providerContext)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var properties otpProperties
+
+	err = json.Unmarshal(mfaData.Properties, &properties)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	properties.ExpiresAt = time.Now().Add(-time.Second)
+	providerContext.MFA.Properties, _ = json.Marshal(properties)
+
+	success, _, err := provider.ValidateDatFlow(context.Background(), providerContext, map[string]any{
+		"otp": *sentCode,
+	})
+
+	if err == nil || success {
+		t.Fatal("expired otp was accepted")
+	}
+}
+
+func TestSMSOTPProviderInvalidPhoneNumber(t *testing.T) {
+	provider, err := newSMSOTPProvider(object.Provider{ProviderType: "sms_otp"})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = provider.GenerateUserConfig(context.Background(), ProviderContext{}, map[string]any{
+		"phone_number": "0151 12345678",
+	})
+
+	if err == nil {
+		t.Fatal("invalid phone number was accepted")
+	}
+}
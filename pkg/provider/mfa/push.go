@@ -0,0 +1,479 @@
+/*
+ * Copyright (C) 2025 Anthrove
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mfa
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/anthrove/identity/pkg/object"
+	"github.com/anthrove/identity/pkg/util"
+	"github.com/go-playground/validator/v10"
+	gonanoid "github.com/matoous/go-nanoid/v2"
+	"math/big"
+	"net/http"
+	"slices"
+	"time"
+)
+
+const (
+	pushDefaultExpiry      = 120
+	pushDefaultPollTimeout = 20
+	pushNumberChoices      = 3
+	pushPollInterval       = 250 * time.Millisecond
+
+	pushDecisionPending  = "pending"
+	pushDecisionApproved = "approved"
+	pushDecisionDenied   = "denied"
+)
+
+// ErrApprovalPending is returned by ValidateDatFlow as long as the user did not decide on the approval.
+var ErrApprovalPending = errors.New("mfa approval is still pending")
+
+type pushConfiguration struct {
+	WebhookURL    string `json:"webhook_url" validate:"required,url,max=500"`
+	Authorization string `json:"authorization" validate:"max=500"`
+	Expiry        int    `json:"expiry" validate:"omitempty,min=30,max=600"`
+	// PollTimeout is how long ValidateDatFlow waits for the decision, 0 disables the long polling.
+	PollTimeout *int `json:"poll_timeout" validate:"omitempty,min=0,max=60"`
+}
+
+type pushProperties struct {
+	// Recipient is passed to the webhook, so the companion app or chat bot knows where to deliver the approval.
+	Recipient  string `json:"recipient,omitempty"`
+	ApprovalID string `json:"approval_id,omitempty"`
+}
+
+type pushSetupData struct {
+	Recipient string `json:"recipient" validate:"max=255"`
+}
+
+// pushWebhookBody is send to the configured webhook. The number is not part of it, the user has to pick it from the choices.
+type pushWebhookBody struct {
+	ApprovalID    string    `json:"approval_id"`
+	Token         string    `json:"token"`
+	TenantID      string    `json:"tenant_id"`
+	TenantName    string    `json:"tenant_name"`
+	UserID        string    `json:"user_id"`
+	Username      string    `json:"username"`
+	Recipient     string    `json:"recipient"`
+	MFAID         string    `json:"mfa_id"`
+	NumberChoices []int     `json:"number_choices"`
+	IPAddress     string    `json:"ip_address"`
+	UserAgent     string    `json:"user_agent"`
+	ApplicationID string    `json:"application_id"`
+	ExpiresAt     time.Time `json:"expires_at"`
+}
+
+type pushProvider struct {
+	provider    object.Provider
+	config      pushConfiguration
+	expiry      time.Duration
+	pollTimeout time.Duration
+}
+
+func newPushProvider(provider object.Provider) (Provider, error) {
+	pushConfig := pushConfiguration{}
+
+	if len(provider.Parameter) > 0 {
+		err := json.Unmarshal(provider.Parameter, &pushConfig)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	pushProvider := pushProvider{
+		provider:    provider,
+		config:      pushConfig,
+		expiry:      pushDefaultExpiry * time.Second,
+		pollTimeout: pushDefaultPollTimeout * time.Second,
+	}
+
+	if pushConfig.Expiry > 0 {
+		pushProvider.expiry = time.Duration(pushConfig.Expiry) * time.Second
+	}
+
+	if pushConfig.PollTimeout != nil {
+		pushProvider.pollTimeout = time.Duration(*pushConfig.PollTimeout) * time.Second
+	}
+
+	return pushProvider, nil
+}
+
+func (p pushProvider) GenerateUserConfig(_ context.Context, _ ProviderContext, data map[string]any) (object.MFAProviderData, error) {
+	var setupData pushSetupData
+
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		return object.MFAProviderData{}, err
+	}
+
+	err = json.Unmarshal(jsonData, &setupData)
+	if err != nil {
+		return object.MFAProviderData{}, err
+	}
+
+	validate := validator.New(validator.WithRequiredStructEnabled())
+	err = validate.Struct(setupData)
+	if err != nil {
+		var validateErrs validator.ValidationErrors
+		if errors.As(err, &validateErrs) {
+			return object.MFAProviderData{}, errors.Join(fmt.Errorf("problem while validating push data"), util.ConvertValidationError(validateErrs))
+		}
+	}
+
+	propertiesJson, err := json.Marshal(pushProperties{
+		Recipient: setupData.Recipient,
+	})
+
+	if err != nil {
+		return object.MFAProviderData{}, err
+	}
+
+	return object.MFAProviderData{
+		Properties: propertiesJson,
+	}, nil
+}
+
+// InitDataFlow creates a new pending approval and sends it to the webhook. The returned number has to be shown to the user,
+// who has to select it in the companion app to approve.
+func (p pushProvider) InitDataFlow(ctx context.Context, providerContext ProviderContext) (object.MFAProviderData, map[string]any, error) {
+	var pushProperties pushProperties
+
+	mfaData := providerData(providerContext.MFA)
+
+	err := json.Unmarshal(mfaData.Properties, &pushProperties)
+	if err != nil {
+		return mfaData, nil, err
+	}
+
+	approvalID, err := gonanoid.New(32)
+	if err != nil {
+		return mfaData, nil, err
+	}
+
+	token, err := util.RandomString(48)
+	if err != nil {
+		return mfaData, nil, err
+	}
+
+	numberChoices, err := randomNumberChoices(pushNumberChoices)
+	if err != nil {
+		return mfaData, nil, err
+	}
+
+	approval := object.PushApproval{
+		ID:            approvalID,
+		MFAID:         providerContext.MFA.ID,
+		ExpiresAt:     time.Now().Add(p.expiry),
+		TokenHash:     hashSecret(token),
+		Number:        numberChoices[0],
+		Decision:      pushDecisionPending,
+		IPAddress:     providerContext.Request.IPAddress,
+		UserAgent:     providerContext.Request.UserAgent,
+		ApplicationID: providerContext.Request.ApplicationID,
+	}
+
+	// The first choice is the right one, it is shuffled before it is send
+	shuffled, err := shuffleNumbers(numberChoices)
+	if err != nil {
+		return mfaData, nil, err
+	}
+
+	err = p.sendWebhook(ctx, pushWebhookBody{
+		ApprovalID:    approvalID,
+		Token:         token,
+		TenantID:      providerContext.Tenant.ID,
+		TenantName:    providerContext.Tenant.DisplayName,
+		UserID:        providerContext.User.ID,
+		Username:      providerContext.User.Username,
+		Recipient:     pushProperties.Recipient,
+		MFAID:         providerContext.MFA.ID,
+		NumberChoices: shuffled,
+		IPAddress:     providerContext.Request.IPAddress,
+		UserAgent:     providerContext.Request.UserAgent,
+		ApplicationID: providerContext.Request.ApplicationID,
+		ExpiresAt:     approval.ExpiresAt,
+	})
+
+	if err != nil {
+		return mfaData, nil, errors.Join(fmt.Errorf("problem while sending push approval"), err)
+	}
+
+	// A previous approval of the same MFA is replaced by the new one
+	if len(pushProperties.ApprovalID) > 0 {
+		err = providerContext.PushApprovals.KillPushApproval(ctx, pushProperties.ApprovalID)
+		if err != nil {
+			return mfaData, nil, err
+		}
+	}
+
+	err = providerContext.PushApprovals.CreatePushApproval(ctx, approval)
+	if err != nil {
+		return mfaData, nil, err
+	}
+
+	pushProperties.ApprovalID = approvalID
+
+	mfaData.Properties, err = json.Marshal(pushProperties)
+	if err != nil {
+		return providerData(providerContext.MFA), nil, err
+	}
+
+	return mfaData, map[string]any{
+		"approval_id": approvalID,
+		"number":      approval.Number,
+		"expires_at":  approval.ExpiresAt,
+	}, nil
+}
+
+// ValidateDatFlow waits up to the poll timeout for the decision on the approval, the stored approval is checked every poll interval.
+// As long as there is no decision, ErrApprovalPending is returned and the client has to call it again.
+// The approval can only be used by the same client and application which started it.
+func (p pushProvider) ValidateDatFlow(ctx context.Context, providerContext ProviderContext, _ map[string]any) (bool, object.MFAProviderData, error) {
+	var pushProperties pushProperties
+
+	mfaData := providerData(providerContext.MFA)
+
+	err := json.Unmarshal(mfaData.Properties, &pushProperties)
+	if err != nil {
+		return false, mfaData, err
+	}
+
+	if len(pushProperties.ApprovalID) == 0 {
+		return false, mfaData, errors.New("no push approval was requested")
+	}
+
+	approval, err := providerContext.PushApprovals.FindPushApproval(ctx, pushProperties.ApprovalID)
+	if err != nil {
+		return false, mfaData, errors.Join(errors.New("no push approval was requested"), err)
+	}
+
+	if approval.RequestInfo() != providerContext.Request {
+		return false, mfaData, errors.New("push approval was requested by another client")
+	}
+
+	approval, err = p.waitForDecision(ctx, providerContext.PushApprovals, approval)
+	if err != nil {
+		return false, mfaData, err
+	}
+
+	expired := time.Now().After(approval.ExpiresAt)
+
+	if approval.Decision == pushDecisionPending && !expired {
+		return false, mfaData, ErrApprovalPending
+	}
+
+	// The approval is used up, a new one has to be requested
+	err = providerContext.PushApprovals.KillPushApproval(ctx, approval.ID)
+	if err != nil {
+		return false, mfaData, err
+	}
+
+	pushProperties.ApprovalID = ""
+
+	mfaData.Properties, err = json.Marshal(pushProperties)
+	if err != nil {
+		return false, providerData(providerContext.MFA), err
+	}
+
+	switch approval.Decision {
+	case pushDecisionApproved:
+		return true, mfaData, nil
+	case pushDecisionDenied:
+		return false, mfaData, errors.New("push approval was denied")
+	}
+
+	return false, mfaData, errors.New("push approval is expired")
+}
+
+// waitForDecision polls the approval until it was decided, it expired, the poll timeout passed or the context is done.
+func (p pushProvider) waitForDecision(ctx context.Context, store PushApprovalStore, approval object.PushApproval) (object.PushApproval, error) {
+	deadline := time.Now().Add(min(p.pollTimeout, time.Until(approval.ExpiresAt)))
+
+	ticker := time.NewTicker(pushPollInterval)
+	defer ticker.Stop()
+
+	for approval.Decision == pushDecisionPending && time.Now().Before(deadline) {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return approval, nil
+		}
+
+		var err error
+		approval, err = store.FindPushApproval(ctx, approval.ID)
+		if err != nil {
+			return approval, err
+		}
+	}
+
+	return approval, nil
+}
+
+// DecidePushApproval stores the decision of the user, it is called by the companion app or chat bot with the token of the webhook.
+// If the selected number does not match, the approval is denied, as the user most likely did not start the sign in.
+func DecidePushApproval(ctx context.Context, store PushApprovalStore, approvalID string, token string, approve bool, number int) error {
+	approval, err := store.FindPushApproval(ctx, approvalID)
+
+	if err != nil || subtle.ConstantTimeCompare([]byte(hashSecret(token)), []byte(approval.TokenHash)) != 1 {
+		return errors.New("push approval not found")
+	}
+
+	if approval.Decision != pushDecisionPending {
+		return errors.New("push approval was already decided")
+	}
+
+	if time.Now().After(approval.ExpiresAt) {
+		return errors.New("push approval is expired")
+	}
+
+	decision := pushDecisionDenied
+
+	if approve && number == approval.Number {
+		decision = pushDecisionApproved
+	}
+
+	updated, err := store.UpdatePushApprovalDecision(ctx, approvalID, pushDecisionPending, decision)
+	if err != nil {
+		return err
+	}
+
+	if !updated {
+		return errors.New("push approval was already decided")
+	}
+
+	if approve && decision == pushDecisionDenied {
+		return errors.New("selected number does not match")
+	}
+
+	return nil
+}
+
+func (p pushProvider) sendWebhook(ctx context.Context, body pushWebhookBody) error {
+	bodyJson, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, p.config.WebhookURL, bytes.NewReader(bodyJson))
+	if err != nil {
+		return err
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+
+	if len(p.config.Authorization) > 0 {
+		request.Header.Set("Authorization", p.config.Authorization)
+	}
+
+	client := http.Client{
+		Timeout: 10 * time.Second,
+	}
+
+	response, err := client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return fmt.Errorf("push webhook responded with status %d", response.StatusCode)
+	}
+
+	return nil
+}
+
+func (p pushProvider) GetConfigurationFields() []object.ProviderConfigurationField {
+	return []object.ProviderConfigurationField{
+		{
+			FieldKey:  "webhook_url",
+			FieldType: "text",
+		},
+		{
+			FieldKey:  "authorization",
+			FieldType: "secret",
+		},
+		{
+			FieldKey:  "expiry",
+			FieldType: "int",
+		},
+		{
+			FieldKey:  "poll_timeout",
+			FieldType: "int",
+		},
+	}
+}
+
+func (p pushProvider) ValidateConfigurationFields() error {
+	pushConfig := pushConfiguration{}
+
+	err := json.Unmarshal(p.provider.Parameter, &pushConfig)
+	if err != nil {
+		return err
+	}
+
+	// use a single instance of Validate, it caches struct info
+	validate := validator.New(validator.WithRequiredStructEnabled())
+	err = validate.Struct(pushConfig)
+	if err != nil {
+		var validateErrs validator.ValidationErrors
+		if errors.As(err, &validateErrs) {
+			return errors.Join(fmt.Errorf("problem while validating push configuration"), validateErrs)
+		}
+	}
+
+	return nil
+}
+
+// randomNumberChoices returns distinct two-digit numbers.
+func randomNumberChoices(amount int) ([]int, error) {
+	choices := make([]int, 0, amount)
+
+	for len(choices) < amount {
+		number, err := rand.Int(rand.Reader, big.NewInt(90))
+		if err != nil {
+			return nil, err
+		}
+
+		choice := int(number.Int64()) + 10
+		if !slices.Contains(choices, choice) {
+			choices = append(choices, choice)
+		}
+	}
+
+	return choices, nil
+}
+
+func shuffleNumbers(numbers []int) ([]int, error) {
+	shuffled := slices.Clone(numbers)
+
+	for i := len(shuffled) - 1; i > 0; i-- {
+		j, err := rand.Int(rand.Reader, big.NewInt(int64(i+1)))
+		if err != nil {
+			return nil, err
+		}
+
+		shuffled[i], shuffled[j.Int64()] = shuffled[j.Int64()], shuffled[i]
+	}
+
+	return shuffled, nil
+}
@@ -0,0 +1,208 @@
+/*
+ * Copyright (C) 2025 Anthrove
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mfa
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/anthrove/identity/pkg/object"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memoryPushApprovals keeps the approvals of the tests in memory, the database is tested by the logic package.
+type memoryPushApprovals struct {
+	sync.Mutex
+	approvals map[string]object.PushApproval
+}
+
+func (m *memoryPushApprovals) CreatePushApproval(_ context.Context, approval object.PushApproval) error {
+	m.Lock()
+	defer m.Unlock()
+
+	m.approvals[approval.ID] = approval
+	return nil
+}
+
+func (m *memoryPushApprovals) FindPushApproval(_ context.Context, approvalID string) (object.PushApproval, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	approval, exists := m.approvals[approvalID]
+	if !exists {
+		return object.PushApproval{}, errors.New("not found")
+	}
+
+	return approval, nil
+}
+
+func (m *memoryPushApprovals) UpdatePushApprovalDecision(_ context.Context, approvalID string, from string, to string) (bool, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	approval, exists := m.approvals[approvalID]
+	if !exists || approval.Decision != from {
+		return false, nil
+	}
+
+	approval.Decision = to
+	m.approvals[approvalID] = approval
+	return true, nil
+}
+
+func (m *memoryPushApprovals) KillPushApproval(_ context.Context, approvalID string) error {
+	m.Lock()
+	defer m.Unlock()
+
+	delete(m.approvals, approvalID)
+	return nil
+}
+
+func newTestPushFlow(t *testing.T, pollTimeout int) (Provider, ProviderContext, chan pushWebhookBody) {
+	webhooks := make(chan pushWebhookBody, 1)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body pushWebhookBody
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		webhooks <- body
+	}))
+	t.Cleanup(server.Close)
+
+	parameter, _ := json.Marshal(map[string]any{
+		"webhook_url":  server.URL,
+		"poll_timeout": pollTimeout,
+	})
+
+	provider, err := GetMFAProvider(object.Provider{
+		ID:           "test",
+		TenantID:     "test",
+		DisplayName:  "Test",
+		Category:     "mfa",
+		ProviderType: "push",
+		Parameter:    parameter,
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = provider.ValidateConfigurationFields(); err != nil {
+		t.Fatal(err)
+	}
+
+	providerContext := ProviderContext{
+		Tenant:  object.Tenant{ID: "test", DisplayName: "Test"},
+		User:    object.User{ID: "test", Username: "testuser"},
+		Request: object.RequestInfo{IPAddress: "127.0.0.1", ApplicationID: "app"},
+
+		PushApprovals: &memoryPushApprovals{approvals: map[string]object.PushApproval{}},
+	}
+
+	mfaData, err := provider.GenerateUserConfig(context.Background(), providerContext, map[string]any{
+		"recipient": "@testuser",
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	providerContext.MFA = object.MFA{ID: "test", Properties: mfaData.Properties}
+
+	return provider, providerContext, webhooks
+}
+
+func TestPushProviderFlow(t *testing.T) {
+	provider, providerContext, webhooks := newTestPushFlow(t, 0)
+
+	mfaData, response, err := provider.InitDataFlow(context.Background(), providerContext)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	webhook := <-webhooks
+
+	if webhook.Recipient != "@testuser" || len(webhook.NumberChoices) != pushNumberChoices {
+		t.Fatalf("unexpected webhook: %+v", webhook)
+	}
+
+	providerContext.MFA.Properties = mfaData.Properties
+
+	_, _, err = provider.ValidateDatFlow(context.Background(), providerContext, nil)
+
+	if !errors.Is(err, ErrApprovalPending) {
+		t.Fatalf("expected pending approval, got: %v", err)
+	}
+
+	otherClient := providerContext
+	otherClient.Request.IPAddress = "10.0.0.1"
+
+	_, _, err = provider.ValidateDatFlow(context.Background(), otherClient, nil)
+
+	if err == nil || errors.Is(err, ErrApprovalPending) {
+		t.Fatal("approval was usable from another client")
+	}
+
+	err = DecidePushApproval(context.Background(), providerContext.PushApprovals, webhook.ApprovalID, webhook.Token, true, response["number"].(int))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	success, _, err := provider.ValidateDatFlow(context.Background(), providerContext, nil)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !success {
+		t.Fatal("approved push was not accepted")
+	}
+}
+
+func TestPushProviderWrongNumber(t *testing.T) {
+	provider, providerContext, webhooks := newTestPushFlow(t, 5)
+
+	mfaData, response, err := provider.InitDataFlow(context.Background(), providerContext)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	webhook := <-webhooks
+	providerContext.MFA.Properties = mfaData.Properties
+
+	if err = DecidePushApproval(context.Background(), providerContext.PushApprovals, webhook.ApprovalID, "wrong-token", true, response["number"].(int)); err == nil {
+		t.Fatal("decision with wrong token was accepted")
+	}
+
+	go func() {
+		// The decision arrives while ValidateDatFlow is waiting for it
+		time.Sleep(50 * time.Millisecond)
+		_ = DecidePushApproval(context.Background(), providerContext.PushApprovals, webhook.ApprovalID, webhook.Token, true, response["number"].(int)+100)
+	}()
+
+	success, _, err := provider.ValidateDatFlow(context.Background(), providerContext, nil)
+
+	if err == nil || success {
+		t.Fatal("push with wrong number was accepted")
+	}
+}
@@ -17,16 +17,59 @@
 package mfa
 
 import (
+	"bytes"
+	"context"
+	"crypto/subtle"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"github.com/anthrove/identity/pkg/object"
+	"github.com/go-playground/validator/v10"
 	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/hotp"
 	"github.com/pquerna/otp/totp"
+	"text/template"
+	"time"
 )
 
+const (
+	totpDefaultAlgorithm = "sha1"
+	totpDefaultDigits    = 6
+	totpDefaultPeriod    = 30
+	totpDefaultSkew      = 1
+	totpDefaultIssuer    = "{{.TenantName}}"
+)
+
+var totpAlgorithms = map[string]otp.Algorithm{
+	"sha1":   otp.AlgorithmSHA1,
+	"sha256": otp.AlgorithmSHA256,
+	"sha512": otp.AlgorithmSHA512,
+}
+
+type totpConfiguration struct {
+	Algorithm string `json:"algorithm" validate:"omitempty,oneof=sha1 sha256 sha512"`
+	Digits    int    `json:"digits" validate:"omitempty,oneof=6 8"`
+	Period    uint   `json:"period" validate:"omitempty,min=15,max=300"`
+	// Skew is a pointer, so an explicitly configured skew of 0 can be told apart from an unset one
+	Skew   *uint  `json:"skew" validate:"omitempty,max=10"`
+	Issuer string `json:"issuer" validate:"omitempty,max=100"`
+}
+
+// totpIssuerData is the data available inside the issuer template
+type totpIssuerData struct {
+	TenantID   string
+	TenantName string
+}
+
 type totpProperties struct {
 	URI    string `json:"uri"`
 	Secret string `json:"secret"`
+
+	// The parameters are kept per user, so changing the provider configuration does not break already enrolled authenticators.
+	// MFAs enrolled before they were stored fall back to the defaults.
+	Algorithm string `json:"algorithm,omitempty"`
+	Digits    int    `json:"digits,omitempty"`
+	Period    uint   `json:"period,omitempty"`
 }
 
 type totpBodyData struct {
@@ -36,29 +79,76 @@ type totpBodyData struct {
 type totpProvider struct {
 	provider      object.Provider
 	period        uint
+	skew          uint
 	digits        otp.Digits
-	hashAlgorithm otp.Algorithm
+	hashAlgorithm string
+	issuer        string
 }
 
 func newTOTPProvider(provider object.Provider) (Provider, error) {
-	return totpProvider{
+	totpConfig := totpConfiguration{}
+
+	if len(provider.Parameter) > 0 {
+		err := json.Unmarshal(provider.Parameter, &totpConfig)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	totpProvider := totpProvider{
 		provider:      provider,
-		hashAlgorithm: otp.AlgorithmSHA1,
-		period:        30,
-		digits:        otp.DigitsSix,
-	}, nil
+		hashAlgorithm: totpDefaultAlgorithm,
+		period:        totpDefaultPeriod,
+		skew:          totpDefaultSkew,
+		digits:        totpDefaultDigits,
+		issuer:        totpDefaultIssuer,
+	}
+
+	if len(totpConfig.Algorithm) > 0 {
+		totpProvider.hashAlgorithm = totpConfig.Algorithm
+	}
+
+	if totpConfig.Digits > 0 {
+		totpProvider.digits = otp.Digits(totpConfig.Digits)
+	}
+
+	if totpConfig.Period > 0 {
+		totpProvider.period = totpConfig.Period
+	}
+
+	if totpConfig.Skew != nil {
+		totpProvider.skew = *totpConfig.Skew
+	}
+
+	if len(totpConfig.Issuer) > 0 {
+		totpProvider.issuer = totpConfig.Issuer
+	}
+
+	return totpProvider, nil
 }
 
-func (t totpProvider) GenerateUserConfig(username string) (object.MFAProviderData, error) {
+func (t totpProvider) GenerateUserConfig(_ context.Context, providerContext ProviderContext, _ map[string]any) (object.MFAProviderData, error) {
+	username := providerContext.User.Username
 	if len(username) == 0 {
 		return object.MFAProviderData{}, errors.New("username is required")
 	}
+
+	algorithm, exists := totpAlgorithms[t.hashAlgorithm]
+	if !exists {
+		return object.MFAProviderData{}, errors.New("unknown totp algorithm: " + t.hashAlgorithm)
+	}
+
+	issuer, err := t.fillIssuer(providerContext.Tenant)
+	if err != nil {
+		return object.MFAProviderData{}, err
+	}
+
 	secret, err := totp.Generate(totp.GenerateOpts{
-		Issuer:      t.provider.TenantID, //TODO: maybe refactor to use the name instead of the ID
+		Issuer:      issuer,
 		AccountName: username,
 		Period:      t.period,
 		Digits:      t.digits,
-		Algorithm:   t.hashAlgorithm,
+		Algorithm:   algorithm,
 	})
 
 	if err != nil {
@@ -66,8 +156,11 @@ func (t totpProvider) GenerateUserConfig(username string) (object.MFAProviderDat
 	}
 
 	propertiesJson, err := json.Marshal(totpProperties{
-		URI:    secret.URL(),
-		Secret: secret.Secret(),
+		URI:       secret.URL(),
+		Secret:    secret.Secret(),
+		Algorithm: t.hashAlgorithm,
+		Digits:    int(t.digits),
+		Period:    t.period,
 	})
 
 	if err != nil {
@@ -79,44 +172,166 @@ func (t totpProvider) GenerateUserConfig(username string) (object.MFAProviderDat
 	}, nil
 }
 
-func (t totpProvider) InitDataFlow(_ json.RawMessage) (map[string]any, error) {
+func (t totpProvider) InitDataFlow(_ context.Context, providerContext ProviderContext) (object.MFAProviderData, map[string]any, error) {
 	// No init is required with totp
-	return nil, nil
+	return providerData(providerContext.MFA), nil, nil
 }
 
-func (t totpProvider) ValidateDatFlow(mfaConfig json.RawMessage, data map[string]any) (bool, error) {
+// ValidateDatFlow checks the otp against all time steps inside the skew window.
+// Time steps up to the LastCounter of the MFA were already used once and are rejected, so a code can't be replayed.
+// On success the time step of the accepted code is returned as the new LastCounter.
+func (t totpProvider) ValidateDatFlow(_ context.Context, providerContext ProviderContext, data map[string]any) (bool, object.MFAProviderData, error) {
 	var parameters totpBodyData
 	var totpProperties totpProperties
 
+	mfaData := providerData(providerContext.MFA)
+
 	jsonData, err := json.Marshal(data)
 	if err != nil {
-		return false, err
+		return false, mfaData, err
 	}
 
 	err = json.Unmarshal(jsonData, &parameters)
 	if err != nil {
-		return false, err
+		return false, mfaData, err
 	}
 
-	err = json.Unmarshal(mfaConfig, &totpProperties)
+	err = json.Unmarshal(mfaData.Properties, &totpProperties)
 	if err != nil {
-		return false, err
+		return false, mfaData, err
 	}
 
 	if len(parameters.OTP) == 0 {
-		return false, errors.New("missing otp")
+		return false, mfaData, errors.New("missing otp")
+	}
+
+	if len(totpProperties.Algorithm) == 0 {
+		totpProperties.Algorithm = totpDefaultAlgorithm
+	}
+
+	if totpProperties.Digits == 0 {
+		totpProperties.Digits = totpDefaultDigits
 	}
 
-	// Validate OTP
-	return totp.Validate(parameters.OTP, totpProperties.Secret), nil
+	if totpProperties.Period == 0 {
+		totpProperties.Period = totpDefaultPeriod
+	}
+
+	algorithm, exists := totpAlgorithms[totpProperties.Algorithm]
+	if !exists {
+		return false, mfaData, errors.New("unknown totp algorithm: " + totpProperties.Algorithm)
+	}
+
+	validateOpts := hotp.ValidateOpts{
+		Digits:    otp.Digits(totpProperties.Digits),
+		Algorithm: algorithm,
+	}
+
+	currentCounter := time.Now().Unix() / int64(totpProperties.Period)
+	skew := int64(t.skew)
+
+	for counter := currentCounter - skew; counter <= currentCounter+skew; counter++ {
+		if counter <= mfaData.LastCounter {
+			continue
+		}
+
+		code, err := hotp.GenerateCodeCustom(totpProperties.Secret, uint64(counter), validateOpts)
+		if err != nil {
+			return false, mfaData, err
+		}
+
+		if subtle.ConstantTimeCompare([]byte(code), []byte(parameters.OTP)) == 1 {
+			mfaData.LastCounter = counter
+			return true, mfaData, nil
+		}
+	}
+
+	return false, mfaData, nil
 }
 
 func (t totpProvider) GetConfigurationFields() []object.ProviderConfigurationField {
-	// There is no configuration that can be returned
-	return []object.ProviderConfigurationField{}
+	return []object.ProviderConfigurationField{
+		{
+			FieldKey:  "algorithm",
+			FieldType: "text",
+		},
+		{
+			FieldKey:  "digits",
+			FieldType: "int",
+		},
+		{
+			FieldKey:  "period",
+			FieldType: "int",
+		},
+		{
+			FieldKey:  "skew",
+			FieldType: "int",
+		},
+		{
+			FieldKey:  "issuer",
+			FieldType: "text",
+		},
+	}
 }
 
 func (t totpProvider) ValidateConfigurationFields() error {
-	// There is no configuration that needs to be validated
+	totpConfig := totpConfiguration{}
+
+	if len(t.provider.Parameter) == 0 {
+		// All fields are optional and fall back to their defaults
+		return nil
+	}
+
+	err := json.Unmarshal(t.provider.Parameter, &totpConfig)
+	if err != nil {
+		return err
+	}
+
+	// use a single instance of Validate, it caches struct info
+	validate := validator.New(validator.WithRequiredStructEnabled())
+	err = validate.Struct(totpConfig)
+	if err != nil {
+		var validateErrs validator.ValidationErrors
+		if errors.As(err, &validateErrs) {
+			return errors.Join(fmt.Errorf("problem while validating totp configuration"), validateErrs)
+		}
+	}
+
+	if len(totpConfig.Issuer) > 0 {
+		_, err = template.New("issuer").Parse(totpConfig.Issuer)
+		if err != nil {
+			return errors.Join(fmt.Errorf("problem while parsing totp issuer template"), err)
+		}
+	}
+
 	return nil
 }
+
+// fillIssuer renders the issuer template, which is shown as the account title inside the authenticator app.
+func (t totpProvider) fillIssuer(tenant object.Tenant) (string, error) {
+	tmpl, err := template.New("issuer").Parse(t.issuer)
+	if err != nil {
+		return "", err
+	}
+
+	issuerData := totpIssuerData{
+		TenantID:   tenant.ID,
+		TenantName: tenant.DisplayName,
+	}
+
+	if len(issuerData.TenantName) == 0 {
+		issuerData.TenantName = tenant.ID
+	}
+
+	var issuer bytes.Buffer
+	err = tmpl.Execute(&issuer, issuerData)
+	if err != nil {
+		return "", err
+	}
+
+	if issuer.Len() == 0 {
+		return issuerData.TenantName, nil
+	}
+
+	return issuer.String(), nil
+}
@@ -17,6 +17,7 @@
 package mfa
 
 import (
+	"context"
 	"encoding/json"
 	"github.com/anthrove/identity/pkg/object"
 	"github.com/pquerna/otp"
@@ -33,13 +34,16 @@ func TestToTpProviderFlow(t *testing.T) {
 		Category:     "mfa",
 		ProviderType: "totp",
 		Parameter:    nil,
-	}, 30, otp.DigitsSix, otp.AlgorithmSHA1)
+	})
 
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	data, err := provider.GenerateUserConfig("testuser")
+	data, err := provider.GenerateUserConfig(context.Background(), ProviderContext{
+		Tenant: object.Tenant{ID: "test", DisplayName: "Test"},
+		User:   object.User{ID: "test", Username: "testuser"},
+	}, nil)
 
 	if err != nil {
 		t.Fatal(err)
@@ -59,7 +63,97 @@ func TestToTpProviderFlow(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	success, err := provider.ValidateDatFlow(data.Properties, map[string]any{
+	success, mfaData, err := provider.ValidateDatFlow(context.Background(), ProviderContext{
+		MFA: object.MFA{Properties: data.Properties},
+	}, map[string]any{
+		"otp": code,
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !success {
+		t.Fatal("invalid otp")
+	}
+
+	success, _, err = provider.ValidateDatFlow(context.Background(), ProviderContext{
+		MFA: object.MFA{Properties: mfaData.Properties, LastCounter: mfaData.LastCounter},
+	}, map[string]any{
+		"otp": code,
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if success {
+		t.Fatal("replayed otp was accepted")
+	}
+}
+
+func TestToTpProviderConfiguration(t *testing.T) {
+	provider, err := newTOTPProvider(object.Provider{
+		ID:           "test",
+		TenantID:     "test",
+		DisplayName:  "Test",
+		Category:     "mfa",
+		ProviderType: "totp",
+		Parameter:    json.RawMessage(`{"algorithm":"sha256","digits":8,"period":60,"skew":0,"issuer":"{{.TenantName}} Login"}`),
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = provider.ValidateConfigurationFields(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := provider.GenerateUserConfig(context.Background(), ProviderContext{
+		Tenant: object.Tenant{ID: "test", DisplayName: "Anthrove"},
+		User:   object.User{ID: "test", Username: "testuser"},
+	}, nil)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var totpProperties totpProperties
+
+	err = json.Unmarshal(data.Properties, &totpProperties)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := otp.NewKeyFromURL(totpProperties.URI)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if key.Issuer() != "Anthrove Login" {
+		t.Fatalf("unexpected issuer: %s", key.Issuer())
+	}
+
+	if key.Digits() != otp.DigitsEight || key.Period() != 60 || key.Algorithm() != otp.AlgorithmSHA256 {
+		t.Fatalf("unexpected key parameters: %s", key.URL())
+	}
+
+	code, err := totp.GenerateCodeCustom(totpProperties.Secret, time.Now(), totp.ValidateOpts{
+		Period:    60,
+		Digits:    otp.DigitsEight,
+		Algorithm: otp.AlgorithmSHA256,
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	success, _, err := provider.ValidateDatFlow(context.Background(), ProviderContext{
+		MFA: object.MFA{Properties: data.Properties},
+	}, map[string]any{
 		"otp": code,
 	})
 
@@ -71,3 +165,22 @@ func TestToTpProviderFlow(t *testing.T) {
 		t.Fatal("invalid otp")
 	}
 }
+
+func TestToTpProviderInvalidConfiguration(t *testing.T) {
+	provider, err := newTOTPProvider(object.Provider{
+		ID:           "test",
+		TenantID:     "test",
+		DisplayName:  "Test",
+		Category:     "mfa",
+		ProviderType: "totp",
+		Parameter:    json.RawMessage(`{"algorithm":"md5","digits":7}`),
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = provider.ValidateConfigurationFields(); err == nil {
+		t.Fatal("invalid configuration was accepted")
+	}
+}
@@ -0,0 +1,446 @@
+/*
+ * Copyright (C) 2025 Anthrove
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mfa
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/anthrove/identity/pkg/object"
+	"github.com/go-playground/validator/v10"
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"strings"
+	"time"
+)
+
+const (
+	webauthnDefaultUserVerification = "preferred"
+	webauthnDefaultAttestation      = "none"
+	webauthnDefaultTimeout          = 300
+	webauthnUserHandleLength        = 32
+)
+
+type webauthnConfiguration struct {
+	RPID          string `json:"rp_id" validate:"required,hostname,max=255"`
+	RPDisplayName string `json:"rp_display_name" validate:"max=100"`
+	// RPOrigins is a comma separated list of the origins the login page is served from, e.g. https://login.example.com
+	RPOrigins        string `json:"rp_origins" validate:"required,max=1000"`
+	UserVerification string `json:"user_verification" validate:"omitempty,oneof=required preferred discouraged"`
+	Attestation      string `json:"attestation" validate:"omitempty,oneof=none indirect direct"`
+	Timeout          int    `json:"timeout" validate:"omitempty,min=30,max=600"`
+}
+
+type webauthnProperties struct {
+	UserHandle []byte `json:"user_handle"`
+
+	// The credential is empty until the registration was finished with the first ValidateDatFlow.
+	CredentialID      []byte   `json:"credential_id,omitempty"`
+	PublicKey         []byte   `json:"public_key,omitempty"`
+	SignCount         uint32   `json:"sign_count"`
+	AttestationFormat string   `json:"attestation_format,omitempty"`
+	AAGUID            []byte   `json:"aaguid,omitempty"`
+	Transports        []string `json:"transports,omitempty"`
+	BackupEligible    bool     `json:"backup_eligible"`
+	BackupState       bool     `json:"backup_state"`
+
+	// Session holds the challenge of the running registration or login, it can only be used once.
+	Session *webauthn.SessionData `json:"session,omitempty"`
+}
+
+// webauthnUser implements webauthn.User for a user with a single credential, every security key is its own MFA.
+type webauthnUser struct {
+	user       object.User
+	properties webauthnProperties
+}
+
+func (w webauthnUser) WebAuthnID() []byte {
+	return w.properties.UserHandle
+}
+
+func (w webauthnUser) WebAuthnName() string {
+	return w.user.Username
+}
+
+func (w webauthnUser) WebAuthnDisplayName() string {
+	if len(w.user.DisplayName) == 0 {
+		return w.user.Username
+	}
+
+	return w.user.DisplayName
+}
+
+func (w webauthnUser) WebAuthnCredentials() []webauthn.Credential {
+	if len(w.properties.CredentialID) == 0 {
+		return nil
+	}
+
+	transports := make([]protocol.AuthenticatorTransport, 0, len(w.properties.Transports))
+	for _, transport := range w.properties.Transports {
+		transports = append(transports, protocol.AuthenticatorTransport(transport))
+	}
+
+	return []webauthn.Credential{
+		{
+			ID:              w.properties.CredentialID,
+			PublicKey:       w.properties.PublicKey,
+			AttestationType: w.properties.AttestationFormat,
+			Transport:       transports,
+			Flags: webauthn.CredentialFlags{
+				BackupEligible: w.properties.BackupEligible,
+				BackupState:    w.properties.BackupState,
+			},
+			Authenticator: webauthn.Authenticator{
+				AAGUID:    w.properties.AAGUID,
+				SignCount: w.properties.SignCount,
+			},
+		},
+	}
+}
+
+type webauthnProvider struct {
+	provider object.Provider
+	config   webauthnConfiguration
+}
+
+func newWebauthnProvider(provider object.Provider) (Provider, error) {
+	webauthnConfig := webauthnConfiguration{}
+
+	if len(provider.Parameter) > 0 {
+		err := json.Unmarshal(provider.Parameter, &webauthnConfig)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(webauthnConfig.UserVerification) == 0 {
+		webauthnConfig.UserVerification = webauthnDefaultUserVerification
+	}
+
+	if len(webauthnConfig.Attestation) == 0 {
+		webauthnConfig.Attestation = webauthnDefaultAttestation
+	}
+
+	if webauthnConfig.Timeout == 0 {
+		webauthnConfig.Timeout = webauthnDefaultTimeout
+	}
+
+	return webauthnProvider{
+		provider: provider,
+		config:   webauthnConfig,
+	}, nil
+}
+
+func (w webauthnProvider) GenerateUserConfig(_ context.Context, providerContext ProviderContext, _ map[string]any) (object.MFAProviderData, error) {
+	if len(providerContext.User.Username) == 0 {
+		return object.MFAProviderData{}, errors.New("username is required")
+	}
+
+	// The user handle is random, so the authenticator can't link the user across relying parties
+	userHandle := make([]byte, webauthnUserHandleLength)
+	_, err := rand.Read(userHandle)
+	if err != nil {
+		return object.MFAProviderData{}, err
+	}
+
+	propertiesJson, err := json.Marshal(webauthnProperties{
+		UserHandle: userHandle,
+	})
+
+	if err != nil {
+		return object.MFAProviderData{}, err
+	}
+
+	return object.MFAProviderData{
+		Properties: propertiesJson,
+	}, nil
+}
+
+// InitDataFlow generates a new challenge. As long as no credential is registered, the options for a registration are returned,
+// afterward the options for a login. The returned data has to be passed to navigator.credentials.create or navigator.credentials.get.
+func (w webauthnProvider) InitDataFlow(_ context.Context, providerContext ProviderContext) (object.MFAProviderData, map[string]any, error) {
+	var webauthnProperties webauthnProperties
+
+	mfaData := providerData(providerContext.MFA)
+
+	err := json.Unmarshal(mfaData.Properties, &webauthnProperties)
+	if err != nil {
+		return mfaData, nil, err
+	}
+
+	webAuthn, err := w.webAuthn(providerContext.Tenant)
+	if err != nil {
+		return mfaData, nil, err
+	}
+
+	user := webauthnUser{
+		user:       providerContext.User,
+		properties: webauthnProperties,
+	}
+
+	var options any
+	var session *webauthn.SessionData
+
+	if len(webauthnProperties.CredentialID) == 0 {
+		options, session, err = webAuthn.BeginRegistration(user,
+			webauthn.WithConveyancePreference(protocol.ConveyancePreference(w.config.Attestation)),
+			webauthn.WithAuthenticatorSelection(protocol.AuthenticatorSelection{
+				ResidentKey:      protocol.ResidentKeyRequirementDiscouraged,
+				UserVerification: protocol.UserVerificationRequirement(w.config.UserVerification),
+			}),
+		)
+	} else {
+		options, session, err = webAuthn.BeginLogin(user,
+			webauthn.WithUserVerification(protocol.UserVerificationRequirement(w.config.UserVerification)),
+		)
+	}
+
+	if err != nil {
+		return mfaData, nil, err
+	}
+
+	webauthnProperties.Session = session
+
+	mfaData.Properties, err = json.Marshal(webauthnProperties)
+	if err != nil {
+		return providerData(providerContext.MFA), nil, err
+	}
+
+	optionsJson, err := json.Marshal(options)
+	if err != nil {
+		return providerData(providerContext.MFA), nil, err
+	}
+
+	var response map[string]any
+	err = json.Unmarshal(optionsJson, &response)
+	if err != nil {
+		return providerData(providerContext.MFA), nil, err
+	}
+
+	return mfaData, response, nil
+}
+
+// ValidateDatFlow finishes the registration or login which was started in InitDataFlow. The data is the PublicKeyCredential
+// returned by the browser. The challenge is removed in any case, so a failed attempt needs a new InitDataFlow.
+func (w webauthnProvider) ValidateDatFlow(_ context.Context, providerContext ProviderContext, data map[string]any) (bool, object.MFAProviderData, error) {
+	var webauthnProperties webauthnProperties
+
+	mfaData := providerData(providerContext.MFA)
+
+	err := json.Unmarshal(mfaData.Properties, &webauthnProperties)
+	if err != nil {
+		return false, mfaData, err
+	}
+
+	if webauthnProperties.Session == nil {
+		return false, mfaData, errors.New("no webauthn challenge was requested")
+	}
+
+	session := *webauthnProperties.Session
+	webauthnProperties.Session = nil
+
+	validateErr := w.validateCredential(providerContext, &webauthnProperties, session, data)
+
+	mfaData.Properties, err = json.Marshal(webauthnProperties)
+	if err != nil {
+		return false, providerData(providerContext.MFA), err
+	}
+
+	if validateErr != nil {
+		return false, mfaData, validateErr
+	}
+
+	return true, mfaData, nil
+}
+
+// validateCredential verifies the response of the browser and updates the stored credential.
+func (w webauthnProvider) validateCredential(providerContext ProviderContext, webauthnProperties *webauthnProperties, session webauthn.SessionData, data map[string]any) error {
+	webAuthn, err := w.webAuthn(providerContext.Tenant)
+	if err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	user := webauthnUser{
+		user:       providerContext.User,
+		properties: *webauthnProperties,
+	}
+
+	if len(webauthnProperties.CredentialID) == 0 {
+		parsedResponse, err := protocol.ParseCredentialCreationResponseBytes(body)
+		if err != nil {
+			return webauthnError(err)
+		}
+
+		credential, err := webAuthn.CreateCredential(user, session, parsedResponse)
+		if err != nil {
+			return webauthnError(err)
+		}
+
+		transports := make([]string, 0, len(credential.Transport))
+		for _, transport := range credential.Transport {
+			transports = append(transports, string(transport))
+		}
+
+		webauthnProperties.CredentialID = credential.ID
+		webauthnProperties.PublicKey = credential.PublicKey
+		webauthnProperties.SignCount = credential.Authenticator.SignCount
+		webauthnProperties.AttestationFormat = credential.AttestationType
+		webauthnProperties.AAGUID = credential.Authenticator.AAGUID
+		webauthnProperties.Transports = transports
+		webauthnProperties.BackupEligible = credential.Flags.BackupEligible
+		webauthnProperties.BackupState = credential.Flags.BackupState
+
+		return nil
+	}
+
+	parsedResponse, err := protocol.ParseCredentialRequestResponseBytes(body)
+	if err != nil {
+		return webauthnError(err)
+	}
+
+	credential, err := webAuthn.ValidateLogin(user, session, parsedResponse)
+	if err != nil {
+		return webauthnError(err)
+	}
+
+	// A sign count which did not increase is a sign that the authenticator was cloned
+	if credential.Authenticator.CloneWarning {
+		return errors.New("webauthn sign count did not increase, the authenticator may be cloned")
+	}
+
+	webauthnProperties.SignCount = credential.Authenticator.SignCount
+	webauthnProperties.BackupState = credential.Flags.BackupState
+
+	return nil
+}
+
+func (w webauthnProvider) GetConfigurationFields() []object.ProviderConfigurationField {
+	return []object.ProviderConfigurationField{
+		{
+			FieldKey:  "rp_id",
+			FieldType: "text",
+		},
+		{
+			FieldKey:  "rp_display_name",
+			FieldType: "text",
+		},
+		{
+			FieldKey:  "rp_origins",
+			FieldType: "text",
+		},
+		{
+			FieldKey:  "user_verification",
+			FieldType: "text",
+		},
+		{
+			FieldKey:  "attestation",
+			FieldType: "text",
+		},
+		{
+			FieldKey:  "timeout",
+			FieldType: "int",
+		},
+	}
+}
+
+func (w webauthnProvider) ValidateConfigurationFields() error {
+	webauthnConfig := webauthnConfiguration{}
+
+	err := json.Unmarshal(w.provider.Parameter, &webauthnConfig)
+	if err != nil {
+		return err
+	}
+
+	// use a single instance of Validate, it caches struct info
+	validate := validator.New(validator.WithRequiredStructEnabled())
+	err = validate.Struct(webauthnConfig)
+	if err != nil {
+		var validateErrs validator.ValidationErrors
+		if errors.As(err, &validateErrs) {
+			return errors.Join(fmt.Errorf("problem while validating webauthn configuration"), validateErrs)
+		}
+	}
+
+	for _, origin := range splitOrigins(webauthnConfig.RPOrigins) {
+		err = validate.Var(origin, "url")
+		if err != nil {
+			return errors.Join(fmt.Errorf("problem while validating webauthn origin: %s", origin), err)
+		}
+	}
+
+	return nil
+}
+
+// webAuthn creates the relying party. If no display name is configured, the name of the tenant is shown by the authenticator.
+func (w webauthnProvider) webAuthn(tenant object.Tenant) (*webauthn.WebAuthn, error) {
+	rpDisplayName := w.config.RPDisplayName
+
+	if len(rpDisplayName) == 0 {
+		rpDisplayName = tenant.DisplayName
+	}
+
+	if len(rpDisplayName) == 0 {
+		rpDisplayName = w.config.RPID
+	}
+
+	timeout := webauthn.TimeoutConfig{
+		Enforce:    true,
+		Timeout:    time.Duration(w.config.Timeout) * time.Second,
+		TimeoutUVD: time.Duration(w.config.Timeout) * time.Second,
+	}
+
+	return webauthn.New(&webauthn.Config{
+		RPID:          w.config.RPID,
+		RPDisplayName: rpDisplayName,
+		RPOrigins:     splitOrigins(w.config.RPOrigins),
+		Timeouts: webauthn.TimeoutsConfig{
+			Login:        timeout,
+			Registration: timeout,
+		},
+	})
+}
+
+func splitOrigins(origins string) []string {
+	var result []string
+
+	for _, origin := range strings.Split(origins, ",") {
+		origin = strings.TrimSpace(origin)
+
+		if len(origin) > 0 {
+			result = append(result, origin)
+		}
+	}
+
+	return result
+}
+
+// webauthnError adds the details of a protocol error, which are not part of its message.
+func webauthnError(err error) error {
+	var protocolErr *protocol.Error
+	if errors.As(err, &protocolErr) && len(protocolErr.Details) > 0 {
+		return errors.Join(errors.New(protocolErr.Details), err)
+	}
+
+	return err
+}
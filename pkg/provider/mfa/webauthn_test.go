@@ -0,0 +1,231 @@
+/*
+ * Copyright (C) 2025 Anthrove
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mfa
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"github.com/anthrove/identity/pkg/object"
+	"testing"
+)
+
+// testAuthenticator is a minimal security key with a P-256 key and none attestation.
+type testAuthenticator struct {
+	key          *ecdsa.PrivateKey
+	credentialID []byte
+	signCount    uint32
+}
+
+const testOrigin = "https://login.example.com"
+
+func (a *testAuthenticator) authData(attestedCredential bool) []byte {
+	rpIDHash := sha256.Sum256([]byte("login.example.com"))
+
+	// User present and user verified
+	flags := byte(0x01 | 0x04)
+	if attestedCredential {
+		flags |= 0x40
+	}
+
+	authData := append([]byte{}, rpIDHash[:]...)
+	authData = append(authData, flags)
+	authData = binary.BigEndian.AppendUint32(authData, a.signCount)
+
+	if attestedCredential {
+		authData = append(authData, make([]byte, 16)...)
+		authData = binary.BigEndian.AppendUint16(authData, uint16(len(a.credentialID)))
+		authData = append(authData, a.credentialID...)
+
+		// COSE EC2 key: {1: 2, 3: -7, -1: 1, -2: x, -3: y}
+		coseKey := []byte{0xa5, 0x01, 0x02, 0x03, 0x26, 0x20, 0x01, 0x21, 0x58, 0x20}
+		coseKey = append(coseKey, a.key.X.FillBytes(make([]byte, 32))...)
+		coseKey = append(coseKey, 0x22, 0x58, 0x20)
+		coseKey = append(coseKey, a.key.Y.FillBytes(make([]byte, 32))...)
+		authData = append(authData, coseKey...)
+	}
+
+	return authData
+}
+
+func (a *testAuthenticator) clientData(t *testing.T, ceremony string, options map[string]any) []byte {
+	publicKey := options["publicKey"].(map[string]any)
+
+	clientData, err := json.Marshal(map[string]any{
+		"type":      ceremony,
+		"challenge": publicKey["challenge"],
+		"origin":    testOrigin,
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return clientData
+}
+
+func (a *testAuthenticator) create(t *testing.T, options map[string]any) map[string]any {
+	authData := a.authData(true)
+
+	// CBOR: {"fmt": "none", "attStmt": {}, "authData": authData}
+	attestationObject := []byte{0xa3, 0x63, 'f', 'm', 't', 0x64, 'n', 'o', 'n', 'e', 0x67, 'a', 't', 't', 'S', 't', 'm', 't', 0xa0, 0x68, 'a', 'u', 't', 'h', 'D', 'a', 't', 'a', 0x59}
+	attestationObject = binary.BigEndian.AppendUint16(attestationObject, uint16(len(authData)))
+	attestationObject = append(attestationObject, authData...)
+
+	return map[string]any{
+		"id":    base64.RawURLEncoding.EncodeToString(a.credentialID),
+		"rawId": base64.RawURLEncoding.EncodeToString(a.credentialID),
+		"type":  "public-key",
+		"response": map[string]any{
+			"clientDataJSON":    base64.RawURLEncoding.EncodeToString(a.clientData(t, "webauthn.create", options)),
+			"attestationObject": base64.RawURLEncoding.EncodeToString(attestationObject),
+		},
+	}
+}
+
+func (a *testAuthenticator) get(t *testing.T, options map[string]any) map[string]any {
+	a.signCount++
+
+	authData := a.authData(false)
+	clientData := a.clientData(t, "webauthn.get", options)
+	clientDataHash := sha256.Sum256(clientData)
+
+	digest := sha256.Sum256(append(append([]byte{}, authData...), clientDataHash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return map[string]any{
+		"id":    base64.RawURLEncoding.EncodeToString(a.credentialID),
+		"rawId": base64.RawURLEncoding.EncodeToString(a.credentialID),
+		"type":  "public-key",
+		"response": map[string]any{
+			"clientDataJSON":    base64.RawURLEncoding.EncodeToString(clientData),
+			"authenticatorData": base64.RawURLEncoding.EncodeToString(authData),
+			"signature":         base64.RawURLEncoding.EncodeToString(signature),
+		},
+	}
+}
+
+func TestWebauthnProviderFlow(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	authenticator := testAuthenticator{
+		key:          key,
+		credentialID: []byte("test-credential-id"),
+	}
+
+	providerObj := object.Provider{
+		ID:           "test",
+		TenantID:     "test",
+		DisplayName:  "Test",
+		Category:     "mfa",
+		ProviderType: "webauthn",
+		Parameter:    json.RawMessage(`{"rp_id":"login.example.com","rp_origins":"https://login.example.com"}`),
+	}
+
+	provider, err := GetMFAProvider(providerObj)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = provider.ValidateConfigurationFields(); err != nil {
+		t.Fatal(err)
+	}
+
+	providerContext := ProviderContext{
+		Tenant: object.Tenant{ID: "test", DisplayName: "Test"},
+		User:   object.User{ID: "test", Username: "testuser"},
+	}
+
+	mfaData, err := provider.GenerateUserConfig(context.Background(), providerContext, nil)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Registration
+	providerContext.MFA = object.MFA{Properties: mfaData.Properties}
+	mfaData, options, err := provider.InitDataFlow(context.Background(), providerContext)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	providerContext.MFA.Properties = mfaData.Properties
+	success, mfaData, err := provider.ValidateDatFlow(context.Background(), providerContext, authenticator.create(t, options))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !success {
+		t.Fatal("registration failed")
+	}
+
+	var properties webauthnProperties
+
+	err = json.Unmarshal(mfaData.Properties, &properties)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(properties.CredentialID) != "test-credential-id" || properties.AttestationFormat != "none" || len(properties.PublicKey) == 0 {
+		t.Fatalf("credential was not stored: %s", mfaData.Properties)
+	}
+
+	// Login
+	providerContext.MFA.Properties = mfaData.Properties
+	mfaData, options, err = provider.InitDataFlow(context.Background(), providerContext)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	providerContext.MFA.Properties = mfaData.Properties
+	assertion := authenticator.get(t, options)
+	success, mfaData, err = provider.ValidateDatFlow(context.Background(), providerContext, assertion)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !success {
+		t.Fatal("login failed")
+	}
+
+	// The challenge can only be used once
+	providerContext.MFA.Properties = mfaData.Properties
+	success, _, err = provider.ValidateDatFlow(context.Background(), providerContext, assertion)
+
+	if err == nil || success {
+		t.Fatal("assertion was accepted twice")
+	}
+}
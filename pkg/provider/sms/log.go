@@ -0,0 +1,44 @@
+/*
+ * Copyright (C) 2025 Anthrove
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sms
+
+import (
+	"github.com/anthrove/identity/pkg/object"
+	"log"
+)
+
+// logProvider doesn't send any sms. The messages are written to the server log instead, so it should only be used for development.
+type logProvider struct {
+	provider object.Provider
+}
+
+func newLogProvider(provider object.Provider) (Provider, error) {
+	return logProvider{provider: provider}, nil
+}
+
+func (l logProvider) GetConfigurationFields() []object.ProviderConfigurationField {
+	return []object.ProviderConfigurationField{}
+}
+
+func (l logProvider) ValidateConfigurationFields() error {
+	return nil
+}
+
+func (l logProvider) SendSMS(toNumber string, message string) error {
+	log.Printf("sms to %s: %s", toNumber, message)
+	return nil
+}
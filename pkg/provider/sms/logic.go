@@ -0,0 +1,60 @@
+/*
+ * Copyright (C) 2025 Anthrove
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sms
+
+import (
+	"errors"
+	"github.com/anthrove/identity/pkg/object"
+	"maps"
+	"slices"
+)
+
+type Provider interface {
+	GetConfigurationFields() []object.ProviderConfigurationField
+	ValidateConfigurationFields() error
+	SendSMS(toNumber string, message string) error
+}
+
+var providerMap = map[string]func(provider object.Provider) (Provider, error){
+	"webhook": newWebhookProvider,
+	"log":     newLogProvider,
+}
+
+func GetSMSProvider(provider object.Provider) (Provider, error) {
+	newFunc, exists := providerMap[provider.ProviderType]
+
+	if !exists {
+		return nil, errors.New("unknown sms provider: " + provider.ProviderType)
+	}
+
+	return newFunc(provider)
+}
+
+func ConfigurationFields(providerType string) []object.ProviderConfigurationField {
+	switch providerType {
+	case "webhook":
+		return webhookProvider{}.GetConfigurationFields()
+	case "log":
+		return logProvider{}.GetConfigurationFields()
+	}
+
+	return nil
+}
+
+func GetSMSTypes() []string {
+	return slices.Collect(maps.Keys(providerMap))
+}
@@ -0,0 +1,136 @@
+/*
+ * Copyright (C) 2025 Anthrove
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sms
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/anthrove/identity/pkg/object"
+	"github.com/go-playground/validator/v10"
+	"net/http"
+	"time"
+)
+
+const webhookDefaultTimeout = 10
+
+type webhookConfiguration struct {
+	URL           string `json:"url" validate:"required,url,max=500"`
+	Authorization string `json:"authorization" validate:"max=500"`
+	Timeout       int    `json:"timeout" validate:"omitempty,min=1,max=60"`
+}
+
+// webhookBody is send as JSON to the configured url, so any sms gateway can be connected with a small adapter.
+type webhookBody struct {
+	To      string `json:"to"`
+	Message string `json:"message"`
+}
+
+type webhookProvider struct {
+	provider object.Provider
+}
+
+func newWebhookProvider(provider object.Provider) (Provider, error) {
+	return webhookProvider{provider: provider}, nil
+}
+
+func (w webhookProvider) GetConfigurationFields() []object.ProviderConfigurationField {
+	return []object.ProviderConfigurationField{
+		{
+			FieldKey:  "url",
+			FieldType: "text",
+		},
+		{
+			FieldKey:  "authorization",
+			FieldType: "secret",
+		},
+		{
+			FieldKey:  "timeout",
+			FieldType: "int",
+		},
+	}
+}
+
+func (w webhookProvider) ValidateConfigurationFields() error {
+	webhookConfig := webhookConfiguration{}
+
+	err := json.Unmarshal(w.provider.Parameter, &webhookConfig)
+	if err != nil {
+		return err
+	}
+
+	// use a single instance of Validate, it caches struct info
+	validate := validator.New(validator.WithRequiredStructEnabled())
+	err = validate.Struct(webhookConfig)
+	if err != nil {
+		var validateErrs validator.ValidationErrors
+		if errors.As(err, &validateErrs) {
+			return errors.Join(fmt.Errorf("problem while validating webhook configuration"), validateErrs)
+		}
+	}
+
+	return nil
+}
+
+func (w webhookProvider) SendSMS(toNumber string, message string) error {
+	webhookConfig := webhookConfiguration{}
+
+	err := json.Unmarshal(w.provider.Parameter, &webhookConfig)
+	if err != nil {
+		return err
+	}
+
+	if webhookConfig.Timeout == 0 {
+		webhookConfig.Timeout = webhookDefaultTimeout
+	}
+
+	body, err := json.Marshal(webhookBody{
+		To:      toNumber,
+		Message: message,
+	})
+	if err != nil {
+		return err
+	}
+
+	request, err := http.NewRequest(http.MethodPost, webhookConfig.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+
+	if len(webhookConfig.Authorization) > 0 {
+		request.Header.Set("Authorization", webhookConfig.Authorization)
+	}
+
+	client := http.Client{
+		Timeout: time.Duration(webhookConfig.Timeout) * time.Second,
+	}
+
+	response, err := client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return fmt.Errorf("sms webhook responded with status %d", response.StatusCode)
+	}
+
+	return nil
+}
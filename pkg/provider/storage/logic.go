@@ -24,6 +24,7 @@ import (
 	"maps"
 	"os"
 	"slices"
+	"time"
 )
 
 type Provider interface {
@@ -38,6 +39,20 @@ type Provider interface {
 	GetURL(path string) (string, error)
 }
 
+// PrivateProvider is implemented by providers whose files can be downloaded directly from the provider.
+// Files of resources which are not public are stored private there and handed out with presigned URLs.
+type PrivateProvider interface {
+	PutPrivate(path string, reader io.Reader) (*oss.Object, error)
+	SetPrivate(path string, private bool) error
+	PresignURL(path string, expiry time.Duration) (string, error)
+}
+
+// RangeProvider is implemented by remote providers which can download a file from an offset, so range requests don't
+// have to download the file up to the requested range.
+type RangeProvider interface {
+	GetRange(path string, offset int64) (io.ReadCloser, error)
+}
+
 var providerMap = map[string]func(provider object.Provider) (Provider, error){
 	"local": newLocalProvider,
 	"s3":    newS3Provider,
@@ -17,13 +17,20 @@
 package storage
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/anthrove/identity/pkg/object"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awss3 "github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 	"github.com/aws/aws-sdk-go/service/s3control"
 	"github.com/go-playground/validator/v10"
+	"github.com/qor/oss"
 	"github.com/qor/oss/s3"
+	"io"
+	"time"
 )
 
 type s3Configuration struct {
@@ -118,3 +125,59 @@ func (s s3Provider) ValidateConfigurationFields() error {
 
 	return nil
 }
+
+// PutPrivate uploads a file which can't be read by its URL without a presigned URL.
+func (s s3Provider) PutPrivate(path string, reader io.Reader) (*oss.Object, error) {
+	config := *s.Config
+	config.ACL = types.ObjectCannedACLPrivate
+
+	client := s.Client
+	client.Config = &config
+
+	return client.Put(path, reader)
+}
+
+// SetPrivate changes if an uploaded file can be read by its URL.
+func (s s3Provider) SetPrivate(path string, private bool) error {
+	acl := types.ObjectCannedACLPublicRead
+	if private {
+		acl = types.ObjectCannedACLPrivate
+	}
+
+	_, err := s.S3.PutObjectAcl(context.Background(), &awss3.PutObjectAclInput{
+		Bucket: aws.String(s.Config.Bucket),
+		Key:    aws.String(s.ToS3Key(path)),
+		ACL:    acl,
+	})
+
+	return err
+}
+
+// PresignURL creates a URL which can download a private file until it expires.
+func (s s3Provider) PresignURL(path string, expiry time.Duration) (string, error) {
+	request, err := awss3.NewPresignClient(s.S3).PresignGetObject(context.Background(), &awss3.GetObjectInput{
+		Bucket: aws.String(s.Config.Bucket),
+		Key:    aws.String(s.ToS3Key(path)),
+	}, awss3.WithPresignExpires(expiry))
+
+	if err != nil {
+		return "", err
+	}
+
+	return request.URL, nil
+}
+
+// GetRange downloads a file from the offset to its end with a ranged GET.
+func (s s3Provider) GetRange(path string, offset int64) (io.ReadCloser, error) {
+	output, err := s.S3.GetObject(context.Background(), &awss3.GetObjectInput{
+		Bucket: aws.String(s.Config.Bucket),
+		Key:    aws.String(s.ToS3Key(path)),
+		Range:  aws.String(fmt.Sprintf("bytes=%d-", offset)),
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return output.Body, nil
+}
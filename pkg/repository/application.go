@@ -32,6 +32,9 @@ func CreateApplication(ctx context.Context, db *gorm.DB, tenantId string, create
 		ForgetURL:    createApplication.ForgetURL,
 		TermsURL:     createApplication.TermsURL,
 		RedirectURLs: createApplication.RedirectURLs,
+
+		RequireFreshMFA: createApplication.RequireFreshMFA,
+		ConsentRequired: createApplication.ConsentRequired,
 	}
 
 	err := db.WithContext(ctx).Model(&object.Application{}).Create(&application).Error
@@ -49,9 +52,21 @@ func UpdateApplication(ctx context.Context, db *gorm.DB, tenantID string, applic
 		ForgetURL:    updateApplication.ForgetURL,
 		TermsURL:     updateApplication.TermsURL,
 		RedirectURLs: updateApplication.RedirectURLs,
+
+		RequireFreshMFA: updateApplication.RequireFreshMFA,
+		ConsentRequired: updateApplication.ConsentRequired,
+	}
+
+	fields := []string{"DisplayName", "Logo", "SignInURL", "SignUpURL", "ForgetURL", "TermsURL", "RedirectURLs", "RequireFreshMFA", "ConsentRequired"}
+
+	if updateApplication.Branding != nil {
+		application.Branding = *updateApplication.Branding
+		fields = append(fields, "Branding")
 	}
 
-	err := db.WithContext(ctx).Model(&object.Application{}).Where("id = ? AND tenant_id = ?", applicationID, tenantID).Updates(&application).Error
+	// select the fields explicitly, so zero values like disabling fresh MFA are stored as well
+	err := db.WithContext(ctx).Model(&object.Application{}).Where("id = ? AND tenant_id = ?", applicationID, tenantID).
+		Select(fields).Updates(&application).Error
 
 	return err
 }
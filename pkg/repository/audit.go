@@ -0,0 +1,84 @@
+/*
+ * Copyright (C) 2025 Anthrove
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package repository
+
+import (
+	"context"
+	"github.com/anthrove/identity/pkg/object"
+	"gorm.io/gorm"
+)
+
+// CreateAuditEvent stores a new audit event within a specified tenant in the database.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancelation, and deadlines.
+//   - db: a gorm.DB instance representing the database connection.
+//   - tenantID: unique identifier of the tenant to which the event belongs.
+//   - createAuditEvent: object containing the details of the event.
+//
+// Returns:
+//   - AuditEvent object if creation is successful.
+//   - Error if there is any issue during creation.
+func CreateAuditEvent(ctx context.Context, db *gorm.DB, tenantID string, createAuditEvent object.CreateAuditEvent) (object.AuditEvent, error) {
+	auditEvent := object.AuditEvent{
+		TenantID:  tenantID,
+		Type:      createAuditEvent.Type,
+		UserID:    createAuditEvent.UserID,
+		ActorID:   createAuditEvent.ActorID,
+		IPAddress: createAuditEvent.IPAddress,
+		UserAgent: createAuditEvent.UserAgent,
+		Details:   createAuditEvent.Details,
+	}
+
+	err := db.WithContext(ctx).Model(&object.AuditEvent{}).Create(&auditEvent).Error
+
+	return auditEvent, err
+}
+
+// FindAuditEvents retrieves the audit events within a specified tenant from the database, newest first and with pagination support.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancelation, and deadlines.
+//   - db: a gorm.DB instance representing the database connection.
+//   - tenantID: unique identifier of the tenant to which the events belong.
+//   - pagination: object containing pagination details (limit and page).
+//
+// Returns:
+//   - Slice of AuditEvent objects if retrieval is successful.
+//   - Error if there is any issue during retrieval.
+func FindAuditEvents(ctx context.Context, db *gorm.DB, tenantID string, pagination object.Pagination) ([]object.AuditEvent, error) {
+	var data []object.AuditEvent
+	err := db.WithContext(ctx).Scopes(Pagination(pagination)).Where("tenant_id = ?", tenantID).Order("created_at DESC").Find(&data).Error
+	return data, err
+}
+
+// FindUserAuditEvents retrieves all audit events about a user or caused by a user, newest first.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancelation, and deadlines.
+//   - db: a gorm.DB instance representing the database connection.
+//   - tenantID: unique identifier of the tenant to which the events belong.
+//   - userID: unique identifier of the user.
+//
+// Returns:
+//   - Slice of AuditEvent objects if retrieval is successful.
+//   - Error if there is any issue during retrieval.
+func FindUserAuditEvents(ctx context.Context, db *gorm.DB, tenantID string, userID string) ([]object.AuditEvent, error) {
+	var data []object.AuditEvent
+	err := db.WithContext(ctx).Where("tenant_id = ? AND (user_id = ? OR actor_id = ?)", tenantID, userID, userID).Order("created_at DESC").Find(&data).Error
+	return data, err
+}
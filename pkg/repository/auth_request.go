@@ -53,7 +53,11 @@ func UpdateAuthRequest(ctx context.Context, db *gorm.DB, tenantID string, authRe
 		TenantID:        tenantID,
 		UserID:          updateAuthRequest.UserID,
 		Authenticated:   updateAuthRequest.Authenticated,
-		AuthenticatedAt: time.Now(),
+		AuthenticatedAt: updateAuthRequest.AuthenticatedAt,
+	}
+
+	if authRequest.AuthenticatedAt.IsZero() {
+		authRequest.AuthenticatedAt = time.Now()
 	}
 
 	err := db.WithContext(ctx).Model(&object.AuthRequest{}).Where("id = ? AND tenant_id = ?", authRequestID, tenantID).Updates(&authRequest).Error
@@ -0,0 +1,100 @@
+/*
+ * Copyright (C) 2025 Anthrove
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package repository
+
+import (
+	"context"
+	"github.com/anthrove/identity/pkg/object"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// FindConsent retrieves the consent a user gave to an application.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancelation, and deadlines.
+//   - db: a gorm.DB instance representing the database connection.
+//   - tenantID: unique identifier of the tenant to which the user belongs.
+//   - userID: unique identifier of the user who gave the consent.
+//   - applicationID: unique identifier of the application the consent was given to.
+//
+// Returns:
+//   - Consent object if retrieval is successful.
+//   - Error if there is any issue during retrieval.
+func FindConsent(ctx context.Context, db *gorm.DB, tenantID string, userID string, applicationID string) (object.Consent, error) {
+	var consent object.Consent
+	err := db.WithContext(ctx).Take(&consent, "tenant_id = ? AND user_id = ? AND application_id = ?", tenantID, userID, applicationID).Error
+	return consent, err
+}
+
+// SaveConsent stores the granted scopes of a user for an application, a previous consent is replaced.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancelation, and deadlines.
+//   - db: a gorm.DB instance representing the database connection.
+//   - tenantID: unique identifier of the tenant to which the user belongs.
+//   - userID: unique identifier of the user who gave the consent.
+//   - applicationID: unique identifier of the application the consent was given to.
+//   - scopes: the granted scopes.
+//
+// Returns:
+//   - Error if there is any issue during saving.
+func SaveConsent(ctx context.Context, db *gorm.DB, tenantID string, userID string, applicationID string, scopes []string) error {
+	consent := object.Consent{
+		TenantID:      tenantID,
+		UserID:        userID,
+		ApplicationID: applicationID,
+		Scopes:        scopes,
+	}
+
+	return db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "tenant_id"}, {Name: "user_id"}, {Name: "application_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"scopes", "updated_at"}),
+	}).Create(&consent).Error
+}
+
+// FindConsents retrieves all consents a user gave to applications.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancelation, and deadlines.
+//   - db: a gorm.DB instance representing the database connection.
+//   - tenantID: unique identifier of the tenant to which the user belongs.
+//   - userID: unique identifier of the user who gave the consents.
+//
+// Returns:
+//   - Slice of Consent objects if retrieval is successful.
+//   - Error if there is any issue during retrieval.
+func FindConsents(ctx context.Context, db *gorm.DB, tenantID string, userID string) ([]object.Consent, error) {
+	var data []object.Consent
+	err := db.WithContext(ctx).Where("tenant_id = ? AND user_id = ?", tenantID, userID).Order("updated_at DESC").Find(&data).Error
+	return data, err
+}
+
+// KillConsent deletes the consent a user gave to an application.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancelation, and deadlines.
+//   - db: a gorm.DB instance representing the database connection.
+//   - tenantID: unique identifier of the tenant to which the user belongs.
+//   - userID: unique identifier of the user who gave the consent.
+//   - applicationID: unique identifier of the application the consent was given to.
+//
+// Returns:
+//   - Error if there is any issue during deletion.
+func KillConsent(ctx context.Context, db *gorm.DB, tenantID string, userID string, applicationID string) error {
+	return db.WithContext(ctx).Delete(&object.Consent{}, "tenant_id = ? AND user_id = ? AND application_id = ?", tenantID, userID, applicationID).Error
+}
@@ -17,13 +17,16 @@
 package repository
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
 	"github.com/anthrove/identity/internal/config"
 	"github.com/anthrove/identity/pkg/object"
 	"github.com/caarlos0/env/v11"
 	"gorm.io/driver/postgres"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
+	"log"
 )
 
 // GetEngine initializes and returns a database connection based on the configuration.
@@ -71,7 +74,7 @@ func GetEngine() (*gorm.DB, error) {
 // Returns:
 //   - An error if there is any issue during the migration.
 func Migrate(engine *gorm.DB) error {
-	return engine.AutoMigrate(&object.Tenant{},
+	err := engine.AutoMigrate(&object.Tenant{},
 		&object.Group{},
 		&object.User{},
 		&object.Provider{},
@@ -88,5 +91,116 @@ func Migrate(engine *gorm.DB) error {
 		&object.Credentials{},
 		&object.MFA{},
 		&object.ProfilePage{},
+		&object.ProfileAttribute{},
+		&object.TrustedDevice{},
+		&object.Lockout{},
+		&object.PushApproval{},
+		&object.ResourceMigrationLock{},
+		&object.AuditEvent{},
+		&object.PasswordReset{},
+		&object.Invitation{},
+		&object.Consent{},
 	)
+
+	if err != nil {
+		return err
+	}
+
+	err = backfillLockoutPolicy(engine)
+	if err != nil {
+		return err
+	}
+
+	return backfillUniqueProfileAttributes(engine)
+}
+
+// backfillLockoutPolicy sets the DefaultLockoutPolicy for tenants which were created before tenants had a lockout policy.
+// A policy saved with zero values disables the throttling on purpose and is kept.
+//
+// Parameters:
+//   - engine: a gorm.DB instance representing the database connection.
+//
+// Returns:
+//   - An error if there is any issue during the update.
+func backfillLockoutPolicy(engine *gorm.DB) error {
+	policy, err := json.Marshal(object.DefaultLockoutPolicy)
+	if err != nil {
+		return err
+	}
+
+	return engine.Model(&object.Tenant{}).Where("lockout_policy IS NULL OR lockout_policy = ''").UpdateColumn("lockout_policy", string(policy)).Error
+}
+
+// backfillUniqueProfileAttributes sets the unique value of lookup index entries which were stored before unique
+// fields were enforced by the database. Values which are already used by several users are logged and skipped, so
+// the server still starts. They stay unenforced until the users change them.
+//
+// Parameters:
+//   - engine: a gorm.DB instance representing the database connection.
+//
+// Returns:
+//   - An error if there is any issue during the update.
+func backfillUniqueProfileAttributes(engine *gorm.DB) error {
+	var tenants []object.Tenant
+	err := engine.Select("id", "profile_fields").Find(&tenants).Error
+	if err != nil {
+		return err
+	}
+
+	for _, tenant := range tenants {
+		for _, field := range tenant.ProfileFields {
+			if !field.Unique {
+				continue
+			}
+
+			duplicates := engine.Model(&object.ProfileAttribute{}).
+				Select("value").
+				Where("tenant_id = ? AND identifier = ?", tenant.ID, field.Identifier).
+				Group("value").
+				Having("COUNT(*) > 1")
+
+			var conflicts []string
+			err = duplicates.Session(&gorm.Session{}).Pluck("value", &conflicts).Error
+			if err != nil {
+				return err
+			}
+
+			if len(conflicts) > 0 {
+				log.Printf("unique profile field %s of tenant %s has values which are used by several users (%d), they are not enforced until the users change them", field.Identifier, tenant.ID, len(conflicts))
+			}
+
+			err = engine.Model(&object.ProfileAttribute{}).
+				Where("tenant_id = ? AND identifier = ? AND unique_value IS NULL AND value NOT IN (?)", tenant.ID, field.Identifier, duplicates).
+				UpdateColumn("unique_value", gorm.Expr("value")).Error
+
+			if err != nil {
+				return errors.Join(fmt.Errorf("problem while backfilling the unique values of profile field %s of tenant %s", field.Identifier, tenant.ID), translateError(engine, err))
+			}
+		}
+	}
+
+	return nil
+}
+
+// translateError maps an error of the database driver to the matching gorm error, e.g. gorm.ErrDuplicatedKey for
+// unique constraints. The engine doesn't translate errors itself, so the other errors keep their driver messages.
+//
+// Parameters:
+//   - db: a gorm.DB instance representing the database connection.
+//   - err: the error returned by the database.
+//
+// Returns:
+//   - The gorm error joined with the original error, or the original error if there is no matching gorm error.
+func translateError(db *gorm.DB, err error) error {
+	translator, ok := db.Dialector.(gorm.ErrorTranslator)
+	if err == nil || !ok {
+		return err
+	}
+
+	translated := translator.Translate(err)
+	if errors.Is(err, translated) {
+		return err
+	}
+
+	return errors.Join(translated, err)
 }
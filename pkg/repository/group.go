@@ -152,3 +152,25 @@ func FindUsersInGroup(ctx context.Context, db *gorm.DB, tenantID string, groupID
 
 	return users, err
 }
+
+// IsUserInGroup reports if a user is a member of a group.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancellation, and deadlines.
+//   - db: a gorm.DB instance representing the database connection.
+//   - tenantID: unique identifier of the tenant to which the group belongs.
+//   - userID: unique identifier of the user.
+//   - groupID: unique identifier of the group.
+//
+// Returns:
+//   - True if the user is a member of the group.
+//   - Error if there is any issue during retrieval.
+func IsUserInGroup(ctx context.Context, db *gorm.DB, tenantID string, userID string, groupID string) (bool, error) {
+	var count int64
+	err := db.WithContext(ctx).Table("user_groups").
+		Joins("JOIN groups ON groups.id = user_groups.group_id").
+		Where("groups.tenant_id = ? AND user_groups.user_id = ? AND user_groups.group_id = ?", tenantID, userID, groupID).
+		Count(&count).Error
+
+	return count > 0, err
+}
@@ -0,0 +1,128 @@
+/*
+ * Copyright (C) 2025 Anthrove
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package repository
+
+import (
+	"context"
+	"github.com/anthrove/identity/pkg/object"
+	"gorm.io/gorm"
+	"time"
+)
+
+// CreateInvitation creates a new invitation within a specified tenant in the database.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancelation, and deadlines.
+//   - db: a gorm.DB instance representing the database connection.
+//   - tenantID: unique identifier of the tenant to which the invitation belongs.
+//   - createInvitation: object containing the details of the invitation to be created.
+//
+// Returns:
+//   - Invitation object if creation is successful.
+//   - Error if there is any issue during creation.
+func CreateInvitation(ctx context.Context, db *gorm.DB, tenantID string, createInvitation object.CreateInvitation) (object.Invitation, error) {
+	invitation := object.Invitation{
+		TenantID:  tenantID,
+		Email:     createInvitation.Email,
+		ExpiresAt: createInvitation.ExpiresAt,
+	}
+
+	err := db.WithContext(ctx).Model(&object.Invitation{}).Create(&invitation).Error
+
+	return invitation, err
+}
+
+// KillInvitation deletes an invitation within a specified tenant from the database.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancelation, and deadlines.
+//   - db: a gorm.DB instance representing the database connection.
+//   - tenantID: unique identifier of the tenant to which the invitation belongs.
+//   - invitationID: unique identifier of the invitation to be deleted.
+//
+// Returns:
+//   - Error if there is any issue during deletion.
+func KillInvitation(ctx context.Context, db *gorm.DB, tenantID string, invitationID string) error {
+	return db.WithContext(ctx).Delete(&object.Invitation{}, "id = ? AND tenant_id = ?", invitationID, tenantID).Error
+}
+
+// FindInvitation retrieves an invitation by its ID.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancelation, and deadlines.
+//   - db: a gorm.DB instance representing the database connection.
+//   - tenantID: unique identifier of the tenant to which the invitation belongs.
+//   - invitationID: unique identifier of the invitation.
+//
+// Returns:
+//   - Invitation object if retrieval is successful.
+//   - Error if there is any issue during retrieval.
+func FindInvitation(ctx context.Context, db *gorm.DB, tenantID string, invitationID string) (object.Invitation, error) {
+	var invitation object.Invitation
+	err := db.WithContext(ctx).Take(&invitation, "id = ? AND tenant_id = ?", invitationID, tenantID).Error
+	return invitation, err
+}
+
+// FindInvitations retrieves a list of invitations within a specified tenant from the database, with pagination support.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancelation, and deadlines.
+//   - db: a gorm.DB instance representing the database connection.
+//   - tenantID: unique identifier of the tenant to which the invitations belong.
+//   - pagination: object containing pagination details (limit and page).
+//
+// Returns:
+//   - Slice of Invitation objects if retrieval is successful.
+//   - Error if there is any issue during retrieval.
+func FindInvitations(ctx context.Context, db *gorm.DB, tenantID string, pagination object.Pagination) ([]object.Invitation, error) {
+	var data []object.Invitation
+	err := db.WithContext(ctx).Scopes(Pagination(pagination)).Where("tenant_id = ?", tenantID).Find(&data).Error
+	return data, err
+}
+
+// UseInvitation marks a not expired invitation as used by the user. Only the first call for an invitation succeeds,
+// so an invitation can't be used for two accounts, even by concurrent requests.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancelation, and deadlines.
+//   - db: a gorm.DB instance representing the database connection.
+//   - tenantID: unique identifier of the tenant to which the invitation belongs.
+//   - invitationID: unique identifier of the invitation.
+//   - userID: unique identifier of the user who signed up with the invitation.
+//
+// Returns:
+//   - Error if the invitation was already used, is expired or there is any issue during updating.
+func UseInvitation(ctx context.Context, db *gorm.DB, tenantID string, invitationID string, userID string) error {
+	now := time.Now()
+
+	result := db.WithContext(ctx).Model(&object.Invitation{}).
+		Where("id = ? AND tenant_id = ? AND used_at IS NULL AND expires_at > ?", invitationID, tenantID, now).
+		Updates(map[string]any{
+			"used_at": now,
+			"user_id": userID,
+		})
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,177 @@
+/*
+ * Copyright (C) 2025 Anthrove
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package repository
+
+import (
+	"context"
+	"github.com/anthrove/identity/pkg/object"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+// RecordLockoutFailure atomically increments the failure counter of a key within a specified tenant in the database.
+// The counter starts again at one, if the last failure happened before the window started.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancelation, and deadlines.
+//   - db: a gorm.DB instance representing the database connection.
+//   - tenantID: unique identifier of the tenant to which the counter belongs.
+//   - lockout: object containing the key, scope, user and IP address of the counter.
+//   - windowStart: failures before this time are forgotten.
+//
+// Returns:
+//   - Lockout object with the incremented counter if successful.
+//   - Error if there is any issue during updating.
+func RecordLockoutFailure(ctx context.Context, db *gorm.DB, tenantID string, lockout object.Lockout, windowStart time.Time) (object.Lockout, error) {
+	now := time.Now()
+
+	lockout.TenantID = tenantID
+	lockout.Failures = 1
+	lockout.LastFailureAt = now
+
+	err := db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "tenant_id"}, {Name: "key"}},
+		DoUpdates: clause.Assignments(map[string]any{
+			"failures":        gorm.Expr("CASE WHEN lockouts.last_failure_at < ? THEN 1 ELSE lockouts.failures + 1 END", windowStart),
+			"last_failure_at": now,
+			"updated_at":      now,
+		}),
+	}).Create(&lockout).Error
+
+	if err != nil {
+		return object.Lockout{}, err
+	}
+
+	return FindLockoutByKey(ctx, db, tenantID, lockout.Key)
+}
+
+// UpdateLockoutLockedUntil locks a key until the given time. An existing longer lock is kept.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancelation, and deadlines.
+//   - db: a gorm.DB instance representing the database connection.
+//   - tenantID: unique identifier of the tenant to which the counter belongs.
+//   - key: key of the counter to be locked.
+//   - lockedUntil: time until no further attempts are allowed.
+//
+// Returns:
+//   - Error if there is any issue during updating.
+func UpdateLockoutLockedUntil(ctx context.Context, db *gorm.DB, tenantID string, key string, lockedUntil time.Time) error {
+	return db.WithContext(ctx).Model(&object.Lockout{}).Where("tenant_id = ? AND key = ? AND locked_until < ?", tenantID, key, lockedUntil).Update("locked_until", lockedUntil).Error
+}
+
+// FindLockoutByKey retrieves the counter of a key within a specified tenant from the database.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancelation, and deadlines.
+//   - db: a gorm.DB instance representing the database connection.
+//   - tenantID: unique identifier of the tenant to which the counter belongs.
+//   - key: key of the counter to be retrieved.
+//
+// Returns:
+//   - Lockout object if retrieval is successful.
+//   - Error if there is any issue during retrieval.
+func FindLockoutByKey(ctx context.Context, db *gorm.DB, tenantID string, key string) (object.Lockout, error) {
+	var lockout object.Lockout
+	err := db.WithContext(ctx).Take(&lockout, "tenant_id = ? AND key = ?", tenantID, key).Error
+	return lockout, err
+}
+
+// FindActiveLockouts retrieves the counters of the given keys which are currently locked.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancelation, and deadlines.
+//   - db: a gorm.DB instance representing the database connection.
+//   - tenantID: unique identifier of the tenant to which the counters belong.
+//   - keys: keys of the counters to be checked.
+//
+// Returns:
+//   - Slice of locked Lockout objects if retrieval is successful.
+//   - Error if there is any issue during retrieval.
+func FindActiveLockouts(ctx context.Context, db *gorm.DB, tenantID string, keys []string) ([]object.Lockout, error) {
+	var data []object.Lockout
+	err := db.WithContext(ctx).Where("tenant_id = ? AND key IN ? AND locked_until > ?", tenantID, keys, time.Now()).Order("locked_until DESC").Find(&data).Error
+	return data, err
+}
+
+// FindLockouts retrieves a list of counters within a specified tenant from the database, with pagination support.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancelation, and deadlines.
+//   - db: a gorm.DB instance representing the database connection.
+//   - tenantID: unique identifier of the tenant to which the counters belong.
+//   - pagination: object containing pagination details (limit and page).
+//
+// Returns:
+//   - Slice of Lockout objects if retrieval is successful.
+//   - Error if there is any issue during retrieval.
+func FindLockouts(ctx context.Context, db *gorm.DB, tenantID string, pagination object.Pagination) ([]object.Lockout, error) {
+	var data []object.Lockout
+	err := db.WithContext(ctx).Scopes(Pagination(pagination)).Where("tenant_id = ?", tenantID).Order("last_failure_at DESC").Find(&data).Error
+	return data, err
+}
+
+// KillLockoutByKey deletes the counter of a key within a specified tenant from the database.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancelation, and deadlines.
+//   - db: a gorm.DB instance representing the database connection.
+//   - tenantID: unique identifier of the tenant to which the counter belongs.
+//   - key: key of the counter to be deleted.
+//
+// Returns:
+//   - Error if there is any issue during deletion.
+func KillLockoutByKey(ctx context.Context, db *gorm.DB, tenantID string, key string) error {
+	return db.WithContext(ctx).Delete(&object.Lockout{}, "tenant_id = ? AND key = ?", tenantID, key).Error
+}
+
+// KillLockout deletes a counter within a specified tenant from the database.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancelation, and deadlines.
+//   - db: a gorm.DB instance representing the database connection.
+//   - tenantID: unique identifier of the tenant to which the counter belongs.
+//   - lockoutID: unique identifier of the counter to be deleted.
+//
+// Returns:
+//   - Lockout object which was deleted.
+//   - Error if there is any issue during deletion.
+func KillLockout(ctx context.Context, db *gorm.DB, tenantID string, lockoutID string) (object.Lockout, error) {
+	var lockout object.Lockout
+	err := db.WithContext(ctx).Take(&lockout, "id = ? AND tenant_id = ?", lockoutID, tenantID).Error
+
+	if err != nil {
+		return object.Lockout{}, err
+	}
+
+	return lockout, db.WithContext(ctx).Delete(&object.Lockout{}, "id = ? AND tenant_id = ?", lockoutID, tenantID).Error
+}
+
+// KillUserLockouts deletes all counters of a user within a specified tenant from the database.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancelation, and deadlines.
+//   - db: a gorm.DB instance representing the database connection.
+//   - tenantID: unique identifier of the tenant to which the counters belong.
+//   - userID: unique identifier of the user whose counters are deleted.
+//
+// Returns:
+//   - Error if there is any issue during deletion.
+func KillUserLockouts(ctx context.Context, db *gorm.DB, tenantID string, userID string) error {
+	return db.WithContext(ctx).Delete(&object.Lockout{}, "tenant_id = ? AND user_id = ?", tenantID, userID).Error
+}
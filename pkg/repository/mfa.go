@@ -18,8 +18,10 @@ package repository
 
 import (
 	"context"
+	"encoding/json"
 	"github.com/anthrove/identity/pkg/object"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // CreateMFA creates a new MFA for a specific User within a specified tenant in the database.
@@ -103,6 +105,24 @@ func FindMFA(ctx context.Context, db *gorm.DB, tenantID string, userID string, m
 	return mfa, err
 }
 
+// LockMFA retrieves a specific MFA within a specified tenant and locks it until the end of the transaction, so
+// parallel requests can't use the same recovery code.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancelation, and deadlines.
+//   - db: a gorm.DB transaction.
+//   - userID: unique identifier of the user to which the MFAs belong
+//   - mfaID: unique identifier of the MFA to be retrieved.
+//
+// Returns:
+//   - MFA object if retrieval is successful.
+//   - Error if there is any issue during retrieval.
+func LockMFA(ctx context.Context, db *gorm.DB, tenantID string, userID string, mfaID string) (object.MFA, error) {
+	var mfa object.MFA
+	err := db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).Take(&mfa, "id = ? AND user_id = ?", mfaID, userID).Error
+	return mfa, err
+}
+
 // FindMFAs retrieves a list of MFAs within a specified tenant from the database, with pagination support.
 //
 // Parameters:
@@ -120,6 +140,22 @@ func FindMFAs(ctx context.Context, db *gorm.DB, tenantID string, userID string,
 	return data, err
 }
 
+// FindVerifiedMFAs retrieves all verified MFAs of a user, ordered by their priority.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancelation, and deadlines.
+//   - db: a gorm.DB instance representing the database connection.
+//   - userID: unique identifier of the user to which the MFAs belong
+//
+// Returns:
+//   - Slice of MFA objects if retrieval is successful.
+//   - Error if there is any issue during retrieval.
+func FindVerifiedMFAs(ctx context.Context, db *gorm.DB, tenantID string, userID string) ([]object.MFA, error) {
+	var data []object.MFA
+	err := db.WithContext(ctx).Where("user_id = ? AND verified = ?", userID, true).Order("priority").Find(&data).Error
+	return data, err
+}
+
 // VerifieMFA updates the verification status of an existing MFA within a specified tenant in the database.
 //
 // Parameters:
@@ -163,3 +199,51 @@ func UpdateMFARecoveryCodes(ctx context.Context, db *gorm.DB, tenantID string, u
 
 	return err
 }
+
+// UpdateMFALastCounter stores the last accepted counter of an existing MFA within a specified tenant in the database.
+// The counter is only updated if it is greater than the stored one, so two requests with the same code can't both succeed.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancelation, and deadlines.
+//   - db: a gorm.DB instance representing the database connection.
+//   - mfaID: unique identifier of the MFA to be updated.
+//   - userID: unique identifier of the user to which the MFAs belong.
+//   - counter: the counter of the accepted code.
+//
+// Returns:
+//   - Boolean indicating if the counter was updated.
+//   - Error if there is any issue during updating.
+func UpdateMFALastCounter(ctx context.Context, db *gorm.DB, tenantID string, userID string, mfaID string, counter int64) (bool, error) {
+	result := db.WithContext(ctx).Model(&object.MFA{}).Where("id = ? AND user_id = ? AND last_counter < ?", mfaID, userID, counter).Update("last_counter", counter)
+
+	return result.RowsAffected > 0, result.Error
+}
+
+// UpdateMFAProperties updates the provider properties of an existing MFA within a specified tenant in the database.
+// The properties are only updated if they still match the previously read ones, so concurrent requests can't overwrite
+// each other, e.g. to get more attempts or use the same code twice.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancelation, and deadlines.
+//   - db: a gorm.DB instance representing the database connection.
+//   - mfaID: unique identifier of the MFA to be updated.
+//   - userID: unique identifier of the user to which the MFAs belong.
+//   - oldProperties: the properties the new ones are based on.
+//   - properties: the new properties of the MFA provider.
+//
+// Returns:
+//   - Boolean indicating if the properties were updated.
+//   - Error if there is any issue during updating.
+func UpdateMFAProperties(ctx context.Context, db *gorm.DB, tenantID string, userID string, mfaID string, oldProperties json.RawMessage, properties json.RawMessage) (bool, error) {
+	query := db.WithContext(ctx).Model(&object.MFA{}).Where("id = ? AND user_id = ?", mfaID, userID)
+
+	if len(oldProperties) == 0 {
+		query = query.Where("properties IS NULL OR properties = ?", []byte{})
+	} else {
+		query = query.Where("properties = ?", []byte(oldProperties))
+	}
+
+	result := query.Update("properties", properties)
+
+	return result.RowsAffected > 0, result.Error
+}
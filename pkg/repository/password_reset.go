@@ -0,0 +1,130 @@
+/*
+ * Copyright (C) 2025 Anthrove
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package repository
+
+import (
+	"context"
+	"github.com/anthrove/identity/pkg/object"
+	"gorm.io/gorm"
+	"time"
+)
+
+// CreatePasswordReset creates a new password reset for a specific user within a specified tenant in the database.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancelation, and deadlines.
+//   - db: a gorm.DB instance representing the database connection.
+//   - tenantID: unique identifier of the tenant to which the user belongs.
+//   - userID: unique identifier of the user whose password is reset.
+//   - createPasswordReset: object containing the details of the password reset to be created.
+//
+// Returns:
+//   - PasswordReset object if creation is successful.
+//   - Error if there is any issue during creation.
+func CreatePasswordReset(ctx context.Context, db *gorm.DB, tenantID string, userID string, createPasswordReset object.CreatePasswordReset) (object.PasswordReset, error) {
+	passwordReset := object.PasswordReset{
+		TenantID:      tenantID,
+		ApplicationID: createPasswordReset.ApplicationID,
+		UserID:        userID,
+		ExpiresAt:     createPasswordReset.ExpiresAt,
+		IPAddress:     createPasswordReset.IPAddress,
+	}
+
+	err := db.WithContext(ctx).Model(&object.PasswordReset{}).Create(&passwordReset).Error
+
+	return passwordReset, err
+}
+
+// FindPasswordReset retrieves a password reset by its ID.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancelation, and deadlines.
+//   - db: a gorm.DB instance representing the database connection.
+//   - tenantID: unique identifier of the tenant to which the password reset belongs.
+//   - passwordResetID: unique identifier of the password reset.
+//
+// Returns:
+//   - PasswordReset object if retrieval is successful.
+//   - Error if there is any issue during retrieval.
+func FindPasswordReset(ctx context.Context, db *gorm.DB, tenantID string, passwordResetID string) (object.PasswordReset, error) {
+	var passwordReset object.PasswordReset
+	err := db.WithContext(ctx).Take(&passwordReset, "id = ? AND tenant_id = ?", passwordResetID, tenantID).Error
+	return passwordReset, err
+}
+
+// CountRecentPasswordResets counts the password resets of a user which were requested after the given time.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancelation, and deadlines.
+//   - db: a gorm.DB instance representing the database connection.
+//   - tenantID: unique identifier of the tenant to which the user belongs.
+//   - userID: unique identifier of the user whose password is reset.
+//   - since: only password resets requested after this time are counted.
+//
+// Returns:
+//   - Number of password resets if counting is successful.
+//   - Error if there is any issue during counting.
+func CountRecentPasswordResets(ctx context.Context, db *gorm.DB, tenantID string, userID string, since time.Time) (int64, error) {
+	var count int64
+	err := db.WithContext(ctx).Model(&object.PasswordReset{}).Where("tenant_id = ? AND user_id = ? AND created_at > ?", tenantID, userID, since).Count(&count).Error
+	return count, err
+}
+
+// UsePasswordReset marks a not expired password reset as used. Only the first call for a reset succeeds,
+// so a reset token can't be used twice, even by concurrent requests.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancelation, and deadlines.
+//   - db: a gorm.DB instance representing the database connection.
+//   - tenantID: unique identifier of the tenant to which the password reset belongs.
+//   - passwordResetID: unique identifier of the password reset.
+//
+// Returns:
+//   - Error if the password reset was already used, is expired or there is any issue during updating.
+func UsePasswordReset(ctx context.Context, db *gorm.DB, tenantID string, passwordResetID string) error {
+	now := time.Now()
+
+	result := db.WithContext(ctx).Model(&object.PasswordReset{}).
+		Where("id = ? AND tenant_id = ? AND used_at IS NULL AND expires_at > ?", passwordResetID, tenantID, now).
+		Update("used_at", now)
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// ExpireUserPasswordResets marks all open password resets of a user as used, e.g. after the password was changed.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancelation, and deadlines.
+//   - db: a gorm.DB instance representing the database connection.
+//   - tenantID: unique identifier of the tenant to which the user belongs.
+//   - userID: unique identifier of the user whose password resets are expired.
+//
+// Returns:
+//   - Error if there is any issue during updating.
+func ExpireUserPasswordResets(ctx context.Context, db *gorm.DB, tenantID string, userID string) error {
+	return db.WithContext(ctx).Model(&object.PasswordReset{}).
+		Where("tenant_id = ? AND user_id = ? AND used_at IS NULL", tenantID, userID).
+		Update("used_at", time.Now()).Error
+}
@@ -0,0 +1,107 @@
+/*
+ * Copyright (C) 2025 Anthrove
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package repository
+
+import (
+	"context"
+	"github.com/anthrove/identity/pkg/object"
+	"gorm.io/gorm"
+)
+
+// ReplaceProfileAttributes replaces the lookup index entries of a user.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancelation, and deadlines.
+//   - db: a gorm.DB instance representing the database connection.
+//   - tenantID: unique identifier of the tenant to which the user belongs.
+//   - userID: unique identifier of the user.
+//   - attributes: the new index entries of the user.
+//
+// Returns:
+//   - Error if there is any issue during saving, gorm.ErrDuplicatedKey if a unique value is used by another user.
+func ReplaceProfileAttributes(ctx context.Context, db *gorm.DB, tenantID string, userID string, attributes []object.ProfileAttribute) error {
+	err := db.WithContext(ctx).Delete(&object.ProfileAttribute{}, "tenant_id = ? AND user_id = ?", tenantID, userID).Error
+	if err != nil {
+		return err
+	}
+
+	if len(attributes) == 0 {
+		return nil
+	}
+
+	for i := range attributes {
+		attributes[i].TenantID = tenantID
+		attributes[i].UserID = userID
+	}
+
+	err = db.WithContext(ctx).Create(&attributes).Error
+
+	return translateError(db, err)
+}
+
+// KillTenantProfileAttributes deletes all lookup index entries of a tenant.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancelation, and deadlines.
+//   - db: a gorm.DB instance representing the database connection.
+//   - tenantID: unique identifier of the tenant.
+//
+// Returns:
+//   - Error if there is any issue during deletion.
+func KillTenantProfileAttributes(ctx context.Context, db *gorm.DB, tenantID string) error {
+	return db.WithContext(ctx).Delete(&object.ProfileAttribute{}, "tenant_id = ?", tenantID).Error
+}
+
+// FindProfileAttributeUserIDs retrieves the users which have a value in the lookup index of a profile field.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancelation, and deadlines.
+//   - db: a gorm.DB instance representing the database connection.
+//   - tenantID: unique identifier of the tenant to which the users belong.
+//   - identifier: identifier of the profile field.
+//   - value: the indexed value.
+//
+// Returns:
+//   - Slice of user IDs if retrieval is successful.
+//   - Error if there is any issue during retrieval.
+func FindProfileAttributeUserIDs(ctx context.Context, db *gorm.DB, tenantID string, identifier string, value string) ([]string, error) {
+	var userIDs []string
+	err := db.WithContext(ctx).Model(&object.ProfileAttribute{}).Distinct("user_id").Where("tenant_id = ? AND identifier = ? AND value = ?", tenantID, identifier, value).Pluck("user_id", &userIDs).Error
+	return userIDs, err
+}
+
+// FindUsersByProfileAttribute retrieves the users which have a value in the lookup index of a profile field, with pagination support.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancelation, and deadlines.
+//   - db: a gorm.DB instance representing the database connection.
+//   - tenantID: unique identifier of the tenant to which the users belong.
+//   - identifier: identifier of the profile field.
+//   - value: the indexed value.
+//   - pagination: object containing pagination details (limit and page).
+//
+// Returns:
+//   - Slice of User objects if retrieval is successful.
+//   - Error if there is any issue during retrieval.
+func FindUsersByProfileAttribute(ctx context.Context, db *gorm.DB, tenantID string, identifier string, value string, pagination object.Pagination) ([]object.User, error) {
+	var data []object.User
+
+	userIDs := db.Model(&object.ProfileAttribute{}).Select("user_id").Where("tenant_id = ? AND identifier = ? AND value = ?", tenantID, identifier, value)
+	err := db.WithContext(ctx).Scopes(Pagination(pagination)).Preload("Groups").Where("tenant_id = ? AND id IN (?)", tenantID, userIDs).Find(&data).Error
+
+	return data, err
+}
@@ -64,3 +64,9 @@ func FindProviders(ctx context.Context, db *gorm.DB, tenantID string, pagination
 	err := db.WithContext(ctx).Scopes(Pagination(pagination)).Where("tenant_id = ?", tenantID).Find(&data).Error
 	return data, err
 }
+
+func FindProvidersByCategory(ctx context.Context, db *gorm.DB, tenantID string, category string) ([]object.Provider, error) {
+	var data []object.Provider
+	err := db.WithContext(ctx).Where("tenant_id = ? AND category = ?", tenantID, category).Find(&data).Error
+	return data, err
+}
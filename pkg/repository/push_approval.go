@@ -0,0 +1,103 @@
+/*
+ * Copyright (C) 2025 Anthrove
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package repository
+
+import (
+	"context"
+	"github.com/anthrove/identity/pkg/object"
+	"gorm.io/gorm"
+	"time"
+)
+
+// CreatePushApproval creates a new push approval within a specified tenant in the database.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancelation, and deadlines.
+//   - db: a gorm.DB instance representing the database connection.
+//   - tenantID: unique identifier of the tenant to which the approval belongs.
+//   - approval: object containing the details of the approval to be created.
+//
+// Returns:
+//   - Error if there is any issue during creation.
+func CreatePushApproval(ctx context.Context, db *gorm.DB, tenantID string, approval object.PushApproval) error {
+	approval.TenantID = tenantID
+	return db.WithContext(ctx).Create(&approval).Error
+}
+
+// FindPushApproval retrieves a push approval within a specified tenant from the database.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancelation, and deadlines.
+//   - db: a gorm.DB instance representing the database connection.
+//   - tenantID: unique identifier of the tenant to which the approval belongs.
+//   - approvalID: unique identifier of the approval to be retrieved.
+//
+// Returns:
+//   - PushApproval object if retrieval is successful.
+//   - Error if there is any issue during retrieval.
+func FindPushApproval(ctx context.Context, db *gorm.DB, tenantID string, approvalID string) (object.PushApproval, error) {
+	var approval object.PushApproval
+	err := db.WithContext(ctx).Take(&approval, "id = ? AND tenant_id = ?", approvalID, tenantID).Error
+	return approval, err
+}
+
+// UpdatePushApprovalDecision stores the decision of a push approval, as long as the approval is still pending.
+// The check and the update are a single statement, so only one decision is accepted.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancelation, and deadlines.
+//   - db: a gorm.DB instance representing the database connection.
+//   - tenantID: unique identifier of the tenant to which the approval belongs.
+//   - approvalID: unique identifier of the approval to be decided.
+//   - pending: decision of an approval which was not decided yet.
+//   - decision: the new decision.
+//
+// Returns:
+//   - True if the decision was stored, false if the approval doesn't exist or was already decided.
+//   - Error if there is any issue during updating.
+func UpdatePushApprovalDecision(ctx context.Context, db *gorm.DB, tenantID string, approvalID string, pending string, decision string) (bool, error) {
+	result := db.WithContext(ctx).Model(&object.PushApproval{}).Where("id = ? AND tenant_id = ? AND decision = ?", approvalID, tenantID, pending).Update("decision", decision)
+	return result.RowsAffected > 0, result.Error
+}
+
+// KillPushApproval deletes a push approval within a specified tenant from the database.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancelation, and deadlines.
+//   - db: a gorm.DB instance representing the database connection.
+//   - tenantID: unique identifier of the tenant to which the approval belongs.
+//   - approvalID: unique identifier of the approval to be deleted.
+//
+// Returns:
+//   - Error if there is any issue during deletion.
+func KillPushApproval(ctx context.Context, db *gorm.DB, tenantID string, approvalID string) error {
+	return db.WithContext(ctx).Delete(&object.PushApproval{}, "id = ? AND tenant_id = ?", approvalID, tenantID).Error
+}
+
+// KillExpiredPushApprovals deletes all push approvals within a specified tenant which expired before the given time.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancelation, and deadlines.
+//   - db: a gorm.DB instance representing the database connection.
+//   - tenantID: unique identifier of the tenant to which the approvals belong.
+//   - before: approvals which expired before this time are deleted.
+//
+// Returns:
+//   - Error if there is any issue during deletion.
+func KillExpiredPushApprovals(ctx context.Context, db *gorm.DB, tenantID string, before time.Time) error {
+	return db.WithContext(ctx).Delete(&object.PushApproval{}, "tenant_id = ? AND expires_at < ?", tenantID, before).Error
+}
@@ -20,6 +20,7 @@ import (
 	"context"
 	"github.com/anthrove/identity/pkg/object"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"path/filepath"
 	"strings"
 )
@@ -31,11 +32,20 @@ import (
 //   - db: a gorm.DB instance representing the database connection.
 //   - tenantId: unique identifier of the tenant to which the resource belongs.
 //   - createResource: object containing the details of the resource to be created.
+//   - resourcePath: path of the resource on the CDN.
+//   - blobPath: path of the content in the storage provider, shared by resources with the same content.
+//   - resourceURL: URL of the content.
+//   - hash: SHA-256 hash of the content.
 //
 // Returns:
 //   - Resource object if creation is successful.
 //   - Error if there is any issue during creation.
-func CreateResource(ctx context.Context, db *gorm.DB, tenantId string, createResource object.CreateResource, resourcePath string, resourceURL string, hash string) (object.Resource, error) {
+func CreateResource(ctx context.Context, db *gorm.DB, tenantId string, createResource object.CreateResource, resourcePath string, blobPath string, resourceURL string, hash string) (object.Resource, error) {
+
+	visibility := createResource.Visibility
+	if len(visibility) == 0 {
+		visibility = object.ResourceVisibilityPublic
+	}
 
 	resource := object.Resource{
 		TenantID:   tenantId,
@@ -47,6 +57,12 @@ func CreateResource(ctx context.Context, db *gorm.DB, tenantId string, createRes
 		Format:     strings.TrimPrefix(filepath.Ext(resourcePath), "."),
 		Url:        resourceURL,
 		Hash:       hash,
+		BlobPath:   blobPath,
+		UserID:     createResource.UserID,
+		ParentID:   createResource.ParentID,
+		Variant:    createResource.Variant,
+		Visibility: visibility,
+		GroupID:    createResource.GroupID,
 	}
 
 	err := db.WithContext(ctx).Model(&object.Resource{}).Create(&resource).Error
@@ -85,6 +101,60 @@ func FindResource(ctx context.Context, db *gorm.DB, tenantID string, resourceID
 	return resource, err
 }
 
+// UpdateResourceVisibility changes the visibility of a resource and its variants.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancelation, and deadlines.
+//   - db: a gorm.DB instance representing the database connection.
+//   - tenantID: unique identifier of the tenant to which the resource belongs.
+//   - resourceID: unique identifier of the resource to be updated.
+//   - updateVisibility: object containing the new visibility.
+//
+// Returns:
+//   - Error if there is any issue during updating.
+func UpdateResourceVisibility(ctx context.Context, db *gorm.DB, tenantID string, resourceID string, updateVisibility object.UpdateResourceVisibility) error {
+	return db.WithContext(ctx).Model(&object.Resource{}).
+		Where("tenant_id = ? AND (id = ? OR parent_id = ?)", tenantID, resourceID, resourceID).
+		Select("Visibility", "GroupID").
+		Updates(&object.Resource{Visibility: updateVisibility.Visibility, GroupID: updateVisibility.GroupID}).Error
+}
+
+// UpdateResourceContent completes a resource whose content was uploaded after the resource was created.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancelation, and deadlines.
+//   - db: a gorm.DB instance representing the database connection.
+//   - tenantID: unique identifier of the tenant to which the resource belongs.
+//   - resourceID: unique identifier of the resource to be updated.
+//   - resourceURL: URL of the content.
+//   - hash: SHA-256 hash of the content.
+//
+// Returns:
+//   - Error if there is any issue during updating.
+func UpdateResourceContent(ctx context.Context, db *gorm.DB, tenantID string, resourceID string, resourceURL string, hash string) error {
+	return db.WithContext(ctx).Model(&object.Resource{}).
+		Where("tenant_id = ? AND id = ?", tenantID, resourceID).
+		Select("Url", "Hash").
+		Updates(&object.Resource{Url: resourceURL, Hash: hash}).Error
+}
+
+// FindResourceByPath retrieves a resource within a specified tenant by its path in the storage provider.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancelation, and deadlines.
+//   - db: a gorm.DB instance representing the database connection.
+//   - tenantID: unique identifier of the tenant to which the resource belongs.
+//   - filePath: path of the resource inside its storage provider.
+//
+// Returns:
+//   - Resource object if retrieval is successful.
+//   - Error if there is any issue during retrieval.
+func FindResourceByPath(ctx context.Context, db *gorm.DB, tenantID string, filePath string) (object.Resource, error) {
+	var resource object.Resource
+	err := db.WithContext(ctx).Take(&resource, "tenant_id = ? AND file_path = ?", tenantID, filePath).Error
+	return resource, err
+}
+
 // FindResources retrieves a list of resources within a specified tenant from the database, with pagination support.
 //
 // Parameters:
@@ -118,3 +188,204 @@ func FindResourceURL(ctx context.Context, db *gorm.DB, tenantID string, resource
 	err := db.WithContext(ctx).Take(&resource, "id = ? AND tenant_id = ?", resourceID, tenantID).Error
 	return resource.Url, err
 }
+
+// FindResourceVariants retrieves the resized variants of a resource.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancelation, and deadlines.
+//   - db: a gorm.DB instance representing the database connection.
+//   - tenantID: unique identifier of the tenant to which the resource belongs.
+//   - resourceID: unique identifier of the parent resource.
+//
+// Returns:
+//   - Slice of Resource objects if retrieval is successful.
+//   - Error if there is any issue during retrieval.
+func FindResourceVariants(ctx context.Context, db *gorm.DB, tenantID string, resourceID string) ([]object.Resource, error) {
+	var data []object.Resource
+	err := db.WithContext(ctx).Where("tenant_id = ? AND parent_id = ?", tenantID, resourceID).Order("variant").Find(&data).Error
+	return data, err
+}
+
+// FindUserResources retrieves the resources a user uploaded through the profile, variants are left out.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancelation, and deadlines.
+//   - db: a gorm.DB instance representing the database connection.
+//   - tenantID: unique identifier of the tenant to which the user belongs.
+//   - userID: unique identifier of the user.
+//
+// Returns:
+//   - Slice of Resource objects if retrieval is successful.
+//   - Error if there is any issue during retrieval.
+func FindUserResources(ctx context.Context, db *gorm.DB, tenantID string, userID string) ([]object.Resource, error) {
+	var data []object.Resource
+	err := db.WithContext(ctx).Where("tenant_id = ? AND user_id = ? AND (parent_id = '' OR parent_id IS NULL)", tenantID, userID).Find(&data).Error
+	return data, err
+}
+
+// blobPathColumn is the path of the content of a resource, resources without BlobPath store their content at FilePath.
+const blobPathColumn = "COALESCE(NULLIF(blob_path, ''), file_path)"
+
+// FindResourceByHash retrieves a resource with the given content in a storage provider.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancelation, and deadlines.
+//   - db: a gorm.DB instance representing the database connection.
+//   - tenantID: unique identifier of the tenant to which the resource belongs.
+//   - providerID: unique identifier of the storage provider.
+//   - hash: SHA-256 hash of the content.
+//
+// Returns:
+//   - Resource object if retrieval is successful.
+//   - Error if there is any issue during retrieval.
+func FindResourceByHash(ctx context.Context, db *gorm.DB, tenantID string, providerID string, hash string) (object.Resource, error) {
+	var resource object.Resource
+	err := db.WithContext(ctx).Take(&resource, "tenant_id = ? AND provider_id = ? AND hash = ?", tenantID, providerID, hash).Error
+	return resource, err
+}
+
+// LockResourceByHash retrieves a resource of a storage provider with the given content and locks it until the
+// end of the transaction, so the content can't be deleted while another resource starts to reference it.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancelation, and deadlines.
+//   - db: a gorm.DB transaction.
+//   - tenantID: unique identifier of the tenant to which the resource belongs.
+//   - providerID: unique identifier of the storage provider.
+//   - hash: SHA-256 hash of the content.
+//
+// Returns:
+//   - Resource object if retrieval is successful.
+//   - Error if there is any issue during retrieval.
+func LockResourceByHash(ctx context.Context, db *gorm.DB, tenantID string, providerID string, hash string) (object.Resource, error) {
+	var resource object.Resource
+	err := db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).Take(&resource, "tenant_id = ? AND provider_id = ? AND hash = ?", tenantID, providerID, hash).Error
+	return resource, err
+}
+
+// LockBlobResources locks the resources whose content is stored at a path of a storage provider until the end of
+// the transaction, so no other resource starts to reference the content while it is deleted.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancelation, and deadlines.
+//   - db: a gorm.DB transaction.
+//   - tenantID: unique identifier of the tenant to which the resources belong.
+//   - providerID: unique identifier of the storage provider.
+//   - blobPath: path of the content in the storage provider.
+//
+// Returns:
+//   - Error if there is any issue during locking.
+func LockBlobResources(ctx context.Context, db *gorm.DB, tenantID string, providerID string, blobPath string) error {
+	var data []object.Resource
+	return db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").Where("tenant_id = ? AND provider_id = ? AND "+blobPathColumn+" = ?", tenantID, providerID, blobPath).Find(&data).Error
+}
+
+// CountBlobResources counts the resources whose content is stored at a path of a storage provider.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancelation, and deadlines.
+//   - db: a gorm.DB instance representing the database connection.
+//   - tenantID: unique identifier of the tenant to which the resources belong.
+//   - providerID: unique identifier of the storage provider.
+//   - blobPath: path of the content in the storage provider.
+//
+// Returns:
+//   - Number of resources referencing the content.
+//   - Error if there is any issue during counting.
+func CountBlobResources(ctx context.Context, db *gorm.DB, tenantID string, providerID string, blobPath string) (int64, error) {
+	var count int64
+	err := db.WithContext(ctx).Model(&object.Resource{}).Where("tenant_id = ? AND provider_id = ? AND "+blobPathColumn+" = ?", tenantID, providerID, blobPath).Count(&count).Error
+	return count, err
+}
+
+// FindBlobResources retrieves the resources whose content is stored at a path of a storage provider.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancelation, and deadlines.
+//   - db: a gorm.DB instance representing the database connection.
+//   - tenantID: unique identifier of the tenant to which the resources belong.
+//   - providerID: unique identifier of the storage provider.
+//   - blobPath: path of the content in the storage provider.
+//
+// Returns:
+//   - Slice of Resource objects if retrieval is successful.
+//   - Error if there is any issue during retrieval.
+func FindBlobResources(ctx context.Context, db *gorm.DB, tenantID string, providerID string, blobPath string) ([]object.Resource, error) {
+	var data []object.Resource
+	err := db.WithContext(ctx).Where("tenant_id = ? AND provider_id = ? AND "+blobPathColumn+" = ?", tenantID, providerID, blobPath).Find(&data).Error
+	return data, err
+}
+
+// FindStorageUsage retrieves the number of resources of a tenant and the size of their content.
+// Content shared by several resources is counted once.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancelation, and deadlines.
+//   - db: a gorm.DB instance representing the database connection.
+//   - tenantID: unique identifier of the tenant to which the resources belong.
+//
+// Returns:
+//   - StorageUsage object without the quota.
+//   - Error if there is any issue during retrieval.
+func FindStorageUsage(ctx context.Context, db *gorm.DB, tenantID string) (object.StorageUsage, error) {
+	var usage object.StorageUsage
+
+	err := db.WithContext(ctx).Model(&object.Resource{}).Where("tenant_id = ?", tenantID).Count(&usage.Files).Error
+	if err != nil {
+		return usage, err
+	}
+
+	blobs := db.Model(&object.Resource{}).
+		Select("MAX(file_size) AS size").
+		Where("tenant_id = ?", tenantID).
+		Group("provider_id, " + blobPathColumn)
+
+	err = db.WithContext(ctx).Table("(?) AS blobs", blobs).Select("COALESCE(SUM(size), 0)").Scan(&usage.Bytes).Error
+
+	return usage, err
+}
+
+// FindProviderResources retrieves all resources of a tenant which are stored in a storage provider.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancelation, and deadlines.
+//   - db: a gorm.DB instance representing the database connection.
+//   - tenantID: unique identifier of the tenant to which the resources belong.
+//   - providerID: unique identifier of the storage provider.
+//
+// Returns:
+//   - Slice of Resource objects if retrieval is successful.
+//   - Error if there is any issue during retrieval.
+func FindProviderResources(ctx context.Context, db *gorm.DB, tenantID string, providerID string) ([]object.Resource, error) {
+	var data []object.Resource
+	err := db.WithContext(ctx).Where("tenant_id = ? AND provider_id = ?", tenantID, providerID).Order("created_at").Find(&data).Error
+	return data, err
+}
+
+// UpdateBlobStorage moves all resources whose content is stored at a path of a storage provider to the content in
+// another storage provider. The resources are updated in a single statement, so they move together.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancelation, and deadlines.
+//   - db: a gorm.DB instance representing the database connection.
+//   - tenantID: unique identifier of the tenant to which the resources belong.
+//   - providerID: unique identifier of the current storage provider.
+//   - blobPath: current path of the content.
+//   - targetProviderID: unique identifier of the new storage provider.
+//   - targetBlobPath: path of the content in the new storage provider.
+//   - targetURL: URL of the content in the new storage provider.
+//
+// Returns:
+//   - Number of moved resources.
+//   - Error if there is any issue during updating.
+func UpdateBlobStorage(ctx context.Context, db *gorm.DB, tenantID string, providerID string, blobPath string, targetProviderID string, targetBlobPath string, targetURL string) (int64, error) {
+	result := db.WithContext(ctx).Model(&object.Resource{}).
+		Where("tenant_id = ? AND provider_id = ? AND "+blobPathColumn+" = ?", tenantID, providerID, blobPath).
+		Updates(map[string]any{
+			"provider_id": targetProviderID,
+			"blob_path":   targetBlobPath,
+			"url":         targetURL,
+		})
+
+	return result.RowsAffected, result.Error
+}
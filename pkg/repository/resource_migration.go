@@ -0,0 +1,87 @@
+/*
+ * Copyright (C) 2025 Anthrove
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package repository
+
+import (
+	"context"
+	"github.com/anthrove/identity/pkg/object"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+// CreateResourceMigrationLock takes the storage migration lock of a specified tenant. A lock which was not refreshed
+// since staleBefore is taken over.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancelation, and deadlines.
+//   - db: a gorm.DB instance representing the database connection.
+//   - tenantID: unique identifier of the tenant whose migration is locked.
+//   - lockID: unique identifier of the migration which takes the lock.
+//   - staleBefore: locks which were not refreshed since this time are taken over.
+//
+// Returns:
+//   - True if the lock was taken, false if another migration holds it.
+//   - Error if there is any issue during creation.
+func CreateResourceMigrationLock(ctx context.Context, db *gorm.DB, tenantID string, lockID string, staleBefore time.Time) (bool, error) {
+	taken := false
+
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Delete(&object.ResourceMigrationLock{}, "tenant_id = ? AND updated_at < ?", tenantID, staleBefore).Error
+		if err != nil {
+			return err
+		}
+
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&object.ResourceMigrationLock{
+			TenantID: tenantID,
+			LockID:   lockID,
+		})
+
+		taken = result.RowsAffected > 0
+		return result.Error
+	})
+
+	return taken, err
+}
+
+// RefreshResourceMigrationLock marks the storage migration lock of a specified tenant as still being held.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancelation, and deadlines.
+//   - db: a gorm.DB instance representing the database connection.
+//   - tenantID: unique identifier of the tenant whose migration is locked.
+//   - lockID: unique identifier of the migration which holds the lock.
+//
+// Returns:
+//   - Error if there is any issue during updating.
+func RefreshResourceMigrationLock(ctx context.Context, db *gorm.DB, tenantID string, lockID string) error {
+	return db.WithContext(ctx).Model(&object.ResourceMigrationLock{}).Where("tenant_id = ? AND lock_id = ?", tenantID, lockID).Update("updated_at", time.Now()).Error
+}
+
+// KillResourceMigrationLock releases the storage migration lock of a specified tenant.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancelation, and deadlines.
+//   - db: a gorm.DB instance representing the database connection.
+//   - tenantID: unique identifier of the tenant whose migration is locked.
+//   - lockID: unique identifier of the migration which holds the lock.
+//
+// Returns:
+//   - Error if there is any issue during deletion.
+func KillResourceMigrationLock(ctx context.Context, db *gorm.DB, tenantID string, lockID string) error {
+	return db.WithContext(ctx).Delete(&object.ResourceMigrationLock{}, "tenant_id = ? AND lock_id = ?", tenantID, lockID).Error
+}
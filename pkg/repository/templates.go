@@ -110,3 +110,19 @@ func FindMessageTemplates(ctx context.Context, db *gorm.DB, tenantID string, pag
 	err := db.WithContext(ctx).Scopes(Pagination(pagination)).Find(&data).Where("tenant_id = ?", tenantID).Error
 	return data, err
 }
+
+// FindMessageTemplateByType retrieves the first template of a given type within a specified tenant from the database.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancelation, and deadlines.
+//   - db: a gorm.DB instance representing the database connection.
+//   - templateType: type of the template to be retrieved (e.g. recovery_code_used).
+//
+// Returns:
+//   - Template object if retrieval is successful.
+//   - Error if there is any issue during retrieval.
+func FindMessageTemplateByType(ctx context.Context, db *gorm.DB, tenantID string, templateType string) (object.MessageTemplate, error) {
+	var template object.MessageTemplate
+	err := db.WithContext(ctx).Take(&template, "tenant_id = ? AND template_type = ?", tenantID, templateType).Error
+	return template, err
+}
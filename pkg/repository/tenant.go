@@ -20,6 +20,7 @@ import (
 	"context"
 	"github.com/anthrove/identity/pkg/object"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // CreateTenant creates a new tenant in the database.
@@ -38,6 +39,21 @@ func CreateTenant(ctx context.Context, db *gorm.DB, createTenant object.CreateTe
 		DisplayName:   createTenant.DisplayName,
 		PasswordType:  createTenant.PasswordType,
 		ProfileFields: createTenant.ProfileFields,
+
+		TrustedDeviceDays: createTenant.TrustedDeviceDays,
+		LockoutPolicy:     object.DefaultLockoutPolicy,
+	}
+
+	if createTenant.LockoutPolicy != nil {
+		tenant.LockoutPolicy = *createTenant.LockoutPolicy
+	}
+
+	if createTenant.PasswordHashParameters != nil {
+		tenant.PasswordHashParameters = *createTenant.PasswordHashParameters
+	}
+
+	if createTenant.SignUpPolicy != nil {
+		tenant.SignUpPolicy = *createTenant.SignUpPolicy
 	}
 
 	err := db.WithContext(ctx).Model(&object.Tenant{}).Create(&tenant).Error
@@ -61,11 +77,45 @@ func UpdateTenant(ctx context.Context, db *gorm.DB, tenantID string, updateTenan
 		PasswordType:         updateTenant.PasswordType,
 		ProfileFields:        updateTenant.ProfileFields,
 		SigningCertificateID: &updateTenant.SigningCertificateID,
+		TrustedDeviceDays:    updateTenant.TrustedDeviceDays,
+	}
+
+	fields := []string{"DisplayName", "PasswordType", "ProfileFields", "SigningCertificateID", "TrustedDeviceDays"}
+
+	if updateTenant.LockoutPolicy != nil {
+		tenant.LockoutPolicy = *updateTenant.LockoutPolicy
+		fields = append(fields, "LockoutPolicy")
+	}
+
+	if updateTenant.PasswordHashParameters != nil {
+		tenant.PasswordHashParameters = *updateTenant.PasswordHashParameters
+		fields = append(fields, "PasswordHashParameters")
 	}
 
+	if updateTenant.SignUpPolicy != nil {
+		tenant.SignUpPolicy = *updateTenant.SignUpPolicy
+		fields = append(fields, "SignUpPolicy")
+	}
+
+	if updateTenant.StorageProviderID != nil {
+		tenant.StorageProviderID = *updateTenant.StorageProviderID
+		fields = append(fields, "StorageProviderID")
+	}
+
+	if updateTenant.StorageQuota != nil {
+		tenant.StorageQuota = *updateTenant.StorageQuota
+		fields = append(fields, "StorageQuota")
+	}
+
+	if updateTenant.Branding != nil {
+		tenant.Branding = *updateTenant.Branding
+		fields = append(fields, "Branding")
+	}
+
+	// select the fields explicitly, so zero values like disabling trusted devices are stored as well
 	err := db.WithContext(ctx).Model(&object.Tenant{
 		ID: tenantID,
-	}).Updates(&tenant).Error
+	}).Select(fields).Updates(&tenant).Error
 
 	return err
 }
@@ -83,6 +133,21 @@ func KillTenant(ctx context.Context, db *gorm.DB, tenantID string) error {
 	return db.WithContext(ctx).Delete(&object.Tenant{}, "id = ?", tenantID).Error
 }
 
+// LockTenant locks a tenant until the end of the transaction, so checks of the resources of the tenant, like the
+// storage quota, can't be passed by parallel requests together.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancelation, and deadlines.
+//   - db: a gorm.DB transaction.
+//   - tenantID: unique identifier of the tenant to be locked.
+//
+// Returns:
+//   - Error if there is any issue during locking.
+func LockTenant(ctx context.Context, db *gorm.DB, tenantID string) error {
+	var tenant object.Tenant
+	return db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").Take(&tenant, "id = ?", tenantID).Error
+}
+
 // FindTenant retrieves a specific tenant from the database.
 //
 // Parameters:
@@ -114,3 +179,31 @@ func FindTenants(ctx context.Context, db *gorm.DB, pagination object.Pagination)
 	err := db.WithContext(ctx).Scopes(Pagination(pagination)).Find(&data).Error
 	return data, err
 }
+
+// UpdateTenantProfileFields replaces the profile fields of a tenant.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancelation, and deadlines.
+//   - db: a gorm.DB instance representing the database connection.
+//   - tenantID: unique identifier of the tenant to be updated.
+//   - profileFields: the new profile fields.
+//
+// Returns:
+//   - Error if there is any issue during updating.
+func UpdateTenantProfileFields(ctx context.Context, db *gorm.DB, tenantID string, profileFields []object.ProfileField) error {
+	return db.WithContext(ctx).Model(&object.Tenant{ID: tenantID}).Select("ProfileFields").Updates(&object.Tenant{ProfileFields: profileFields}).Error
+}
+
+// UpdateTenantResourceSigningKey sets the resource signing key of a tenant, unless the tenant already has one.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancelation, and deadlines.
+//   - db: a gorm.DB instance representing the database connection.
+//   - tenantID: unique identifier of the tenant to be updated.
+//   - key: the new signing key.
+//
+// Returns:
+//   - Error if there is any issue during updating.
+func UpdateTenantResourceSigningKey(ctx context.Context, db *gorm.DB, tenantID string, key string) error {
+	return db.WithContext(ctx).Model(&object.Tenant{}).Where("id = ? AND (resource_signing_key = '' OR resource_signing_key IS NULL)", tenantID).Update("resource_signing_key", key).Error
+}
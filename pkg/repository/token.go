@@ -78,3 +78,13 @@ func FindUserTokens(ctx context.Context, db *gorm.DB, tenantID string, applicati
 	err := db.WithContext(ctx).Where("tenant_id = ? AND application_id = ? AND user_id = ?", tenantID, applicationID, userID).Find(&data).Error
 	return data, err
 }
+
+// KillUserTokens deletes all tokens of a user in every application of the tenant, e.g. after the password was reset.
+func KillUserTokens(ctx context.Context, db *gorm.DB, tenantID string, userID string) error {
+	return db.WithContext(ctx).Delete(&object.Token{}, "tenant_id = ? AND user_id = ?", tenantID, userID).Error
+}
+
+// KillUserApplicationTokens deletes all tokens of a user in one application, e.g. after the consent was revoked.
+func KillUserApplicationTokens(ctx context.Context, db *gorm.DB, tenantID string, userID string, applicationID string) error {
+	return db.WithContext(ctx).Delete(&object.Token{}, "tenant_id = ? AND user_id = ? AND application_id = ?", tenantID, userID, applicationID).Error
+}
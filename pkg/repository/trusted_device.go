@@ -0,0 +1,124 @@
+/*
+ * Copyright (C) 2025 Anthrove
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package repository
+
+import (
+	"context"
+	"github.com/anthrove/identity/pkg/object"
+	"gorm.io/gorm"
+	"time"
+)
+
+// CreateTrustedDevice creates a new trusted device for a specific user within a specified tenant in the database.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancelation, and deadlines.
+//   - db: a gorm.DB instance representing the database connection.
+//   - tenantID: unique identifier of the tenant to which the user belongs.
+//   - userID: unique identifier of the user who trusts the device.
+//   - createTrustedDevice: object containing the details of the trusted device to be created.
+//
+// Returns:
+//   - TrustedDevice object if creation is successful.
+//   - Error if there is any issue during creation.
+func CreateTrustedDevice(ctx context.Context, db *gorm.DB, tenantID string, userID string, createTrustedDevice object.CreateTrustedDevice) (object.TrustedDevice, error) {
+	trustedDevice := object.TrustedDevice{
+		TenantID:   tenantID,
+		UserID:     userID,
+		TokenHash:  createTrustedDevice.TokenHash,
+		UserAgent:  createTrustedDevice.UserAgent,
+		IPAddress:  createTrustedDevice.IPAddress,
+		LastUsedAt: time.Now(),
+		ExpiresAt:  createTrustedDevice.ExpiresAt,
+	}
+
+	err := db.WithContext(ctx).Model(&object.TrustedDevice{}).Create(&trustedDevice).Error
+
+	return trustedDevice, err
+}
+
+// FindTrustedDeviceByToken retrieves a not expired trusted device of a user by the hash of its token.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancelation, and deadlines.
+//   - db: a gorm.DB instance representing the database connection.
+//   - tenantID: unique identifier of the tenant to which the user belongs.
+//   - userID: unique identifier of the user who trusts the device.
+//   - tokenHash: SHA-256 hash of the device token.
+//
+// Returns:
+//   - TrustedDevice object if retrieval is successful.
+//   - Error if there is any issue during retrieval.
+func FindTrustedDeviceByToken(ctx context.Context, db *gorm.DB, tenantID string, userID string, tokenHash string) (object.TrustedDevice, error) {
+	var trustedDevice object.TrustedDevice
+	err := db.WithContext(ctx).Take(&trustedDevice, "tenant_id = ? AND user_id = ? AND token_hash = ? AND expires_at > ?", tenantID, userID, tokenHash, time.Now()).Error
+	return trustedDevice, err
+}
+
+// FindTrustedDevices retrieves a list of not expired trusted devices of a user from the database, with pagination support.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancelation, and deadlines.
+//   - db: a gorm.DB instance representing the database connection.
+//   - tenantID: unique identifier of the tenant to which the user belongs.
+//   - userID: unique identifier of the user who trusts the devices.
+//   - pagination: object containing pagination details (limit and page).
+//
+// Returns:
+//   - Slice of TrustedDevice objects if retrieval is successful.
+//   - Error if there is any issue during retrieval.
+func FindTrustedDevices(ctx context.Context, db *gorm.DB, tenantID string, userID string, pagination object.Pagination) ([]object.TrustedDevice, error) {
+	var data []object.TrustedDevice
+	err := db.WithContext(ctx).Scopes(Pagination(pagination)).Where("tenant_id = ? AND user_id = ? AND expires_at > ?", tenantID, userID, time.Now()).Order("last_used_at DESC").Find(&data).Error
+	return data, err
+}
+
+// UpdateTrustedDeviceLastUsed stores the time and client of the last sign in with a trusted device.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancelation, and deadlines.
+//   - db: a gorm.DB instance representing the database connection.
+//   - tenantID: unique identifier of the tenant to which the user belongs.
+//   - userID: unique identifier of the user who trusts the device.
+//   - trustedDeviceID: unique identifier of the trusted device to be updated.
+//   - ipAddress: IP address of the client which used the device.
+//
+// Returns:
+//   - Error if there is any issue during updating.
+func UpdateTrustedDeviceLastUsed(ctx context.Context, db *gorm.DB, tenantID string, userID string, trustedDeviceID string, ipAddress string) error {
+	trustedDevice := object.TrustedDevice{
+		IPAddress:  ipAddress,
+		LastUsedAt: time.Now(),
+	}
+
+	return db.WithContext(ctx).Model(&object.TrustedDevice{}).Where("id = ? AND tenant_id = ? AND user_id = ?", trustedDeviceID, tenantID, userID).Updates(&trustedDevice).Error
+}
+
+// KillTrustedDevice deletes a trusted device of a user from the database.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancelation, and deadlines.
+//   - db: a gorm.DB instance representing the database connection.
+//   - tenantID: unique identifier of the tenant to which the user belongs.
+//   - userID: unique identifier of the user who trusts the device.
+//   - trustedDeviceID: unique identifier of the trusted device to be deleted.
+//
+// Returns:
+//   - Error if there is any issue during deletion.
+func KillTrustedDevice(ctx context.Context, db *gorm.DB, tenantID string, userID string, trustedDeviceID string) error {
+	return db.WithContext(ctx).Delete(&object.TrustedDevice{}, "id = ? AND tenant_id = ? AND user_id = ?", trustedDeviceID, tenantID, userID).Error
+}
@@ -74,20 +74,73 @@ func UpdateUser(ctx context.Context, db *gorm.DB, tenantID string, userId string
 	return err
 }
 
+// UpdateUserEmail updates the email and the email verification state of a user within a specified tenant in the database.
+// Every new verification token resets the failed verification attempts.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancelation, and deadlines.
+//   - db: a gorm.DB instance representing the database connection.
+//   - tenantID: unique identifier of the tenant to which the user belongs.
+//   - userId: unique identifier of the user to be updated.
+//   - updateUserEmail: object containing the email and the verification state.
+//
+// Returns:
+//   - Error if there is any issue during updating.
 func UpdateUserEmail(ctx context.Context, db *gorm.DB, tenantID string, userId string, updateUserEmail object.UpdateEmail) error {
 	user := object.User{
-		ID:                     userId,
-		TenantID:               tenantID,
-		Email:                  updateUserEmail.Email,
-		EmailVerified:          updateUserEmail.EmailVerified,
-		EmailVerificationToken: updateUserEmail.EmailVerificationToken,
+		ID:                         userId,
+		TenantID:                   tenantID,
+		Email:                      updateUserEmail.Email,
+		EmailVerified:              updateUserEmail.EmailVerified,
+		EmailVerificationToken:     updateUserEmail.EmailVerificationToken,
+		EmailVerificationExpiresAt: updateUserEmail.EmailVerificationExpiresAt,
+		EmailVerificationAttempts:  0,
+		PendingEmail:               updateUserEmail.PendingEmail,
 	}
 
-	err := db.WithContext(ctx).Model(&object.User{}).Where("id = ? AND tenant_id = ?", userId, tenantID).Updates(&user).Error
+	// select the fields explicitly, so zero values like an unverified email or an empty token are stored as well
+	err := db.WithContext(ctx).Model(&object.User{}).Where("id = ? AND tenant_id = ?", userId, tenantID).
+		Select("Email", "EmailVerified", "EmailVerificationToken", "EmailVerificationExpiresAt", "EmailVerificationAttempts", "PendingEmail").
+		Updates(&user).Error
 
 	return err
 }
 
+// UpdateUserAvatar sets the avatar resource of a user, an empty resourceID removes the avatar.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancelation, and deadlines.
+//   - db: a gorm.DB instance representing the database connection.
+//   - tenantID: unique identifier of the tenant to which the user belongs.
+//   - userId: unique identifier of the user to be updated.
+//   - resourceID: unique identifier of the avatar resource.
+//
+// Returns:
+//   - Error if there is any issue during updating.
+func UpdateUserAvatar(ctx context.Context, db *gorm.DB, tenantID string, userId string, resourceID string) error {
+	return db.WithContext(ctx).Model(&object.User{}).Where("id = ? AND tenant_id = ?", userId, tenantID).Update("avatar_resource_id", resourceID).Error
+}
+
+// IncrementEmailVerificationAttempts counts an email verification attempt of a user, unless the user already
+// reached the maximum of attempts.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancelation, and deadlines.
+//   - db: a gorm.DB instance representing the database connection.
+//   - tenantID: unique identifier of the tenant to which the user belongs.
+//   - userId: unique identifier of the user who tries to verify the email.
+//   - maxAttempts: the number of attempts a user has.
+//
+// Returns:
+//   - Boolean indicating if the attempt was counted, false if no attempts are left.
+//   - Error if there is any issue during updating.
+func IncrementEmailVerificationAttempts(ctx context.Context, db *gorm.DB, tenantID string, userId string, maxAttempts int) (bool, error) {
+	result := db.WithContext(ctx).Model(&object.User{}).Where("id = ? AND tenant_id = ? AND email_verification_attempts < ?", userId, tenantID, maxAttempts).
+		UpdateColumn("email_verification_attempts", gorm.Expr("email_verification_attempts + 1"))
+
+	return result.RowsAffected > 0, result.Error
+}
+
 // KillUser deletes an existing user within a specified tenant from the database.
 //
 // Parameters:
@@ -169,3 +222,19 @@ func FindUsersByEmail(ctx context.Context, db *gorm.DB, tenantID string, email s
 	err := db.WithContext(ctx).Model(object.User{}).Preload("Groups").Where("tenant_id = ? AND email = ?", tenantID, email).Scan(&users).Error
 	return users, err
 }
+
+// CountUsers counts the users of a tenant.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancelation, and deadlines.
+//   - db: a gorm.DB instance representing the database connection.
+//   - tenantID: unique identifier of the tenant to which the users belong.
+//
+// Returns:
+//   - Number of users if retrieval is successful.
+//   - Error if there is any issue during retrieval.
+func CountUsers(ctx context.Context, db *gorm.DB, tenantID string) (int64, error) {
+	var count int64
+	err := db.WithContext(ctx).Model(&object.User{}).Where("tenant_id = ?", tenantID).Count(&count).Error
+	return count, err
+}
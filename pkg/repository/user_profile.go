@@ -53,3 +53,19 @@ func FindProfilePage(ctx context.Context, db *gorm.DB, tenantID string, userID s
 	err := db.WithContext(ctx).Take(&profilePage, "user_id = ?", userID).Error
 	return profilePage, err
 }
+
+// FindTenantProfilePages retrieves the profile pages of all users of a tenant.
+//
+// Parameters:
+//   - ctx: context for managing request-scoped values, cancelation, and deadlines.
+//   - db: a gorm.DB instance representing the database connection.
+//   - tenantID: unique identifier of the tenant to which the users belong.
+//
+// Returns:
+//   - Slice of ProfilePage objects if retrieval is successful.
+//   - Error if there is any issue during retrieval.
+func FindTenantProfilePages(ctx context.Context, db *gorm.DB, tenantID string) ([]object.ProfilePage, error) {
+	var data []object.ProfilePage
+	err := db.WithContext(ctx).Where("user_id IN (?)", db.Model(&object.User{}).Select("id").Where("tenant_id = ?", tenantID)).Find(&data).Error
+	return data, err
+}
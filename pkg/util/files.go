@@ -17,21 +17,21 @@
 package util
 
 import (
-	"crypto/md5"
+	"crypto/sha256"
 	"encoding/hex"
 	"io"
 )
 
-// HashFileMD5 computes the MD5 hash of the content read from the provided io.Reader.
+// HashFileSHA256 computes the SHA-256 hash of the content read from the provided io.Reader.
 //
 // Parameters:
 //   - file: an io.Reader representing the file content to be hashed.
 //
 // Returns:
-//   - A string representing the MD5 hash in hexadecimal format.
+//   - A string representing the SHA-256 hash in hexadecimal format.
 //   - An error if there is any issue during hashing.
-func HashFileMD5(file io.Reader) (string, error) {
-	hasher := md5.New()
+func HashFileSHA256(file io.Reader) (string, error) {
+	hasher := sha256.New()
 	if _, err := io.Copy(hasher, file); err != nil {
 		return "", err
 	}
@@ -65,3 +65,16 @@ func RandomPassPhrase(length int, concatSymbol string) (string, error) {
 	}
 	return strings.Join(list, concatSymbol), nil
 }
+
+// RandomDigits returns a string of random digits. Unlike RandomNumber, leading zeros are kept.
+func RandomDigits(length int) (string, error) {
+	result := make([]byte, length)
+	for i := range result {
+		digit, err := rand.Int(rand.Reader, big.NewInt(10))
+		if err != nil {
+			return "", err
+		}
+		result[i] = byte('0' + digit.Int64())
+	}
+	return string(result), nil
+}